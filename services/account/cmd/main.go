@@ -21,7 +21,6 @@ import (
 	"context"
 	"log"
 	"net/http"
-	"os"
 	"os/signal"
 	"syscall"
 	"time"
@@ -31,7 +30,9 @@ import (
 	"transfa/services/account/internal/config"
 	"transfa/services/account/internal/store"
 	"transfa/services/account/pkg/anchor"
-	"transfa/services/account/pkg/rabbitmq"
+	"transfa/shared/events"
+	"transfa/shared/inbox"
+	"transfa/shared/messaging"
 )
 
 func main() {
@@ -59,7 +60,10 @@ func main() {
 	service := app.NewService(repository, anchorClient)
 
 	// Initialize and start RabbitMQ consumer
-	consumer, err := rabbitmq.NewConsumer(cfg.RabbitMQURL)
+	consumer, err := messaging.NewConsumer(cfg.RabbitMQURL, messaging.RetryPolicy{
+		MaxAttempts:  cfg.MaxDeliveryAttempts,
+		InitialDelay: cfg.RetryInitialDelay,
+	})
 	if err != nil {
 		log.Fatalf("failed to create RabbitMQ consumer: %v", err)
 	}
@@ -71,15 +75,46 @@ func main() {
 		cfg.CustomerVerifiedQueue,
 		cfg.CustomerVerifiedRK,
 		cfg.ConsumerTag,
-		service.HandleCustomerVerifiedEvent,
+		// Skip messages this queue has already processed.
+		inbox.New(dbpool, cfg.CustomerVerifiedQueue).Wrap(service.HandleCustomerVerifiedEvent),
+		messaging.Concurrency{
+			Workers:  cfg.ConsumerWorkers,
+			Prefetch: cfg.ConsumerPrefetch,
+			// Handle each user's events one at a time, so two deliveries for one user
+			// never race to create their wallet.
+			OrderingKey: events.OrderingKey("user_id"),
+		},
 	)
 	if err != nil {
 		log.Fatalf("failed to start RabbitMQ consumer: %v", err)
 	}
 
+	// Close or freeze the accounts of users deleted in Clerk.
+	err = consumer.StartConsumer(
+		ctx,
+		cfg.UserDeletedEx,
+		cfg.UserDeletedQueue,
+		cfg.UserDeletedRK,
+		cfg.ConsumerTag+"_user_deleted",
+		inbox.New(dbpool, cfg.UserDeletedQueue).Wrap(service.HandleUserDeletedEvent),
+		messaging.Concurrency{
+			Workers:     cfg.ConsumerWorkers,
+			Prefetch:    cfg.ConsumerPrefetch,
+			OrderingKey: events.OrderingKey("user_id"),
+		},
+	)
+	if err != nil {
+		log.Fatalf("failed to start user.deleted consumer: %v", err)
+	}
+
 	// Start a simple HTTP server for health checks in a goroutine
 	go func() {
 		http.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+			// Report unhealthy while the consumer is reconnecting to RabbitMQ.
+			if !consumer.IsConnected() {
+				http.Error(w, "RabbitMQ consumer disconnected", http.StatusServiceUnavailable)
+				return
+			}
 			w.WriteHeader(http.StatusOK)
 		})
 		log.Printf("Account Service health check listening on port %s", cfg.Port)
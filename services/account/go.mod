@@ -34,4 +34,7 @@ require (
 	golang.org/x/text v0.14.0 // indirect
 	gopkg.in/ini.v1 v1.67.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
-)
\ No newline at end of file
+	transfa/shared v0.0.0
+)
+
+replace transfa/shared => ../../shared
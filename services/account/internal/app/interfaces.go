@@ -8,6 +8,8 @@
  * - "context": For passing request-scoped data and cancellation signals.
  * - "github.com/google/uuid": For user identifiers.
  * - "transfa/services/account/internal/domain": For core data models.
+ * - "transfa/services/account/pkg/anchor": For account balances.
+ * - "transfa/shared/onboarding": For onboarding statuses.
  */
 package app
 
@@ -16,15 +18,22 @@ import (
 
 	"github.com/google/uuid"
 	"transfa/services/account/internal/domain"
+	"transfa/services/account/pkg/anchor"
+	"transfa/shared/onboarding"
 )
 
 // Repository defines the interface for data persistence operations.
 type Repository interface {
 	CreateAccount(ctx context.Context, account *domain.Account) (*domain.Account, error)
 	GetUserByID(ctx context.Context, userID uuid.UUID) (*domain.User, error)
+	GetAccountByUserIDAndPurpose(ctx context.Context, userID uuid.UUID, purpose string) (*domain.Account, error)
+	GetAccountsByUserID(ctx context.Context, userID uuid.UUID) ([]*domain.Account, error)
+	UpdateAccountStatus(ctx context.Context, accountID uuid.UUID, from, to string) (bool, error)
+	AdvanceOnboarding(ctx context.Context, userID uuid.UUID, status onboarding.Status, reason string) error
 }
 
 // AnchorClient defines the interface for communicating with the Anchor BaaS API.
 type AnchorClient interface {
 	CreateDepositAccount(ctx context.Context, anchorCustomerID, customerType, productName string) (string, error)
+	GetAccountBalance(ctx context.Context, anchorAccountID string) (*anchor.Balance, error)
 }
\ No newline at end of file
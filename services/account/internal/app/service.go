@@ -2,23 +2,34 @@
  * @description
  * This file contains the core business logic for the Account service. The Service struct
  * orchestrates the process of handling a `customer.verified` event by interacting with the
- * Anchor client and the database repository to create a user wallet.
+ * Anchor client and the database repository to create a user wallet, and closes the
+ * accounts of users deleted in Clerk on `user.deleted`.
  *
  * @dependencies
- * - Go standard libraries: "context", "encoding/json", "fmt", "log"
+ * - Go standard libraries: "context", "errors", "fmt", "log"
+ * - "github.com/google/uuid": For user identifiers.
  * - "github.com/rabbitmq/amqp091-go": For message handling.
- * - "transfa/services/account/internal/domain": For core data models and events.
+ * - "transfa/services/account/internal/domain": For core data models.
+ * - "transfa/services/account/internal/store": For repository error values.
+ * - "transfa/shared/events": For the `customer.verified` and `user.deleted` events.
+ * - "transfa/shared/messaging": For marking errors that must not be retried.
+ * - "transfa/shared/onboarding": For completing the user's onboarding status.
  */
 package app
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 
+	"github.com/google/uuid"
 	"github.com/rabbitmq/amqp091-go"
 	"transfa/services/account/internal/domain"
+	"transfa/services/account/internal/store"
+	"transfa/shared/events"
+	"transfa/shared/messaging"
+	"transfa/shared/onboarding"
 )
 
 // Service provides the application's business logic for account management.
@@ -38,12 +49,13 @@ func NewService(repo Repository, anchorClient AnchorClient) *Service {
 // HandleCustomerVerifiedEvent is the message handler for `customer.verified` events.
 // It orchestrates creating the Anchor DepositAccount and saving it to the local DB.
 func (s *Service) HandleCustomerVerifiedEvent(ctx context.Context, msg amqp091.Delivery) error {
-	var event domain.CustomerVerifiedEvent
-	if err := json.Unmarshal(msg.Body, &event); err != nil {
-		return fmt.Errorf("failed to unmarshal CustomerVerifiedEvent: %w", err)
+	var event events.CustomerVerified
+	envelope, err := events.DecodeDelivery(msg, &event)
+	if err != nil {
+		return err
 	}
 
-	log.Printf("Processing CustomerVerifiedEvent for UserID: %s", event.UserID)
+	log.Printf("Processing customer.verified event %s (correlation %s) for UserID: %s", envelope.EventID, envelope.CorrelationID, event.UserID)
 
 	// Step 1: Fetch the user from our DB to get their account type.
 	user, err := s.repo.GetUserByID(ctx, event.UserID)
@@ -52,7 +64,19 @@ func (s *Service) HandleCustomerVerifiedEvent(ctx context.Context, msg amqp091.D
 		return fmt.Errorf("failed to get user by ID %s: %w", event.UserID, err)
 	}
 
-	// Step 2: Determine the correct product and customer types based on the user's account type.
+	// Step 2: Skip users who already have a main wallet, so a redelivered event never
+	// creates a second Anchor DepositAccount. Their onboarding status is still completed,
+	// in case that failed on the first delivery.
+	existing, err := s.repo.GetAccountByUserIDAndPurpose(ctx, user.ID, "main_wallet")
+	if err == nil {
+		log.Printf("User %s already has main wallet %s; ignoring duplicate customer.verified event", user.ID, existing.ID)
+		return s.completeOnboarding(ctx, user.ID)
+	}
+	if !errors.Is(err, store.ErrAccountNotFound) {
+		return fmt.Errorf("failed to check existing wallet for user %s: %w", user.ID, err)
+	}
+
+	// Step 3: Determine the correct product and customer types based on the user's account type.
 	var productName, customerType string
 	switch user.AccountType {
 	case "personal":
@@ -62,10 +86,10 @@ func (s *Service) HandleCustomerVerifiedEvent(ctx context.Context, msg amqp091.D
 		productName = "CURRENT"
 		customerType = "BusinessCustomer"
 	default:
-		return fmt.Errorf("unknown account type '%s' for user %s", user.AccountType, user.ID)
+		return messaging.Permanent(fmt.Errorf("unknown account type '%s' for user %s", user.AccountType, user.ID))
 	}
 
-	// Step 3: Call the Anchor API to create the DepositAccount.
+	// Step 4: Call the Anchor API to create the DepositAccount.
 	anchorAccountID, err := s.anchorClient.CreateDepositAccount(ctx, event.AnchorCustomerID, customerType, productName)
 	if err != nil {
 		return fmt.Errorf("failed to create deposit account in anchor for user %s: %w", event.UserID, err)
@@ -73,7 +97,7 @@ func (s *Service) HandleCustomerVerifiedEvent(ctx context.Context, msg amqp091.D
 
 	log.Printf("Successfully created Anchor DepositAccount with ID: %s for UserID: %s", anchorAccountID, event.UserID)
 
-	// Step 4: Create the account record in our local database.
+	// Step 5: Create the account record in our local database.
 	newAccount := &domain.Account{
 		UserID:          user.ID,
 		AnchorAccountID: anchorAccountID,
@@ -92,5 +116,70 @@ func (s *Service) HandleCustomerVerifiedEvent(ctx context.Context, msg amqp091.D
 	// The technical spec mentions a final `account.opened` webhook from Anchor.
 	// The Notification service would listen for this and could send a final "Welcome!" push notification.
 
+	// Step 6: Mark the user's onboarding as complete.
+	return s.completeOnboarding(ctx, user.ID)
+}
+
+// completeOnboarding advances the user's onboarding status to wallet_ready.
+func (s *Service) completeOnboarding(ctx context.Context, userID uuid.UUID) error {
+	if err := s.repo.AdvanceOnboarding(ctx, userID, onboarding.StatusWalletReady, ""); err != nil {
+		return fmt.Errorf("failed to complete onboarding of user %s: %w", userID, err)
+	}
 	return nil
-}
\ No newline at end of file
+}
+
+// Account statuses set when the account's owner is deleted.
+const (
+	statusActive = "active"
+	statusClosed = "closed"
+	statusFrozen = "frozen"
+)
+
+// HandleUserDeletedEvent is the message handler for `user.deleted` events. Each of the
+// user's active accounts is closed if Anchor reports it empty. Accounts that still hold
+// money are frozen instead, so the funds can be returned to the user by hand.
+func (s *Service) HandleUserDeletedEvent(ctx context.Context, msg amqp091.Delivery) error {
+	var event events.UserDeleted
+	envelope, err := events.DecodeDelivery(msg, &event)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Processing user.deleted event %s (correlation %s) for UserID: %s", envelope.EventID, envelope.CorrelationID, event.UserID)
+
+	// Step 1: Fetch all of the user's accounts.
+	accounts, err := s.repo.GetAccountsByUserID(ctx, event.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to get accounts of user %s: %w", event.UserID, err)
+	}
+
+	// Step 2: Close or freeze each account that is still active. Accounts already closed
+	// or frozen by an earlier delivery are left alone. The balance is fetched from Anchor,
+	// which holds the money; if it cannot be fetched the event is retried.
+	for _, account := range accounts {
+		if account.Status != statusActive {
+			continue
+		}
+
+		balance, err := s.anchorClient.GetAccountBalance(ctx, account.AnchorAccountID)
+		if err != nil {
+			return fmt.Errorf("failed to get balance of account %s: %w", account.ID, err)
+		}
+
+		status := statusClosed
+		if !balance.Empty() {
+			status = statusFrozen
+			log.Printf("WARNING: Account %s of deleted user %s holds %d kobo (%d on hold); freezing it for manual review", account.ID, event.UserID, balance.LedgerBalance, balance.Hold)
+		}
+
+		updated, err := s.repo.UpdateAccountStatus(ctx, account.ID, statusActive, status)
+		if err != nil {
+			return fmt.Errorf("failed to set status of account %s to %s: %w", account.ID, status, err)
+		}
+		if updated {
+			log.Printf("Account %s of deleted user %s is now %s", account.ID, event.UserID, status)
+		}
+	}
+
+	return nil
+}
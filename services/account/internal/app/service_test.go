@@ -0,0 +1,186 @@
+package app
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"transfa/services/account/internal/domain"
+	"transfa/services/account/internal/store"
+	"transfa/services/account/pkg/anchor"
+	"transfa/shared/events"
+	"transfa/shared/eventtest"
+	"transfa/shared/messaging"
+	"transfa/shared/messaging/memory"
+	"transfa/shared/onboarding"
+)
+
+const (
+	customerVerifiedEx    = "customer_events"
+	customerVerifiedRK    = "customer.verified"
+	customerVerifiedQueue = "account_service_customer_verified"
+	userDeletedEx         = "user_events"
+	userDeletedRK         = "user.deleted"
+	userDeletedQueue      = "account_service_user_deleted"
+)
+
+// fakeRepository stores the scenario's user, the accounts created for it and the
+// onboarding statuses it was advanced to.
+type fakeRepository struct {
+	accounts []*domain.Account
+	statuses []onboarding.Status
+}
+
+func (r *fakeRepository) CreateAccount(ctx context.Context, account *domain.Account) (*domain.Account, error) {
+	account.ID = uuid.New()
+	r.accounts = append(r.accounts, account)
+	return account, nil
+}
+
+func (r *fakeRepository) GetUserByID(ctx context.Context, userID uuid.UUID) (*domain.User, error) {
+	if userID != eventtest.Onboarding.UserID {
+		return nil, store.ErrUserNotFound
+	}
+	return &domain.User{ID: userID, AccountType: eventtest.Onboarding.AccountType}, nil
+}
+
+func (r *fakeRepository) GetAccountByUserIDAndPurpose(ctx context.Context, userID uuid.UUID, purpose string) (*domain.Account, error) {
+	for _, account := range r.accounts {
+		if account.UserID == userID && account.AccountPurpose == purpose {
+			return account, nil
+		}
+	}
+	return nil, store.ErrAccountNotFound
+}
+
+func (r *fakeRepository) GetAccountsByUserID(ctx context.Context, userID uuid.UUID) ([]*domain.Account, error) {
+	var accounts []*domain.Account
+	for _, account := range r.accounts {
+		if account.UserID == userID {
+			accounts = append(accounts, account)
+		}
+	}
+	return accounts, nil
+}
+
+func (r *fakeRepository) UpdateAccountStatus(ctx context.Context, accountID uuid.UUID, from, to string) (bool, error) {
+	for _, account := range r.accounts {
+		if account.ID == accountID && account.Status == from {
+			account.Status = to
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *fakeRepository) AdvanceOnboarding(ctx context.Context, userID uuid.UUID, status onboarding.Status, reason string) error {
+	r.statuses = append(r.statuses, status)
+	return nil
+}
+
+// fakeAnchorClient records the deposit accounts created and reports the balances of
+// accounts by their Anchor ID; accounts without a balance are empty.
+type fakeAnchorClient struct {
+	customerIDs []string
+	balances    map[string]anchor.Balance
+}
+
+func (c *fakeAnchorClient) CreateDepositAccount(ctx context.Context, anchorCustomerID, customerType, productName string) (string, error) {
+	c.customerIDs = append(c.customerIDs, anchorCustomerID)
+	return eventtest.Onboarding.AnchorAccountID, nil
+}
+
+func (c *fakeAnchorClient) GetAccountBalance(ctx context.Context, anchorAccountID string) (*anchor.Balance, error) {
+	balance := c.balances[anchorAccountID]
+	return &balance, nil
+}
+
+// TestHandleCustomerVerifiedEventCreatesMainWallet is the Account service's leg of the
+// onboarding scenario: `customer.verified` creates the user's main wallet, which ends
+// onboarding.
+func TestHandleCustomerVerifiedEventCreatesMainWallet(t *testing.T) {
+	repo := &fakeRepository{}
+	anchor := &fakeAnchorClient{}
+	service := NewService(repo, anchor)
+
+	broker := memory.NewBroker(messaging.RetryPolicy{MaxAttempts: 3})
+	err := broker.StartConsumer(context.Background(), customerVerifiedEx, customerVerifiedQueue, customerVerifiedRK, "test", service.HandleCustomerVerifiedEvent, messaging.Concurrency{})
+	if err != nil {
+		t.Fatalf("StartConsumer() error = %v", err)
+	}
+
+	eventtest.Publish(t, broker, customerVerifiedEx, customerVerifiedRK, "notification", eventtest.CustomerVerified())
+	// A redelivery must not create a second wallet.
+	eventtest.Publish(t, broker, customerVerifiedEx, customerVerifiedRK, "notification", eventtest.CustomerVerified())
+	broker.Drain()
+
+	if dead := broker.DeadLetters(customerVerifiedQueue); len(dead) != 0 {
+		t.Fatalf("%d messages were dead-lettered", len(dead))
+	}
+	if len(anchor.customerIDs) != 1 || anchor.customerIDs[0] != eventtest.Onboarding.AnchorCustomerID {
+		t.Fatalf("created deposit accounts for %v, want one for %s", anchor.customerIDs, eventtest.Onboarding.AnchorCustomerID)
+	}
+	if len(repo.accounts) != 1 {
+		t.Fatalf("stored %d accounts, want 1", len(repo.accounts))
+	}
+	wallet := repo.accounts[0]
+	if wallet.UserID != eventtest.Onboarding.UserID || wallet.AnchorAccountID != eventtest.Onboarding.AnchorAccountID || wallet.AccountPurpose != "main_wallet" {
+		t.Errorf("stored account = %+v, want the user's main wallet", wallet)
+	}
+	for _, status := range repo.statuses {
+		if status != onboarding.StatusWalletReady {
+			t.Errorf("onboarding advanced to %q, want only %q", status, onboarding.StatusWalletReady)
+		}
+	}
+	if len(repo.statuses) == 0 {
+		t.Errorf("onboarding was not advanced to %q", onboarding.StatusWalletReady)
+	}
+}
+
+// TestHandleUserDeletedEventClosesAccounts checks that deleting a user closes the
+// accounts Anchor reports empty and freezes those that still hold money, whatever the
+// local balance column says.
+func TestHandleUserDeletedEventClosesAccounts(t *testing.T) {
+	userID := eventtest.Onboarding.UserID
+	wallet := &domain.Account{ID: uuid.New(), UserID: userID, AnchorAccountID: "anchor_wallet", AccountPurpose: "main_wallet", Status: "active"}
+	held := &domain.Account{ID: uuid.New(), UserID: userID, AnchorAccountID: "anchor_held", AccountPurpose: "main_wallet", Status: "active"}
+	drop := &domain.Account{ID: uuid.New(), UserID: userID, AnchorAccountID: "anchor_drop", AccountPurpose: "money_drop_wallet", Status: "active"}
+	other := &domain.Account{ID: uuid.New(), UserID: uuid.New(), AnchorAccountID: "anchor_other", AccountPurpose: "main_wallet", Status: "active"}
+	repo := &fakeRepository{accounts: []*domain.Account{wallet, held, drop, other}}
+	anchorClient := &fakeAnchorClient{balances: map[string]anchor.Balance{
+		"anchor_wallet": {AvailableBalance: 150000, LedgerBalance: 150000},
+		"anchor_held":   {Hold: 5000},
+	}}
+	service := NewService(repo, anchorClient)
+
+	broker := memory.NewBroker(messaging.RetryPolicy{MaxAttempts: 3})
+	err := broker.StartConsumer(context.Background(), userDeletedEx, userDeletedQueue, userDeletedRK, "test", service.HandleUserDeletedEvent, messaging.Concurrency{})
+	if err != nil {
+		t.Fatalf("StartConsumer() error = %v", err)
+	}
+
+	deleted := events.UserDeleted{UserID: userID, ClerkID: eventtest.Onboarding.ClerkID, DeletedAt: time.Now().UTC()}
+	eventtest.Publish(t, broker, userDeletedEx, userDeletedRK, "auth", deleted)
+	// A redelivery must leave the accounts as they are.
+	eventtest.Publish(t, broker, userDeletedEx, userDeletedRK, "auth", deleted)
+	broker.Drain()
+
+	if dead := broker.DeadLetters(userDeletedQueue); len(dead) != 0 {
+		t.Fatalf("%d messages were dead-lettered", len(dead))
+	}
+	for _, tt := range []struct {
+		name    string
+		account *domain.Account
+		want    string
+	}{
+		{"funded wallet", wallet, "frozen"},
+		{"wallet with money on hold", held, "frozen"},
+		{"empty wallet", drop, "closed"},
+		{"other user's wallet", other, "active"},
+	} {
+		if tt.account.Status != tt.want {
+			t.Errorf("%s status = %q, want %q", tt.name, tt.account.Status, tt.want)
+		}
+	}
+}
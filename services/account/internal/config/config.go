@@ -10,7 +10,11 @@
  */
 package config
 
-import "github.com/spf13/viper"
+import (
+	"time"
+
+	"github.com/spf13/viper"
+)
 
 // Config stores all configuration for the application.
 // The values are read by viper from a config file or environment variable.
@@ -23,7 +27,19 @@ type Config struct {
 	CustomerVerifiedQueue string `mapstructure:"CUSTOMER_VERIFIED_QUEUE"`
 	CustomerVerifiedEx    string `mapstructure:"CUSTOMER_VERIFIED_EX"`
 	CustomerVerifiedRK    string `mapstructure:"CUSTOMER_VERIFIED_RK"`
+	UserDeletedQueue      string `mapstructure:"USER_DELETED_QUEUE"`
+	UserDeletedEx         string `mapstructure:"USER_DELETED_EX"`
+	UserDeletedRK         string `mapstructure:"USER_DELETED_RK"`
 	ConsumerTag           string `mapstructure:"CONSUMER_TAG"`
+	// MaxDeliveryAttempts is how many times a message is handled before it is dead-lettered.
+	MaxDeliveryAttempts int `mapstructure:"MAX_DELIVERY_ATTEMPTS"`
+	// RetryInitialDelay is the delay before a failed message is first retried. It doubles
+	// with each further attempt.
+	RetryInitialDelay time.Duration `mapstructure:"RETRY_INITIAL_DELAY"`
+	// ConsumerWorkers is how many messages are handled at once.
+	ConsumerWorkers int `mapstructure:"CONSUMER_WORKERS"`
+	// ConsumerPrefetch is how many unacknowledged messages RabbitMQ sends ahead.
+	ConsumerPrefetch int `mapstructure:"CONSUMER_PREFETCH"`
 }
 
 // LoadConfig reads configuration from file or environment variables.
@@ -40,7 +56,14 @@ func LoadConfig() (config Config, err error) {
 	viper.SetDefault("CUSTOMER_VERIFIED_EX", "customer_events")
 	viper.SetDefault("CUSTOMER_VERIFIED_RK", "customer.verified")
 	viper.SetDefault("CUSTOMER_VERIFIED_QUEUE", "account_service_customer_verified")
+	viper.SetDefault("USER_DELETED_EX", "user_events")
+	viper.SetDefault("USER_DELETED_RK", "user.deleted")
+	viper.SetDefault("USER_DELETED_QUEUE", "account_service_user_deleted")
 	viper.SetDefault("CONSUMER_TAG", "account_service_consumer")
+	viper.SetDefault("MAX_DELIVERY_ATTEMPTS", 5)
+	viper.SetDefault("RETRY_INITIAL_DELAY", "10s")
+	viper.SetDefault("CONSUMER_WORKERS", 4)
+	viper.SetDefault("CONSUMER_PREFETCH", 16)
 
 	err = viper.ReadInConfig()
 	// It's okay if the config file is not found, we can rely on env vars.
@@ -4,10 +4,11 @@
  * It encapsulates all database-specific logic, such as creating account records and fetching user data.
  *
  * @dependencies
- * - Go standard library packages: "context", "errors", "fmt"
+ * - Go standard library packages: "context", "errors", "fmt", "log"
  * - "github.com/jackc/pgx/v5": For checking specific database errors.
  * - "github.com/jackc/pgx/v5/pgxpool": The PostgreSQL driver and connection pool.
  * - "transfa/services/account/internal/domain": For core data models.
+ * - "transfa/shared/onboarding": For advancing the user's onboarding status.
  */
 package store
 
@@ -15,14 +16,19 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"transfa/services/account/internal/domain"
+	"transfa/shared/onboarding"
 )
 
-var ErrUserNotFound = errors.New("user not found")
+var (
+	ErrUserNotFound    = errors.New("user not found")
+	ErrAccountNotFound = errors.New("account not found")
+)
 
 // PostgresRepository is the concrete implementation for database operations.
 type PostgresRepository struct {
@@ -59,6 +65,89 @@ func (r *PostgresRepository) CreateAccount(ctx context.Context, account *domain.
 	return account, nil
 }
 
+// GetAccountByUserIDAndPurpose retrieves a user's account with the given purpose, such
+// as their main wallet. It returns ErrAccountNotFound if the user has no such account.
+func (r *PostgresRepository) GetAccountByUserIDAndPurpose(ctx context.Context, userID uuid.UUID, purpose string) (*domain.Account, error) {
+	query := `
+        SELECT id, user_id, anchor_account_id, account_purpose, balance, status, created_at, updated_at
+        FROM public.accounts
+        WHERE user_id = $1 AND account_purpose = $2
+        ORDER BY created_at
+        LIMIT 1
+    `
+
+	var account domain.Account
+	err := r.db.QueryRow(ctx, query, userID, purpose).Scan(
+		&account.ID,
+		&account.UserID,
+		&account.AnchorAccountID,
+		&account.AccountPurpose,
+		&account.Balance,
+		&account.Status,
+		&account.CreatedAt,
+		&account.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("%w: %s account for user %s", ErrAccountNotFound, purpose, userID)
+		}
+		return nil, fmt.Errorf("failed to query account by user id: %w", err)
+	}
+
+	return &account, nil
+}
+
+// GetAccountsByUserID retrieves all of a user's accounts, oldest first.
+func (r *PostgresRepository) GetAccountsByUserID(ctx context.Context, userID uuid.UUID) ([]*domain.Account, error) {
+	query := `
+        SELECT id, user_id, anchor_account_id, account_purpose, balance, status, created_at, updated_at
+        FROM public.accounts
+        WHERE user_id = $1
+        ORDER BY created_at
+    `
+
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query accounts by user id: %w", err)
+	}
+	defer rows.Close()
+
+	var accounts []*domain.Account
+	for rows.Next() {
+		var account domain.Account
+		if err := rows.Scan(
+			&account.ID,
+			&account.UserID,
+			&account.AnchorAccountID,
+			&account.AccountPurpose,
+			&account.Balance,
+			&account.Status,
+			&account.CreatedAt,
+			&account.UpdatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan account: %w", err)
+		}
+		accounts = append(accounts, &account)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read accounts: %w", err)
+	}
+
+	return accounts, nil
+}
+
+// UpdateAccountStatus changes an account's status from `from` to `to`. It returns false
+// if the account's status was no longer `from`, e.g. because it was already changed.
+func (r *PostgresRepository) UpdateAccountStatus(ctx context.Context, accountID uuid.UUID, from, to string) (bool, error) {
+	query := `UPDATE public.accounts SET status = $3 WHERE id = $1 AND status = $2`
+
+	tag, err := r.db.Exec(ctx, query, accountID, from, to)
+	if err != nil {
+		return false, fmt.Errorf("failed to update account status: %w", err)
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
 // GetUserByID retrieves a user's ID and account type from the database.
 // This is necessary to determine what kind of Anchor account to create.
 func (r *PostgresRepository) GetUserByID(ctx context.Context, userID uuid.UUID) (*domain.User, error) {
@@ -75,4 +164,17 @@ func (r *PostgresRepository) GetUserByID(ctx context.Context, userID uuid.UUID)
 	}
 
 	return &user, nil
-}
\ No newline at end of file
+}
+
+// AdvanceOnboarding moves the user's onboarding to status if the state machine allows
+// it from their current status.
+func (r *PostgresRepository) AdvanceOnboarding(ctx context.Context, userID uuid.UUID, status onboarding.Status, reason string) error {
+	advanced, err := onboarding.Advance(ctx, r.db, userID, status, reason)
+	if err != nil {
+		return err
+	}
+	if !advanced {
+		log.Printf("Onboarding of user %s is already past %s; status unchanged", userID, status)
+	}
+	return nil
+}
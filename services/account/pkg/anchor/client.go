@@ -52,6 +52,23 @@ type createAccountResponse struct {
 	} `json:"data"`
 }
 
+// Balance is the balance of a deposit account in Anchor, in kobo.
+type Balance struct {
+	AvailableBalance int64 `json:"availableBalance"`
+	LedgerBalance    int64 `json:"ledgerBalance"`
+	Hold             int64 `json:"hold"`
+}
+
+// Empty reports whether the account holds no money, settled, pending or on hold.
+func (b Balance) Empty() bool {
+	return b.AvailableBalance == 0 && b.LedgerBalance == 0 && b.Hold == 0
+}
+
+// Defines the structure of Anchor's account balance response.
+type balanceResponse struct {
+	Data Balance `json:"data"`
+}
+
 // NewClient creates a new Anchor API client.
 func NewClient(baseURL, apiKey string) *Client {
 	return &Client{
@@ -112,4 +129,32 @@ func (c *Client) CreateDepositAccount(ctx context.Context, anchorCustomerID, cus
 	}
 
 	return successRes.Data.ID, nil
-}
\ No newline at end of file
+}
+
+// GetAccountBalance fetches the current balance of a deposit account from Anchor.
+func (c *Client) GetAccountBalance(ctx context.Context, anchorAccountID string) (*Balance, error) {
+	url := fmt.Sprintf("%s/api/v1/accounts/balance/%s", c.BaseURL, anchorAccountID)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create new http request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("x-anchor-key", c.APIKey)
+
+	res, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute request to anchor: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(res.Body)
+		return nil, fmt.Errorf("anchor API returned non-success status: %s, body: %s", res.Status, string(bodyBytes))
+	}
+
+	var balanceRes balanceResponse
+	if err := json.NewDecoder(res.Body).Decode(&balanceRes); err != nil {
+		return nil, fmt.Errorf("failed to decode balance response from anchor: %w", err)
+	}
+	return &balanceRes.Data, nil
+}
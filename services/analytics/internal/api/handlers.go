@@ -0,0 +1,131 @@
+/**
+ * @description
+ * This file contains the HTTP handlers for the Analytics service. Handlers are responsible
+ * for parsing incoming requests, calling the appropriate application service method,
+ * and writing the HTTP response.
+ *
+ * @dependencies
+ * - "encoding/json": For JSON serialization.
+ * - "errors": For mapping service errors to HTTP status codes.
+ * - "log": For logging.
+ * - "net/http": For standard HTTP handling.
+ * - "time": For parsing dates in query parameters.
+ * - "transfa/services/analytics/internal/app": Imports the application service layer.
+ * - "transfa/services/analytics/internal/domain": Imports the data models/DTOs.
+ * - "transfa/shared/apierror": For JSON:API error responses.
+ * - "transfa/shared/authn": For the Clerk User ID of the caller.
+ */
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"transfa/services/analytics/internal/app"
+	"transfa/services/analytics/internal/domain"
+	"transfa/shared/apierror"
+	"transfa/shared/authn"
+)
+
+// AnalyticsHandler holds dependencies for the analytics-related HTTP handlers.
+type AnalyticsHandler struct {
+	service *app.Service
+}
+
+// NewAnalyticsHandler creates a new handler with the given application service.
+func NewAnalyticsHandler(service *app.Service) *AnalyticsHandler {
+	return &AnalyticsHandler{
+		service: service,
+	}
+}
+
+// GetCashFlowHandler handles the `GET /analytics/cash-flow?from=&to=&granularity=` request.
+// `from` and `to` are optional dates (YYYY-MM-DD); `granularity` is `day` (default) or `month`.
+func (h *AnalyticsHandler) GetCashFlowHandler(w http.ResponseWriter, r *http.Request) {
+	clerkID, ok := authn.SubjectFromContext(r.Context())
+	if !ok {
+		apierror.Write(w, http.StatusUnauthorized, "could not retrieve claims")
+		return
+	}
+
+	query := domain.CashFlowQuery{Granularity: r.URL.Query().Get("granularity")}
+	var err error
+	if query.From, err = parseQueryTime(r, "from", "2006-01-02"); err != nil {
+		apierror.Write(w, http.StatusBadRequest, "invalid from date")
+		return
+	}
+	if query.To, err = parseQueryTime(r, "to", "2006-01-02"); err != nil {
+		apierror.Write(w, http.StatusBadRequest, "invalid to date")
+		return
+	}
+
+	cashFlow, err := h.service.GetCashFlow(r.Context(), clerkID, query)
+	if err != nil {
+		log.Printf("Failed to get cash flow for clerk_id %s: %v", clerkID, err)
+		writeServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, cashFlow)
+}
+
+// GetSpendingSummaryHandler handles the `GET /analytics/spending-summary?month=` request.
+// `month` is an optional month (YYYY-MM) and defaults to the current month.
+func (h *AnalyticsHandler) GetSpendingSummaryHandler(w http.ResponseWriter, r *http.Request) {
+	clerkID, ok := authn.SubjectFromContext(r.Context())
+	if !ok {
+		apierror.Write(w, http.StatusUnauthorized, "could not retrieve claims")
+		return
+	}
+
+	month, err := parseQueryTime(r, "month", "2006-01")
+	if err != nil {
+		apierror.Write(w, http.StatusBadRequest, "invalid month")
+		return
+	}
+
+	summary, err := h.service.GetSpendingSummary(r.Context(), clerkID, month)
+	if err != nil {
+		log.Printf("Failed to get spending summary for clerk_id %s: %v", clerkID, err)
+		writeServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, summary)
+}
+
+// parseQueryTime parses an optional query parameter with the given layout. A missing
+// parameter yields the zero time.
+func parseQueryTime(r *http.Request, name, layout string) (time.Time, error) {
+	raw := r.URL.Query().Get(name)
+	if raw == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(layout, raw)
+}
+
+// writeServiceError maps application errors to HTTP status codes.
+func writeServiceError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, app.ErrInvalidGranularity),
+		errors.Is(err, app.ErrInvalidRange),
+		errors.Is(err, app.ErrRangeTooLarge):
+		apierror.Write(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, app.ErrUserNotFound):
+		apierror.Write(w, http.StatusNotFound, err.Error())
+	default:
+		apierror.Write(w, http.StatusInternalServerError, "")
+	}
+}
+
+// writeJSON writes a JSON response with the given status code.
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		log.Printf("Failed to write response: %v", err)
+	}
+}
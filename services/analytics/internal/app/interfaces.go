@@ -0,0 +1,29 @@
+/**
+ * @description
+ * This file defines the interfaces (ports) for the Analytics service's application logic.
+ * These interfaces define the contracts for external dependencies, such as the database,
+ * allowing for a clean separation of concerns and easier testing.
+ *
+ * @dependencies
+ * - "context": For passing request-scoped data and cancellation signals.
+ * - "time": For aggregate periods.
+ * - "github.com/google/uuid": For record identifiers.
+ * - "transfa/services/analytics/internal/domain": For core data models.
+ */
+package app
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"transfa/services/analytics/internal/domain"
+)
+
+// Repository defines the interface for data persistence operations.
+type Repository interface {
+	GetUserIDByClerkID(ctx context.Context, clerkID string) (uuid.UUID, error)
+	ApplyTransaction(ctx context.Context, transactionID uuid.UUID, completedAt time.Time, entries []domain.CashFlowEntry) (bool, error)
+	GetCashFlow(ctx context.Context, userID uuid.UUID, granularity string, from, to time.Time) (map[time.Time]domain.CashFlowPoint, error)
+	GetCategorySpending(ctx context.Context, userID uuid.UUID, month time.Time) ([]domain.CategorySpending, error)
+}
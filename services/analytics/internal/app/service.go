@@ -0,0 +1,250 @@
+/**
+ * @description
+ * This file contains the core business logic for the Analytics service. The Service struct
+ * turns `transaction.completed` events into per-user cash flow and spending aggregates,
+ * and serves those aggregates to the Analytics tab.
+ *
+ * Key features:
+ * - Each transaction is money out for its sender (amount plus fee) and money in for its
+ *   recipient. Transfers between two of the same user's wallets (such as funding a Money
+ *   Drop) do not change that user's cash flow and are not counted.
+ * - Spending is grouped by the transaction's category, falling back to its type.
+ * - Events are applied idempotently on transaction_id, so redeliveries are harmless.
+ * - All periods are UTC days and months.
+ *
+ * @dependencies
+ * - Go standard libraries: "context", "errors", "fmt", "log", "time"
+ * - "github.com/rabbitmq/amqp091-go": For message handling.
+ * - "transfa/services/analytics/internal/domain": For core data models.
+ * - "transfa/services/analytics/internal/store": For repository error values.
+ * - "transfa/shared/events": For the `transaction.completed` event.
+ * - "transfa/shared/messaging": For marking errors that must not be retried.
+ */
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rabbitmq/amqp091-go"
+	"transfa/services/analytics/internal/domain"
+	"transfa/services/analytics/internal/store"
+	"transfa/shared/events"
+	"transfa/shared/messaging"
+)
+
+const (
+	// Date formats used in query parameters and responses.
+	dateLayout  = "2006-01-02"
+	monthLayout = "2006-01"
+
+	// Default and maximum number of periods returned by GetCashFlow.
+	defaultCashFlowDays   = 30
+	defaultCashFlowMonths = 12
+	maxCashFlowDays       = 366
+	maxCashFlowMonths     = 60
+)
+
+var (
+	ErrUserNotFound       = errors.New("user not found")
+	ErrInvalidGranularity = errors.New("granularity must be one of day or month")
+	ErrInvalidRange       = errors.New("from must not be after to")
+	ErrRangeTooLarge      = errors.New("requested range is too large")
+)
+
+// Service provides the application's business logic for analytics.
+type Service struct {
+	repo Repository
+}
+
+// NewService creates a new application service.
+func NewService(repo Repository) *Service {
+	return &Service{
+		repo: repo,
+	}
+}
+
+// HandleTransactionCompletedEvent is the message handler for `transaction.completed` events.
+func (s *Service) HandleTransactionCompletedEvent(ctx context.Context, msg amqp091.Delivery) error {
+	var event events.TransactionCompleted
+	if _, err := events.DecodeDelivery(msg, &event); err != nil {
+		return err
+	}
+	if event.TransactionID == uuid.Nil {
+		return messaging.Permanent(fmt.Errorf("transaction.completed event is missing transaction_id"))
+	}
+
+	applied, err := s.repo.ApplyTransaction(ctx, event.TransactionID, event.CompletedAt, cashFlowEntries(event))
+	if err != nil {
+		return fmt.Errorf("failed to apply transaction %s: %w", event.TransactionID, err)
+	}
+	if !applied {
+		log.Printf("Transaction %s was already applied; ignoring redelivered event", event.TransactionID)
+		return nil
+	}
+
+	log.Printf("Applied transaction %s to analytics aggregates", event.TransactionID)
+	return nil
+}
+
+// cashFlowEntries returns the effect of a completed transaction on each party's aggregates.
+func cashFlowEntries(event events.TransactionCompleted) []domain.CashFlowEntry {
+	sender, recipient := event.SenderUserID, event.RecipientUserID
+	if sender.Valid && recipient.Valid && sender.UUID == recipient.UUID {
+		// Money moved between the user's own wallets.
+		return nil
+	}
+
+	category := event.Type
+	if event.Category != nil && *event.Category != "" {
+		category = *event.Category
+	}
+
+	var entries []domain.CashFlowEntry
+	if sender.Valid {
+		entries = append(entries, domain.CashFlowEntry{
+			UserID:    sender.UUID,
+			Direction: domain.DirectionOut,
+			Category:  category,
+			Amount:    event.Amount + event.Fee,
+		})
+	}
+	if recipient.Valid {
+		entries = append(entries, domain.CashFlowEntry{
+			UserID:    recipient.UUID,
+			Direction: domain.DirectionIn,
+			Category:  category,
+			Amount:    event.Amount,
+		})
+	}
+	return entries
+}
+
+// GetCashFlow returns the authenticated user's money in and money out per day or month.
+// By default it covers the last 30 days, or the last 12 months, up to today.
+func (s *Service) GetCashFlow(ctx context.Context, clerkID string, query domain.CashFlowQuery) (*domain.CashFlow, error) {
+	granularity := query.Granularity
+	if granularity == "" {
+		granularity = domain.GranularityDay
+	}
+
+	// Step 1: Normalise the range to period boundaries and apply defaults.
+	var step func(time.Time) time.Time
+	var layout string
+	var from, to time.Time
+	switch granularity {
+	case domain.GranularityDay:
+		step = func(t time.Time) time.Time { return t.AddDate(0, 0, 1) }
+		layout = dateLayout
+		to = startOfDay(orNow(query.To))
+		from = to.AddDate(0, 0, 1-defaultCashFlowDays)
+		if !query.From.IsZero() {
+			from = startOfDay(query.From)
+		}
+	case domain.GranularityMonth:
+		step = func(t time.Time) time.Time { return t.AddDate(0, 1, 0) }
+		layout = monthLayout
+		to = startOfMonth(orNow(query.To))
+		from = to.AddDate(0, 1-defaultCashFlowMonths, 0)
+		if !query.From.IsZero() {
+			from = startOfMonth(query.From)
+		}
+	default:
+		return nil, ErrInvalidGranularity
+	}
+	if from.After(to) {
+		return nil, ErrInvalidRange
+	}
+	if granularity == domain.GranularityDay && int(to.Sub(from).Hours()/24)+1 > maxCashFlowDays {
+		return nil, ErrRangeTooLarge
+	}
+	if granularity == domain.GranularityMonth && (to.Year()-from.Year())*12+int(to.Month()-from.Month())+1 > maxCashFlowMonths {
+		return nil, ErrRangeTooLarge
+	}
+
+	// Step 2: Read the aggregates and fill in periods without activity.
+	userID, err := s.resolveUser(ctx, clerkID)
+	if err != nil {
+		return nil, err
+	}
+	stored, err := s.repo.GetCashFlow(ctx, userID, granularity, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	cashFlow := &domain.CashFlow{
+		Granularity: granularity,
+		From:        from.Format(layout),
+		To:          to.Format(layout),
+		Points:      []domain.CashFlowPoint{},
+	}
+	for period := from; !period.After(to); period = step(period) {
+		point := stored[period]
+		point.Period = period.Format(layout)
+		point.Net = point.MoneyIn - point.MoneyOut
+		cashFlow.TotalMoneyIn += point.MoneyIn
+		cashFlow.TotalMoneyOut += point.MoneyOut
+		cashFlow.Points = append(cashFlow.Points, point)
+	}
+	return cashFlow, nil
+}
+
+// GetSpendingSummary returns the authenticated user's spending by category for the
+// month containing `month`, or the current month if it is zero.
+func (s *Service) GetSpendingSummary(ctx context.Context, clerkID string, month time.Time) (*domain.SpendingSummary, error) {
+	start := startOfMonth(orNow(month))
+
+	userID, err := s.resolveUser(ctx, clerkID)
+	if err != nil {
+		return nil, err
+	}
+	categories, err := s.repo.GetCategorySpending(ctx, userID, start)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &domain.SpendingSummary{
+		Month:      start.Format(monthLayout),
+		Categories: categories,
+	}
+	for _, c := range categories {
+		summary.TotalSpent += c.Amount
+	}
+	return summary, nil
+}
+
+// resolveUser looks up the authenticated user's internal ID.
+func (s *Service) resolveUser(ctx context.Context, clerkID string) (uuid.UUID, error) {
+	userID, err := s.repo.GetUserIDByClerkID(ctx, clerkID)
+	if err != nil {
+		if errors.Is(err, store.ErrUserNotFound) {
+			return uuid.Nil, ErrUserNotFound
+		}
+		return uuid.Nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	return userID, nil
+}
+
+// orNow returns t, or the current time if t is zero.
+func orNow(t time.Time) time.Time {
+	if t.IsZero() {
+		return time.Now()
+	}
+	return t
+}
+
+// startOfDay returns the start of t's UTC day.
+func startOfDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// startOfMonth returns the start of t's UTC month.
+func startOfMonth(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,205 @@
+package app
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"transfa/services/analytics/internal/domain"
+	"transfa/services/analytics/internal/store"
+	"transfa/shared/events"
+	"transfa/shared/eventtest"
+	"transfa/shared/messaging"
+	"transfa/shared/messaging/memory"
+)
+
+const (
+	transactionCompletedEx    = "transaction_events"
+	transactionCompletedRK    = "transaction.completed"
+	transactionCompletedQueue = "analytics_service_transaction_completed"
+)
+
+// periodKey identifies one user's aggregate for a UTC day or month.
+type periodKey struct {
+	userID uuid.UUID
+	period time.Time
+}
+
+// categoryKey identifies one user's spending in a category for a UTC month.
+type categoryKey struct {
+	userID   uuid.UUID
+	month    time.Time
+	category string
+}
+
+// fakeRepository keeps the aggregates in memory and, like the Postgres repository,
+// applies each transaction ID once.
+type fakeRepository struct {
+	users     map[string]uuid.UUID
+	processed map[uuid.UUID]bool
+	daily     map[periodKey]domain.CashFlowPoint
+	monthly   map[periodKey]domain.CashFlowPoint
+	spending  map[categoryKey]domain.CategorySpending
+}
+
+func newFakeRepository() *fakeRepository {
+	return &fakeRepository{
+		users:     make(map[string]uuid.UUID),
+		processed: make(map[uuid.UUID]bool),
+		daily:     make(map[periodKey]domain.CashFlowPoint),
+		monthly:   make(map[periodKey]domain.CashFlowPoint),
+		spending:  make(map[categoryKey]domain.CategorySpending),
+	}
+}
+
+func (r *fakeRepository) GetUserIDByClerkID(ctx context.Context, clerkID string) (uuid.UUID, error) {
+	userID, ok := r.users[clerkID]
+	if !ok {
+		return uuid.Nil, store.ErrUserNotFound
+	}
+	return userID, nil
+}
+
+func (r *fakeRepository) ApplyTransaction(ctx context.Context, transactionID uuid.UUID, completedAt time.Time, entries []domain.CashFlowEntry) (bool, error) {
+	if r.processed[transactionID] {
+		return false, nil
+	}
+	r.processed[transactionID] = true
+
+	day, month := startOfDay(completedAt), startOfMonth(completedAt)
+	for _, entry := range entries {
+		for _, agg := range []struct {
+			points map[periodKey]domain.CashFlowPoint
+			period time.Time
+		}{{r.daily, day}, {r.monthly, month}} {
+			key := periodKey{entry.UserID, agg.period}
+			point := agg.points[key]
+			if entry.Direction == domain.DirectionIn {
+				point.MoneyIn += entry.Amount
+			} else {
+				point.MoneyOut += entry.Amount
+			}
+			agg.points[key] = point
+		}
+
+		if entry.Direction != domain.DirectionOut {
+			continue
+		}
+		key := categoryKey{entry.UserID, month, entry.Category}
+		spending := r.spending[key]
+		spending.Category = entry.Category
+		spending.Amount += entry.Amount
+		spending.TransactionCount++
+		r.spending[key] = spending
+	}
+	return true, nil
+}
+
+func (r *fakeRepository) GetCashFlow(ctx context.Context, userID uuid.UUID, granularity string, from, to time.Time) (map[time.Time]domain.CashFlowPoint, error) {
+	points := r.daily
+	if granularity == domain.GranularityMonth {
+		points = r.monthly
+	}
+	result := make(map[time.Time]domain.CashFlowPoint)
+	for key, point := range points {
+		if key.userID == userID && !key.period.Before(from) && !key.period.After(to) {
+			result[key.period] = point
+		}
+	}
+	return result, nil
+}
+
+func (r *fakeRepository) GetCategorySpending(ctx context.Context, userID uuid.UUID, month time.Time) ([]domain.CategorySpending, error) {
+	var result []domain.CategorySpending
+	for key, spending := range r.spending {
+		if key.userID == userID && key.month.Equal(month) {
+			result = append(result, spending)
+		}
+	}
+	return result, nil
+}
+
+// TestRedeliveredTransactionIsCountedOnce delivers the same `transaction.completed`
+// twice, as the Transaction service's outbox may after a crash, and checks that the
+// daily, monthly and category aggregates of both parties count it once.
+func TestRedeliveredTransactionIsCountedOnce(t *testing.T) {
+	repo := newFakeRepository()
+	sender, recipient := uuid.New(), uuid.New()
+	repo.users["clerk_sender"] = sender
+	repo.users["clerk_recipient"] = recipient
+
+	service := NewService(repo)
+	broker := memory.NewBroker(messaging.RetryPolicy{MaxAttempts: 3})
+	err := broker.StartConsumer(context.Background(), transactionCompletedEx, transactionCompletedQueue, transactionCompletedRK, "test", service.HandleTransactionCompletedEvent, messaging.Concurrency{})
+	if err != nil {
+		t.Fatalf("StartConsumer() error = %v", err)
+	}
+
+	completedAt := time.Date(2024, time.March, 14, 15, 30, 0, 0, time.UTC)
+	category := "food"
+	transfer := events.TransactionCompleted{
+		TransactionID:   uuid.New(),
+		Type:            "p2p_transfer",
+		Amount:          50000,
+		Fee:             1000,
+		Category:        &category,
+		SenderUserID:    uuid.NullUUID{UUID: sender, Valid: true},
+		RecipientUserID: uuid.NullUUID{UUID: recipient, Valid: true},
+		CompletedAt:     completedAt,
+	}
+	eventtest.Publish(t, broker, transactionCompletedEx, transactionCompletedRK, "transaction", transfer)
+	eventtest.Publish(t, broker, transactionCompletedEx, transactionCompletedRK, "transaction", transfer)
+	// A different transaction in the same category must still be counted.
+	later := transfer
+	later.TransactionID = uuid.New()
+	later.Amount, later.Fee = 20000, 0
+	eventtest.Publish(t, broker, transactionCompletedEx, transactionCompletedRK, "transaction", later)
+	broker.Drain()
+
+	if dead := broker.DeadLetters(transactionCompletedQueue); len(dead) != 0 {
+		t.Fatalf("%d event(s) were dead-lettered, want none", len(dead))
+	}
+
+	ctx := context.Background()
+	for _, tt := range []struct {
+		clerkID           string
+		moneyIn, moneyOut int64
+	}{
+		{"clerk_sender", 0, 71000},
+		{"clerk_recipient", 70000, 0},
+	} {
+		for _, granularity := range []string{domain.GranularityDay, domain.GranularityMonth} {
+			cashFlow, err := service.GetCashFlow(ctx, tt.clerkID, domain.CashFlowQuery{From: completedAt, To: completedAt, Granularity: granularity})
+			if err != nil {
+				t.Fatalf("GetCashFlow(%s, %s) error = %v", tt.clerkID, granularity, err)
+			}
+			if len(cashFlow.Points) != 1 {
+				t.Fatalf("GetCashFlow(%s, %s) returned %d points, want 1", tt.clerkID, granularity, len(cashFlow.Points))
+			}
+			if got := cashFlow.Points[0]; got.MoneyIn != tt.moneyIn || got.MoneyOut != tt.moneyOut {
+				t.Errorf("%s %s cash flow = in %d, out %d; want in %d, out %d", tt.clerkID, granularity, got.MoneyIn, got.MoneyOut, tt.moneyIn, tt.moneyOut)
+			}
+		}
+	}
+
+	summary, err := service.GetSpendingSummary(ctx, "clerk_sender", completedAt)
+	if err != nil {
+		t.Fatalf("GetSpendingSummary() error = %v", err)
+	}
+	want := domain.CategorySpending{Category: "food", Amount: 71000, TransactionCount: 2}
+	if len(summary.Categories) != 1 || summary.Categories[0] != want {
+		t.Errorf("spending categories = %+v, want [%+v]", summary.Categories, want)
+	}
+	if summary.TotalSpent != 71000 {
+		t.Errorf("total spent = %d, want 71000", summary.TotalSpent)
+	}
+
+	summary, err = service.GetSpendingSummary(ctx, "clerk_recipient", completedAt)
+	if err != nil {
+		t.Fatalf("GetSpendingSummary() error = %v", err)
+	}
+	if len(summary.Categories) != 0 {
+		t.Errorf("recipient spending categories = %+v, want none", summary.Categories)
+	}
+}
@@ -0,0 +1,78 @@
+/**
+ * @description
+ * This file handles configuration management for the Analytics service.
+ * It uses the Viper library to read configuration from environment variables
+ * and a local .env file, making the service easily configurable across
+ * different environments (development, staging, production).
+ *
+ * @dependencies
+ * - "github.com/spf13/viper": A popular library for handling application configuration.
+ */
+package config
+
+import (
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// Config stores all configuration for the application.
+// The values are read by viper from a config file or environment variable.
+type Config struct {
+	DatabaseURL string `mapstructure:"DATABASE_URL"`
+	RabbitMQURL string `mapstructure:"RABBITMQ_URL"`
+	// ClerkIssuer is the Clerk instance's Frontend API URL, the `iss` of its session tokens.
+	ClerkIssuer string `mapstructure:"CLERK_ISSUER"`
+	// ClerkJWKSURL overrides where Clerk's signing keys are fetched from.
+	ClerkJWKSURL string `mapstructure:"CLERK_JWKS_URL"`
+	// ClerkAudience, if set, must be an audience of every session token.
+	ClerkAudience string `mapstructure:"CLERK_AUDIENCE"`
+	// ClerkClockSkew is the clock skew allowed when checking session token times.
+	ClerkClockSkew            time.Duration `mapstructure:"CLERK_CLOCK_SKEW"`
+	Port                      string        `mapstructure:"PORT"`
+	TransactionCompletedEx    string        `mapstructure:"TRANSACTION_COMPLETED_EX"`
+	TransactionCompletedRK    string        `mapstructure:"TRANSACTION_COMPLETED_RK"`
+	TransactionCompletedQueue string        `mapstructure:"TRANSACTION_COMPLETED_QUEUE"`
+	ConsumerTag               string        `mapstructure:"CONSUMER_TAG"`
+	// MaxDeliveryAttempts is how many times a message is handled before it is dead-lettered.
+	MaxDeliveryAttempts int `mapstructure:"MAX_DELIVERY_ATTEMPTS"`
+	// RetryInitialDelay is the delay before a failed message is first retried. It doubles
+	// with each further attempt.
+	RetryInitialDelay time.Duration `mapstructure:"RETRY_INITIAL_DELAY"`
+	// ConsumerWorkers is how many messages are handled at once.
+	ConsumerWorkers int `mapstructure:"CONSUMER_WORKERS"`
+	// ConsumerPrefetch is how many unacknowledged messages RabbitMQ sends ahead.
+	ConsumerPrefetch int `mapstructure:"CONSUMER_PREFETCH"`
+}
+
+// LoadConfig reads configuration from file or environment variables.
+func LoadConfig() (config Config, err error) {
+	viper.AddConfigPath("./")
+	viper.SetConfigName(".env")
+	viper.SetConfigType("env")
+
+	viper.AutomaticEnv()
+
+	// Set default values for robust startup
+	viper.SetDefault("PORT", "8087") // Use a different default port from other services
+	viper.SetDefault("CLERK_CLOCK_SKEW", "5s")
+	viper.SetDefault("TRANSACTION_COMPLETED_EX", "transaction_events")
+	viper.SetDefault("TRANSACTION_COMPLETED_RK", "transaction.completed")
+	viper.SetDefault("TRANSACTION_COMPLETED_QUEUE", "analytics_service_transaction_completed")
+	viper.SetDefault("CONSUMER_TAG", "analytics_service_consumer")
+	viper.SetDefault("MAX_DELIVERY_ATTEMPTS", 5)
+	viper.SetDefault("RETRY_INITIAL_DELAY", "10s")
+	viper.SetDefault("CONSUMER_WORKERS", 4)
+	viper.SetDefault("CONSUMER_PREFETCH", 16)
+
+	err = viper.ReadInConfig()
+	// It's okay if the config file is not found, we can rely on env vars.
+	if err != nil {
+		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
+			return
+		}
+	}
+
+	err = viper.Unmarshal(&config)
+	return
+}
@@ -0,0 +1,80 @@
+/**
+ * @description
+ * This file defines the core domain models for the Analytics service.
+ *
+ * Key features:
+ * - `CashFlowEntry`: One user's side of a transaction, as applied to the aggregate tables.
+ * - `CashFlow` and `SpendingSummary`: The response bodies of the analytics endpoints.
+ *
+ * @dependencies
+ * - "time": Used for timestamps and aggregate periods.
+ * - "github.com/google/uuid": Used for universally unique identifiers.
+ */
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Cash flow granularities accepted by `GET /analytics/cash-flow`.
+const (
+	GranularityDay   = "day"
+	GranularityMonth = "month"
+)
+
+// Directions of a cash flow entry.
+const (
+	DirectionIn  = "in"
+	DirectionOut = "out"
+)
+
+// CashFlowEntry is the effect of a transaction on one user's aggregates.
+type CashFlowEntry struct {
+	UserID    uuid.UUID
+	Direction string
+	Category  string
+	Amount    int64 // In kobo
+}
+
+// CashFlowQuery describes the range requested from `GET /analytics/cash-flow`.
+// Zero values select the defaults.
+type CashFlowQuery struct {
+	From        time.Time
+	To          time.Time
+	Granularity string
+}
+
+// CashFlowPoint is the money in and money out of one day or month.
+type CashFlowPoint struct {
+	Period   string `json:"period"`    // YYYY-MM-DD for days, YYYY-MM for months
+	MoneyIn  int64  `json:"money_in"`  // In kobo
+	MoneyOut int64  `json:"money_out"` // In kobo
+	Net      int64  `json:"net"`       // In kobo
+}
+
+// CashFlow is the response body of `GET /analytics/cash-flow`. Points cover every
+// period in the range, including periods without activity.
+type CashFlow struct {
+	Granularity   string          `json:"granularity"`
+	From          string          `json:"from"`
+	To            string          `json:"to"`
+	TotalMoneyIn  int64           `json:"total_money_in"`
+	TotalMoneyOut int64           `json:"total_money_out"`
+	Points        []CashFlowPoint `json:"points"`
+}
+
+// CategorySpending is the money out in one category.
+type CategorySpending struct {
+	Category         string `json:"category"`
+	Amount           int64  `json:"amount"` // In kobo
+	TransactionCount int    `json:"transaction_count"`
+}
+
+// SpendingSummary is the response body of `GET /analytics/spending-summary`.
+type SpendingSummary struct {
+	Month      string             `json:"month"`       // YYYY-MM
+	TotalSpent int64              `json:"total_spent"` // In kobo
+	Categories []CategorySpending `json:"categories"`
+}
@@ -0,0 +1,207 @@
+/**
+ * @description
+ * This file provides the PostgreSQL implementation of the Repository interface for the
+ * Analytics service. It maintains the pre-aggregated cash flow and spending tables and
+ * serves reads from them.
+ *
+ * Applying a transaction is idempotent: the transaction ID is recorded in
+ * `analytics_processed_transactions` in the same database transaction as the aggregate
+ * updates, so a redelivered event changes nothing.
+ *
+ * @dependencies
+ * - Go standard library packages: "context", "errors", "fmt", "time"
+ * - "github.com/google/uuid": For record identifiers.
+ * - "github.com/jackc/pgx/v5": For checking specific database errors.
+ * - "github.com/jackc/pgx/v5/pgxpool": The PostgreSQL driver and connection pool.
+ * - "transfa/services/analytics/internal/domain": For core data models.
+ */
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"transfa/services/analytics/internal/domain"
+)
+
+var ErrUserNotFound = errors.New("user not found")
+
+// PostgresRepository is the concrete implementation for database operations.
+type PostgresRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewPostgresRepository creates a new repository with a database connection pool.
+func NewPostgresRepository(db *pgxpool.Pool) *PostgresRepository {
+	return &PostgresRepository{
+		db: db,
+	}
+}
+
+// GetUserIDByClerkID resolves the internal user ID for a Clerk User ID.
+func (r *PostgresRepository) GetUserIDByClerkID(ctx context.Context, clerkID string) (uuid.UUID, error) {
+	var userID uuid.UUID
+	err := r.db.QueryRow(ctx, `SELECT id FROM public.users WHERE clerk_id = $1`, clerkID).Scan(&userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return uuid.Nil, fmt.Errorf("%w: with clerk_id %s", ErrUserNotFound, clerkID)
+		}
+		return uuid.Nil, fmt.Errorf("failed to query user by clerk id: %w", err)
+	}
+	return userID, nil
+}
+
+// ApplyTransaction adds a transaction's cash flow entries to the aggregates of the UTC
+// day and month it completed in. It reports false, and writes nothing, if the
+// transaction was already applied.
+func (r *PostgresRepository) ApplyTransaction(ctx context.Context, transactionID uuid.UUID, completedAt time.Time, entries []domain.CashFlowEntry) (bool, error) {
+	dbTx, err := r.db.Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin aggregate transaction: %w", err)
+	}
+	defer dbTx.Rollback(ctx)
+
+	// Step 1: Claim the transaction. A conflict means it was already counted.
+	cmdTag, err := dbTx.Exec(ctx, `
+        INSERT INTO public.analytics_processed_transactions (transaction_id)
+        VALUES ($1)
+        ON CONFLICT (transaction_id) DO NOTHING
+    `, transactionID)
+	if err != nil {
+		return false, fmt.Errorf("failed to record processed transaction: %w", err)
+	}
+	if cmdTag.RowsAffected() == 0 {
+		return false, nil
+	}
+
+	// Step 2: Update the aggregates.
+	completedAt = completedAt.UTC()
+	day := time.Date(completedAt.Year(), completedAt.Month(), completedAt.Day(), 0, 0, 0, 0, time.UTC)
+	month := time.Date(completedAt.Year(), completedAt.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	for _, entry := range entries {
+		var moneyIn, moneyOut int64
+		if entry.Direction == domain.DirectionIn {
+			moneyIn = entry.Amount
+		} else {
+			moneyOut = entry.Amount
+		}
+
+		_, err = dbTx.Exec(ctx, `
+            INSERT INTO public.analytics_daily_cash_flow (user_id, day, money_in, money_out)
+            VALUES ($1, $2, $3, $4)
+            ON CONFLICT (user_id, day) DO UPDATE
+            SET money_in = public.analytics_daily_cash_flow.money_in + EXCLUDED.money_in,
+                money_out = public.analytics_daily_cash_flow.money_out + EXCLUDED.money_out
+        `, entry.UserID, day, moneyIn, moneyOut)
+		if err != nil {
+			return false, fmt.Errorf("failed to update daily cash flow: %w", err)
+		}
+
+		_, err = dbTx.Exec(ctx, `
+            INSERT INTO public.analytics_monthly_cash_flow (user_id, month, money_in, money_out)
+            VALUES ($1, $2, $3, $4)
+            ON CONFLICT (user_id, month) DO UPDATE
+            SET money_in = public.analytics_monthly_cash_flow.money_in + EXCLUDED.money_in,
+                money_out = public.analytics_monthly_cash_flow.money_out + EXCLUDED.money_out
+        `, entry.UserID, month, moneyIn, moneyOut)
+		if err != nil {
+			return false, fmt.Errorf("failed to update monthly cash flow: %w", err)
+		}
+
+		if entry.Direction != domain.DirectionOut {
+			continue
+		}
+		_, err = dbTx.Exec(ctx, `
+            INSERT INTO public.analytics_category_spending (user_id, month, category, amount, transaction_count)
+            VALUES ($1, $2, $3, $4, 1)
+            ON CONFLICT (user_id, month, category) DO UPDATE
+            SET amount = public.analytics_category_spending.amount + EXCLUDED.amount,
+                transaction_count = public.analytics_category_spending.transaction_count + 1
+        `, entry.UserID, month, entry.Category, entry.Amount)
+		if err != nil {
+			return false, fmt.Errorf("failed to update category spending: %w", err)
+		}
+	}
+
+	if err := dbTx.Commit(ctx); err != nil {
+		return false, fmt.Errorf("failed to commit aggregate transaction: %w", err)
+	}
+	return true, nil
+}
+
+// GetCashFlow returns the user's cash flow aggregates for the days or months from `from`
+// to `to` inclusive, keyed by the UTC start of each period. Periods without activity
+// are omitted.
+func (r *PostgresRepository) GetCashFlow(ctx context.Context, userID uuid.UUID, granularity string, from, to time.Time) (map[time.Time]domain.CashFlowPoint, error) {
+	var query string
+	switch granularity {
+	case domain.GranularityDay:
+		query = `
+            SELECT day, money_in, money_out
+            FROM public.analytics_daily_cash_flow
+            WHERE user_id = $1 AND day BETWEEN $2 AND $3
+        `
+	case domain.GranularityMonth:
+		query = `
+            SELECT month, money_in, money_out
+            FROM public.analytics_monthly_cash_flow
+            WHERE user_id = $1 AND month BETWEEN $2 AND $3
+        `
+	default:
+		return nil, fmt.Errorf("unknown cash flow granularity %q", granularity)
+	}
+
+	rows, err := r.db.Query(ctx, query, userID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cash flow: %w", err)
+	}
+	defer rows.Close()
+
+	points := map[time.Time]domain.CashFlowPoint{}
+	for rows.Next() {
+		var period time.Time
+		var point domain.CashFlowPoint
+		if err := rows.Scan(&period, &point.MoneyIn, &point.MoneyOut); err != nil {
+			return nil, fmt.Errorf("failed to scan cash flow: %w", err)
+		}
+		points[period.UTC()] = point
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get cash flow: %w", err)
+	}
+	return points, nil
+}
+
+// GetCategorySpending returns the user's spending by category in the month starting at
+// `month`, largest first.
+func (r *PostgresRepository) GetCategorySpending(ctx context.Context, userID uuid.UUID, month time.Time) ([]domain.CategorySpending, error) {
+	rows, err := r.db.Query(ctx, `
+        SELECT category, amount, transaction_count
+        FROM public.analytics_category_spending
+        WHERE user_id = $1 AND month = $2
+        ORDER BY amount DESC, category
+    `, userID, month)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get category spending: %w", err)
+	}
+	defer rows.Close()
+
+	categories := []domain.CategorySpending{}
+	for rows.Next() {
+		var c domain.CategorySpending
+		if err := rows.Scan(&c.Category, &c.Amount, &c.TransactionCount); err != nil {
+			return nil, fmt.Errorf("failed to scan category spending: %w", err)
+		}
+		categories = append(categories, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get category spending: %w", err)
+	}
+	return categories, nil
+}
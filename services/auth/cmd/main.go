@@ -6,17 +6,17 @@
  * - Loading configuration from environment variables.
  * - Establishing connections to external services (PostgreSQL, RabbitMQ).
  * - Wiring together all the application layers (repository, service, handlers, router).
+ * - Running the outbox relay that publishes queued events.
  * - Starting the HTTP server to listen for requests.
  *
  * @dependencies
  * - "context"
  * - "log"
  * - "net/http"
- * - "os"
  * - "os/signal"
  * - "syscall"
  * - "time"
- * - All internal packages (api, app, config, store, pkg/rabbitmq)
+ * - All internal packages (api, app, config, store) and the shared messaging package
  * - External libraries for pgxpool.
  */
 package main
@@ -25,19 +25,19 @@ import (
 	"context"
 	"log"
 	"net/http"
-	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
-	"github.com/clerk/clerk-sdk-go/v2"
 	"github.com/jackc/pgx/v5/pgxpool"
 
 	"transfa/services/auth/internal/api"
 	"transfa/services/auth/internal/app"
 	"transfa/services/auth/internal/config"
 	"transfa/services/auth/internal/store"
-	"transfa/services/auth/pkg/rabbitmq"
+	"transfa/shared/authn"
+	"transfa/shared/messaging"
+	"transfa/shared/outbox"
 )
 
 func main() {
@@ -47,13 +47,24 @@ func main() {
 		log.Fatalf("could not load config: %v", err)
 	}
 
-	// Set the Clerk secret key
-	clerk.SetKey(cfg.ClerkSecretKey)
-
 	// Create context that listens for the interrupt signal from the OS.
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
 
+	// Verify Clerk session tokens offline against the instance's cached signing keys.
+	verifier, err := authn.NewJWKSVerifier(authn.Config{
+		Issuer:   cfg.ClerkIssuer,
+		JWKSURL:  cfg.ClerkJWKSURL,
+		Audience: cfg.ClerkAudience,
+		Leeway:   cfg.ClerkClockSkew,
+	})
+	if err != nil {
+		log.Fatalf("could not create session token verifier: %v", err)
+	}
+	if err := verifier.Refresh(ctx); err != nil {
+		log.Printf("WARNING: could not fetch Clerk signing keys, will retry on the first request: %v", err)
+	}
+
 	// Initialize database connection pool
 	dbpool, err := pgxpool.New(ctx, cfg.DatabaseURL)
 	if err != nil {
@@ -63,7 +74,7 @@ func main() {
 	log.Println("Database connection pool established.")
 
 	// Initialize RabbitMQ publisher
-	publisher, err := rabbitmq.NewPublisher(cfg.RabbitMQURL)
+	publisher, err := messaging.NewPublisher(cfg.RabbitMQURL)
 	if err != nil {
 		log.Fatalf("unable to create RabbitMQ publisher: %v", err)
 	}
@@ -72,9 +83,17 @@ func main() {
 
 	// Wire application components
 	repository := store.NewPostgresRepository(dbpool)
-	service := app.NewService(repository, publisher, cfg)
+	service := app.NewService(repository, cfg)
 	handler := api.NewAuthHandler(service)
-	router := api.NewRouter(handler)
+	router := api.NewRouter(handler, verifier)
+
+	// Start the outbox relay
+	relay := outbox.NewRelay(dbpool, publisher, store.OutboxSource, cfg.OutboxPollInterval)
+	relayDone := make(chan struct{})
+	go func() {
+		defer close(relayDone)
+		relay.Run(ctx)
+	}()
 
 	// Set up and start HTTP server
 	srv := &http.Server{
@@ -104,5 +123,8 @@ func main() {
 		log.Fatalf("Server forced to shutdown: %v", err)
 	}
 
+	// Wait for the relay to finish its current batch before closing the publisher.
+	<-relayDone
+
 	log.Println("Server exiting")
-}
\ No newline at end of file
+}
@@ -6,22 +6,56 @@
  *
  * @dependencies
  * - "encoding/json": For JSON serialization and deserialization.
+ * - "errors": For mapping service errors to HTTP status codes.
+ * - "io": For reading webhook bodies.
  * - "log": For logging.
  * - "net/http": For standard HTTP handling.
- * - "github.com/clerk/clerk-sdk-go/v2/jwt": To access session claims.
+ * - "strconv", "time": For the Retry-After header of locked PINs.
+ * - "github.com/go-chi/chi/v5": For URL parameters.
  * - "transfa/services/auth/internal/app": Imports the application service layer.
  * - "transfa/services/auth/internal/domain": Imports the data models/DTOs.
+ * - "transfa/services/auth/pkg/svix": For the headers of Clerk webhooks.
+ * - "transfa/shared/apierror": For JSON:API error responses.
+ * - "transfa/shared/authn": For the Clerk User ID of the caller.
+ * - "transfa/shared/onboarding": For onboarding statuses.
  */
 package api
 
 import (
 	"encoding/json"
+	"errors"
+	"io"
 	"log"
 	"net/http"
+	"strconv"
+	"time"
 
-	"github.com/clerk/clerk-sdk-go/v2/jwt"
+	"github.com/go-chi/chi/v5"
 	"transfa/services/auth/internal/app"
 	"transfa/services/auth/internal/domain"
+	"transfa/services/auth/pkg/svix"
+	"transfa/shared/apierror"
+	"transfa/shared/authn"
+	"transfa/shared/onboarding"
+)
+
+// maxWebhookBodySize bounds the size of the webhook bodies read into memory.
+const maxWebhookBodySize = 1 << 20
+
+// Machine-readable codes of the onboarding conflicts, for clients to branch on.
+const (
+	codeUsernameTaken    = "username_taken"
+	codeAlreadyOnboarded = "already_onboarded"
+	codeNotOnboarded     = "not_onboarded"
+)
+
+// Machine-readable codes of rejected transaction PIN requests.
+const (
+	codePINNotSet            = "pin_not_set"
+	codePINAlreadySet        = "pin_already_set"
+	codeIncorrectPIN         = "incorrect_pin"
+	codePINLocked            = "pin_locked"
+	codeRecentSignInRequired = "recent_sign_in_required"
 )
 
 // AuthHandler holds dependencies for the authentication-related HTTP handlers.
@@ -38,47 +72,292 @@ func NewAuthHandler(service *app.Service) *AuthHandler {
 
 // OnboardingHandler handles the `POST /onboarding` request.
 func (h *AuthHandler) OnboardingHandler(w http.ResponseWriter, r *http.Request) {
-	// 1. Get claims from context (set by middleware).
-	claims, ok := r.Context().Value(sessionClaimsKey).(*jwt.Claims)
-	if !ok || claims == nil {
-		http.Error(w, "Unauthorized: Could not retrieve claims", http.StatusUnauthorized)
+	// 1. Get the Clerk User ID from the session claims (set by middleware).
+	clerkID, ok := authn.SubjectFromContext(r.Context())
+	if !ok {
+		apierror.Write(w, http.StatusUnauthorized, "could not retrieve claims")
 		return
 	}
 
-	// The Clerk User ID is in the 'Subject' field of the claims.
-	clerkID := claims.Subject
-
 	// 2. Decode the request body.
 	var req domain.OnboardingRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Bad Request: Invalid JSON body", http.StatusBadRequest)
+		apierror.Write(w, http.StatusBadRequest, "invalid JSON body")
 		return
 	}
 
-	// 3. Basic validation (can be expanded).
-	if req.Username == "" || req.AccountType == "" {
-		http.Error(w, "Bad Request: username and account_type are required", http.StatusBadRequest)
+	// 3. Call the application service, which validates the request.
+	user, err := h.service.OnboardUser(r.Context(), clerkID, req)
+	if err != nil {
+		log.Printf("Onboarding failed for clerk_id %s: %v", clerkID, err)
+		writeServiceError(w, err)
 		return
 	}
-	if req.AccountType != "personal" && req.AccountType != "merchant" {
-		http.Error(w, "Bad Request: account_type must be 'personal' or 'merchant'", http.StatusBadRequest)
+
+	// 4. Write the success response.
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(user); err != nil {
+		log.Printf("Failed to write response: %v", err)
+	}
+}
+
+// OnboardingStatusHandler handles the `GET /onboarding/status` request.
+func (h *AuthHandler) OnboardingStatusHandler(w http.ResponseWriter, r *http.Request) {
+	clerkID, ok := authn.SubjectFromContext(r.Context())
+	if !ok {
+		apierror.Write(w, http.StatusUnauthorized, "could not retrieve claims")
 		return
 	}
 
-	// 4. Call the application service.
-	user, err := h.service.OnboardUser(r.Context(), clerkID, req)
+	state, err := h.service.GetOnboardingStatus(r.Context(), clerkID)
 	if err != nil {
-		// A more sophisticated error handling could map service errors to HTTP status codes.
-		// For example, a "username taken" error could map to 409 Conflict.
-		log.Printf("Onboarding failed for clerk_id %s: %v", clerkID, err)
-		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		if !errors.Is(err, domain.ErrUserNotFound) && !errors.Is(err, onboarding.ErrNotFound) {
+			log.Printf("Failed to get onboarding status for clerk_id %s: %v", clerkID, err)
+		}
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(state); err != nil {
+		log.Printf("Failed to write response: %v", err)
+	}
+}
+
+// UsernameAvailabilityHandler handles the `GET /usernames/{name}/availability` request.
+// It answers 200 OK whether or not the username is available, and reserves nothing.
+func (h *AuthHandler) UsernameAvailabilityHandler(w http.ResponseWriter, r *http.Request) {
+	clerkID, ok := authn.SubjectFromContext(r.Context())
+	if !ok {
+		apierror.Write(w, http.StatusUnauthorized, "could not retrieve claims")
+		return
+	}
+
+	availability, err := h.service.CheckUsernameAvailability(r.Context(), clerkID, chi.URLParam(r, "name"))
+	if err != nil {
+		log.Printf("Failed to check username availability for clerk_id %s: %v", clerkID, err)
+		writeServiceError(w, err)
+		return
+	}
+
+	writeAvailability(w, availability)
+}
+
+// UsernameReservationHandler handles the `POST /usernames/{name}/reservation` request.
+// It answers 200 OK whether or not the username could be reserved; reserved_until is set
+// when it was.
+func (h *AuthHandler) UsernameReservationHandler(w http.ResponseWriter, r *http.Request) {
+	clerkID, ok := authn.SubjectFromContext(r.Context())
+	if !ok {
+		apierror.Write(w, http.StatusUnauthorized, "could not retrieve claims")
 		return
 	}
 
-	// 5. Write the success response.
+	availability, err := h.service.ReserveUsername(r.Context(), clerkID, chi.URLParam(r, "name"))
+	if err != nil {
+		log.Printf("Failed to reserve username for clerk_id %s: %v", clerkID, err)
+		writeServiceError(w, err)
+		return
+	}
+
+	writeAvailability(w, availability)
+}
+
+// writeAvailability writes the result of checking or reserving a username.
+func writeAvailability(w http.ResponseWriter, availability *domain.UsernameAvailability) {
 	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(availability); err != nil {
+		log.Printf("Failed to write response: %v", err)
+	}
+}
+
+// SetPINHandler handles the `POST /pin` request, which sets the caller's first
+// transaction PIN.
+func (h *AuthHandler) SetPINHandler(w http.ResponseWriter, r *http.Request) {
+	clerkID, ok := authn.SubjectFromContext(r.Context())
+	if !ok {
+		apierror.Write(w, http.StatusUnauthorized, "could not retrieve claims")
+		return
+	}
+
+	var req domain.SetPINRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.Write(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+
+	if err := h.service.SetPIN(r.Context(), clerkID, req); err != nil {
+		logPINError("set transaction PIN", clerkID, err)
+		writeServiceError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ChangePINHandler handles the `PUT /pin` request, which replaces the caller's
+// transaction PIN given their current one.
+func (h *AuthHandler) ChangePINHandler(w http.ResponseWriter, r *http.Request) {
+	clerkID, ok := authn.SubjectFromContext(r.Context())
+	if !ok {
+		apierror.Write(w, http.StatusUnauthorized, "could not retrieve claims")
+		return
+	}
+
+	var req domain.ChangePINRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.Write(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+
+	if err := h.service.ChangePIN(r.Context(), clerkID, req); err != nil {
+		logPINError("change transaction PIN", clerkID, err)
+		writeServiceError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ResetPINHandler handles the `POST /pin/reset` request, which replaces the caller's
+// forgotten transaction PIN after a recent sign-in.
+func (h *AuthHandler) ResetPINHandler(w http.ResponseWriter, r *http.Request) {
+	clerkID, ok := authn.SubjectFromContext(r.Context())
+	if !ok {
+		apierror.Write(w, http.StatusUnauthorized, "could not retrieve claims")
+		return
+	}
+
+	var req domain.ResetPINRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.Write(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+
+	if err := h.service.ResetPIN(r.Context(), clerkID, req); err != nil {
+		logPINError("reset transaction PIN", clerkID, err)
+		writeServiceError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// StepUpHandler handles the `POST /pin/step-up` request. Given the caller's transaction
+// PIN, it returns a step-up token that approves the transfer named in the body.
+func (h *AuthHandler) StepUpHandler(w http.ResponseWriter, r *http.Request) {
+	clerkID, ok := authn.SubjectFromContext(r.Context())
+	if !ok {
+		apierror.Write(w, http.StatusUnauthorized, "could not retrieve claims")
+		return
+	}
+
+	var req domain.StepUpRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.Write(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+
+	grant, err := h.service.IssueStepUpToken(r.Context(), clerkID, req)
+	if err != nil {
+		logPINError("issue step-up token", clerkID, err)
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
 	w.WriteHeader(http.StatusCreated)
-	if err := json.NewEncoder(w).Encode(user); err != nil {
+	if err := json.NewEncoder(w).Encode(grant); err != nil {
 		log.Printf("Failed to write response: %v", err)
 	}
-}
\ No newline at end of file
+}
+
+// logPINError logs unexpected failures of PIN requests. Rejected PINs are logged by the
+// service, so an incorrect PIN is not logged twice.
+func logPINError(action, clerkID string, err error) {
+	var validationErr *domain.ValidationError
+	var incorrectErr *domain.IncorrectPINError
+	var lockedErr *domain.PINLockedError
+	if errors.As(err, &validationErr) || errors.As(err, &incorrectErr) || errors.As(err, &lockedErr) {
+		return
+	}
+	log.Printf("Failed to %s for clerk_id %s: %v", action, clerkID, err)
+}
+
+// ClerkWebhookHandler handles the `POST /webhooks/clerk` request. It is authenticated by
+// the webhook's Svix signature rather than a session token.
+func (h *AuthHandler) ClerkWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	// 1. Read the body. The signature covers its exact bytes.
+	payload, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookBodySize))
+	if err != nil {
+		apierror.Write(w, http.StatusBadRequest, "cannot read request body")
+		return
+	}
+
+	// 2. Verify and apply the webhook.
+	headers := svix.Headers{
+		ID:        r.Header.Get("svix-id"),
+		Timestamp: r.Header.Get("svix-timestamp"),
+		Signature: r.Header.Get("svix-signature"),
+	}
+	if err := h.service.ProcessClerkWebhook(r.Context(), payload, headers); err != nil {
+		log.Printf("Error processing Clerk webhook %s: %v", headers.ID, err)
+		switch {
+		case errors.Is(err, app.ErrInvalidWebhookSignature):
+			apierror.Write(w, http.StatusUnauthorized, "invalid webhook signature")
+		case errors.Is(err, app.ErrInvalidWebhookPayload):
+			apierror.Write(w, http.StatusBadRequest, "invalid webhook payload")
+		default:
+			// Clerk retries webhooks that fail with a 5xx status.
+			apierror.Write(w, http.StatusInternalServerError, "")
+		}
+		return
+	}
+
+	// 3. Acknowledge it.
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte(`{"status":"received"}`))
+}
+
+// writeServiceError maps application errors to HTTP status codes.
+func writeServiceError(w http.ResponseWriter, err error) {
+	var validationErr *domain.ValidationError
+	var incorrectErr *domain.IncorrectPINError
+	var lockedErr *domain.PINLockedError
+	switch {
+	case errors.As(err, &validationErr):
+		fieldErrs := make([]apierror.Error, len(validationErr.Fields))
+		for i, f := range validationErr.Fields {
+			fieldErrs[i] = apierror.New(http.StatusBadRequest, f.Message).WithCode(f.Code).WithPointer(f.Pointer)
+		}
+		apierror.WriteErrors(w, fieldErrs...)
+	case errors.Is(err, domain.ErrUsernameTaken):
+		apierror.WriteErrors(w, apierror.New(http.StatusConflict, domain.ErrUsernameTaken.Error()).
+			WithCode(codeUsernameTaken).
+			WithPointer("/username"))
+	case errors.Is(err, domain.ErrAlreadyOnboarded):
+		apierror.WriteErrors(w, apierror.New(http.StatusConflict, domain.ErrAlreadyOnboarded.Error()).
+			WithCode(codeAlreadyOnboarded))
+	case errors.As(err, &incorrectErr):
+		apierror.WriteErrors(w, apierror.New(http.StatusForbidden, incorrectErr.Error()).
+			WithCode(codeIncorrectPIN))
+	case errors.As(err, &lockedErr):
+		retryAfter := time.Until(lockedErr.Until).Round(time.Second)
+		w.Header().Set("Retry-After", strconv.Itoa(int(retryAfter.Seconds())))
+		apierror.WriteErrors(w, apierror.New(http.StatusTooManyRequests, lockedErr.Error()).
+			WithCode(codePINLocked))
+	case errors.Is(err, domain.ErrPINNotSet):
+		apierror.WriteErrors(w, apierror.New(http.StatusConflict, domain.ErrPINNotSet.Error()).
+			WithCode(codePINNotSet))
+	case errors.Is(err, domain.ErrPINAlreadySet):
+		apierror.WriteErrors(w, apierror.New(http.StatusConflict, domain.ErrPINAlreadySet.Error()).
+			WithCode(codePINAlreadySet))
+	case errors.Is(err, domain.ErrRecentSignInRequired):
+		apierror.WriteErrors(w, apierror.New(http.StatusForbidden, domain.ErrRecentSignInRequired.Error()).
+			WithCode(codeRecentSignInRequired))
+	case errors.Is(err, domain.ErrUserNotFound), errors.Is(err, onboarding.ErrNotFound):
+		apierror.WriteErrors(w, apierror.New(http.StatusNotFound, domain.ErrUserNotFound.Error()).
+			WithCode(codeNotOnboarded))
+	default:
+		apierror.Write(w, http.StatusInternalServerError, "")
+	}
+}
@@ -0,0 +1,334 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"transfa/services/auth/internal/app"
+	"transfa/services/auth/internal/config"
+	"transfa/services/auth/internal/domain"
+	"transfa/shared/apierror"
+	"transfa/shared/authn"
+	"transfa/shared/authn/authntest"
+	"transfa/shared/onboarding"
+	"transfa/shared/outbox"
+)
+
+// failingRepository fails every call with err.
+type failingRepository struct {
+	err error
+}
+
+func (r failingRepository) CreateUser(ctx context.Context, user *domain.User, event outbox.Event) (*domain.User, error) {
+	return nil, r.err
+}
+
+func (r failingRepository) GetOnboardingStatus(ctx context.Context, clerkID string) (*onboarding.State, error) {
+	return nil, r.err
+}
+
+func (r failingRepository) GetUserByClerkID(ctx context.Context, clerkID string) (*domain.User, error) {
+	return nil, r.err
+}
+
+func (r failingRepository) UpdateContactDetails(ctx context.Context, clerkID string, details domain.ContactDetails) (bool, error) {
+	return false, r.err
+}
+
+func (r failingRepository) RecordSignIn(ctx context.Context, clerkID string, at time.Time) error {
+	return r.err
+}
+
+func (r failingRepository) MarkUserDeleted(ctx context.Context, userID uuid.UUID, deletedAt time.Time, event outbox.Event) (bool, error) {
+	return false, r.err
+}
+
+func (r failingRepository) CheckUsername(ctx context.Context, username, clerkID string) error {
+	return r.err
+}
+
+func (r failingRepository) ReserveUsername(ctx context.Context, username, clerkID string, expiresAt time.Time) error {
+	return r.err
+}
+
+func (r failingRepository) UnavailableUsernames(ctx context.Context, usernames []string, clerkID string) (map[string]bool, error) {
+	return nil, r.err
+}
+
+func (r failingRepository) CreatePIN(ctx context.Context, userID uuid.UUID, hash string) error {
+	return r.err
+}
+
+func (r failingRepository) BeginPINAttempt(ctx context.Context, userID uuid.UUID, maxAttempts int, lockedUntil time.Time) (*domain.PIN, error) {
+	return nil, r.err
+}
+
+func (r failingRepository) ResetPINAttempts(ctx context.Context, userID uuid.UUID) error {
+	return r.err
+}
+
+func (r failingRepository) ReplacePIN(ctx context.Context, userID uuid.UUID, hash string) error {
+	return r.err
+}
+
+func (r failingRepository) CreateStepUpToken(ctx context.Context, token domain.StepUpToken) error {
+	return r.err
+}
+
+// statusRepository returns the onboarding status of a single user.
+type statusRepository struct {
+	failingRepository
+	clerkID string
+	state   onboarding.State
+}
+
+func (r statusRepository) GetOnboardingStatus(ctx context.Context, clerkID string) (*onboarding.State, error) {
+	if clerkID != r.clerkID {
+		return nil, fmt.Errorf("%w: clerk_id %s", domain.ErrUserNotFound, clerkID)
+	}
+	return &r.state, nil
+}
+
+// pinRepository has one onboarded user, whose PIN attempts fail with err.
+type pinRepository struct {
+	failingRepository
+	user domain.User
+}
+
+func (r pinRepository) GetUserByClerkID(ctx context.Context, clerkID string) (*domain.User, error) {
+	return &r.user, nil
+}
+
+// withClaims returns req as authenticated as the Clerk user clerkID.
+func withClaims(req *http.Request, clerkID string) *http.Request {
+	return req.WithContext(authn.WithClaims(req.Context(), &authn.Claims{Subject: clerkID}))
+}
+
+// onboard sends a valid onboarding request to a handler whose repository
+// fails with repoErr.
+func onboard(t *testing.T, repoErr error) *httptest.ResponseRecorder {
+	t.Helper()
+	handler := NewAuthHandler(app.NewService(failingRepository{err: repoErr}, config.Config{}))
+
+	body := `{"username":"ada","account_type":"personal","kyc_details":{"full_name":"Ada Obi","bvn":"22222222222","date_of_birth":"1990-01-01"}}`
+	req := withClaims(httptest.NewRequest(http.MethodPost, "/onboarding", strings.NewReader(body)), "user_2abc")
+
+	rec := httptest.NewRecorder()
+	handler.OnboardingHandler(rec, req)
+	return rec
+}
+
+// decodeErrors decodes a JSON:API error document.
+func decodeErrors(t *testing.T, rec *httptest.ResponseRecorder) []apierror.Error {
+	t.Helper()
+	var doc struct {
+		Errors []apierror.Error `json:"errors"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("response is not a JSON:API error document: %v: %s", err, rec.Body.String())
+	}
+	return doc.Errors
+}
+
+func TestOnboardingHandlerConflicts(t *testing.T) {
+	tests := []struct {
+		name     string
+		repoErr  error
+		wantCode string
+	}{
+		{"username taken", fmt.Errorf("%w: duplicate key", domain.ErrUsernameTaken), codeUsernameTaken},
+		{"already onboarded", fmt.Errorf("%w: duplicate key", domain.ErrAlreadyOnboarded), codeAlreadyOnboarded},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := onboard(t, tt.repoErr)
+
+			if rec.Code != http.StatusConflict {
+				t.Errorf("status = %d, want %d", rec.Code, http.StatusConflict)
+			}
+			errs := decodeErrors(t, rec)
+			if len(errs) != 1 || errs[0].Status != "409" || errs[0].Code != tt.wantCode {
+				t.Errorf("errors = %+v, want one 409 with code %s", errs, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestOnboardingHandlerHidesInternalErrors(t *testing.T) {
+	rec := onboard(t, fmt.Errorf("failed to insert user: connection refused"))
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusInternalServerError)
+	}
+	errs := decodeErrors(t, rec)
+	if len(errs) != 1 || strings.Contains(errs[0].Detail, "connection refused") {
+		t.Errorf("errors = %+v, want one error without the cause", errs)
+	}
+}
+
+func TestOnboardingHandlerReportsInvalidFields(t *testing.T) {
+	handler := NewAuthHandler(app.NewService(failingRepository{}, config.Config{MinimumAge: 18}))
+
+	body := `{"username":"ad","account_type":"personal","kyc_details":{"full_name":"Ada Obi","bvn":"123","date_of_birth":"1990-01-01"}}`
+	req := withClaims(httptest.NewRequest(http.MethodPost, "/onboarding", strings.NewReader(body)), "user_2abc")
+
+	rec := httptest.NewRecorder()
+	handler.OnboardingHandler(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+	var pointers []string
+	for _, e := range decodeErrors(t, rec) {
+		if e.Source != nil {
+			pointers = append(pointers, e.Source.Pointer)
+		}
+	}
+	if strings.Join(pointers, ",") != "/username,/kyc_details/bvn" {
+		t.Errorf("error pointers = %v, want /username and /kyc_details/bvn", pointers)
+	}
+}
+
+func TestOnboardingStatusHandler(t *testing.T) {
+	state := onboarding.State{
+		UserID:    uuid.New(),
+		Status:    onboarding.StatusKYCRejected,
+		Reason:    "KYC details could not be verified.",
+		UpdatedAt: time.Date(2026, time.October, 16, 9, 30, 0, 0, time.UTC),
+	}
+	repo := statusRepository{clerkID: "user_2abc", state: state}
+	handler := NewAuthHandler(app.NewService(repo, config.Config{}))
+
+	rec := httptest.NewRecorder()
+	handler.OnboardingStatusHandler(rec, withClaims(httptest.NewRequest(http.MethodGet, "/onboarding/status", nil), "user_2abc"))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body.String())
+	}
+	var got onboarding.State
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("invalid response: %v", err)
+	}
+	if got != state {
+		t.Errorf("state = %+v, want %+v", got, state)
+	}
+}
+
+func TestOnboardingStatusHandlerNotOnboarded(t *testing.T) {
+	repo := statusRepository{clerkID: "user_2abc"}
+	handler := NewAuthHandler(app.NewService(repo, config.Config{}))
+
+	rec := httptest.NewRecorder()
+	handler.OnboardingStatusHandler(rec, withClaims(httptest.NewRequest(http.MethodGet, "/onboarding/status", nil), "user_other"))
+
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+	if errs := decodeErrors(t, rec); len(errs) != 1 || errs[0].Code != codeNotOnboarded {
+		t.Errorf("errors = %+v, want one with code %s", errs, codeNotOnboarded)
+	}
+}
+
+func TestRouterAuthenticatesSessionTokens(t *testing.T) {
+	issuer := authntest.NewIssuer(t)
+	repo := statusRepository{clerkID: "user_2abc", state: onboarding.State{Status: onboarding.StatusInitiated}}
+	router := NewRouter(NewAuthHandler(app.NewService(repo, config.Config{})), issuer.Verifier(t))
+
+	tests := []struct {
+		name  string
+		token string
+		want  int
+	}{
+		{"valid token", issuer.Token(t, "user_2abc"), http.StatusOK},
+		{"no token", "", http.StatusUnauthorized},
+		{"token of another issuer", authntest.NewIssuer(t).Token(t, "user_2abc"), http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/onboarding/status", nil)
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestUsernameAvailabilityHandler(t *testing.T) {
+	issuer := authntest.NewIssuer(t)
+	cfg := config.Config{UsernameReservationTTL: 10 * time.Minute}
+	router := NewRouter(NewAuthHandler(app.NewService(failingRepository{}, cfg)), issuer.Verifier(t))
+
+	tests := []struct {
+		method       string
+		path         string
+		wantReserved bool
+	}{
+		{http.MethodGet, "/usernames/AdaL/availability", false},
+		{http.MethodPost, "/usernames/AdaL/reservation", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			req.Header.Set("Authorization", "Bearer "+issuer.Token(t, "user_2abc"))
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body.String())
+			}
+			var got domain.UsernameAvailability
+			if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+				t.Fatalf("invalid response: %v", err)
+			}
+			if got.Username != "adal" || !got.Available || (got.ReservedUntil != nil) != tt.wantReserved {
+				t.Errorf("availability = %+v, want adal available and reserved %t", got, tt.wantReserved)
+			}
+		})
+	}
+}
+
+func TestStepUpHandlerRejectsPINs(t *testing.T) {
+	tests := []struct {
+		name           string
+		repoErr        error
+		wantStatus     int
+		wantCode       string
+		wantRetryAfter string
+	}{
+		{"locked", &domain.PINLockedError{Until: time.Now().Add(90 * time.Second)}, http.StatusTooManyRequests, codePINLocked, "90"},
+		{"not set", domain.ErrPINNotSet, http.StatusConflict, codePINNotSet, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := pinRepository{failingRepository{err: tt.repoErr}, domain.User{ID: uuid.New(), ClerkID: "user_2abc"}}
+			handler := NewAuthHandler(app.NewService(repo, config.Config{PINMaxAttempts: 5}))
+
+			body := `{"pin":"4821","purpose":"p2p_transfer","amount":250000,"recipient_username":"lovelace"}`
+			rec := httptest.NewRecorder()
+			handler.StepUpHandler(rec, withClaims(httptest.NewRequest(http.MethodPost, "/pin/step-up", strings.NewReader(body)), "user_2abc"))
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if got := rec.Header().Get("Retry-After"); got != tt.wantRetryAfter {
+				t.Errorf("Retry-After = %q, want %q", got, tt.wantRetryAfter)
+			}
+			if errs := decodeErrors(t, rec); len(errs) != 1 || errs[0].Code != tt.wantCode {
+				t.Errorf("errors = %+v, want one with code %s", errs, tt.wantCode)
+			}
+		})
+	}
+}
@@ -9,6 +9,7 @@
  * - "github.com/go-chi/chi/v5": The Chi router library.
  * - "github.com/go-chi/chi/v5/middleware": For standard Chi middleware.
  * - "github.com/go-chi/cors": For CORS middleware.
+ * - "transfa/shared/authn": For the Clerk session token middleware.
  */
 package api
 
@@ -18,10 +19,11 @@ import (
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/cors"
+	"transfa/shared/authn"
 )
 
 // NewRouter creates and configures a new Chi router for the Auth service.
-func NewRouter(handler *AuthHandler) http.Handler {
+func NewRouter(handler *AuthHandler, verifier authn.TokenVerifier) http.Handler {
 	r := chi.NewRouter()
 
 	// A good base middleware stack
@@ -46,14 +48,26 @@ func NewRouter(handler *AuthHandler) http.Handler {
 		w.Write([]byte(`{"status": "ok"}`))
 	})
 
+	// Clerk webhooks are authenticated by their Svix signature.
+	r.Post("/webhooks/clerk", handler.ClerkWebhookHandler)
+
 	// Protected routes
 	r.Group(func(r chi.Router) {
-		// Apply the Clerk authentication middleware to this group.
-		r.Use(ClerkAuth())
+		// Require a valid Clerk session token on this group.
+		r.Use(authn.Middleware(verifier))
 
 		// Define the onboarding route.
 		r.Post("/onboarding", handler.OnboardingHandler)
+		r.Get("/onboarding/status", handler.OnboardingStatusHandler)
+		r.Get("/usernames/{name}/availability", handler.UsernameAvailabilityHandler)
+		r.Post("/usernames/{name}/reservation", handler.UsernameReservationHandler)
+
+		// Transaction PIN routes. Outbound transfers need a step-up token from /pin/step-up.
+		r.Post("/pin", handler.SetPINHandler)
+		r.Put("/pin", handler.ChangePINHandler)
+		r.Post("/pin/reset", handler.ResetPINHandler)
+		r.Post("/pin/step-up", handler.StepUpHandler)
 	})
 
 	return r
-}
\ No newline at end of file
+}
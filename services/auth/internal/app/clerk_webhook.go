@@ -0,0 +1,158 @@
+/**
+ * @description
+ * This file contains the Auth service's handling of Clerk webhooks, which keep
+ * public.users in step with changes made in Clerk after onboarding.
+ *
+ * Key features:
+ * - Every webhook's Svix signature is verified before it is parsed.
+ * - `user.created` and `user.updated` copy the user's primary email and phone number.
+ *   Clerk's `updated_at` orders the updates, so a retried older webhook is ignored.
+ * - `user.deleted` marks the user deleted, stops them sending money and queues a
+ *   `user.deleted` event in the outbox, in one transaction, for the Account service to
+ *   close their accounts. Users are never hard-deleted: their accounts and transactions
+ *   reference them.
+ * - `session.created` records the user's last sign-in; other session events are only
+ *   logged.
+ * - Webhooks for Clerk users who have not onboarded are acknowledged and ignored.
+ *
+ * @dependencies
+ * - "context", "encoding/json", "errors", "fmt", "log", "time"
+ * - "transfa/services/auth/internal/domain": For the Clerk payloads.
+ * - "transfa/services/auth/pkg/svix": For verifying webhook signatures.
+ * - "transfa/shared/events": For the `user.deleted` event.
+ * - "transfa/shared/outbox": For queueing it with the deletion.
+ */
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"transfa/services/auth/internal/domain"
+	"transfa/services/auth/pkg/svix"
+	"transfa/shared/events"
+	"transfa/shared/outbox"
+)
+
+var (
+	// ErrInvalidWebhookSignature is returned for webhooks that were not signed by Clerk.
+	ErrInvalidWebhookSignature = errors.New("invalid webhook signature")
+	// ErrInvalidWebhookPayload is returned for signed webhooks that cannot be parsed.
+	ErrInvalidWebhookPayload = errors.New("invalid webhook payload")
+)
+
+// ProcessClerkWebhook verifies a Clerk webhook and applies it to the user it concerns.
+func (s *Service) ProcessClerkWebhook(ctx context.Context, payload []byte, headers svix.Headers) error {
+	// 1. Verify the signature.
+	if s.config.ClerkWebhookSecret == "" {
+		return errors.New("clerk webhook secret is not configured")
+	}
+	verifier, err := svix.NewVerifier(s.config.ClerkWebhookSecret)
+	if err != nil {
+		return err
+	}
+	if err := verifier.Verify(payload, headers, time.Now()); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidWebhookSignature, err)
+	}
+
+	// 2. Parse the webhook.
+	var webhook domain.ClerkWebhook
+	if err := json.Unmarshal(payload, &webhook); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidWebhookPayload, err)
+	}
+
+	log.Printf("Received Clerk webhook %s of type %s", headers.ID, webhook.Type)
+
+	// 3. Apply it.
+	switch webhook.Type {
+	case domain.ClerkUserCreated, domain.ClerkUserUpdated:
+		var user domain.ClerkUser
+		if err := json.Unmarshal(webhook.Data, &user); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidWebhookPayload, err)
+		}
+		return s.syncContactDetails(ctx, user)
+	case domain.ClerkUserDeleted:
+		var deleted domain.ClerkDeletedObject
+		if err := json.Unmarshal(webhook.Data, &deleted); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidWebhookPayload, err)
+		}
+		return s.deleteUser(ctx, deleted.ID, headers.ID)
+	case domain.ClerkSessionCreated:
+		var session domain.ClerkSession
+		if err := json.Unmarshal(webhook.Data, &session); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidWebhookPayload, err)
+		}
+		return s.repo.RecordSignIn(ctx, session.UserID, time.UnixMilli(session.CreatedAt).UTC())
+	case domain.ClerkSessionEnded, domain.ClerkSessionRemoved, domain.ClerkSessionRevoked:
+		log.Printf("Clerk webhook %s: %s", headers.ID, webhook.Type)
+		return nil
+	default:
+		log.Printf("Unhandled Clerk webhook type: %s", webhook.Type)
+		return nil // Acknowledge unhandled events so Clerk does not retry them.
+	}
+}
+
+// syncContactDetails copies a Clerk user's primary contact details to their profile.
+func (s *Service) syncContactDetails(ctx context.Context, user domain.ClerkUser) error {
+	if user.ID == "" {
+		return fmt.Errorf("%w: missing user id", ErrInvalidWebhookPayload)
+	}
+
+	updated, err := s.repo.UpdateContactDetails(ctx, user.ID, user.ContactDetails())
+	if err != nil {
+		return fmt.Errorf("failed to update contact details of clerk user %s: %w", user.ID, err)
+	}
+	if !updated {
+		// Either the user has not onboarded yet, in which case the details are copied
+		// by their next update, or a newer update has already been applied.
+		log.Printf("Contact details of clerk user %s unchanged: not onboarded, deleted or already up to date", user.ID)
+	}
+	return nil
+}
+
+// deleteUser marks the user with the given Clerk ID deleted and queues a `user.deleted`
+// event. webhookID correlates the event with the Clerk webhook.
+func (s *Service) deleteUser(ctx context.Context, clerkID, webhookID string) error {
+	if clerkID == "" {
+		return fmt.Errorf("%w: missing user id", ErrInvalidWebhookPayload)
+	}
+
+	user, err := s.repo.GetUserByClerkID(ctx, clerkID)
+	if errors.Is(err, domain.ErrUserNotFound) {
+		log.Printf("Clerk user %s was deleted before onboarding; nothing to do", clerkID)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get user for clerk user %s: %w", clerkID, err)
+	}
+
+	deletedAt := time.Now().UTC()
+	envelope, err := events.New(EventProducer, events.UserDeleted{
+		UserID:    user.ID,
+		ClerkID:   clerkID,
+		DeletedAt: deletedAt,
+	}, events.Metadata{CorrelationID: webhookID})
+	if err != nil {
+		return err
+	}
+
+	deleted, err := s.repo.MarkUserDeleted(ctx, user.ID, deletedAt, outbox.Event{
+		Exchange:   s.config.UserDeletedEx,
+		RoutingKey: s.config.UserDeletedRK,
+		Envelope:   envelope,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete user %s: %w", user.ID, err)
+	}
+	if !deleted {
+		log.Printf("User %s was already deleted; ignoring duplicate user.deleted webhook", user.ID)
+		return nil
+	}
+
+	log.Printf("Deleted user %s and queued user.deleted event %s", user.ID, envelope.EventID)
+	return nil
+}
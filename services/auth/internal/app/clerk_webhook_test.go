@@ -0,0 +1,159 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"transfa/services/auth/internal/config"
+	"transfa/services/auth/internal/domain"
+	"transfa/services/auth/pkg/svix"
+	"transfa/shared/events"
+	"transfa/shared/messaging"
+	"transfa/shared/messaging/memory"
+)
+
+const webhookSecret = "whsec_MfKQ9r8GKYqrTwjUPD8ILPZIo2LaLaSw"
+
+// newWebhookService returns a service whose repository holds one onboarded user.
+func newWebhookService(t *testing.T) (*Service, *fakeRepository, *domain.User) {
+	t.Helper()
+	user := &domain.User{ID: uuid.New(), ClerkID: "user_2abc", Username: "ada", AccountType: "personal"}
+	repo := &fakeRepository{users: []*domain.User{user}}
+	cfg := config.Config{
+		ClerkWebhookSecret: webhookSecret,
+		UserDeletedEx:      "user_events",
+		UserDeletedRK:      "user.deleted",
+	}
+	return NewService(repo, cfg), repo, user
+}
+
+// signed returns the Svix headers of payload, signed now with webhookSecret.
+func signed(t *testing.T, id string, payload []byte) svix.Headers {
+	t.Helper()
+	verifier, err := svix.NewVerifier(webhookSecret)
+	if err != nil {
+		t.Fatalf("NewVerifier() error = %v", err)
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	return svix.Headers{ID: id, Timestamp: timestamp, Signature: verifier.Sign(id, timestamp, payload)}
+}
+
+// userUpdated returns a user.updated webhook for clerkID with the given email, updated
+// at the given Unix millisecond.
+func userUpdated(clerkID, email string, updatedAt int64) []byte {
+	return []byte(fmt.Sprintf(`{"type":"user.updated","object":"event","data":{
+		"id":%q,
+		"primary_email_address_id":"idn_1",
+		"email_addresses":[{"id":"idn_0","email_address":"old@example.com"},{"id":"idn_1","email_address":%q}],
+		"primary_phone_number_id":"idn_2",
+		"phone_numbers":[{"id":"idn_2","phone_number":"+2348012345678"}],
+		"updated_at":%d}}`, clerkID, email, updatedAt))
+}
+
+func TestClerkWebhookRejectsBadSignatures(t *testing.T) {
+	service, repo, user := newWebhookService(t)
+
+	payload := userUpdated(user.ClerkID, "ada@example.com", 1760600000000)
+	headers := signed(t, "msg_1", payload)
+	headers.Signature = "v1,c2lnbmF0dXJl"
+
+	err := service.ProcessClerkWebhook(context.Background(), payload, headers)
+	if !errors.Is(err, ErrInvalidWebhookSignature) {
+		t.Errorf("ProcessClerkWebhook() error = %v, want %v", err, ErrInvalidWebhookSignature)
+	}
+	if len(repo.contacts) != 0 {
+		t.Errorf("contact details were updated by an unsigned webhook")
+	}
+}
+
+func TestClerkUserUpdatedSyncsContactDetails(t *testing.T) {
+	service, repo, user := newWebhookService(t)
+	ctx := context.Background()
+
+	newer := userUpdated(user.ClerkID, "ada@example.com", 1760600000000)
+	if err := service.ProcessClerkWebhook(ctx, newer, signed(t, "msg_2", newer)); err != nil {
+		t.Fatalf("ProcessClerkWebhook() error = %v", err)
+	}
+	// An older update retried by Clerk after the newer one must not win.
+	older := userUpdated(user.ClerkID, "stale@example.com", 1760500000000)
+	if err := service.ProcessClerkWebhook(ctx, older, signed(t, "msg_1", older)); err != nil {
+		t.Fatalf("ProcessClerkWebhook() error = %v", err)
+	}
+
+	got := repo.contacts[user.ClerkID]
+	if got.Email != "ada@example.com" || got.PhoneNumber != "+2348012345678" {
+		t.Errorf("contact details = %+v, want the primary ones of the newer update", got)
+	}
+}
+
+func TestClerkWebhookForUserWhoHasNotOnboarded(t *testing.T) {
+	service, repo, _ := newWebhookService(t)
+
+	payload := userUpdated("user_unknown", "someone@example.com", 1760600000000)
+	if err := service.ProcessClerkWebhook(context.Background(), payload, signed(t, "msg_1", payload)); err != nil {
+		t.Fatalf("ProcessClerkWebhook() error = %v", err)
+	}
+	if len(repo.contacts) != 0 {
+		t.Errorf("contact details = %v, want none", repo.contacts)
+	}
+}
+
+func TestClerkUserDeletedQueuesUserDeleted(t *testing.T) {
+	service, repo, user := newWebhookService(t)
+	ctx := context.Background()
+
+	payload := []byte(fmt.Sprintf(`{"type":"user.deleted","object":"event","data":{"id":%q,"object":"user","deleted":true}}`, user.ClerkID))
+	// Clerk may deliver the webhook more than once.
+	for i := 0; i < 2; i++ {
+		if err := service.ProcessClerkWebhook(ctx, payload, signed(t, "msg_1", payload)); err != nil {
+			t.Fatalf("ProcessClerkWebhook() error = %v", err)
+		}
+	}
+
+	if _, ok := repo.deleted[user.ID]; !ok {
+		t.Fatal("user was not marked deleted")
+	}
+	if len(repo.events) != 1 {
+		t.Fatalf("queued %d events, want 1", len(repo.events))
+	}
+
+	// The queued event reaches the Account service's queue.
+	broker := memory.NewBroker(messaging.RetryPolicy{})
+	broker.Bind("user_events", "user.deleted", "account_service_user_deleted")
+	queued := repo.events[0]
+	if err := events.Publish(ctx, broker, queued.Exchange, queued.RoutingKey, queued.Envelope); err != nil {
+		t.Fatalf("failed to publish queued event: %v", err)
+	}
+	msgs := broker.Messages("account_service_user_deleted")
+	if len(msgs) != 1 {
+		t.Fatalf("account queue holds %d messages, want 1", len(msgs))
+	}
+	var got events.UserDeleted
+	env, err := events.DecodeDelivery(msgs[0], &got)
+	if err != nil {
+		t.Fatalf("DecodeDelivery() error = %v", err)
+	}
+	if got.UserID != user.ID || got.ClerkID != user.ClerkID {
+		t.Errorf("user.deleted = %+v, want user %s", got, user.ID)
+	}
+	if env.CorrelationID != "msg_1" {
+		t.Errorf("correlation ID = %q, want the webhook ID", env.CorrelationID)
+	}
+}
+
+func TestClerkSessionCreatedRecordsSignIn(t *testing.T) {
+	service, repo, user := newWebhookService(t)
+
+	payload := []byte(fmt.Sprintf(`{"type":"session.created","object":"event","data":{"id":"sess_1","user_id":%q,"status":"active","created_at":1760600000000}}`, user.ClerkID))
+	if err := service.ProcessClerkWebhook(context.Background(), payload, signed(t, "msg_1", payload)); err != nil {
+		t.Fatalf("ProcessClerkWebhook() error = %v", err)
+	}
+	if got, want := repo.signIns[user.ClerkID], time.UnixMilli(1760600000000).UTC(); !got.Equal(want) {
+		t.Errorf("last sign-in = %s, want %s", got, want)
+	}
+}
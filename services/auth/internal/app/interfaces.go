@@ -2,29 +2,70 @@
  * @description
  * This file defines the interfaces (ports) for the application's core logic.
  * Following hexagonal architecture principles, these interfaces act as contracts
- * that external adapters (like the database repository or RabbitMQ publisher) must implement.
+ * that external adapters (like the database repository) must implement.
  * This decouples the core business logic from specific technologies.
  *
  * @dependencies
  * - "context": For passing request-scoped data and cancellation signals.
+ * - "time": For the times recorded from Clerk webhooks, reservation expiries and PIN lockouts.
+ * - "github.com/google/uuid": For user identifiers.
  * - "transfa/services/auth/internal/domain": Imports the core data models.
+ * - "transfa/shared/onboarding": For onboarding statuses.
+ * - "transfa/shared/outbox": For the events written alongside a new user.
  */
 package app
 
 import (
 	"context"
+	"time"
+
+	"github.com/google/uuid"
 	"transfa/services/auth/internal/domain"
+	"transfa/shared/onboarding"
+	"transfa/shared/outbox"
 )
 
 // Repository defines the interface for data persistence operations.
 // Any database implementation must satisfy this interface.
 type Repository interface {
-	CreateUser(ctx context.Context, user *domain.User) (*domain.User, error)
+	// CreateUser persists the user and writes event to the outbox atomically.
+	CreateUser(ctx context.Context, user *domain.User, event outbox.Event) (*domain.User, error)
+	// GetOnboardingStatus returns the onboarding status of the user with the given Clerk ID.
+	GetOnboardingStatus(ctx context.Context, clerkID string) (*onboarding.State, error)
+	// GetUserByClerkID returns the user with the given Clerk ID, or an error wrapping
+	// domain.ErrUserNotFound.
+	GetUserByClerkID(ctx context.Context, clerkID string) (*domain.User, error)
+	// UpdateContactDetails stores the contact details of the user with the given Clerk ID
+	// unless they are older than the stored ones. It reports whether they were stored.
+	UpdateContactDetails(ctx context.Context, clerkID string, details domain.ContactDetails) (bool, error)
+	// RecordSignIn records that the user with the given Clerk ID signed in at the given time.
+	RecordSignIn(ctx context.Context, clerkID string, at time.Time) error
+	// MarkUserDeleted marks the user deleted and writes event to the outbox atomically.
+	// It reports false, without writing the event, if the user was already deleted.
+	MarkUserDeleted(ctx context.Context, userID uuid.UUID, deletedAt time.Time, event outbox.Event) (bool, error)
+	// CheckUsername returns domain.ErrUsernameTaken if a user has username or gave it up
+	// within its grace period, and domain.ErrUsernameHeld if it is held for a user other
+	// than the one with the given Clerk ID. It does not change any reservation.
+	CheckUsername(ctx context.Context, username, clerkID string) error
+	// ReserveUsername holds username for the user with the given Clerk ID until expiresAt,
+	// releasing any other username held for them. It returns domain.ErrUsernameTaken if a
+	// user has the username and domain.ErrUsernameHeld if another user holds it.
+	ReserveUsername(ctx context.Context, username, clerkID string, expiresAt time.Time) error
+	// UnavailableUsernames returns which of usernames belong to a user or are held for a
+	// user other than the one with the given Clerk ID.
+	UnavailableUsernames(ctx context.Context, usernames []string, clerkID string) (map[string]bool, error)
+	// CreatePIN stores the user's first PIN hash. It returns domain.ErrPINAlreadySet if
+	// they already have one.
+	CreatePIN(ctx context.Context, userID uuid.UUID, hash string) error
+	// BeginPINAttempt counts one attempt at the user's PIN, locking it until lockedUntil
+	// if the attempt reaches maxAttempts, and returns the PIN to check. It returns
+	// domain.ErrPINNotSet or a *domain.PINLockedError.
+	BeginPINAttempt(ctx context.Context, userID uuid.UUID, maxAttempts int, lockedUntil time.Time) (*domain.PIN, error)
+	// ResetPINAttempts clears the failed attempts and lockout of the user's PIN.
+	ResetPINAttempts(ctx context.Context, userID uuid.UUID) error
+	// ReplacePIN replaces the user's PIN hash, clears its lockout and revokes their unused
+	// step-up tokens atomically. It returns domain.ErrPINNotSet if they have no PIN.
+	ReplacePIN(ctx context.Context, userID uuid.UUID, hash string) error
+	// CreateStepUpToken stores an issued step-up token.
+	CreateStepUpToken(ctx context.Context, token domain.StepUpToken) error
 }
-
-// Publisher defines the interface for publishing messages to a message broker.
-// Any message broker implementation must satisfy this interface.
-type Publisher interface {
-	Publish(ctx context.Context, body []byte, exchange, routingKey string) error
-	Close()
-}
\ No newline at end of file
@@ -0,0 +1,195 @@
+/**
+ * @description
+ * This file contains the business logic for transaction PINs, which approve every
+ * outbound money movement.
+ *
+ * Key features:
+ * - Users set a PIN once, then change it with their current PIN or, if they forgot it,
+ *   reset it after signing in again within `PINResetWindow`.
+ * - PINs are stored as argon2id hashes. Every attempt is counted before the PIN is
+ *   checked; `PINMaxAttempts` incorrect PINs in a row lock it for `PINLockoutDuration`.
+ * - A correct PIN earns a step-up token bound to one transfer (its purpose, amount and
+ *   recipient) that expires after `StepUpTokenTTL`. The Transaction service spends the
+ *   token before it moves money, so each token approves at most one transfer.
+ * - Changing or resetting the PIN revokes the user's unused step-up tokens.
+ *
+ * @dependencies
+ * - "context", "fmt", "log", "time"
+ * - "github.com/google/uuid": For user identifiers.
+ * - "transfa/services/auth/internal/domain": For PINs and step-up tokens.
+ * - "transfa/services/auth/pkg/pinhash": For hashing PINs.
+ * - "transfa/shared/stepup": For issuing step-up tokens.
+ */
+package app
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"transfa/services/auth/internal/domain"
+	"transfa/services/auth/pkg/pinhash"
+	"transfa/shared/stepup"
+)
+
+// SetPIN sets the first transaction PIN of the user with the given Clerk ID.
+func (s *Service) SetPIN(ctx context.Context, clerkID string, req domain.SetPINRequest) error {
+	// 1. Validate the PIN.
+	verr := &domain.ValidationError{}
+	if domain.ValidatePIN(verr, "/pin", req.PIN); len(verr.Fields) > 0 {
+		return verr
+	}
+
+	// 2. Store its hash.
+	user, err := s.repo.GetUserByClerkID(ctx, clerkID)
+	if err != nil {
+		return err
+	}
+	hash, err := pinhash.Hash(req.PIN)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.CreatePIN(ctx, user.ID, hash); err != nil {
+		return err
+	}
+
+	log.Printf("User %s set their transaction PIN", user.ID)
+	return nil
+}
+
+// ChangePIN replaces the transaction PIN of the user with the given Clerk ID, once they
+// have entered their current PIN.
+func (s *Service) ChangePIN(ctx context.Context, clerkID string, req domain.ChangePINRequest) error {
+	// 1. Validate the new PIN.
+	verr := &domain.ValidationError{}
+	if req.CurrentPIN == "" {
+		verr.Fields = append(verr.Fields, domain.FieldError{Pointer: "/current_pin", Code: domain.CodeRequired, Message: "current PIN is required"})
+	}
+	if domain.ValidatePIN(verr, "/new_pin", req.NewPIN); len(verr.Fields) > 0 {
+		return verr
+	}
+
+	// 2. Check the current PIN.
+	user, err := s.repo.GetUserByClerkID(ctx, clerkID)
+	if err != nil {
+		return err
+	}
+	if err := s.verifyPIN(ctx, user.ID, req.CurrentPIN); err != nil {
+		return err
+	}
+
+	// 3. Replace it.
+	if err := s.replacePIN(ctx, user.ID, req.NewPIN); err != nil {
+		return err
+	}
+
+	log.Printf("User %s changed their transaction PIN", user.ID)
+	return nil
+}
+
+// ResetPIN replaces the forgotten transaction PIN of the user with the given Clerk ID.
+// Instead of the current PIN, it requires that the user signed in again within
+// PINResetWindow, so someone holding an unlocked phone cannot reset it.
+func (s *Service) ResetPIN(ctx context.Context, clerkID string, req domain.ResetPINRequest) error {
+	// 1. Validate the new PIN.
+	verr := &domain.ValidationError{}
+	if domain.ValidatePIN(verr, "/new_pin", req.NewPIN); len(verr.Fields) > 0 {
+		return verr
+	}
+
+	// 2. Check the user signed in recently.
+	user, err := s.repo.GetUserByClerkID(ctx, clerkID)
+	if err != nil {
+		return err
+	}
+	if user.LastSignInAt == nil || time.Since(*user.LastSignInAt) > s.config.PINResetWindow {
+		return domain.ErrRecentSignInRequired
+	}
+
+	// 3. Replace the PIN, which also lifts any lockout.
+	if err := s.replacePIN(ctx, user.ID, req.NewPIN); err != nil {
+		return err
+	}
+
+	log.Printf("User %s reset their transaction PIN", user.ID)
+	return nil
+}
+
+// IssueStepUpToken checks the transaction PIN of the user with the given Clerk ID and
+// returns a step-up token that approves the transfer the request names.
+func (s *Service) IssueStepUpToken(ctx context.Context, clerkID string, req domain.StepUpRequest) (*domain.StepUpGrant, error) {
+	// 1. Validate the transfer to approve.
+	binding, err := req.Binding()
+	if err != nil {
+		return nil, err
+	}
+	if req.PIN == "" {
+		return nil, &domain.ValidationError{Fields: []domain.FieldError{{Pointer: "/pin", Code: domain.CodeRequired, Message: "PIN is required"}}}
+	}
+
+	// 2. Check the PIN.
+	user, err := s.repo.GetUserByClerkID(ctx, clerkID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.verifyPIN(ctx, user.ID, req.PIN); err != nil {
+		return nil, err
+	}
+
+	// 3. Issue a token bound to the transfer.
+	token, hash, err := stepup.NewToken()
+	if err != nil {
+		return nil, err
+	}
+	expiresAt := time.Now().Add(s.config.StepUpTokenTTL).UTC()
+	err = s.repo.CreateStepUpToken(ctx, domain.StepUpToken{
+		UserID:    user.ID,
+		TokenHash: hash,
+		Binding:   binding,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Issued a %s step-up token to user %s", binding.Purpose, user.ID)
+	return &domain.StepUpGrant{StepUpToken: token, ExpiresAt: expiresAt}, nil
+}
+
+// verifyPIN checks pin against the user's PIN. It returns a *domain.IncorrectPINError or,
+// once the attempts run out, a *domain.PINLockedError.
+func (s *Service) verifyPIN(ctx context.Context, userID uuid.UUID, pin string) error {
+	lockedUntil := time.Now().Add(s.config.PINLockoutDuration).UTC()
+	stored, err := s.repo.BeginPINAttempt(ctx, userID, s.config.PINMaxAttempts, lockedUntil)
+	if err != nil {
+		return err
+	}
+
+	ok, err := pinhash.Verify(pin, stored.Hash)
+	if err != nil {
+		return fmt.Errorf("failed to verify transaction PIN: %w", err)
+	}
+	if !ok {
+		if stored.LockedUntil != nil {
+			log.Printf("WARNING: transaction PIN of user %s locked after %d incorrect attempts", userID, stored.FailedAttempts)
+			return &domain.PINLockedError{Until: *stored.LockedUntil}
+		}
+		return &domain.IncorrectPINError{AttemptsRemaining: s.config.PINMaxAttempts - stored.FailedAttempts}
+	}
+
+	if err := s.repo.ResetPINAttempts(ctx, userID); err != nil {
+		return err
+	}
+	return nil
+}
+
+// replacePIN stores the hash of pin as the user's new PIN.
+func (s *Service) replacePIN(ctx context.Context, userID uuid.UUID, pin string) error {
+	hash, err := pinhash.Hash(pin)
+	if err != nil {
+		return err
+	}
+	return s.repo.ReplacePIN(ctx, userID, hash)
+}
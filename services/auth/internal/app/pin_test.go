@@ -0,0 +1,160 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"transfa/services/auth/internal/config"
+	"transfa/services/auth/internal/domain"
+	"transfa/shared/stepup"
+)
+
+// newPINTestService returns a service for a user who has set the PIN 4821.
+func newPINTestService(t *testing.T) (*Service, *fakeRepository, *domain.User) {
+	t.Helper()
+	user := &domain.User{ID: uuid.New(), ClerkID: "user_2abc"}
+	repo := &fakeRepository{users: []*domain.User{user}}
+	service := NewService(repo, config.Config{
+		PINMaxAttempts:     3,
+		PINLockoutDuration: 30 * time.Minute,
+		PINResetWindow:     10 * time.Minute,
+		StepUpTokenTTL:     2 * time.Minute,
+	})
+	if err := service.SetPIN(context.Background(), user.ClerkID, domain.SetPINRequest{PIN: "4821"}); err != nil {
+		t.Fatalf("SetPIN() error = %v", err)
+	}
+	return service, repo, user
+}
+
+func p2pStepUp(pin string) domain.StepUpRequest {
+	return domain.StepUpRequest{PIN: pin, Purpose: stepup.PurposeP2PTransfer, Amount: 250000, RecipientUsername: "@Lovelace"}
+}
+
+func TestSetPIN(t *testing.T) {
+	ctx := context.Background()
+	service, repo, user := newPINTestService(t)
+
+	if repo.pins[user.ID].Hash == "4821" {
+		t.Error("SetPIN() stored the PIN in plain text")
+	}
+	if err := service.SetPIN(ctx, user.ClerkID, domain.SetPINRequest{PIN: "7305"}); !errors.Is(err, domain.ErrPINAlreadySet) {
+		t.Errorf("second SetPIN() error = %v, want ErrPINAlreadySet", err)
+	}
+
+	for pin, code := range map[string]string{"": domain.CodeRequired, "48a1": domain.CodeInvalidFormat, "48215": domain.CodeInvalidFormat, "1234": domain.CodeWeakPIN, "0000": domain.CodeWeakPIN} {
+		var verr *domain.ValidationError
+		err := service.SetPIN(ctx, "user_other", domain.SetPINRequest{PIN: pin})
+		if !errors.As(err, &verr) || verr.Fields[0].Code != code {
+			t.Errorf("SetPIN(%q) error = %v, want a %s validation error", pin, err, code)
+		}
+	}
+}
+
+func TestIssueStepUpTokenBindsTheTransfer(t *testing.T) {
+	service, repo, user := newPINTestService(t)
+
+	grant, err := service.IssueStepUpToken(context.Background(), user.ClerkID, p2pStepUp("4821"))
+	if err != nil {
+		t.Fatalf("IssueStepUpToken() error = %v", err)
+	}
+	if len(repo.stepUpTokens) != 1 {
+		t.Fatalf("stored %d step-up tokens, want 1", len(repo.stepUpTokens))
+	}
+	stored := repo.stepUpTokens[0]
+	if stored.TokenHash != stepup.HashToken(grant.StepUpToken) {
+		t.Error("the stored token hash does not match the issued token")
+	}
+	want := stepup.Binding{Purpose: stepup.PurposeP2PTransfer, Amount: 250000, Recipient: "user:lovelace"}
+	if stored.UserID != user.ID || stored.Binding != want {
+		t.Errorf("stored token = %+v, want user %s bound to %+v", stored, user.ID, want)
+	}
+	if !stored.ExpiresAt.Equal(grant.ExpiresAt) || time.Until(grant.ExpiresAt) > 2*time.Minute {
+		t.Errorf("token expires at %s, want within the TTL", grant.ExpiresAt)
+	}
+}
+
+func TestIncorrectPINsLockThePIN(t *testing.T) {
+	ctx := context.Background()
+	service, repo, user := newPINTestService(t)
+
+	for remaining := 2; remaining > 0; remaining-- {
+		var incorrect *domain.IncorrectPINError
+		_, err := service.IssueStepUpToken(ctx, user.ClerkID, p2pStepUp("0482"))
+		if !errors.As(err, &incorrect) || incorrect.AttemptsRemaining != remaining {
+			t.Fatalf("IssueStepUpToken() error = %v, want %d attempts remaining", err, remaining)
+		}
+	}
+
+	// The last attempt locks the PIN, and even the correct PIN is refused until it ends.
+	var locked *domain.PINLockedError
+	if _, err := service.IssueStepUpToken(ctx, user.ClerkID, p2pStepUp("0482")); !errors.As(err, &locked) {
+		t.Fatalf("third incorrect PIN error = %v, want PINLockedError", err)
+	}
+	if _, err := service.IssueStepUpToken(ctx, user.ClerkID, p2pStepUp("4821")); !errors.As(err, &locked) {
+		t.Fatalf("correct PIN while locked error = %v, want PINLockedError", err)
+	}
+	if len(repo.stepUpTokens) != 0 {
+		t.Errorf("issued %d step-up tokens while locked, want 0", len(repo.stepUpTokens))
+	}
+
+	// Once the lockout ends, the correct PIN works and clears the count.
+	ended := time.Now().Add(-time.Second)
+	repo.pins[user.ID].LockedUntil = &ended
+	if _, err := service.IssueStepUpToken(ctx, user.ClerkID, p2pStepUp("4821")); err != nil {
+		t.Fatalf("IssueStepUpToken() after the lockout error = %v", err)
+	}
+	if pin := repo.pins[user.ID]; pin.FailedAttempts != 0 || pin.LockedUntil != nil {
+		t.Errorf("PIN after a correct attempt = %+v, want the count cleared", pin)
+	}
+}
+
+func TestChangePIN(t *testing.T) {
+	ctx := context.Background()
+	service, repo, user := newPINTestService(t)
+	if _, err := service.IssueStepUpToken(ctx, user.ClerkID, p2pStepUp("4821")); err != nil {
+		t.Fatalf("IssueStepUpToken() error = %v", err)
+	}
+
+	var incorrect *domain.IncorrectPINError
+	err := service.ChangePIN(ctx, user.ClerkID, domain.ChangePINRequest{CurrentPIN: "0482", NewPIN: "7305"})
+	if !errors.As(err, &incorrect) {
+		t.Fatalf("ChangePIN() with the wrong PIN error = %v, want IncorrectPINError", err)
+	}
+
+	if err := service.ChangePIN(ctx, user.ClerkID, domain.ChangePINRequest{CurrentPIN: "4821", NewPIN: "7305"}); err != nil {
+		t.Fatalf("ChangePIN() error = %v", err)
+	}
+	if len(repo.stepUpTokens) != 0 {
+		t.Error("ChangePIN() did not revoke the unused step-up tokens")
+	}
+	if _, err := service.IssueStepUpToken(ctx, user.ClerkID, p2pStepUp("7305")); err != nil {
+		t.Errorf("IssueStepUpToken() with the new PIN error = %v", err)
+	}
+}
+
+func TestResetPINRequiresARecentSignIn(t *testing.T) {
+	ctx := context.Background()
+	service, _, user := newPINTestService(t)
+	req := domain.ResetPINRequest{NewPIN: "7305"}
+
+	if err := service.ResetPIN(ctx, user.ClerkID, req); !errors.Is(err, domain.ErrRecentSignInRequired) {
+		t.Errorf("ResetPIN() without a sign-in error = %v, want ErrRecentSignInRequired", err)
+	}
+	stale := time.Now().Add(-time.Hour)
+	user.LastSignInAt = &stale
+	if err := service.ResetPIN(ctx, user.ClerkID, req); !errors.Is(err, domain.ErrRecentSignInRequired) {
+		t.Errorf("ResetPIN() after an old sign-in error = %v, want ErrRecentSignInRequired", err)
+	}
+
+	recent := time.Now().Add(-time.Minute)
+	user.LastSignInAt = &recent
+	if err := service.ResetPIN(ctx, user.ClerkID, req); err != nil {
+		t.Fatalf("ResetPIN() error = %v", err)
+	}
+	if _, err := service.IssueStepUpToken(ctx, user.ClerkID, p2pStepUp("7305")); err != nil {
+		t.Errorf("IssueStepUpToken() with the new PIN error = %v", err)
+	}
+}
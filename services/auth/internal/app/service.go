@@ -2,48 +2,62 @@
  * @description
  * This file contains the core business logic for the Auth service.
  * The Service struct orchestrates operations by coordinating between the domain models,
- * the repository (for data persistence), and the publisher (for event messaging).
+ * the repository (for data persistence) and the event outbox.
  *
  * @dependencies
  * - "context": For passing request-scoped data and cancellation signals.
- * - "encoding/json": For serializing event data.
  * - "log": For logging information and errors.
+ * - "time": For validating dates of birth.
  * - "github.com/google/uuid": To generate UUIDs for new users.
  * - "transfa/services/auth/internal/config": Imports app configuration.
  * - "transfa/services/auth/internal/domain": Imports the core data models.
+ * - "transfa/shared/events": For the `user.created` event.
+ * - "transfa/shared/onboarding": For onboarding statuses.
+ * - "transfa/shared/outbox": For the events written alongside a new user.
  */
 package app
 
 import (
 	"context"
-	"encoding/json"
 	"log"
+	"time"
 
 	"github.com/google/uuid"
 	"transfa/services/auth/internal/config"
 	"transfa/services/auth/internal/domain"
+	"transfa/shared/events"
+	"transfa/shared/onboarding"
+	"transfa/shared/outbox"
 )
 
+// EventProducer names the Auth service in the events it publishes.
+const EventProducer = "auth"
+
 // Service provides the application's business logic.
 type Service struct {
-	repo      Repository
-	publisher Publisher
-	config    config.Config
+	repo   Repository
+	config config.Config
 }
 
 // NewService creates a new application service.
-func NewService(repo Repository, publisher Publisher, cfg config.Config) *Service {
+func NewService(repo Repository, cfg config.Config) *Service {
 	return &Service{
-		repo:      repo,
-		publisher: publisher,
-		config:    cfg,
+		repo:   repo,
+		config: cfg,
 	}
 }
 
 // OnboardUser handles the business logic for creating a new user.
-// It creates a user record in the database and publishes a `user.created` event.
+// It creates a user record in the database and queues a `user.created` event in the outbox.
+// It returns a *domain.ValidationError if the request breaks the onboarding rules.
 func (s *Service) OnboardUser(ctx context.Context, clerkID string, req domain.OnboardingRequest) (*domain.User, error) {
-	// 1. Construct the user object from the request.
+	// 1. Validate the request.
+	req.Normalize()
+	if err := req.Validate(time.Now(), s.config.MinimumAge); err != nil {
+		return nil, err
+	}
+
+	// 2. Construct the user object from the request.
 	newUser := &domain.User{
 		ID:          uuid.New(), // Generate a new UUID for the user.
 		ClerkID:     clerkID,
@@ -58,38 +72,49 @@ func (s *Service) OnboardUser(ctx context.Context, clerkID string, req domain.On
 		newUser.AllowSending = true
 	}
 
-	// 2. Persist the user to the database.
-	createdUser, err := s.repo.CreateUser(ctx, newUser)
-	if err != nil {
-		log.Printf("Failed to create user in repository: %v", err)
-		return nil, err
+	// 3. Prepare the `user.created` event. It is written to the outbox together with the
+	// user and published by the outbox relay once the transaction commits.
+	payload := events.UserCreated{
+		UserID:      newUser.ID,
+		ClerkID:     newUser.ClerkID,
+		AccountType: newUser.AccountType,
 	}
-
-	// 3. Prepare and publish the `user.created` event.
-	event := domain.UserCreatedEvent{
-		UserID:      createdUser.ID,
-		ClerkID:     createdUser.ClerkID,
-		AccountType: createdUser.AccountType,
-		KYCDetails:  req.KYCDetails,
-		KYBDetails:  req.KYBDetails,
+	if req.KYCDetails != nil {
+		payload.KYCDetails = &events.KYCDetails{
+			FullName:    req.KYCDetails.FullName,
+			BVN:         req.KYCDetails.BVN,
+			DateOfBirth: req.KYCDetails.DateOfBirth,
+		}
+	}
+	if req.KYBDetails != nil {
+		payload.KYBDetails = &events.KYBDetails{
+			BusinessName: req.KYBDetails.BusinessName,
+			RCNumber:     req.KYBDetails.RCNumber,
+		}
 	}
 
-	eventBody, err := json.Marshal(event)
+	envelope, err := events.New(EventProducer, payload, events.Metadata{})
 	if err != nil {
-		// Log the error but don't fail the whole operation, as the user is already created.
-		// This might be a scenario for a dead-letter queue or another recovery mechanism.
-		log.Printf("ERROR: Failed to marshal UserCreatedEvent for user %s: %v", createdUser.ID, err)
-		return createdUser, nil
+		return nil, err
 	}
 
-	err = s.publisher.Publish(ctx, eventBody, s.config.UserCreatedEx, s.config.UserCreatedRK)
+	// 4. Persist the user and the event in one transaction.
+	createdUser, err := s.repo.CreateUser(ctx, newUser, outbox.Event{
+		Exchange:   s.config.UserCreatedEx,
+		RoutingKey: s.config.UserCreatedRK,
+		Envelope:   envelope,
+	})
 	if err != nil {
-		// Same as above, log the error but the user creation itself was successful.
-		log.Printf("ERROR: Failed to publish UserCreatedEvent for user %s: %v", createdUser.ID, err)
-	} else {
-		log.Printf("Successfully published UserCreatedEvent for user %s", createdUser.ID)
+		log.Printf("Failed to create user in repository: %v", err)
+		return nil, err
 	}
 
-
+	log.Printf("Created user %s and queued user.created event %s", createdUser.ID, envelope.EventID)
 	return createdUser, nil
-}
\ No newline at end of file
+}
+
+// GetOnboardingStatus returns how far the user with the given Clerk ID has got through
+// onboarding. The other services advance the status as they handle the user's events.
+func (s *Service) GetOnboardingStatus(ctx context.Context, clerkID string) (*onboarding.State, error) {
+	return s.repo.GetOnboardingStatus(ctx, clerkID)
+}
@@ -0,0 +1,234 @@
+package app
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"transfa/services/auth/internal/config"
+	"transfa/services/auth/internal/domain"
+	"transfa/shared/events"
+	"transfa/shared/eventtest"
+	"transfa/shared/messaging"
+	"transfa/shared/messaging/memory"
+	"transfa/shared/onboarding"
+	"transfa/shared/outbox"
+)
+
+// fakeRepository stores users and records the outbox events written with them.
+type fakeRepository struct {
+	users  []*domain.User
+	events []outbox.Event
+	// The following fields record the changes made by Clerk webhooks.
+	contacts map[string]domain.ContactDetails
+	signIns  map[string]time.Time
+	deleted  map[uuid.UUID]time.Time
+	// reservations holds the Clerk ID each username is reserved for.
+	reservations map[string]string
+	// pins and stepUpTokens hold transaction PINs and the step-up tokens issued.
+	pins         map[uuid.UUID]*domain.PIN
+	stepUpTokens []domain.StepUpToken
+}
+
+func (r *fakeRepository) CreateUser(ctx context.Context, user *domain.User, event outbox.Event) (*domain.User, error) {
+	r.users = append(r.users, user)
+	r.events = append(r.events, event)
+	return user, nil
+}
+
+func (r *fakeRepository) GetOnboardingStatus(ctx context.Context, clerkID string) (*onboarding.State, error) {
+	return nil, domain.ErrUserNotFound
+}
+
+func (r *fakeRepository) GetUserByClerkID(ctx context.Context, clerkID string) (*domain.User, error) {
+	for _, user := range r.users {
+		if user.ClerkID == clerkID {
+			return user, nil
+		}
+	}
+	return nil, domain.ErrUserNotFound
+}
+
+func (r *fakeRepository) UpdateContactDetails(ctx context.Context, clerkID string, details domain.ContactDetails) (bool, error) {
+	if _, err := r.GetUserByClerkID(ctx, clerkID); err != nil {
+		return false, nil
+	}
+	if current, ok := r.contacts[clerkID]; ok && !current.UpdatedAt.Before(details.UpdatedAt) {
+		return false, nil
+	}
+	if r.contacts == nil {
+		r.contacts = make(map[string]domain.ContactDetails)
+	}
+	r.contacts[clerkID] = details
+	return true, nil
+}
+
+func (r *fakeRepository) RecordSignIn(ctx context.Context, clerkID string, at time.Time) error {
+	if r.signIns == nil {
+		r.signIns = make(map[string]time.Time)
+	}
+	r.signIns[clerkID] = at
+	return nil
+}
+
+func (r *fakeRepository) MarkUserDeleted(ctx context.Context, userID uuid.UUID, deletedAt time.Time, event outbox.Event) (bool, error) {
+	if _, ok := r.deleted[userID]; ok {
+		return false, nil
+	}
+	if r.deleted == nil {
+		r.deleted = make(map[uuid.UUID]time.Time)
+	}
+	r.deleted[userID] = deletedAt
+	r.events = append(r.events, event)
+	return true, nil
+}
+
+func (r *fakeRepository) CheckUsername(ctx context.Context, username, clerkID string) error {
+	for _, user := range r.users {
+		if user.Username == username {
+			return domain.ErrUsernameTaken
+		}
+	}
+	if holder, ok := r.reservations[username]; ok && holder != clerkID {
+		return domain.ErrUsernameHeld
+	}
+	return nil
+}
+
+func (r *fakeRepository) ReserveUsername(ctx context.Context, username, clerkID string, expiresAt time.Time) error {
+	for _, user := range r.users {
+		if user.Username == username {
+			return domain.ErrUsernameTaken
+		}
+	}
+	if holder, ok := r.reservations[username]; ok && holder != clerkID {
+		return domain.ErrUsernameHeld
+	}
+	if r.reservations == nil {
+		r.reservations = make(map[string]string)
+	}
+	for reserved, holder := range r.reservations {
+		if holder == clerkID {
+			delete(r.reservations, reserved)
+		}
+	}
+	r.reservations[username] = clerkID
+	return nil
+}
+
+func (r *fakeRepository) UnavailableUsernames(ctx context.Context, usernames []string, clerkID string) (map[string]bool, error) {
+	unavailable := make(map[string]bool)
+	for _, username := range usernames {
+		if holder, ok := r.reservations[username]; ok && holder != clerkID {
+			unavailable[username] = true
+		}
+		for _, user := range r.users {
+			if user.Username == username {
+				unavailable[username] = true
+			}
+		}
+	}
+	return unavailable, nil
+}
+
+func (r *fakeRepository) CreatePIN(ctx context.Context, userID uuid.UUID, hash string) error {
+	if _, ok := r.pins[userID]; ok {
+		return domain.ErrPINAlreadySet
+	}
+	if r.pins == nil {
+		r.pins = make(map[uuid.UUID]*domain.PIN)
+	}
+	r.pins[userID] = &domain.PIN{UserID: userID, Hash: hash}
+	return nil
+}
+
+func (r *fakeRepository) BeginPINAttempt(ctx context.Context, userID uuid.UUID, maxAttempts int, lockedUntil time.Time) (*domain.PIN, error) {
+	pin, ok := r.pins[userID]
+	if !ok {
+		return nil, domain.ErrPINNotSet
+	}
+	if pin.LockedUntil != nil {
+		if time.Now().Before(*pin.LockedUntil) {
+			return nil, &domain.PINLockedError{Until: *pin.LockedUntil}
+		}
+		// The lockout has ended, so the count restarts.
+		pin.FailedAttempts, pin.LockedUntil = 0, nil
+	}
+	pin.FailedAttempts++
+	if pin.FailedAttempts >= maxAttempts {
+		pin.LockedUntil = &lockedUntil
+	}
+	copied := *pin
+	return &copied, nil
+}
+
+func (r *fakeRepository) ResetPINAttempts(ctx context.Context, userID uuid.UUID) error {
+	if pin, ok := r.pins[userID]; ok {
+		pin.FailedAttempts, pin.LockedUntil = 0, nil
+	}
+	return nil
+}
+
+func (r *fakeRepository) ReplacePIN(ctx context.Context, userID uuid.UUID, hash string) error {
+	pin, ok := r.pins[userID]
+	if !ok {
+		return domain.ErrPINNotSet
+	}
+	pin.Hash, pin.FailedAttempts, pin.LockedUntil = hash, 0, nil
+	r.stepUpTokens = nil
+	return nil
+}
+
+func (r *fakeRepository) CreateStepUpToken(ctx context.Context, token domain.StepUpToken) error {
+	r.stepUpTokens = append(r.stepUpTokens, token)
+	return nil
+}
+
+// TestOnboardUserPublishesUserCreated is the Auth service's leg of the onboarding
+// scenario: onboarding a user queues the `user.created` event the Customer service consumes.
+func TestOnboardUserPublishesUserCreated(t *testing.T) {
+	cfg := config.Config{UserCreatedEx: "user_events", UserCreatedRK: "user.created"}
+	repo := &fakeRepository{}
+	service := NewService(repo, cfg)
+
+	onboarding := eventtest.Onboarding
+	user, err := service.OnboardUser(context.Background(), onboarding.ClerkID, domain.OnboardingRequest{
+		Username:    onboarding.Username,
+		AccountType: onboarding.AccountType,
+		KYCDetails: &domain.KYCDetails{
+			FullName:    onboarding.KYCDetails.FullName,
+			BVN:         onboarding.KYCDetails.BVN,
+			DateOfBirth: onboarding.KYCDetails.DateOfBirth,
+		},
+	})
+	if err != nil {
+		t.Fatalf("OnboardUser() error = %v", err)
+	}
+	if len(repo.events) != 1 {
+		t.Fatalf("OnboardUser() queued %d events, want 1", len(repo.events))
+	}
+
+	// Publish the queued event as the outbox relay would, to the Customer service's queue.
+	broker := memory.NewBroker(messaging.RetryPolicy{})
+	broker.Bind(cfg.UserCreatedEx, cfg.UserCreatedRK, "customer_service_user_created")
+	queued := repo.events[0]
+	if err := events.Publish(context.Background(), broker, queued.Exchange, queued.RoutingKey, queued.Envelope); err != nil {
+		t.Fatalf("failed to publish queued event: %v", err)
+	}
+
+	var got events.UserCreated
+	env := eventtest.Received(t, broker, "customer_service_user_created", &got)
+
+	if env.Producer != EventProducer {
+		t.Errorf("producer = %q, want %q", env.Producer, EventProducer)
+	}
+	want := eventtest.UserCreated()
+	want.UserID = user.ID
+	if got.UserID != want.UserID || got.ClerkID != want.ClerkID || got.AccountType != want.AccountType {
+		t.Errorf("user.created = %+v, want %+v", got, want)
+	}
+	if got.KYCDetails == nil || *got.KYCDetails != *want.KYCDetails {
+		t.Errorf("user.created KYC details = %+v, want %+v", got.KYCDetails, want.KYCDetails)
+	}
+}
@@ -0,0 +1,134 @@
+/**
+ * @description
+ * This file contains the username availability check and reservation, which users run
+ * while they pick a username during onboarding.
+ *
+ * Key features:
+ * - The username is normalized and validated first, so invalid, reserved and blocked
+ *   usernames are reported with the same codes as `POST /onboarding` would return.
+ * - Checking a username is read-only, so clients can check as the user types.
+ * - Reserving an available username holds it for the caller for
+ *   `UsernameReservationTTL`, so another user who onboards at the same time cannot take
+ *   it. A user holds one reservation at a time: reserving another username releases the
+ *   previous one.
+ * - For a taken username, a few available alternatives are suggested.
+ *
+ * @dependencies
+ * - "context", "errors", "fmt", "log", "time"
+ * - "transfa/services/auth/internal/domain": For the availability result.
+ * - "transfa/shared/username": For the username rules.
+ */
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"transfa/services/auth/internal/domain"
+	"transfa/shared/username"
+)
+
+// maxUsernameSuggestions is the most alternatives suggested for a taken username.
+const maxUsernameSuggestions = 3
+
+// CheckUsernameAvailability reports whether the username name can be claimed by the user
+// with the given Clerk ID. It does not reserve the username; see ReserveUsername.
+func (s *Service) CheckUsernameAvailability(ctx context.Context, clerkID, name string) (*domain.UsernameAvailability, error) {
+	// 1. Validate the username.
+	result, ok := validateUsernameAvailability(name)
+	if !ok {
+		return result, nil
+	}
+
+	// 2. Look it up, without holding it.
+	err := s.repo.CheckUsername(ctx, result.Username, clerkID)
+	if err == nil {
+		result.Available = true
+		return result, nil
+	}
+	return s.usernameUnavailable(ctx, clerkID, result, err)
+}
+
+// ReserveUsername reserves the username name for the user with the given Clerk ID for
+// UsernameReservationTTL, if it is available, so another user who onboards at the same
+// time cannot take it. A user holds one reservation at a time: reserving another
+// username releases the previous one.
+func (s *Service) ReserveUsername(ctx context.Context, clerkID, name string) (*domain.UsernameAvailability, error) {
+	// 1. Validate the username.
+	result, ok := validateUsernameAvailability(name)
+	if !ok {
+		return result, nil
+	}
+
+	// 2. Reserve it for the caller.
+	expiresAt := time.Now().Add(s.config.UsernameReservationTTL).UTC()
+	err := s.repo.ReserveUsername(ctx, result.Username, clerkID, expiresAt)
+	if err == nil {
+		result.Available = true
+		result.ReservedUntil = &expiresAt
+		log.Printf("Reserved username %q for clerk_id %s until %s", result.Username, clerkID, expiresAt.Format(time.RFC3339))
+		return result, nil
+	}
+	return s.usernameUnavailable(ctx, clerkID, result, err)
+}
+
+// validateUsernameAvailability normalizes name and, if it breaks a username rule,
+// returns it as unavailable with the rule's code as the reason.
+func validateUsernameAvailability(name string) (*domain.UsernameAvailability, bool) {
+	result := &domain.UsernameAvailability{Username: username.Normalize(name)}
+	verr := &domain.ValidationError{}
+	if domain.ValidateUsername(verr, result.Username); len(verr.Fields) > 0 {
+		result.Reason = verr.Fields[0].Code
+		result.Message = verr.Fields[0].Message
+		return result, false
+	}
+	return result, true
+}
+
+// usernameUnavailable completes result for a username that is taken or held, as
+// reported by err, with alternatives to it. Other errors are returned.
+func (s *Service) usernameUnavailable(ctx context.Context, clerkID string, result *domain.UsernameAvailability, err error) (*domain.UsernameAvailability, error) {
+	switch {
+	case errors.Is(err, domain.ErrUsernameTaken):
+		result.Reason = domain.ReasonTaken
+		result.Message = fmt.Sprintf("username %q is taken", result.Username)
+	case errors.Is(err, domain.ErrUsernameHeld):
+		// Not told apart from a taken username in the message, so clients cannot learn
+		// which usernames other users are about to claim.
+		result.Reason = domain.ReasonHeld
+		result.Message = fmt.Sprintf("username %q is taken", result.Username)
+	default:
+		return nil, fmt.Errorf("failed to check username: %w", err)
+	}
+
+	// The check is still answered if the alternatives cannot be looked up.
+	suggestions, err := s.suggestUsernames(ctx, clerkID, result.Username)
+	if err != nil {
+		log.Printf("WARNING: failed to suggest alternatives to username %q: %v", result.Username, err)
+	}
+	result.Suggestions = suggestions
+	return result, nil
+}
+
+// suggestUsernames returns up to maxUsernameSuggestions available alternatives to name.
+func (s *Service) suggestUsernames(ctx context.Context, clerkID, name string) ([]string, error) {
+	candidates := username.Candidates(name)
+	unavailable, err := s.repo.UnavailableUsernames(ctx, candidates, clerkID)
+	if err != nil {
+		return nil, err
+	}
+
+	var suggestions []string
+	for _, candidate := range candidates {
+		if len(suggestions) == maxUsernameSuggestions {
+			break
+		}
+		if !unavailable[candidate] {
+			suggestions = append(suggestions, candidate)
+		}
+	}
+	return suggestions, nil
+}
@@ -0,0 +1,109 @@
+package app
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"transfa/services/auth/internal/config"
+	"transfa/services/auth/internal/domain"
+)
+
+func TestCheckUsernameAvailability(t *testing.T) {
+	repo := &fakeRepository{
+		users:        []*domain.User{{ClerkID: "user_ada", Username: "ada"}},
+		reservations: map[string]string{"adang": "user_other", "grace": "user_other"},
+	}
+	service := NewService(repo, config.Config{UsernameReservationTTL: 10 * time.Minute})
+	ctx := context.Background()
+
+	tests := []struct {
+		name            string
+		username        string
+		wantAvailable   bool
+		wantReason      string
+		wantSuggestions []string
+	}{
+		{"available", " Turing ", true, "", nil},
+		{"taken", "ADA", false, domain.ReasonTaken, []string{"adapay", "adahq", "ada1"}},
+		{"held by another user", "grace", false, domain.ReasonHeld, []string{"graceng", "gracepay", "gracehq"}},
+		{"reserved", "Admin", false, domain.CodeReserved, nil},
+		{"blocked", "scamking", false, domain.CodeBlocked, nil},
+		{"invalid", "a_b", false, domain.CodeInvalidFormat, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := service.CheckUsernameAvailability(ctx, "user_2abc", tt.username)
+			if err != nil {
+				t.Fatalf("CheckUsernameAvailability() error = %v", err)
+			}
+			if got.Available != tt.wantAvailable || got.Reason != tt.wantReason {
+				t.Errorf("availability = %+v, want available %v with reason %q", got, tt.wantAvailable, tt.wantReason)
+			}
+			if !reflect.DeepEqual(got.Suggestions, tt.wantSuggestions) {
+				t.Errorf("suggestions = %v, want %v", got.Suggestions, tt.wantSuggestions)
+			}
+			if got.ReservedUntil != nil {
+				t.Error("checking a username reserved it")
+			}
+		})
+	}
+	if want := map[string]string{"adang": "user_other", "grace": "user_other"}; !reflect.DeepEqual(repo.reservations, want) {
+		t.Errorf("reservations = %v, want %v unchanged", repo.reservations, want)
+	}
+}
+
+func TestReserveUsername(t *testing.T) {
+	repo := &fakeRepository{
+		users:        []*domain.User{{ClerkID: "user_ada", Username: "ada"}},
+		reservations: map[string]string{"grace": "user_other"},
+	}
+	service := NewService(repo, config.Config{UsernameReservationTTL: 10 * time.Minute})
+	ctx := context.Background()
+
+	got, err := service.ReserveUsername(ctx, "user_2abc", " Turing ")
+	if err != nil {
+		t.Fatalf("ReserveUsername() error = %v", err)
+	}
+	if !got.Available || got.ReservedUntil == nil || repo.reservations["turing"] != "user_2abc" {
+		t.Errorf("availability = %+v, reservations = %v, want turing reserved for user_2abc", got, repo.reservations)
+	}
+
+	for name, reason := range map[string]string{"ada": domain.ReasonTaken, "grace": domain.ReasonHeld, "admin": domain.CodeReserved} {
+		got, err := service.ReserveUsername(ctx, "user_2abc", name)
+		if err != nil {
+			t.Fatalf("ReserveUsername(%q) error = %v", name, err)
+		}
+		if got.Available || got.Reason != reason || got.ReservedUntil != nil {
+			t.Errorf("ReserveUsername(%q) = %+v, want unavailable with reason %q", name, got, reason)
+		}
+	}
+	if repo.reservations["turing"] != "user_2abc" {
+		t.Error("a failed reservation released the caller's reservation")
+	}
+}
+
+func TestReserveUsernameHoldsOneReservation(t *testing.T) {
+	repo := &fakeRepository{}
+	service := NewService(repo, config.Config{UsernameReservationTTL: 10 * time.Minute})
+	ctx := context.Background()
+
+	for _, username := range []string{"turing", "lovelace"} {
+		if got, err := service.ReserveUsername(ctx, "user_2abc", username); err != nil || !got.Available {
+			t.Fatalf("ReserveUsername(%q) = %+v, %v, want available", username, got, err)
+		}
+	}
+	if want := map[string]string{"lovelace": "user_2abc"}; !reflect.DeepEqual(repo.reservations, want) {
+		t.Errorf("reservations = %v, want %v", repo.reservations, want)
+	}
+
+	// Another user cannot take the username while it is reserved.
+	got, err := service.CheckUsernameAvailability(ctx, "user_other", "lovelace")
+	if err != nil {
+		t.Fatalf("CheckUsernameAvailability() error = %v", err)
+	}
+	if got.Available || got.Reason != domain.ReasonHeld {
+		t.Errorf("availability = %+v, want held", got)
+	}
+}
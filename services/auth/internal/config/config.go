@@ -10,17 +10,47 @@
  */
 package config
 
-import "github.com/spf13/viper"
+import (
+	"time"
+
+	"github.com/spf13/viper"
+)
 
 // Config stores all configuration for the application.
 // The values are read by viper from a config file or environment variable.
 type Config struct {
-	DatabaseURL     string `mapstructure:"DATABASE_URL"`
-	RabbitMQURL     string `mapstructure:"RABBITMQ_URL"`
-	ClerkSecretKey  string `mapstructure:"CLERK_SECRET_KEY"`
-	Port            string `mapstructure:"PORT"`
-	UserCreatedEx   string `mapstructure:"USER_CREATED_EX"`
-	UserCreatedRK   string `mapstructure:"USER_CREATED_RK"`
+	DatabaseURL string `mapstructure:"DATABASE_URL"`
+	RabbitMQURL string `mapstructure:"RABBITMQ_URL"`
+	// ClerkIssuer is the Clerk instance's Frontend API URL, the `iss` of its session tokens.
+	ClerkIssuer string `mapstructure:"CLERK_ISSUER"`
+	// ClerkJWKSURL overrides where Clerk's signing keys are fetched from.
+	ClerkJWKSURL string `mapstructure:"CLERK_JWKS_URL"`
+	// ClerkAudience, if set, must be an audience of every session token.
+	ClerkAudience string `mapstructure:"CLERK_AUDIENCE"`
+	// ClerkClockSkew is the clock skew allowed when checking session token times.
+	ClerkClockSkew time.Duration `mapstructure:"CLERK_CLOCK_SKEW"`
+	// ClerkWebhookSecret is the Svix signing secret of the Clerk webhook endpoint.
+	ClerkWebhookSecret string `mapstructure:"CLERK_WEBHOOK_SECRET"`
+	Port               string `mapstructure:"PORT"`
+	UserCreatedEx      string `mapstructure:"USER_CREATED_EX"`
+	UserCreatedRK      string `mapstructure:"USER_CREATED_RK"`
+	UserDeletedEx      string `mapstructure:"USER_DELETED_EX"`
+	UserDeletedRK      string `mapstructure:"USER_DELETED_RK"`
+	// OutboxPollInterval is how often the outbox relay looks for events to publish.
+	OutboxPollInterval time.Duration `mapstructure:"OUTBOX_POLL_INTERVAL"`
+	// MinimumAge is the minimum age in years of users onboarding a personal account.
+	MinimumAge int `mapstructure:"ONBOARDING_MIN_AGE"`
+	// UsernameReservationTTL is how long an available username is held for the user who
+	// checked it, so they can finish onboarding with it.
+	UsernameReservationTTL time.Duration `mapstructure:"USERNAME_RESERVATION_TTL"`
+	// PINMaxAttempts is how many incorrect transaction PINs in a row lock the PIN.
+	PINMaxAttempts int `mapstructure:"PIN_MAX_ATTEMPTS"`
+	// PINLockoutDuration is how long a PIN stays locked after too many incorrect attempts.
+	PINLockoutDuration time.Duration `mapstructure:"PIN_LOCKOUT_DURATION"`
+	// PINResetWindow is how recently the user must have signed in to reset their PIN.
+	PINResetWindow time.Duration `mapstructure:"PIN_RESET_WINDOW"`
+	// StepUpTokenTTL is how long a step-up token can approve its transfer.
+	StepUpTokenTTL time.Duration `mapstructure:"STEP_UP_TOKEN_TTL"`
 }
 
 // LoadConfig reads configuration from file or environment variables.
@@ -33,9 +63,18 @@ func LoadConfig() (config Config, err error) {
 
 	// Set default values
 	viper.SetDefault("PORT", "8080")
+	viper.SetDefault("CLERK_CLOCK_SKEW", "5s")
 	viper.SetDefault("USER_CREATED_EX", "user_events")
 	viper.SetDefault("USER_CREATED_RK", "user.created")
-
+	viper.SetDefault("USER_DELETED_EX", "user_events")
+	viper.SetDefault("USER_DELETED_RK", "user.deleted")
+	viper.SetDefault("OUTBOX_POLL_INTERVAL", "1s")
+	viper.SetDefault("ONBOARDING_MIN_AGE", 18)
+	viper.SetDefault("USERNAME_RESERVATION_TTL", "10m")
+	viper.SetDefault("PIN_MAX_ATTEMPTS", 5)
+	viper.SetDefault("PIN_LOCKOUT_DURATION", "30m")
+	viper.SetDefault("PIN_RESET_WINDOW", "10m")
+	viper.SetDefault("STEP_UP_TOKEN_TTL", "2m")
 
 	err = viper.ReadInConfig()
 	if err != nil {
@@ -47,4 +86,4 @@ func LoadConfig() (config Config, err error) {
 
 	err = viper.Unmarshal(&config)
 	return
-}
\ No newline at end of file
+}
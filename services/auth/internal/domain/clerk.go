@@ -0,0 +1,102 @@
+/**
+ * @description
+ * This file defines the Clerk webhook payloads the Auth service handles, reduced to the
+ * fields it uses.
+ *
+ * Key features:
+ * - `ClerkWebhook`: The envelope of every Clerk webhook, with its `data` left raw until
+ *   the event type is known.
+ * - `ClerkUser`, `ClerkDeletedObject` & `ClerkSession`: The `data` of user and session
+ *   events.
+ * - `ContactDetails`: The primary email and phone number kept in public.users.
+ *
+ * @dependencies
+ * - "encoding/json": For the raw event data.
+ * - "time": For converting Clerk's millisecond timestamps.
+ */
+package domain
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Clerk webhook event types handled by the Auth service.
+const (
+	ClerkUserCreated    = "user.created"
+	ClerkUserUpdated    = "user.updated"
+	ClerkUserDeleted    = "user.deleted"
+	ClerkSessionCreated = "session.created"
+	ClerkSessionEnded   = "session.ended"
+	ClerkSessionRemoved = "session.removed"
+	ClerkSessionRevoked = "session.revoked"
+)
+
+// ClerkWebhook is the body of a Clerk webhook.
+type ClerkWebhook struct {
+	Type   string          `json:"type"`
+	Object string          `json:"object"`
+	Data   json.RawMessage `json:"data"`
+}
+
+// ClerkEmailAddress is one of a Clerk user's email addresses.
+type ClerkEmailAddress struct {
+	ID           string `json:"id"`
+	EmailAddress string `json:"email_address"`
+}
+
+// ClerkPhoneNumber is one of a Clerk user's phone numbers.
+type ClerkPhoneNumber struct {
+	ID          string `json:"id"`
+	PhoneNumber string `json:"phone_number"`
+}
+
+// ClerkUser is the data of user.created and user.updated webhooks.
+type ClerkUser struct {
+	ID                    string              `json:"id"`
+	PrimaryEmailAddressID string              `json:"primary_email_address_id"`
+	EmailAddresses        []ClerkEmailAddress `json:"email_addresses"`
+	PrimaryPhoneNumberID  string              `json:"primary_phone_number_id"`
+	PhoneNumbers          []ClerkPhoneNumber  `json:"phone_numbers"`
+	UpdatedAt             int64               `json:"updated_at"` // Unix milliseconds
+}
+
+// ContactDetails returns the user's primary email address and phone number. Either may
+// be empty.
+func (u ClerkUser) ContactDetails() ContactDetails {
+	var details ContactDetails
+	for _, email := range u.EmailAddresses {
+		if email.ID == u.PrimaryEmailAddressID {
+			details.Email = email.EmailAddress
+		}
+	}
+	for _, phone := range u.PhoneNumbers {
+		if phone.ID == u.PrimaryPhoneNumberID {
+			details.PhoneNumber = phone.PhoneNumber
+		}
+	}
+	details.UpdatedAt = time.UnixMilli(u.UpdatedAt).UTC()
+	return details
+}
+
+// ClerkDeletedObject is the data of user.deleted webhooks.
+type ClerkDeletedObject struct {
+	ID      string `json:"id"`
+	Deleted bool   `json:"deleted"`
+}
+
+// ClerkSession is the data of session webhooks.
+type ClerkSession struct {
+	ID        string `json:"id"`
+	UserID    string `json:"user_id"`
+	Status    string `json:"status"`
+	CreatedAt int64  `json:"created_at"` // Unix milliseconds
+}
+
+// ContactDetails are a user's primary contact details in Clerk.
+type ContactDetails struct {
+	Email       string
+	PhoneNumber string
+	// UpdatedAt is when Clerk last changed the user.
+	UpdatedAt time.Time
+}
@@ -0,0 +1,192 @@
+/**
+ * @description
+ * This file defines the models, rules and errors of transaction PINs and the step-up
+ * tokens issued once a PIN is entered correctly.
+ *
+ * Key features:
+ * - PINs are 4 digits. All-same-digit and consecutive PINs (`1111`, `1234`, `9876`) are
+ *   rejected, since they are the first an attacker with the phone would try.
+ * - `IncorrectPINError` and `PINLockedError` carry what clients need to explain a
+ *   rejected PIN: the attempts left before the lockout, or when the lockout ends.
+ * - `StepUpRequest` describes the transfer a step-up token is requested for, so the
+ *   token can be bound to it.
+ *
+ * @dependencies
+ * - "errors", "fmt", "time"
+ * - "github.com/google/uuid": For user, payment request and beneficiary identifiers.
+ * - "transfa/shared/stepup": For the purposes and recipients of step-up tokens.
+ */
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"transfa/shared/stepup"
+)
+
+// PINLength is the number of digits of a transaction PIN.
+const PINLength = 4
+
+// Codes of the PIN field errors, besides CodeRequired and CodeInvalidFormat.
+const (
+	CodeWeakPIN = "weak_pin"
+)
+
+var (
+	// ErrPINNotSet is returned when the user has not set a transaction PIN yet.
+	ErrPINNotSet = errors.New("transaction PIN has not been set")
+	// ErrPINAlreadySet is returned when setting a PIN for a user who has one; they must
+	// change or reset it instead.
+	ErrPINAlreadySet = errors.New("transaction PIN has already been set")
+	// ErrRecentSignInRequired is returned when resetting a PIN without having signed in
+	// again recently.
+	ErrRecentSignInRequired = errors.New("sign in again to reset your transaction PIN")
+)
+
+// PIN is a user's stored transaction PIN.
+type PIN struct {
+	UserID uuid.UUID
+	// Hash is the argon2id hash of the PIN.
+	Hash string
+	// FailedAttempts is the number of incorrect PINs since the last correct one,
+	// counting the attempt being checked.
+	FailedAttempts int
+	// LockedUntil is when the lockout ends, if too many attempts failed.
+	LockedUntil *time.Time
+}
+
+// IncorrectPINError is returned for an incorrect PIN.
+type IncorrectPINError struct {
+	// AttemptsRemaining is how many more incorrect PINs lock the PIN.
+	AttemptsRemaining int
+}
+
+func (e *IncorrectPINError) Error() string {
+	return fmt.Sprintf("incorrect transaction PIN, %d attempts remaining", e.AttemptsRemaining)
+}
+
+// PINLockedError is returned while a PIN is locked after too many incorrect attempts.
+type PINLockedError struct {
+	Until time.Time
+}
+
+func (e *PINLockedError) Error() string {
+	return fmt.Sprintf("transaction PIN is locked until %s", e.Until.Format(time.RFC3339))
+}
+
+// SetPINRequest is the body of `POST /pin`.
+type SetPINRequest struct {
+	PIN string `json:"pin"`
+}
+
+// ChangePINRequest is the body of `PUT /pin`.
+type ChangePINRequest struct {
+	CurrentPIN string `json:"current_pin"`
+	NewPIN     string `json:"new_pin"`
+}
+
+// ResetPINRequest is the body of `POST /pin/reset`.
+type ResetPINRequest struct {
+	NewPIN string `json:"new_pin"`
+}
+
+// StepUpRequest is the body of `POST /pin/step-up`. It names the transfer to approve:
+// a P2P transfer to RecipientUsername or paying PaymentRequestID, a self-transfer to
+// BeneficiaryID, or the funding of a Money Drop.
+type StepUpRequest struct {
+	PIN               string     `json:"pin"`
+	Purpose           string     `json:"purpose"`
+	Amount            int64      `json:"amount"` // In kobo
+	RecipientUsername string     `json:"recipient_username,omitempty"`
+	PaymentRequestID  *uuid.UUID `json:"payment_request_id,omitempty"`
+	BeneficiaryID     *uuid.UUID `json:"beneficiary_id,omitempty"`
+}
+
+// Binding returns the transfer the request names, or a *ValidationError.
+func (r StepUpRequest) Binding() (stepup.Binding, error) {
+	verr := &ValidationError{}
+	binding := stepup.Binding{Purpose: r.Purpose, Amount: r.Amount}
+
+	switch r.Purpose {
+	case stepup.PurposeP2PTransfer:
+		// A payment request decides the recipient, so it takes precedence.
+		switch {
+		case r.PaymentRequestID != nil:
+			binding.Recipient = stepup.PaymentRequestRecipient(*r.PaymentRequestID)
+		case r.RecipientUsername != "":
+			binding.Recipient = stepup.UserRecipient(r.RecipientUsername)
+		default:
+			verr.add("/recipient_username", CodeRequired, "recipient_username or payment_request_id is required for a P2P transfer")
+		}
+	case stepup.PurposeSelfTransfer:
+		if r.BeneficiaryID == nil {
+			verr.add("/beneficiary_id", CodeRequired, "beneficiary_id is required for a self-transfer")
+		} else {
+			binding.Recipient = stepup.BeneficiaryRecipient(*r.BeneficiaryID)
+		}
+	case stepup.PurposeMoneyDrop:
+		binding.Recipient = stepup.MoneyDropRecipient
+	case "":
+		verr.add("/purpose", CodeRequired, "purpose is required")
+	default:
+		verr.add("/purpose", CodeInvalidFormat, "purpose must be %s, %s or %s", stepup.PurposeP2PTransfer, stepup.PurposeSelfTransfer, stepup.PurposeMoneyDrop)
+	}
+	if r.Amount <= 0 {
+		verr.add("/amount", CodeInvalidFormat, "amount must be greater than zero")
+	}
+
+	if len(verr.Fields) > 0 {
+		return stepup.Binding{}, verr
+	}
+	return binding, nil
+}
+
+// StepUpToken is an issued step-up token. Only the hash of the token is stored.
+type StepUpToken struct {
+	UserID    uuid.UUID
+	TokenHash string
+	Binding   stepup.Binding
+	ExpiresAt time.Time
+}
+
+// StepUpGrant is the response of `POST /pin/step-up`.
+type StepUpGrant struct {
+	StepUpToken string    `json:"step_up_token"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+// ValidatePIN adds the field error of pin, if any, to verr under pointer.
+func ValidatePIN(verr *ValidationError, pointer, pin string) {
+	if pin == "" {
+		verr.add(pointer, CodeRequired, "PIN is required")
+		return
+	}
+	if len(pin) != PINLength {
+		verr.add(pointer, CodeInvalidFormat, "PIN must be %d digits", PINLength)
+		return
+	}
+	for _, c := range pin {
+		if c < '0' || c > '9' {
+			verr.add(pointer, CodeInvalidFormat, "PIN must be %d digits", PINLength)
+			return
+		}
+	}
+	if isWeakPIN(pin) {
+		verr.add(pointer, CodeWeakPIN, "PIN must not be the same digit repeated or consecutive digits")
+	}
+}
+
+// isWeakPIN reports whether pin repeats one digit or counts up or down by one.
+func isWeakPIN(pin string) bool {
+	same, up, down := true, true, true
+	for i := 1; i < len(pin); i++ {
+		diff := int(pin[i]) - int(pin[i-1])
+		same = same && diff == 0
+		up = up && diff == 1
+		down = down && diff == -1
+	}
+	return same || up || down
+}
@@ -7,19 +7,31 @@
  * - `OnboardingRequest`: Defines the JSON structure for the POST /onboarding endpoint.
  * - `KYCDetails` & `KYBDetails`: Specific structures for personal and merchant identity information.
  * - `User`: Represents the user entity as it's stored in the database.
- * - `UserCreatedEvent`: Defines the structure of the event published to RabbitMQ after user creation.
+ * - `ErrUsernameTaken` & `ErrAlreadyOnboarded`: The conflicts onboarding can run into.
+ * - `ErrUserNotFound`: Returned for Clerk users who have not onboarded yet.
  *
  * @dependencies
+ * - "errors": For the domain errors.
  * - "time": Used for timestamping records.
  * - "github.com/google/uuid": Used for universally unique identifiers.
  */
 package domain
 
 import (
+	"errors"
 	"time"
 	"github.com/google/uuid"
 )
 
+var (
+	// ErrUsernameTaken is returned when another user already has the requested username.
+	ErrUsernameTaken = errors.New("username is already taken")
+	// ErrAlreadyOnboarded is returned when the Clerk user has already completed onboarding.
+	ErrAlreadyOnboarded = errors.New("user has already completed onboarding")
+	// ErrUserNotFound is returned when the Clerk user has not started onboarding.
+	ErrUserNotFound = errors.New("user has not started onboarding")
+)
+
 // KYCDetails holds the Know Your Customer information for personal users.
 type KYCDetails struct {
 	FullName    string `json:"full_name"`
@@ -51,18 +63,11 @@ type User struct {
 	AllowSending bool     `json:"allow_sending" db:"allow_sending"`
 	CreatedAt   time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+	// LastSignInAt is when the user last started a Clerk session, if known. Resetting the
+	// transaction PIN requires a recent sign-in.
+	LastSignInAt *time.Time `json:"-" db:"last_sign_in_at"`
 	// The following fields will be populated by other services.
 	// AnchorCustomerID string    `json:"anchor_customer_id" db:"anchor_customer_id"`
 	// KYCStatus        string    `json:"kyc_status" db:"kyc_status"`
 	// ProfileImageURL  *string   `json:"profile_image_url,omitempty" db:"profile_image_url"`
 }
-
-// UserCreatedEvent represents the payload published to RabbitMQ after a user is created.
-// This event triggers the customer creation flow in the Customer service.
-type UserCreatedEvent struct {
-	UserID      uuid.UUID    `json:"user_id"`
-	ClerkID     string       `json:"clerk_id"`
-	AccountType string       `json:"account_type"`
-	KYCDetails  *KYCDetails  `json:"kyc_details,omitempty"`
-	KYBDetails  *KYBDetails  `json:"kyb_details,omitempty"`
-}
\ No newline at end of file
@@ -0,0 +1,40 @@
+/**
+ * @description
+ * This file contains the result of checking whether a username is available. The rules
+ * for usernames themselves are shared with the Customer service in `shared/username`.
+ *
+ * @dependencies
+ * - "errors", "time"
+ */
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// Reasons, besides the validation codes, that a username is unavailable.
+const (
+	// ReasonTaken is returned for the username of an existing user.
+	ReasonTaken = "taken"
+	// ReasonHeld is returned for a username reserved by another user's onboarding.
+	ReasonHeld = "held"
+)
+
+// ErrUsernameHeld is returned when another user has an unexpired reservation of the username.
+var ErrUsernameHeld = errors.New("username is reserved by another user")
+
+// UsernameAvailability is the result of checking a username.
+type UsernameAvailability struct {
+	// Username is the normalized username.
+	Username  string `json:"username"`
+	Available bool   `json:"available"`
+	// Reason is why the username is unavailable: a validation code such as `reserved`
+	// or `blocked`, ReasonTaken or ReasonHeld.
+	Reason  string `json:"reason,omitempty"`
+	Message string `json:"message,omitempty"`
+	// ReservedUntil is when the caller's reservation of an available username expires.
+	ReservedUntil *time.Time `json:"reserved_until,omitempty"`
+	// Suggestions are available alternatives to a taken username.
+	Suggestions []string `json:"suggestions,omitempty"`
+}
@@ -0,0 +1,198 @@
+/**
+ * @description
+ * This file contains the server-side validation of onboarding requests. Clients
+ * validate too, but the Auth service is the only place the rules are enforced.
+ *
+ * Key features:
+ * - Usernames follow the rules of `shared/username`: 3-20 ASCII letters and digits,
+ *   and not a reserved name or containing a blocked word.
+ * - Personal accounts need KYC details and merchant accounts need KYB details; the
+ *   details of the other account type are rejected.
+ * - BVNs are 11 digits, dates of birth are past YYYY-MM-DD dates of users at least the
+ *   minimum age, and RC numbers are CAC registration numbers.
+ * - Every invalid field is reported, each with a JSON pointer to it, so clients can
+ *   show the errors next to the fields.
+ *
+ * @dependencies
+ * - "fmt", "regexp", "strings", "time"
+ * - "transfa/shared/username": For the username rules.
+ */
+package domain
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"transfa/shared/username"
+)
+
+// Codes of the field errors, for clients to branch on.
+const (
+	CodeRequired      = username.CodeRequired
+	CodeInvalidFormat = username.CodeInvalidFormat
+	CodeReserved      = username.CodeReserved
+	CodeBlocked       = username.CodeBlocked
+	CodeNotAllowed    = "not_allowed"
+	CodeTooYoung      = "too_young"
+)
+
+const (
+	fullNameMaxLength     = 100
+	businessNameMaxLength = 100
+	dateOfBirthLayout     = "2006-01-02"
+)
+
+var (
+	bvnPattern = regexp.MustCompile(`^[0-9]{11}$`)
+	// fullNamePattern requires at least a first and a last name made of letters,
+	// apostrophes, hyphens and dots.
+	fullNamePattern = regexp.MustCompile(`^[\p{L}.'-]+( [\p{L}.'-]+)+$`)
+	// rcNumberPattern matches CAC registration numbers: companies (RC), business names
+	// (BN) and incorporated trustees (IT), with or without the prefix.
+	rcNumberPattern = regexp.MustCompile(`^(RC|BN|IT)?[0-9]{4,8}$`)
+)
+
+// FieldError describes one invalid field of a request.
+type FieldError struct {
+	// Pointer is the JSON pointer of the field in the request body, e.g. "/kyc_details/bvn".
+	Pointer string
+	// Code is one of the Code constants.
+	Code string
+	// Message explains the rule the field breaks.
+	Message string
+}
+
+// ValidationError is returned for a request with invalid fields.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+// Error lists the invalid fields.
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		messages[i] = f.Message
+	}
+	return "invalid request: " + strings.Join(messages, "; ")
+}
+
+// add records an invalid field.
+func (e *ValidationError) add(pointer, code, format string, args ...interface{}) {
+	e.Fields = append(e.Fields, FieldError{Pointer: pointer, Code: code, Message: fmt.Sprintf(format, args...)})
+}
+
+// Normalize trims surrounding whitespace from the request's fields and lowercases the
+// username.
+func (r *OnboardingRequest) Normalize() {
+	r.Username = username.Normalize(r.Username)
+	r.AccountType = strings.TrimSpace(r.AccountType)
+	if r.KYCDetails != nil {
+		r.KYCDetails.FullName = strings.Join(strings.Fields(r.KYCDetails.FullName), " ")
+		r.KYCDetails.BVN = strings.TrimSpace(r.KYCDetails.BVN)
+		r.KYCDetails.DateOfBirth = strings.TrimSpace(r.KYCDetails.DateOfBirth)
+	}
+	if r.KYBDetails != nil {
+		r.KYBDetails.BusinessName = strings.Join(strings.Fields(r.KYBDetails.BusinessName), " ")
+		r.KYBDetails.RCNumber = strings.ToUpper(strings.TrimSpace(r.KYBDetails.RCNumber))
+	}
+}
+
+// Validate checks the request against the onboarding rules. now is the current time
+// and minAge the minimum age in years of personal users. It returns a *ValidationError
+// listing every invalid field, or nil.
+func (r *OnboardingRequest) Validate(now time.Time, minAge int) error {
+	verr := &ValidationError{}
+
+	ValidateUsername(verr, r.Username)
+
+	switch r.AccountType {
+	case "":
+		verr.add("/account_type", CodeRequired, "account_type is required")
+	case "personal":
+		if r.KYCDetails == nil {
+			verr.add("/kyc_details", CodeRequired, "kyc_details is required for personal accounts")
+		} else {
+			r.KYCDetails.validate(verr, now, minAge)
+		}
+		if r.KYBDetails != nil {
+			verr.add("/kyb_details", CodeNotAllowed, "kyb_details is only accepted for merchant accounts")
+		}
+	case "merchant":
+		if r.KYBDetails == nil {
+			verr.add("/kyb_details", CodeRequired, "kyb_details is required for merchant accounts")
+		} else {
+			r.KYBDetails.validate(verr)
+		}
+		if r.KYCDetails != nil {
+			verr.add("/kyc_details", CodeNotAllowed, "kyc_details is only accepted for personal accounts")
+		}
+	default:
+		verr.add("/account_type", CodeInvalidFormat, "account_type must be 'personal' or 'merchant'")
+	}
+
+	if len(verr.Fields) > 0 {
+		return verr
+	}
+	return nil
+}
+
+// ValidateUsername records the username rule the normalized name breaks in verr.
+func ValidateUsername(verr *ValidationError, name string) {
+	if code, message := username.Check(name); code != "" {
+		verr.add("/username", code, "%s", message)
+	}
+}
+
+// validate records the invalid KYC fields in verr.
+func (d *KYCDetails) validate(verr *ValidationError, now time.Time, minAge int) {
+	switch {
+	case d.FullName == "":
+		verr.add("/kyc_details/full_name", CodeRequired, "full_name is required")
+	case len(d.FullName) > fullNameMaxLength || !fullNamePattern.MatchString(d.FullName):
+		verr.add("/kyc_details/full_name", CodeInvalidFormat, "full_name must be a first and last name of at most %d characters", fullNameMaxLength)
+	}
+
+	switch {
+	case d.BVN == "":
+		verr.add("/kyc_details/bvn", CodeRequired, "bvn is required")
+	case !bvnPattern.MatchString(d.BVN):
+		verr.add("/kyc_details/bvn", CodeInvalidFormat, "bvn must be 11 digits")
+	}
+
+	if d.DateOfBirth == "" {
+		verr.add("/kyc_details/date_of_birth", CodeRequired, "date_of_birth is required")
+		return
+	}
+	dob, err := time.Parse(dateOfBirthLayout, d.DateOfBirth)
+	if err != nil {
+		verr.add("/kyc_details/date_of_birth", CodeInvalidFormat, "date_of_birth must be a valid date in YYYY-MM-DD format")
+		return
+	}
+	if !dob.Before(now) {
+		verr.add("/kyc_details/date_of_birth", CodeInvalidFormat, "date_of_birth must be in the past")
+		return
+	}
+	// A user born on February 29 comes of age on March 1 in common years.
+	if dob.AddDate(minAge, 0, 0).After(now) {
+		verr.add("/kyc_details/date_of_birth", CodeTooYoung, "users must be at least %d years old", minAge)
+	}
+}
+
+// validate records the invalid KYB fields in verr.
+func (d *KYBDetails) validate(verr *ValidationError) {
+	switch {
+	case d.BusinessName == "":
+		verr.add("/kyb_details/business_name", CodeRequired, "business_name is required")
+	case len(d.BusinessName) > businessNameMaxLength:
+		verr.add("/kyb_details/business_name", CodeInvalidFormat, "business_name must be at most %d characters", businessNameMaxLength)
+	}
+
+	switch {
+	case d.RCNumber == "":
+		verr.add("/kyb_details/rc_number", CodeRequired, "rc_number is required")
+	case !rcNumberPattern.MatchString(d.RCNumber):
+		verr.add("/kyb_details/rc_number", CodeInvalidFormat, "rc_number must be a CAC registration number such as RC123456")
+	}
+}
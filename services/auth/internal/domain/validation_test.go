@@ -0,0 +1,108 @@
+package domain
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+var now = time.Date(2026, time.October, 16, 12, 0, 0, 0, time.UTC)
+
+func personal() OnboardingRequest {
+	return OnboardingRequest{
+		Username:    "ada",
+		AccountType: "personal",
+		KYCDetails: &KYCDetails{
+			FullName:    "Ada Lovelace",
+			BVN:         "22212345678",
+			DateOfBirth: "1990-12-10",
+		},
+	}
+}
+
+func merchant() OnboardingRequest {
+	return OnboardingRequest{
+		Username:    "adashop",
+		AccountType: "merchant",
+		KYBDetails: &KYBDetails{
+			BusinessName: "Ada Ventures Ltd",
+			RCNumber:     "RC123456",
+		},
+	}
+}
+
+// invalidFields returns the pointer and code of each field error of req.
+func invalidFields(t *testing.T, req OnboardingRequest) []string {
+	t.Helper()
+	req.Normalize()
+	err := req.Validate(now, 18)
+	if err == nil {
+		return nil
+	}
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("Validate() error = %v, want a *ValidationError", err)
+	}
+	var fields []string
+	for _, f := range verr.Fields {
+		fields = append(fields, f.Pointer+" "+f.Code)
+	}
+	return fields
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*OnboardingRequest)
+		base   func() OnboardingRequest
+		want   []string
+	}{
+		{"valid personal", func(r *OnboardingRequest) {}, personal, nil},
+		{"valid merchant", func(r *OnboardingRequest) {}, merchant, nil},
+		{"surrounding whitespace is trimmed", func(r *OnboardingRequest) {
+			r.Username = " ada "
+			r.KYCDetails.FullName = " Ada  Lovelace "
+		}, personal, nil},
+		{"rc number with a space", func(r *OnboardingRequest) { r.KYBDetails.RCNumber = "bn 1234" }, merchant, []string{"/kyb_details/rc_number invalid_format"}},
+		{"lowercase rc prefix", func(r *OnboardingRequest) { r.KYBDetails.RCNumber = "rc1234567" }, merchant, nil},
+		{"missing username", func(r *OnboardingRequest) { r.Username = "" }, personal, []string{"/username required"}},
+		{"short username", func(r *OnboardingRequest) { r.Username = "ad" }, personal, []string{"/username invalid_format"}},
+		{"long username", func(r *OnboardingRequest) { r.Username = "abcdefghijklmnopqrstu" }, personal, []string{"/username invalid_format"}},
+		{"username with symbols", func(r *OnboardingRequest) { r.Username = "ada_l" }, personal, []string{"/username invalid_format"}},
+		{"reserved username", func(r *OnboardingRequest) { r.Username = "Transfa" }, personal, []string{"/username reserved"}},
+		{"missing account type", func(r *OnboardingRequest) { r.AccountType = "" }, personal, []string{"/account_type required"}},
+		{"unknown account type", func(r *OnboardingRequest) { r.AccountType = "business" }, personal, []string{"/account_type invalid_format"}},
+		{"personal without kyc", func(r *OnboardingRequest) { r.KYCDetails = nil }, personal, []string{"/kyc_details required"}},
+		{"personal with kyb", func(r *OnboardingRequest) { r.KYBDetails = merchant().KYBDetails }, personal, []string{"/kyb_details not_allowed"}},
+		{"merchant without kyb", func(r *OnboardingRequest) { r.KYBDetails = nil }, merchant, []string{"/kyb_details required"}},
+		{"merchant with kyc", func(r *OnboardingRequest) { r.KYCDetails = personal().KYCDetails }, merchant, []string{"/kyc_details not_allowed"}},
+		{"single name", func(r *OnboardingRequest) { r.KYCDetails.FullName = "Ada" }, personal, []string{"/kyc_details/full_name invalid_format"}},
+		{"short bvn", func(r *OnboardingRequest) { r.KYCDetails.BVN = "2221234567" }, personal, []string{"/kyc_details/bvn invalid_format"}},
+		{"bvn with letters", func(r *OnboardingRequest) { r.KYCDetails.BVN = "2221234567a" }, personal, []string{"/kyc_details/bvn invalid_format"}},
+		{"date of birth format", func(r *OnboardingRequest) { r.KYCDetails.DateOfBirth = "10/12/1990" }, personal, []string{"/kyc_details/date_of_birth invalid_format"}},
+		{"impossible date of birth", func(r *OnboardingRequest) { r.KYCDetails.DateOfBirth = "1990-02-30" }, personal, []string{"/kyc_details/date_of_birth invalid_format"}},
+		{"future date of birth", func(r *OnboardingRequest) { r.KYCDetails.DateOfBirth = "2027-01-01" }, personal, []string{"/kyc_details/date_of_birth invalid_format"}},
+		{"too young", func(r *OnboardingRequest) { r.KYCDetails.DateOfBirth = "2008-10-17" }, personal, []string{"/kyc_details/date_of_birth too_young"}},
+		{"eighteenth birthday", func(r *OnboardingRequest) { r.KYCDetails.DateOfBirth = "2008-10-16" }, personal, nil},
+		{"missing business name", func(r *OnboardingRequest) { r.KYBDetails.BusinessName = "  " }, merchant, []string{"/kyb_details/business_name required"}},
+		{"every error is reported", func(r *OnboardingRequest) {
+			r.Username = "x"
+			r.KYCDetails.BVN = ""
+			r.KYCDetails.DateOfBirth = ""
+		}, personal, []string{
+			"/username invalid_format",
+			"/kyc_details/bvn required",
+			"/kyc_details/date_of_birth required",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := tt.base()
+			tt.modify(&req)
+			if got := invalidFields(t, req); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("invalid fields = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
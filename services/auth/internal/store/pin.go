@@ -0,0 +1,161 @@
+/**
+ * @description
+ * This file contains the queries behind transaction PINs and step-up tokens.
+ *
+ * Key features:
+ * - `BeginPINAttempt` counts an attempt before the PIN is checked, in a single UPDATE,
+ *   so concurrent guesses cannot all slip past the lockout before any of them is counted.
+ * - Replacing a PIN clears its lockout and revokes the user's unused step-up tokens.
+ *
+ * @dependencies
+ * - "context", "errors", "fmt", "time"
+ * - "github.com/google/uuid": For user identifiers.
+ * - "github.com/jackc/pgx/v5": For detecting missing rows.
+ * - "transfa/services/auth/internal/domain": For PINs and step-up tokens.
+ */
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"transfa/services/auth/internal/domain"
+)
+
+// CreatePIN stores the first PIN hash of the user. It returns domain.ErrPINAlreadySet if
+// they already have one.
+func (r *PostgresRepository) CreatePIN(ctx context.Context, userID uuid.UUID, hash string) error {
+	tag, err := r.db.Exec(ctx, `
+        INSERT INTO public.transaction_pins (user_id, pin_hash)
+        VALUES ($1, $2)
+        ON CONFLICT (user_id) DO NOTHING
+    `, userID, hash)
+	if err != nil {
+		return fmt.Errorf("failed to insert transaction PIN: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return domain.ErrPINAlreadySet
+	}
+	return nil
+}
+
+// BeginPINAttempt counts one attempt at the user's PIN and returns the PIN to check it
+// against. The attempt that reaches maxAttempts locks the PIN until lockedUntil; a
+// correct PIN then clears the count with ResetPINAttempts. The count restarts after a
+// lockout ends. It returns domain.ErrPINNotSet if the user has no PIN and a
+// *domain.PINLockedError while the PIN is locked.
+func (r *PostgresRepository) BeginPINAttempt(ctx context.Context, userID uuid.UUID, maxAttempts int, lockedUntil time.Time) (*domain.PIN, error) {
+	pin := domain.PIN{UserID: userID}
+	err := r.db.QueryRow(ctx, `
+        UPDATE public.transaction_pins
+        SET failed_attempts = CASE WHEN locked_until IS NULL THEN failed_attempts + 1 ELSE 1 END,
+            locked_until = CASE
+                WHEN (CASE WHEN locked_until IS NULL THEN failed_attempts + 1 ELSE 1 END) >= $2 THEN $3
+                ELSE NULL
+            END
+        WHERE user_id = $1 AND (locked_until IS NULL OR locked_until <= now())
+        RETURNING pin_hash, failed_attempts, locked_until
+    `, userID, maxAttempts, lockedUntil).Scan(&pin.Hash, &pin.FailedAttempts, &pin.LockedUntil)
+	if err == nil {
+		return &pin, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("failed to count PIN attempt: %w", err)
+	}
+
+	// Either there is no PIN or it is locked.
+	var until *time.Time
+	err = r.db.QueryRow(ctx, `SELECT locked_until FROM public.transaction_pins WHERE user_id = $1`, userID).Scan(&until)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrPINNotSet
+		}
+		return nil, fmt.Errorf("failed to query transaction PIN: %w", err)
+	}
+	if until == nil {
+		// The lockout was cleared in between; the caller may try again.
+		return nil, fmt.Errorf("transaction PIN changed during the attempt")
+	}
+	return nil, &domain.PINLockedError{Until: *until}
+}
+
+// ResetPINAttempts clears the failed attempts and lockout of the user's PIN.
+func (r *PostgresRepository) ResetPINAttempts(ctx context.Context, userID uuid.UUID) error {
+	_, err := r.db.Exec(ctx, `
+        UPDATE public.transaction_pins
+        SET failed_attempts = 0, locked_until = NULL
+        WHERE user_id = $1
+    `, userID)
+	if err != nil {
+		return fmt.Errorf("failed to reset PIN attempts: %w", err)
+	}
+	return nil
+}
+
+// ReplacePIN replaces the user's PIN hash, clears its failed attempts and lockout and
+// revokes their unused step-up tokens, in one transaction. It returns
+// domain.ErrPINNotSet if the user has no PIN.
+func (r *PostgresRepository) ReplacePIN(ctx context.Context, userID uuid.UUID, hash string) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `
+        UPDATE public.transaction_pins
+        SET pin_hash = $2, failed_attempts = 0, locked_until = NULL
+        WHERE user_id = $1
+    `, userID, hash)
+	if err != nil {
+		return fmt.Errorf("failed to update transaction PIN: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return domain.ErrPINNotSet
+	}
+
+	// Tokens approved with the old PIN must not outlive it.
+	if _, err := tx.Exec(ctx, `DELETE FROM public.step_up_tokens WHERE user_id = $1 AND used_at IS NULL`, userID); err != nil {
+		return fmt.Errorf("failed to revoke step-up tokens: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// CreateStepUpToken stores an issued step-up token and deletes the user's expired and
+// used ones, which can no longer approve anything.
+func (r *PostgresRepository) CreateStepUpToken(ctx context.Context, token domain.StepUpToken) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `
+        DELETE FROM public.step_up_tokens
+        WHERE user_id = $1 AND (used_at IS NOT NULL OR expires_at <= now())
+    `, token.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to delete spent step-up tokens: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, `
+        INSERT INTO public.step_up_tokens (user_id, token_hash, purpose, amount, recipient, expires_at)
+        VALUES ($1, $2, $3, $4, $5, $6)
+    `, token.UserID, token.TokenHash, token.Binding.Purpose, token.Binding.Amount, token.Binding.Recipient, token.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to insert step-up token: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
@@ -6,17 +6,43 @@
  *
  * @dependencies
  * - "context": For passing request-scoped data and cancellation signals.
+ * - "time": For the times recorded from Clerk webhooks.
+ * - "github.com/google/uuid": For user identifiers.
+ * - "github.com/jackc/pgx/v5": For checking specific database errors.
+ * - "github.com/jackc/pgx/v5/pgconn": To detect unique constraint violations.
  * - "github.com/jackc/pgx/v5/pgxpool": For managing the database connection pool.
  * - "transfa/services/auth/internal/domain": Imports the core User model.
+ * - "transfa/shared/onboarding": For the user's onboarding status.
+ * - "transfa/shared/outbox": For writing events in the same transaction as the user.
  */
 package store
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log"
+	"time"
 
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"transfa/services/auth/internal/domain"
+	"transfa/shared/onboarding"
+	"transfa/shared/outbox"
+)
+
+// OutboxSource identifies the Auth service's rows in the event outbox.
+const OutboxSource = "auth"
+
+// uniqueViolation is the PostgreSQL error code of a unique constraint violation.
+const uniqueViolation = "23505"
+
+// Constraints of public.users whose violation is a domain error rather than a failure.
+const (
+	usersUsernameKey = "users_username_key"
+	usersClerkIDKey  = "users_clerk_id_key"
 )
 
 // PostgresRepository is the concrete implementation for database operations.
@@ -31,9 +57,39 @@ func NewPostgresRepository(db *pgxpool.Pool) *PostgresRepository {
 	}
 }
 
-// CreateUser inserts a new user record into the public.users table.
+// CreateUser inserts a new user record into the public.users table, starts their
+// onboarding status and writes event to the outbox in the same transaction, so the event
+// is published if and only if the user is created.
 // It returns the newly created user with fields populated by the database (like id, created_at).
-func (r *PostgresRepository) CreateUser(ctx context.Context, user *domain.User) (*domain.User, error) {
+func (r *PostgresRepository) CreateUser(ctx context.Context, user *domain.User, event outbox.Event) (*domain.User, error) {
+	dbTx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer dbTx.Rollback(ctx)
+
+	// Another user's unexpired reservation keeps the username for them, and a username
+	// another user changed still belongs to them during its grace period.
+	var held, inGracePeriod bool
+	err = dbTx.QueryRow(ctx, `
+        SELECT EXISTS (
+            SELECT 1 FROM public.username_reservations
+            WHERE username = $1 AND clerk_id <> $2 AND expires_at > now()
+        ), EXISTS (
+            SELECT 1 FROM public.username_history
+            WHERE old_username = $1 AND grace_until > now()
+        )
+    `, user.Username, user.ClerkID).Scan(&held, &inGracePeriod)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check username reservation: %w", err)
+	}
+	if held {
+		return nil, fmt.Errorf("%w: %w", domain.ErrUsernameTaken, domain.ErrUsernameHeld)
+	}
+	if inGracePeriod {
+		return nil, domain.ErrUsernameTaken
+	}
+
 	query := `
         INSERT INTO public.users (id, clerk_id, username, account_type, allow_sending)
         VALUES ($1, $2, $3, $4, $5)
@@ -45,7 +101,7 @@ func (r *PostgresRepository) CreateUser(ctx context.Context, user *domain.User)
 	// The Clerk User ID from the JWT 'sub' claim will be the 'clerk_id'.
 
 	var createdUser domain.User
-	err := r.db.QueryRow(ctx, query,
+	err = dbTx.QueryRow(ctx, query,
 		user.ID,
 		user.ClerkID,
 		user.Username,
@@ -62,11 +118,271 @@ func (r *PostgresRepository) CreateUser(ctx context.Context, user *domain.User)
 	)
 
 	if err != nil {
+		if conflict := uniqueConflict(err); conflict != nil {
+			return nil, conflict
+		}
 		log.Printf("Error creating user in database: %v", err)
-		// Here you could check for specific pgx errors, e.g., unique constraint violation
-		// and return a more specific application-level error.
+		return nil, fmt.Errorf("failed to insert user: %w", err)
+	}
+
+	if err := onboarding.Start(ctx, dbTx, createdUser.ID); err != nil {
 		return nil, err
 	}
 
+	// The user has their username now, so they no longer need a reservation.
+	if _, err := dbTx.Exec(ctx, `DELETE FROM public.username_reservations WHERE clerk_id = $1`, user.ClerkID); err != nil {
+		return nil, fmt.Errorf("failed to release username reservation: %w", err)
+	}
+
+	if err := outbox.Insert(ctx, dbTx, OutboxSource, event); err != nil {
+		return nil, err
+	}
+
+	if err := dbTx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
 	return &createdUser, nil
-}
\ No newline at end of file
+}
+
+// GetOnboardingStatus returns the onboarding status of the user with the given Clerk ID.
+// It returns an error wrapping domain.ErrUserNotFound if they have not onboarded.
+func (r *PostgresRepository) GetOnboardingStatus(ctx context.Context, clerkID string) (*onboarding.State, error) {
+	query := `SELECT id FROM public.users WHERE clerk_id = $1`
+
+	var userID uuid.UUID
+	if err := r.db.QueryRow(ctx, query, clerkID).Scan(&userID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("%w: clerk_id %s", domain.ErrUserNotFound, clerkID)
+		}
+		return nil, fmt.Errorf("failed to query user by clerk id: %w", err)
+	}
+
+	return onboarding.Get(ctx, r.db, userID)
+}
+
+// GetUserByClerkID retrieves the user with the given Clerk ID. It returns an error
+// wrapping domain.ErrUserNotFound if they have not onboarded.
+func (r *PostgresRepository) GetUserByClerkID(ctx context.Context, clerkID string) (*domain.User, error) {
+	query := `
+        SELECT id, clerk_id, username, account_type, allow_sending, created_at, updated_at, last_sign_in_at
+        FROM public.users
+        WHERE clerk_id = $1
+    `
+	var user domain.User
+	err := r.db.QueryRow(ctx, query, clerkID).Scan(
+		&user.ID,
+		&user.ClerkID,
+		&user.Username,
+		&user.AccountType,
+		&user.AllowSending,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+		&user.LastSignInAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("%w: clerk_id %s", domain.ErrUserNotFound, clerkID)
+		}
+		return nil, fmt.Errorf("failed to query user by clerk id: %w", err)
+	}
+	return &user, nil
+}
+
+// UpdateContactDetails stores a user's primary email and phone number from Clerk. Details
+// older than those already stored, as of Clerk's updated_at, are ignored. It reports
+// whether the user was updated.
+func (r *PostgresRepository) UpdateContactDetails(ctx context.Context, clerkID string, details domain.ContactDetails) (bool, error) {
+	query := `
+        UPDATE public.users
+        SET email = NULLIF($2, ''), phone_number = NULLIF($3, ''), clerk_updated_at = $4
+        WHERE clerk_id = $1
+          AND deleted_at IS NULL
+          AND (clerk_updated_at IS NULL OR clerk_updated_at < $4)
+    `
+	cmdTag, err := r.db.Exec(ctx, query, clerkID, details.Email, details.PhoneNumber, details.UpdatedAt)
+	if err != nil {
+		return false, fmt.Errorf("failed to update contact details: %w", err)
+	}
+	return cmdTag.RowsAffected() == 1, nil
+}
+
+// RecordSignIn records the time the user last started a Clerk session. Users who have
+// not onboarded are ignored.
+func (r *PostgresRepository) RecordSignIn(ctx context.Context, clerkID string, at time.Time) error {
+	query := `
+        UPDATE public.users
+        SET last_sign_in_at = GREATEST(last_sign_in_at, $2)
+        WHERE clerk_id = $1
+    `
+	if _, err := r.db.Exec(ctx, query, clerkID, at); err != nil {
+		return fmt.Errorf("failed to record sign-in: %w", err)
+	}
+	return nil
+}
+
+// MarkUserDeleted marks the user deleted, disables sending and writes event to the
+// outbox in the same transaction. It reports false, and writes nothing, if the user was
+// already deleted.
+func (r *PostgresRepository) MarkUserDeleted(ctx context.Context, userID uuid.UUID, deletedAt time.Time, event outbox.Event) (bool, error) {
+	dbTx, err := r.db.Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer dbTx.Rollback(ctx)
+
+	query := `
+        UPDATE public.users
+        SET deleted_at = $2, allow_sending = false
+        WHERE id = $1 AND deleted_at IS NULL
+    `
+	cmdTag, err := dbTx.Exec(ctx, query, userID, deletedAt)
+	if err != nil {
+		return false, fmt.Errorf("failed to mark user deleted: %w", err)
+	}
+	if cmdTag.RowsAffected() == 0 {
+		return false, nil
+	}
+
+	if err := outbox.Insert(ctx, dbTx, OutboxSource, event); err != nil {
+		return false, err
+	}
+
+	if err := dbTx.Commit(ctx); err != nil {
+		return false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return true, nil
+}
+
+// ReserveUsername holds username for the user with the given Clerk ID until expiresAt,
+// releasing any other username held for them. It renews the user's own reservation. It
+// returns domain.ErrUsernameTaken if a user has the username or gave it up within its
+// grace period, and domain.ErrUsernameHeld if another user's reservation of it has not
+// expired.
+func (r *PostgresRepository) ReserveUsername(ctx context.Context, username, clerkID string, expiresAt time.Time) error {
+	dbTx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer dbTx.Rollback(ctx)
+
+	var taken bool
+	err = dbTx.QueryRow(ctx, `
+        SELECT EXISTS (
+            SELECT 1 FROM public.users WHERE username = $1
+        ) OR EXISTS (
+            SELECT 1 FROM public.username_history
+            WHERE old_username = $1 AND grace_until > now()
+        )
+    `, username).Scan(&taken)
+	if err != nil {
+		return fmt.Errorf("failed to check username: %w", err)
+	}
+	if taken {
+		return domain.ErrUsernameTaken
+	}
+
+	if _, err := dbTx.Exec(ctx, `
+        DELETE FROM public.username_reservations WHERE clerk_id = $1 AND username <> $2
+    `, clerkID, username); err != nil {
+		return fmt.Errorf("failed to release previous reservation: %w", err)
+	}
+
+	// The primary key serializes concurrent reservations of the username: the row is
+	// only taken over once the other user's reservation has expired.
+	tag, err := dbTx.Exec(ctx, `
+        INSERT INTO public.username_reservations (username, clerk_id, expires_at)
+        VALUES ($1, $2, $3)
+        ON CONFLICT (username) DO UPDATE
+        SET clerk_id = EXCLUDED.clerk_id, expires_at = EXCLUDED.expires_at, created_at = now()
+        WHERE username_reservations.clerk_id = EXCLUDED.clerk_id
+           OR username_reservations.expires_at <= now()
+    `, username, clerkID, expiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to reserve username: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return domain.ErrUsernameHeld
+	}
+
+	if err := dbTx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// CheckUsername reports, without reserving it, whether username is taken or held for a
+// user other than the one with the given Clerk ID.
+func (r *PostgresRepository) CheckUsername(ctx context.Context, username, clerkID string) error {
+	var taken, held bool
+	err := r.db.QueryRow(ctx, `
+        SELECT
+            EXISTS (
+                SELECT 1 FROM public.users WHERE username = $1
+            ) OR EXISTS (
+                SELECT 1 FROM public.username_history
+                WHERE old_username = $1 AND grace_until > now()
+            ),
+            EXISTS (
+                SELECT 1 FROM public.username_reservations
+                WHERE username = $1 AND clerk_id <> $2 AND expires_at > now()
+            )
+    `, username, clerkID).Scan(&taken, &held)
+	if err != nil {
+		return fmt.Errorf("failed to check username: %w", err)
+	}
+	switch {
+	case taken:
+		return domain.ErrUsernameTaken
+	case held:
+		return domain.ErrUsernameHeld
+	}
+	return nil
+}
+
+// UnavailableUsernames returns which of usernames belong to a user, are in the grace
+// period of a user who changed them, or are held for a user other than the one with the
+// given Clerk ID.
+func (r *PostgresRepository) UnavailableUsernames(ctx context.Context, usernames []string, clerkID string) (map[string]bool, error) {
+	query := `
+        SELECT username FROM public.users WHERE username = ANY($1)
+        UNION
+        SELECT old_username FROM public.username_history
+        WHERE old_username = ANY($1) AND grace_until > now()
+        UNION
+        SELECT username FROM public.username_reservations
+        WHERE username = ANY($1) AND clerk_id <> $2 AND expires_at > now()
+    `
+	rows, err := r.db.Query(ctx, query, usernames, clerkID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query usernames: %w", err)
+	}
+	defer rows.Close()
+
+	unavailable := make(map[string]bool)
+	for rows.Next() {
+		var username string
+		if err := rows.Scan(&username); err != nil {
+			return nil, fmt.Errorf("failed to scan username: %w", err)
+		}
+		unavailable[username] = true
+	}
+	return unavailable, rows.Err()
+}
+
+// uniqueConflict translates a unique violation on public.users into the matching domain
+// error. It returns nil for any other error.
+func uniqueConflict(err error) error {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) || pgErr.Code != uniqueViolation {
+		return nil
+	}
+	switch pgErr.ConstraintName {
+	case usersUsernameKey:
+		return fmt.Errorf("%w: %v", domain.ErrUsernameTaken, err)
+	case usersClerkIDKey:
+		return fmt.Errorf("%w: %v", domain.ErrAlreadyOnboarded, err)
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,102 @@
+/**
+ * @description
+ * This package hashes transaction PINs with argon2id. A 4-digit PIN has only 10,000
+ * values, so the hash cannot stop a brute force on its own; its job is to make every
+ * guess against a leaked hash expensive, while the Auth service's attempt counter and
+ * lockout stop guesses through the API.
+ *
+ * Key features:
+ * - Hashes are encoded in the PHC string format
+ *   (`$argon2id$v=19$m=19456,t=2,p=1$<salt>$<hash>`), so the parameters are stored with
+ *   each hash and can be raised without invalidating existing PINs.
+ * - Hashes are compared in constant time.
+ *
+ * @dependencies
+ * - "crypto/rand", "crypto/subtle", "encoding/base64", "errors", "fmt", "strings"
+ * - "golang.org/x/crypto/argon2": For argon2id.
+ */
+package pinhash
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// Params are the argon2id cost parameters.
+type Params struct {
+	// Memory is in KiB.
+	Memory  uint32
+	Time    uint32
+	Threads uint8
+}
+
+// DefaultParams are the parameters new hashes are made with, the minimum OWASP
+// recommends for argon2id.
+var DefaultParams = Params{Memory: 19 * 1024, Time: 2, Threads: 1}
+
+const (
+	saltLength = 16
+	keyLength  = 32
+)
+
+// ErrInvalidHash is returned for a string that is not an argon2id hash made by Hash.
+var ErrInvalidHash = errors.New("invalid argon2id hash")
+
+// Hash returns the encoded argon2id hash of pin, made with DefaultParams and a random salt.
+func Hash(pin string) (string, error) {
+	salt := make([]byte, saltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("failed to generate salt: %w", err)
+	}
+	p := DefaultParams
+	key := argon2.IDKey([]byte(pin), salt, p.Time, p.Memory, p.Threads, keyLength)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, p.Memory, p.Time, p.Threads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+// Verify reports whether pin matches the encoded hash.
+func Verify(pin, encoded string) (bool, error) {
+	p, salt, key, err := decode(encoded)
+	if err != nil {
+		return false, err
+	}
+	other := argon2.IDKey([]byte(pin), salt, p.Time, p.Memory, p.Threads, uint32(len(key)))
+	return subtle.ConstantTimeCompare(key, other) == 1, nil
+}
+
+// decode parses an encoded hash into its parameters, salt and key.
+func decode(encoded string) (Params, []byte, []byte, error) {
+	// "", "argon2id", "v=19", "m=...,t=...,p=...", salt, key
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return Params{}, nil, nil, ErrInvalidHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return Params{}, nil, nil, ErrInvalidHash
+	}
+	var p Params
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.Memory, &p.Time, &p.Threads); err != nil {
+		return Params{}, nil, nil, ErrInvalidHash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return Params{}, nil, nil, ErrInvalidHash
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return Params{}, nil, nil, ErrInvalidHash
+	}
+	return p, salt, key, nil
+}
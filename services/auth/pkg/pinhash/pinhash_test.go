@@ -0,0 +1,51 @@
+package pinhash
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestHashAndVerify(t *testing.T) {
+	hash, err := Hash("4821")
+	if err != nil {
+		t.Fatalf("Hash() error = %v", err)
+	}
+	if !strings.HasPrefix(hash, "$argon2id$v=19$m=19456,t=2,p=1$") {
+		t.Errorf("Hash() = %q, want an argon2id PHC string with the default parameters", hash)
+	}
+
+	for pin, want := range map[string]bool{"4821": true, "4822": false, "": false} {
+		ok, err := Verify(pin, hash)
+		if err != nil {
+			t.Fatalf("Verify(%q) error = %v", pin, err)
+		}
+		if ok != want {
+			t.Errorf("Verify(%q) = %t, want %t", pin, ok, want)
+		}
+	}
+
+	other, err := Hash("4821")
+	if err != nil {
+		t.Fatalf("Hash() error = %v", err)
+	}
+	if other == hash {
+		t.Error("Hash() returned the same hash twice; salts are not random")
+	}
+}
+
+func TestVerifyInvalidHash(t *testing.T) {
+	for _, encoded := range []string{
+		"",
+		"4821",
+		"$2a$10$abcdefghijklmnopqrstuv",
+		"$argon2i$v=19$m=19456,t=2,p=1$c2FsdA$a2V5",
+		"$argon2id$v=16$m=19456,t=2,p=1$c2FsdA$a2V5",
+		"$argon2id$v=19$m=x,t=2,p=1$c2FsdA$a2V5",
+		"$argon2id$v=19$m=19456,t=2,p=1$!!$a2V5",
+	} {
+		if _, err := Verify("4821", encoded); !errors.Is(err, ErrInvalidHash) {
+			t.Errorf("Verify(%q) error = %v, want ErrInvalidHash", encoded, err)
+		}
+	}
+}
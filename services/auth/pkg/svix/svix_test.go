@@ -0,0 +1,53 @@
+package svix
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// secret is a Svix endpoint secret in the format Clerk shows it.
+const secret = "whsec_MfKQ9r8GKYqrTwjUPD8ILPZIo2LaLaSw"
+
+func TestVerify(t *testing.T) {
+	verifier, err := NewVerifier(secret)
+	if err != nil {
+		t.Fatalf("NewVerifier() error = %v", err)
+	}
+	now := time.Unix(1760600000, 0)
+	payload := []byte(`{"type":"user.updated","data":{"id":"user_2abc"}}`)
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	signature := verifier.Sign("msg_1", timestamp, payload)
+
+	tests := []struct {
+		name    string
+		payload []byte
+		headers Headers
+		want    error
+	}{
+		{"valid", payload, Headers{"msg_1", timestamp, signature}, nil},
+		{"one of several signatures", payload, Headers{"msg_1", timestamp, "v1,b2xk " + signature}, nil},
+		{"missing header", payload, Headers{"msg_1", timestamp, ""}, ErrMissingHeaders},
+		{"tampered payload", []byte(`{"type":"user.deleted"}`), Headers{"msg_1", timestamp, signature}, ErrNoMatchingSignature},
+		{"other message id", payload, Headers{"msg_2", timestamp, signature}, ErrNoMatchingSignature},
+		{"unknown version", payload, Headers{"msg_1", timestamp, "v2," + signature[3:]}, ErrNoMatchingSignature},
+		{"stale", payload, Headers{"msg_1", strconv.FormatInt(now.Add(-Tolerance-time.Second).Unix(), 10), signature}, ErrInvalidTimestamp},
+		{"malformed timestamp", payload, Headers{"msg_1", "yesterday", signature}, ErrInvalidTimestamp},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := verifier.Verify(tt.payload, tt.headers, now); !errors.Is(err, tt.want) {
+				t.Errorf("Verify() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewVerifierRejectsInvalidSecrets(t *testing.T) {
+	for _, secret := range []string{"", "whsec_", "whsec_not base64!"} {
+		if _, err := NewVerifier(secret); err == nil {
+			t.Errorf("NewVerifier(%q) succeeded, want an error", secret)
+		}
+	}
+}
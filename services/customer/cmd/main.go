@@ -7,7 +7,7 @@
  * - Establishing connections to the PostgreSQL database and RabbitMQ.
  * - Initializing and wiring together all application components (repository, service, handlers, etc.).
  * - Starting the RabbitMQ consumer to listen for events.
- * - Starting a minimal HTTP server for health checks.
+ * - Starting the HTTP server for the API and health checks.
  *
  * @dependencies
  * - Standard library packages for context, logging, HTTP, OS signals.
@@ -19,17 +19,21 @@ import (
 	"context"
 	"log"
 	"net/http"
-	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
+	"transfa/services/customer/internal/api"
 	"transfa/services/customer/internal/app"
 	"transfa/services/customer/internal/config"
 	"transfa/services/customer/internal/store"
 	"transfa/services/customer/pkg/anchor"
-	"transfa/services/customer/pkg/rabbitmq"
+	"transfa/shared/authn"
+	"transfa/shared/events"
+	"transfa/shared/inbox"
+	"transfa/shared/messaging"
+	"transfa/shared/outbox"
 )
 
 func main() {
@@ -43,6 +47,20 @@ func main() {
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
 
+	// Verify Clerk session tokens offline against the instance's cached signing keys.
+	verifier, err := authn.NewJWKSVerifier(authn.Config{
+		Issuer:   cfg.ClerkIssuer,
+		JWKSURL:  cfg.ClerkJWKSURL,
+		Audience: cfg.ClerkAudience,
+		Leeway:   cfg.ClerkClockSkew,
+	})
+	if err != nil {
+		log.Fatalf("could not create session token verifier: %v", err)
+	}
+	if err := verifier.Refresh(ctx); err != nil {
+		log.Printf("WARNING: could not fetch Clerk signing keys, will retry on the first request: %v", err)
+	}
+
 	// Initialize database connection pool
 	dbpool, err := pgxpool.New(ctx, cfg.DatabaseURL)
 	if err != nil {
@@ -51,13 +69,32 @@ func main() {
 	defer dbpool.Close()
 	log.Println("Database connection pool established.")
 
+	// Initialize RabbitMQ publisher
+	publisher, err := messaging.NewPublisher(cfg.RabbitMQURL)
+	if err != nil {
+		log.Fatalf("unable to create RabbitMQ publisher: %v", err)
+	}
+	defer publisher.Close()
+	log.Println("RabbitMQ publisher established.")
+
 	// Wire application components
 	repository := store.NewPostgresRepository(dbpool)
 	anchorClient := anchor.NewClient(cfg.AnchorBaseURL, cfg.AnchorAPIKey)
-	service := app.NewService(repository, anchorClient)
+	service := app.NewService(repository, anchorClient, cfg)
+
+	// Start the outbox relay
+	relay := outbox.NewRelay(dbpool, publisher, store.OutboxSource, cfg.OutboxPollInterval)
+	relayDone := make(chan struct{})
+	go func() {
+		defer close(relayDone)
+		relay.Run(ctx)
+	}()
 
 	// Initialize and start RabbitMQ consumer
-	consumer, err := rabbitmq.NewConsumer(cfg.RabbitMQURL)
+	consumer, err := messaging.NewConsumer(cfg.RabbitMQURL, messaging.RetryPolicy{
+		MaxAttempts:  cfg.MaxDeliveryAttempts,
+		InitialDelay: cfg.RetryInitialDelay,
+	})
 	if err != nil {
 		log.Fatalf("failed to create RabbitMQ consumer: %v", err)
 	}
@@ -69,20 +106,31 @@ func main() {
 		cfg.UserCreatedQueue,
 		cfg.UserCreatedRK,
 		cfg.ConsumerTag,
-		service.HandleUserCreatedEvent,
+		// Skip messages this queue has already processed.
+		inbox.New(dbpool, cfg.UserCreatedQueue).Wrap(service.HandleUserCreatedEvent),
+		messaging.Concurrency{
+			Workers:  cfg.ConsumerWorkers,
+			Prefetch: cfg.ConsumerPrefetch,
+			// Handle each user's events one at a time, so two deliveries for one user
+			// never race to create their Anchor customer.
+			OrderingKey: events.OrderingKey("user_id"),
+		},
 	)
 	if err != nil {
 		log.Fatalf("failed to start RabbitMQ consumer: %v", err)
 	}
 
-	// Start a simple HTTP server for health checks in a goroutine
+	// Set up and start HTTP server
+	handler := api.NewCustomerHandler(service)
+	srv := &http.Server{
+		Addr:    ":" + cfg.Port,
+		Handler: api.NewRouter(handler, verifier, consumer.IsConnected),
+	}
+
 	go func() {
-		http.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
-			w.WriteHeader(http.StatusOK)
-		})
-		log.Printf("Customer Service health check listening on port %s", cfg.Port)
-		if err := http.ListenAndServe(":"+cfg.Port, nil); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("health check server failed: %v", err)
+		log.Printf("Customer Service is starting on port %s...", cfg.Port)
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatalf("listen: %s\n", err)
 		}
 	}()
 
@@ -92,6 +140,14 @@ func main() {
 	stop()
 	log.Println("shutting down gracefully")
 
-	// Allow some time for cleanup
-	time.Sleep(2 * time.Second)
+	// The context is used to inform the server it has 5 seconds to finish
+	// the requests it is currently handling
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Fatalf("Server forced to shutdown: %v", err)
+	}
+
+	// Wait for the relay to finish its current batch before closing the publisher.
+	<-relayDone
 }
\ No newline at end of file
@@ -0,0 +1,113 @@
+/**
+ * @description
+ * This file contains the HTTP handlers for the Customer service. Handlers are
+ * responsible for parsing incoming requests, calling the appropriate application
+ * service method, and writing the HTTP response.
+ *
+ * @dependencies
+ * - "encoding/json": For JSON serialization and deserialization.
+ * - "errors": For mapping service errors to HTTP status codes.
+ * - "log": For logging.
+ * - "net/http": For standard HTTP handling.
+ * - "strconv", "time": For the Retry-After header of rejected username changes.
+ * - "transfa/services/customer/internal/app": Imports the application service layer.
+ * - "transfa/services/customer/internal/domain": Imports the data models/DTOs.
+ * - "transfa/shared/apierror": For JSON:API error responses.
+ * - "transfa/shared/authn": For the Clerk User ID of the caller.
+ */
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"transfa/services/customer/internal/app"
+	"transfa/services/customer/internal/domain"
+	"transfa/shared/apierror"
+	"transfa/shared/authn"
+)
+
+// Machine-readable codes of the rejected username changes, for clients to branch on.
+const (
+	codeUsernameTaken          = "username_taken"
+	codeUsernameUnchanged      = "username_unchanged"
+	codeUsernameChangeCooldown = "username_change_cooldown"
+	codeUsernameChangeConflict = "username_change_conflict"
+	codeNotOnboarded           = "not_onboarded"
+)
+
+// CustomerHandler holds dependencies for the customer-related HTTP handlers.
+type CustomerHandler struct {
+	service *app.Service
+}
+
+// NewCustomerHandler creates a new handler with the given application service.
+func NewCustomerHandler(service *app.Service) *CustomerHandler {
+	return &CustomerHandler{
+		service: service,
+	}
+}
+
+// ChangeUsernameHandler handles the `PUT /users/me/username` request.
+func (h *CustomerHandler) ChangeUsernameHandler(w http.ResponseWriter, r *http.Request) {
+	clerkID, ok := authn.SubjectFromContext(r.Context())
+	if !ok {
+		apierror.Write(w, http.StatusUnauthorized, "could not retrieve claims")
+		return
+	}
+
+	var req domain.ChangeUsernameRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.Write(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+
+	change, err := h.service.ChangeUsername(r.Context(), clerkID, req.Username)
+	if err != nil {
+		log.Printf("Username change failed for clerk_id %s: %v", clerkID, err)
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(change); err != nil {
+		log.Printf("Failed to write response: %v", err)
+	}
+}
+
+// writeServiceError maps application errors to HTTP status codes.
+func writeServiceError(w http.ResponseWriter, err error) {
+	var invalidErr *domain.InvalidUsernameError
+	var cooldownErr *domain.CooldownError
+	switch {
+	case errors.As(err, &invalidErr):
+		apierror.WriteErrors(w, apierror.New(http.StatusBadRequest, invalidErr.Message).
+			WithCode(invalidErr.Code).
+			WithPointer("/username"))
+	case errors.As(err, &cooldownErr):
+		retryAfter := time.Until(cooldownErr.NextChangeAt).Round(time.Second)
+		w.Header().Set("Retry-After", strconv.Itoa(int(retryAfter.Seconds())))
+		apierror.WriteErrors(w, apierror.New(http.StatusTooManyRequests, cooldownErr.Error()).
+			WithCode(codeUsernameChangeCooldown))
+	case errors.Is(err, domain.ErrUsernameTaken):
+		apierror.WriteErrors(w, apierror.New(http.StatusConflict, domain.ErrUsernameTaken.Error()).
+			WithCode(codeUsernameTaken).
+			WithPointer("/username"))
+	case errors.Is(err, domain.ErrUsernameUnchanged):
+		apierror.WriteErrors(w, apierror.New(http.StatusConflict, domain.ErrUsernameUnchanged.Error()).
+			WithCode(codeUsernameUnchanged).
+			WithPointer("/username"))
+	case errors.Is(err, domain.ErrUsernameChangeConflict):
+		apierror.WriteErrors(w, apierror.New(http.StatusConflict, domain.ErrUsernameChangeConflict.Error()).
+			WithCode(codeUsernameChangeConflict))
+	case errors.Is(err, domain.ErrUserNotFound):
+		apierror.WriteErrors(w, apierror.New(http.StatusNotFound, domain.ErrUserNotFound.Error()).
+			WithCode(codeNotOnboarded))
+	default:
+		apierror.Write(w, http.StatusInternalServerError, "")
+	}
+}
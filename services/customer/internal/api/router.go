@@ -0,0 +1,65 @@
+/**
+ * @description
+ * This file sets up the HTTP router for the Customer service using the Chi router.
+ * It defines all the API routes, applies middleware like CORS and authentication,
+ * and connects the routes to their respective handlers.
+ *
+ * @dependencies
+ * - "net/http": For standard HTTP handling.
+ * - "github.com/go-chi/chi/v5": The Chi router library.
+ * - "github.com/go-chi/chi/v5/middleware": For standard Chi middleware.
+ * - "github.com/go-chi/cors": For CORS middleware.
+ * - "transfa/shared/authn": For the Clerk session token middleware.
+ */
+package api
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/cors"
+	"transfa/shared/authn"
+)
+
+// NewRouter creates and configures a new Chi router for the Customer service.
+// consumerConnected reports whether the RabbitMQ consumer is connected, for /health.
+func NewRouter(handler *CustomerHandler, verifier authn.TokenVerifier, consumerConnected func() bool) http.Handler {
+	r := chi.NewRouter()
+
+	// A good base middleware stack
+	r.Use(middleware.RequestID)
+	r.Use(middleware.RealIP)
+	r.Use(middleware.Logger)
+	r.Use(middleware.Recoverer)
+
+	// Basic CORS configuration. This should be more restrictive in production.
+	r.Use(cors.Handler(cors.Options{
+		AllowedOrigins:   []string{"*"}, // Or specify your client's origin
+		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
+		ExposedHeaders:   []string{"Link"},
+		AllowCredentials: true,
+		MaxAge:           300, // Maximum value not ignored by any major browsers
+	}))
+
+	// Health check endpoint - does not require authentication
+	r.Get("/health", func(w http.ResponseWriter, r *http.Request) {
+		// Report unhealthy while the consumer is reconnecting to RabbitMQ.
+		if !consumerConnected() {
+			http.Error(w, "RabbitMQ consumer disconnected", http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+
+	// Protected routes
+	r.Group(func(r chi.Router) {
+		// Require a valid Clerk session token on this group.
+		r.Use(authn.Middleware(verifier))
+
+		r.Put("/users/me/username", handler.ChangeUsernameHandler)
+	})
+
+	return r
+}
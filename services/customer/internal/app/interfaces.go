@@ -6,26 +6,46 @@
  *
  * @dependencies
  * - "context": For passing request-scoped data and cancellation signals.
+ * - "time": For the cooldown between username changes.
  * - "github.com/google/uuid": For user identifiers.
- * - "transfa/services/customer/internal/domain": For event data structures.
+ * - "transfa/services/customer/internal/domain": For users and username changes.
+ * - "transfa/shared/events": For event data structures.
+ * - "transfa/shared/outbox": For the events written alongside a username change.
+ * - "transfa/shared/onboarding": For onboarding statuses.
  */
 package app
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 	"transfa/services/customer/internal/domain"
+	"transfa/shared/events"
+	"transfa/shared/onboarding"
+	"transfa/shared/outbox"
 )
 
 // Repository defines the interface for data persistence operations.
 type Repository interface {
+	GetAnchorCustomerID(ctx context.Context, userID uuid.UUID) (string, error)
+	// UpdateUserWithAnchorID stores the user's Anchor customer ID and advances their
+	// onboarding to customer_created.
 	UpdateUserWithAnchorID(ctx context.Context, userID uuid.UUID, anchorCustomerID string) error
+	AdvanceOnboarding(ctx context.Context, userID uuid.UUID, status onboarding.Status, reason string) error
+	// GetUserByClerkID returns the active user with the given Clerk ID, or an error
+	// wrapping domain.ErrUserNotFound.
+	GetUserByClerkID(ctx context.Context, clerkID string) (*domain.User, error)
+	// ChangeUsername applies change, records it in the username history and writes event
+	// to the outbox atomically. It returns domain.ErrUsernameTaken if another user holds
+	// the new username and domain.ErrUsernameChangeConflict if the username was changed
+	// after notChangedSince.
+	ChangeUsername(ctx context.Context, clerkID string, change domain.UsernameChange, notChangedSince time.Time, event outbox.Event) error
 }
 
 // AnchorClient defines the interface for communicating with the Anchor BaaS API.
 type AnchorClient interface {
-	CreateIndividualCustomer(ctx context.Context, event domain.UserCreatedEvent) (string, error)
-	TriggerIndividualVerification(ctx context.Context, anchorCustomerID string, kycDetails *domain.KYCDetails) error
+	CreateIndividualCustomer(ctx context.Context, event events.UserCreated) (string, error)
+	TriggerIndividualVerification(ctx context.Context, anchorCustomerID string, kycDetails *events.KYCDetails) error
 	// CreateBusinessCustomer and TriggerBusinessVerification would be defined here as well.
 }
\ No newline at end of file
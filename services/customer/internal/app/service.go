@@ -5,54 +5,75 @@
  * Anchor client and the database repository.
  *
  * @dependencies
- * - "context", "encoding/json", "fmt", "log"
+ * - "context", "fmt", "log"
  * - "github.com/rabbitmq/amqp091-go": For message handling.
- * - "transfa/services/customer/internal/domain": For core data models and events.
+ * - "transfa/services/customer/internal/config": Imports app configuration.
+ * - "transfa/shared/events": For the `user.created` event.
+ * - "transfa/shared/messaging": For marking errors that must not be retried.
+ * - "transfa/shared/onboarding": For advancing the user's onboarding status.
  */
 package app
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"log"
 
 	"github.com/rabbitmq/amqp091-go"
-	"transfa/services/customer/internal/domain"
+	"transfa/services/customer/internal/config"
+	"transfa/shared/events"
+	"transfa/shared/messaging"
+	"transfa/shared/onboarding"
 )
 
+// EventProducer names the Customer service in the events it publishes.
+const EventProducer = "customer"
+
 // Service provides the application's business logic.
 type Service struct {
 	repo         Repository
 	anchorClient AnchorClient
+	config       config.Config
 }
 
 // NewService creates a new application service.
-func NewService(repo Repository, anchorClient AnchorClient) *Service {
+func NewService(repo Repository, anchorClient AnchorClient, cfg config.Config) *Service {
 	return &Service{
 		repo:         repo,
 		anchorClient: anchorClient,
+		config:       cfg,
 	}
 }
 
 // HandleUserCreatedEvent is the message handler for `user.created` events.
 // It orchestrates creating the customer in Anchor, updating the local DB, and triggering KYC.
 func (s *Service) HandleUserCreatedEvent(ctx context.Context, msg amqp091.Delivery) error {
-	var event domain.UserCreatedEvent
-	if err := json.Unmarshal(msg.Body, &event); err != nil {
-		return fmt.Errorf("failed to unmarshal UserCreatedEvent: %w", err)
+	var event events.UserCreated
+	envelope, err := events.DecodeDelivery(msg, &event)
+	if err != nil {
+		return err
 	}
 
-	log.Printf("Processing UserCreatedEvent for UserID: %s", event.UserID)
+	log.Printf("Processing user.created event %s (correlation %s) for UserID: %s", envelope.EventID, envelope.CorrelationID, event.UserID)
 
-	// Step 1: Create customer in Anchor based on account type.
+	// Step 1: Skip users who already have an Anchor customer, so a redelivered event
+	// never creates a duplicate one.
+	existingID, err := s.repo.GetAnchorCustomerID(ctx, event.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to check existing Anchor customer for user %s: %w", event.UserID, err)
+	}
+	if existingID != "" {
+		log.Printf("User %s already has Anchor customer %s; ignoring duplicate user.created event", event.UserID, existingID)
+		return nil
+	}
+
+	// Step 2: Create customer in Anchor based on account type.
 	var anchorCustomerID string
-	var err error
 
 	switch event.AccountType {
 	case "personal":
 		if event.KYCDetails == nil {
-			return fmt.Errorf("KYCDetails are required for personal account type")
+			return messaging.Permanent(fmt.Errorf("KYCDetails are required for personal account type"))
 		}
 		anchorCustomerID, err = s.anchorClient.CreateIndividualCustomer(ctx, event)
 		if err != nil {
@@ -67,12 +88,12 @@ func (s *Service) HandleUserCreatedEvent(ctx context.Context, msg amqp091.Delive
 		// anchorCustomerID, err = s.anchorClient.CreateBusinessCustomer(ctx, event)
 		return nil // Acknowledge message to prevent requeue loop
 	default:
-		return fmt.Errorf("unknown account type: %s", event.AccountType)
+		return messaging.Permanent(fmt.Errorf("unknown account type: %s", event.AccountType))
 	}
 
 	log.Printf("Successfully created Anchor customer with ID: %s for UserID: %s", anchorCustomerID, event.UserID)
 
-	// Step 2: Update our local user record with the Anchor Customer ID.
+	// Step 3: Update our local user record with the Anchor Customer ID.
 	if err := s.repo.UpdateUserWithAnchorID(ctx, event.UserID, anchorCustomerID); err != nil {
 		// This is a critical error. If this fails, we have an orphaned Anchor customer.
 		// This might require a retry mechanism or manual intervention.
@@ -80,11 +101,17 @@ func (s *Service) HandleUserCreatedEvent(ctx context.Context, msg amqp091.Delive
 	}
 	log.Printf("Successfully updated user %s with anchor_customer_id", event.UserID)
 
-	// Step 3: Trigger the verification process in Anchor.
+	// Step 4: Trigger the verification process in Anchor.
 	if event.AccountType == "personal" {
 		if err := s.anchorClient.TriggerIndividualVerification(ctx, anchorCustomerID, event.KYCDetails); err != nil {
 			// This is less critical; it can be retried. We still acknowledge the message.
 			log.Printf("WARNING: Failed to trigger KYC verification for anchor customer %s: %v", anchorCustomerID, err)
+			return nil
+		}
+		// A redelivered event stops at step 1, so a failure here is logged rather than
+		// retried: the status catches up when Anchor's verdict arrives.
+		if err := s.repo.AdvanceOnboarding(ctx, event.UserID, onboarding.StatusKYCPending, ""); err != nil {
+			log.Printf("WARNING: Failed to advance onboarding of user %s to %s: %v", event.UserID, onboarding.StatusKYCPending, err)
 		}
 	}
 	// Similar logic for business verification would go here.
@@ -0,0 +1,184 @@
+package app
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"transfa/services/customer/internal/config"
+	"transfa/services/customer/internal/domain"
+	"transfa/shared/events"
+	"transfa/shared/eventtest"
+	"transfa/shared/messaging"
+	"transfa/shared/messaging/memory"
+	"transfa/shared/onboarding"
+	"transfa/shared/outbox"
+)
+
+const (
+	userCreatedEx    = "user_events"
+	userCreatedRK    = "user.created"
+	userCreatedQueue = "customer_service_user_created"
+)
+
+// fakeRepository stores Anchor customer IDs, onboarding statuses, users and username
+// changes in memory.
+type fakeRepository struct {
+	anchorIDs map[uuid.UUID]string
+	statuses  map[uuid.UUID]onboarding.Status
+	// users are keyed by Clerk ID.
+	users map[string]*domain.User
+	// held are usernames reserved for or recently given up by other users.
+	held    map[string]bool
+	history []domain.UsernameChange
+	outbox  []outbox.Event
+}
+
+func newFakeRepository() *fakeRepository {
+	return &fakeRepository{
+		anchorIDs: make(map[uuid.UUID]string),
+		statuses:  make(map[uuid.UUID]onboarding.Status),
+		users:     make(map[string]*domain.User),
+		held:      make(map[string]bool),
+	}
+}
+
+func (r *fakeRepository) GetAnchorCustomerID(ctx context.Context, userID uuid.UUID) (string, error) {
+	return r.anchorIDs[userID], nil
+}
+
+func (r *fakeRepository) UpdateUserWithAnchorID(ctx context.Context, userID uuid.UUID, anchorCustomerID string) error {
+	r.anchorIDs[userID] = anchorCustomerID
+	r.statuses[userID] = onboarding.StatusCustomerCreated
+	return nil
+}
+
+func (r *fakeRepository) AdvanceOnboarding(ctx context.Context, userID uuid.UUID, status onboarding.Status, reason string) error {
+	if onboarding.CanAdvance(r.statuses[userID], status) {
+		r.statuses[userID] = status
+	}
+	return nil
+}
+
+func (r *fakeRepository) GetUserByClerkID(ctx context.Context, clerkID string) (*domain.User, error) {
+	user, ok := r.users[clerkID]
+	if !ok {
+		return nil, domain.ErrUserNotFound
+	}
+	copied := *user
+	return &copied, nil
+}
+
+func (r *fakeRepository) ChangeUsername(ctx context.Context, clerkID string, change domain.UsernameChange, notChangedSince time.Time, event outbox.Event) error {
+	if r.held[change.NewUsername] {
+		return domain.ErrUsernameTaken
+	}
+	for id, user := range r.users {
+		if id != clerkID && user.Username == change.NewUsername {
+			return domain.ErrUsernameTaken
+		}
+	}
+	user := r.users[clerkID]
+	if user.Username != change.OldUsername || (user.UsernameChangedAt != nil && user.UsernameChangedAt.After(notChangedSince)) {
+		return domain.ErrUsernameChangeConflict
+	}
+	user.Username = change.NewUsername
+	user.UsernameChangedAt = &change.ChangedAt
+	r.history = append(r.history, change)
+	r.outbox = append(r.outbox, event)
+	return nil
+}
+
+// fakeAnchorClient records the customers created and verifications triggered.
+type fakeAnchorClient struct {
+	customerID    string
+	created       []events.UserCreated
+	verifications []*events.KYCDetails
+}
+
+func (c *fakeAnchorClient) CreateIndividualCustomer(ctx context.Context, event events.UserCreated) (string, error) {
+	c.created = append(c.created, event)
+	return c.customerID, nil
+}
+
+func (c *fakeAnchorClient) TriggerIndividualVerification(ctx context.Context, anchorCustomerID string, kycDetails *events.KYCDetails) error {
+	c.verifications = append(c.verifications, kycDetails)
+	return nil
+}
+
+// newTestConsumer returns a broker on which service consumes `user.created`.
+func newTestConsumer(t *testing.T, service *Service) *memory.Broker {
+	t.Helper()
+	broker := memory.NewBroker(messaging.RetryPolicy{MaxAttempts: 3})
+	err := broker.StartConsumer(context.Background(), userCreatedEx, userCreatedQueue, userCreatedRK, "test", service.HandleUserCreatedEvent, messaging.Concurrency{})
+	if err != nil {
+		t.Fatalf("StartConsumer() error = %v", err)
+	}
+	return broker
+}
+
+// TestHandleUserCreatedEventCreatesAnchorCustomer is the Customer service's leg of the
+// onboarding scenario: `user.created` creates the Anchor customer that Anchor's
+// approval webhook later refers to.
+func TestHandleUserCreatedEventCreatesAnchorCustomer(t *testing.T) {
+	repo := newFakeRepository()
+	anchor := &fakeAnchorClient{customerID: eventtest.Onboarding.AnchorCustomerID}
+	broker := newTestConsumer(t, NewService(repo, anchor, config.Config{}))
+
+	eventtest.Publish(t, broker, userCreatedEx, userCreatedRK, "auth", eventtest.UserCreated())
+	broker.Drain()
+
+	if dead := broker.DeadLetters(userCreatedQueue); len(dead) != 0 {
+		t.Fatalf("%d messages were dead-lettered", len(dead))
+	}
+	if len(anchor.created) != 1 {
+		t.Fatalf("created %d Anchor customers, want 1", len(anchor.created))
+	}
+	if got := repo.anchorIDs[eventtest.Onboarding.UserID]; got != eventtest.Onboarding.AnchorCustomerID {
+		t.Errorf("stored anchor customer ID = %q, want %q", got, eventtest.Onboarding.AnchorCustomerID)
+	}
+	if len(anchor.verifications) != 1 || *anchor.verifications[0] != eventtest.Onboarding.KYCDetails {
+		t.Errorf("verifications = %v, want one with the user's KYC details", anchor.verifications)
+	}
+	if got := repo.statuses[eventtest.Onboarding.UserID]; got != onboarding.StatusKYCPending {
+		t.Errorf("onboarding status = %q, want %q", got, onboarding.StatusKYCPending)
+	}
+}
+
+// TestHandleUserCreatedEventIgnoresDuplicates checks that a second `user.created` for a
+// user who already has an Anchor customer does not create another one.
+func TestHandleUserCreatedEventIgnoresDuplicates(t *testing.T) {
+	repo := newFakeRepository()
+	anchor := &fakeAnchorClient{customerID: eventtest.Onboarding.AnchorCustomerID}
+	broker := newTestConsumer(t, NewService(repo, anchor, config.Config{}))
+
+	eventtest.Publish(t, broker, userCreatedEx, userCreatedRK, "auth", eventtest.UserCreated())
+	eventtest.Publish(t, broker, userCreatedEx, userCreatedRK, "auth", eventtest.UserCreated())
+	broker.Drain()
+
+	if len(anchor.created) != 1 {
+		t.Errorf("created %d Anchor customers, want 1", len(anchor.created))
+	}
+}
+
+// TestHandleUserCreatedEventDeadLettersInvalidMessages checks that messages that are not
+// `user.created` envelopes are dead-lettered without retries.
+func TestHandleUserCreatedEventDeadLettersInvalidMessages(t *testing.T) {
+	repo := newFakeRepository()
+	anchor := &fakeAnchorClient{}
+	broker := newTestConsumer(t, NewService(repo, anchor, config.Config{}))
+
+	// A bare payload, as published before events had envelopes.
+	broker.Publish(context.Background(), []byte(`{"user_id":"5b0f4c0e-1d7a-4a51-9f0e-3c1a2b4d6e80"}`), userCreatedEx, userCreatedRK)
+	// Another type of event.
+	eventtest.Publish(t, broker, userCreatedEx, userCreatedRK, "auth", eventtest.CustomerVerified())
+	broker.Drain()
+
+	if dead := broker.DeadLetters(userCreatedQueue); len(dead) != 2 {
+		t.Errorf("%d messages were dead-lettered, want 2", len(dead))
+	}
+	if len(anchor.created) != 0 {
+		t.Errorf("created %d Anchor customers, want 0", len(anchor.created))
+	}
+}
@@ -0,0 +1,93 @@
+/**
+ * @description
+ * This file contains the business logic for changing a user's username.
+ *
+ * Key features:
+ * - The new username follows the same rules as at onboarding (`shared/username`).
+ * - Users can change their username once per `UsernameChangeCooldown`, so a handle
+ *   cannot be swapped back and forth to confuse payers.
+ * - The old username keeps pointing to the user for `UsernameGracePeriod`: no one else
+ *   can claim it, and the Transaction service resolves payments to it.
+ * - A `username.changed` event is written to the outbox in the same transaction as the
+ *   change, so that caches and QR payloads keyed by the old username are invalidated
+ *   even if RabbitMQ is unavailable when the username changes.
+ *
+ * @dependencies
+ * - "context", "log", "time"
+ * - "transfa/services/customer/internal/domain": For username changes.
+ * - "transfa/shared/events": For the `username.changed` event.
+ * - "transfa/shared/outbox": For the event written alongside the change.
+ * - "transfa/shared/username": For the username rules.
+ */
+package app
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"transfa/services/customer/internal/domain"
+	"transfa/shared/events"
+	"transfa/shared/outbox"
+	"transfa/shared/username"
+)
+
+// ChangeUsername changes the username of the user with the given Clerk ID to name.
+func (s *Service) ChangeUsername(ctx context.Context, clerkID, name string) (*domain.UsernameChange, error) {
+	// Step 1: Validate the new username.
+	newUsername := username.Normalize(name)
+	if code, message := username.Check(newUsername); code != "" {
+		return nil, &domain.InvalidUsernameError{Code: code, Message: message}
+	}
+
+	// Step 2: Check the user may change their username now.
+	user, err := s.repo.GetUserByClerkID(ctx, clerkID)
+	if err != nil {
+		return nil, err
+	}
+	if user.Username == newUsername {
+		return nil, domain.ErrUsernameUnchanged
+	}
+	now := time.Now().UTC()
+	if user.UsernameChangedAt != nil {
+		if next := user.UsernameChangedAt.Add(s.config.UsernameChangeCooldown); now.Before(next) {
+			return nil, &domain.CooldownError{NextChangeAt: next}
+		}
+	}
+
+	// Step 3: Describe the change. The old username is kept for the grace period.
+	change := domain.UsernameChange{
+		UserID:       user.ID,
+		OldUsername:  user.Username,
+		NewUsername:  newUsername,
+		ChangedAt:    now,
+		GraceUntil:   now.Add(s.config.UsernameGracePeriod),
+		NextChangeAt: now.Add(s.config.UsernameChangeCooldown),
+	}
+
+	// Step 4: Prepare the `username.changed` event. It is written to the outbox together
+	// with the change and published by the outbox relay once the transaction commits.
+	envelope, err := events.New(EventProducer, events.UsernameChanged{
+		UserID:      change.UserID,
+		OldUsername: change.OldUsername,
+		NewUsername: change.NewUsername,
+		ChangedAt:   change.ChangedAt,
+		GraceUntil:  change.GraceUntil,
+	}, events.Metadata{})
+	if err != nil {
+		return nil, err
+	}
+
+	// Step 5: Persist the change and the event in one transaction.
+	err = s.repo.ChangeUsername(ctx, clerkID, change, now.Add(-s.config.UsernameChangeCooldown), outbox.Event{
+		Exchange:   s.config.UsernameChangedEx,
+		RoutingKey: s.config.UsernameChangedRK,
+		Envelope:   envelope,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("User %s changed their username from %s to %s and queued username.changed event %s", user.ID, change.OldUsername, change.NewUsername, envelope.EventID)
+	return &change, nil
+}
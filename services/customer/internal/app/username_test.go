@@ -0,0 +1,186 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"transfa/services/customer/internal/config"
+	"transfa/services/customer/internal/domain"
+	"transfa/shared/events"
+	"transfa/shared/username"
+)
+
+const testClerkID = "user_123"
+
+func newUsernameTestService(repo *fakeRepository) *Service {
+	return NewService(repo, &fakeAnchorClient{}, config.Config{
+		UsernameChangeCooldown: 30 * 24 * time.Hour,
+		UsernameGracePeriod:    14 * 24 * time.Hour,
+		UsernameChangedEx:      "user_events",
+		UsernameChangedRK:      "username.changed",
+	})
+}
+
+func newUsernameTestRepository(changedAt *time.Time) *fakeRepository {
+	repo := newFakeRepository()
+	repo.users[testClerkID] = &domain.User{ID: uuid.New(), ClerkID: testClerkID, Username: "ada", UsernameChangedAt: changedAt}
+	repo.users["user_456"] = &domain.User{ID: uuid.New(), ClerkID: "user_456", Username: "grace"}
+	return repo
+}
+
+func TestChangeUsername(t *testing.T) {
+	repo := newUsernameTestRepository(nil)
+	service := newUsernameTestService(repo)
+
+	change, err := service.ChangeUsername(context.Background(), testClerkID, "  LoveLace ")
+	if err != nil {
+		t.Fatalf("ChangeUsername() error = %v", err)
+	}
+	if change.OldUsername != "ada" || change.NewUsername != "lovelace" {
+		t.Errorf("change = %s -> %s, want ada -> lovelace", change.OldUsername, change.NewUsername)
+	}
+	if got := change.GraceUntil.Sub(change.ChangedAt); got != 14*24*time.Hour {
+		t.Errorf("grace period = %s, want 336h", got)
+	}
+	if got := change.NextChangeAt.Sub(change.ChangedAt); got != 30*24*time.Hour {
+		t.Errorf("cooldown = %s, want 720h", got)
+	}
+	if len(repo.history) != 1 {
+		t.Fatalf("history has %d changes, want 1", len(repo.history))
+	}
+
+	// The event is queued in the outbox with the change rather than published directly.
+	if len(repo.outbox) != 1 {
+		t.Fatalf("outbox has %d events, want 1", len(repo.outbox))
+	}
+	event := repo.outbox[0]
+	if event.Exchange != "user_events" || event.RoutingKey != "username.changed" {
+		t.Errorf("event routed to %s/%s, want user_events/username.changed", event.Exchange, event.RoutingKey)
+	}
+	var payload events.UsernameChanged
+	if err := event.Envelope.Decode(&payload); err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if payload.UserID != change.UserID || payload.OldUsername != "ada" || payload.NewUsername != "lovelace" {
+		t.Errorf("payload = %+v, want the change", payload)
+	}
+}
+
+func TestChangeUsernameRejected(t *testing.T) {
+	recently := time.Now().Add(-24 * time.Hour)
+	longAgo := time.Now().Add(-60 * 24 * time.Hour)
+
+	tests := []struct {
+		name      string
+		changedAt *time.Time
+		held      string
+		username  string
+		check     func(t *testing.T, err error)
+	}{
+		{
+			name:     "invalid",
+			username: "a!",
+			check: func(t *testing.T, err error) {
+				var invalid *domain.InvalidUsernameError
+				if !errors.As(err, &invalid) || invalid.Code != username.CodeInvalidFormat {
+					t.Errorf("error = %v, want an InvalidUsernameError with code %s", err, username.CodeInvalidFormat)
+				}
+			},
+		},
+		{
+			name:     "unchanged",
+			username: "ADA",
+			check: func(t *testing.T, err error) {
+				if !errors.Is(err, domain.ErrUsernameUnchanged) {
+					t.Errorf("error = %v, want ErrUsernameUnchanged", err)
+				}
+			},
+		},
+		{
+			name:      "cooldown",
+			changedAt: &recently,
+			username:  "lovelace",
+			check: func(t *testing.T, err error) {
+				var cooldown *domain.CooldownError
+				if !errors.As(err, &cooldown) {
+					t.Fatalf("error = %v, want a CooldownError", err)
+				}
+				if want := recently.Add(30 * 24 * time.Hour); !cooldown.NextChangeAt.Equal(want) {
+					t.Errorf("NextChangeAt = %s, want %s", cooldown.NextChangeAt, want)
+				}
+			},
+		},
+		{
+			name:      "taken",
+			changedAt: &longAgo,
+			username:  "grace",
+			check: func(t *testing.T, err error) {
+				if !errors.Is(err, domain.ErrUsernameTaken) {
+					t.Errorf("error = %v, want ErrUsernameTaken", err)
+				}
+			},
+		},
+		{
+			name:     "taken by another user's grace period",
+			held:     "hopper",
+			username: "hopper",
+			check: func(t *testing.T, err error) {
+				if !errors.Is(err, domain.ErrUsernameTaken) {
+					t.Errorf("error = %v, want ErrUsernameTaken", err)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newUsernameTestRepository(tt.changedAt)
+			if tt.held != "" {
+				repo.held[tt.held] = true
+			}
+			service := newUsernameTestService(repo)
+
+			_, err := service.ChangeUsername(context.Background(), testClerkID, tt.username)
+			tt.check(t, err)
+			if len(repo.history) != 0 || len(repo.outbox) != 0 {
+				t.Errorf("rejected change recorded %d changes and %d events", len(repo.history), len(repo.outbox))
+			}
+		})
+	}
+}
+
+// staleRepository returns the user as they were before any change, like a request that
+// loaded the user just before another request changed their username.
+type staleRepository struct {
+	*fakeRepository
+	stale domain.User
+}
+
+func (r *staleRepository) GetUserByClerkID(ctx context.Context, clerkID string) (*domain.User, error) {
+	user := r.stale
+	return &user, nil
+}
+
+// TestChangeUsernameConflict checks that of two changes racing past the cooldown check,
+// only the first is applied.
+func TestChangeUsernameConflict(t *testing.T) {
+	repo := newUsernameTestRepository(nil)
+	racing := NewService(&staleRepository{fakeRepository: repo, stale: *repo.users[testClerkID]}, &fakeAnchorClient{}, newUsernameTestService(repo).config)
+
+	if _, err := newUsernameTestService(repo).ChangeUsername(context.Background(), testClerkID, "lovelace"); err != nil {
+		t.Fatalf("first ChangeUsername() error = %v", err)
+	}
+	_, err := racing.ChangeUsername(context.Background(), testClerkID, "countess")
+	if !errors.Is(err, domain.ErrUsernameChangeConflict) {
+		t.Errorf("second ChangeUsername() error = %v, want ErrUsernameChangeConflict", err)
+	}
+	if got := repo.users[testClerkID].Username; got != "lovelace" {
+		t.Errorf("username = %q, want lovelace", got)
+	}
+	if len(repo.outbox) != 1 {
+		t.Errorf("outbox has %d events, want 1", len(repo.outbox))
+	}
+}
@@ -10,7 +10,11 @@
  */
 package config
 
-import "github.com/spf13/viper"
+import (
+	"time"
+
+	"github.com/spf13/viper"
+)
 
 // Config stores all configuration for the application.
 // The values are read by viper from a config file or environment variable.
@@ -24,6 +28,31 @@ type Config struct {
 	UserCreatedEx     string `mapstructure:"USER_CREATED_EX"`
 	UserCreatedRK     string `mapstructure:"USER_CREATED_RK"`
 	ConsumerTag       string `mapstructure:"CONSUMER_TAG"`
+	// MaxDeliveryAttempts is how many times a message is handled before it is dead-lettered.
+	MaxDeliveryAttempts int `mapstructure:"MAX_DELIVERY_ATTEMPTS"`
+	// RetryInitialDelay is the delay before a failed message is first retried. It doubles
+	// with each further attempt.
+	RetryInitialDelay time.Duration `mapstructure:"RETRY_INITIAL_DELAY"`
+	// ConsumerWorkers is how many messages are handled at once.
+	ConsumerWorkers int `mapstructure:"CONSUMER_WORKERS"`
+	// ConsumerPrefetch is how many unacknowledged messages RabbitMQ sends ahead.
+	ConsumerPrefetch int `mapstructure:"CONSUMER_PREFETCH"`
+	// ClerkIssuer is the Clerk instance's Frontend API URL, the `iss` of its session tokens.
+	ClerkIssuer string `mapstructure:"CLERK_ISSUER"`
+	// ClerkJWKSURL overrides where Clerk's signing keys are fetched from.
+	ClerkJWKSURL string `mapstructure:"CLERK_JWKS_URL"`
+	// ClerkAudience, if set, must be an audience of every session token.
+	ClerkAudience string `mapstructure:"CLERK_AUDIENCE"`
+	// ClerkClockSkew is the clock skew allowed when checking session token times.
+	ClerkClockSkew time.Duration `mapstructure:"CLERK_CLOCK_SKEW"`
+	// UsernameChangeCooldown is the least time between two username changes of a user.
+	UsernameChangeCooldown time.Duration `mapstructure:"USERNAME_CHANGE_COOLDOWN"`
+	// UsernameGracePeriod is how long an old username keeps pointing to its user.
+	UsernameGracePeriod time.Duration `mapstructure:"USERNAME_GRACE_PERIOD"`
+	UsernameChangedEx   string        `mapstructure:"USERNAME_CHANGED_EX"`
+	UsernameChangedRK   string        `mapstructure:"USERNAME_CHANGED_RK"`
+	// OutboxPollInterval is how often the outbox relay looks for events to publish.
+	OutboxPollInterval time.Duration `mapstructure:"OUTBOX_POLL_INTERVAL"`
 }
 
 // LoadConfig reads configuration from file or environment variables.
@@ -41,6 +70,16 @@ func LoadConfig() (config Config, err error) {
 	viper.SetDefault("USER_CREATED_RK", "user.created")
 	viper.SetDefault("USER_CREATED_QUEUE", "customer_service_user_created")
 	viper.SetDefault("CONSUMER_TAG", "customer_service_consumer")
+	viper.SetDefault("MAX_DELIVERY_ATTEMPTS", 5)
+	viper.SetDefault("RETRY_INITIAL_DELAY", "10s")
+	viper.SetDefault("CONSUMER_WORKERS", 4)
+	viper.SetDefault("CONSUMER_PREFETCH", 16)
+	viper.SetDefault("CLERK_CLOCK_SKEW", "5s")
+	viper.SetDefault("USERNAME_CHANGE_COOLDOWN", "720h")
+	viper.SetDefault("USERNAME_GRACE_PERIOD", "336h")
+	viper.SetDefault("USERNAME_CHANGED_EX", "user_events")
+	viper.SetDefault("USERNAME_CHANGED_RK", "username.changed")
+	viper.SetDefault("OUTBOX_POLL_INTERVAL", "1s")
 
 	err = viper.ReadInConfig()
 	// It's okay if the config file is not found, we can rely on env vars.
@@ -32,6 +32,8 @@ AnchorCustomerID string    `json:"anchor_customer_id" db:"anchor_customer_id"`
 KYCStatus        string    `json:"kyc_status" db:"kyc_status"`
 ProfileImageURL  *string   `json:"profile_image_url,omitempty" db:"profile_image_url"`
 AllowSending     bool      `json:"allow_sending" db:"allow_sending"`
+// UsernameChangedAt is when the user last changed their username, if they ever did.
+UsernameChangedAt *time.Time `json:"username_changed_at,omitempty" db:"username_changed_at"`
 CreatedAt        time.Time `json:"created_at" db:"created_at"`
 UpdatedAt        time.Time `json:"updated_at" db:"updated_at"`
 }
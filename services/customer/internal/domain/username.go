@@ -0,0 +1,74 @@
+/**
+ * @description
+ * This file defines the models and errors of username changes.
+ *
+ * Key features:
+ * - `UsernameChange`: One change of a user's username, recorded in `username_history`.
+ *   Until `GraceUntil`, the old username still belongs to the user, so payments to it
+ *   are not lost and no one else can claim it.
+ * - `InvalidUsernameError` and `CooldownError` carry the details clients need to
+ *   explain a rejected change.
+ *
+ * @dependencies
+ * - "errors", "fmt", "time"
+ * - "github.com/google/uuid": For user identifiers.
+ */
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrUserNotFound is returned for a Clerk user who has not onboarded or was deleted.
+	ErrUserNotFound = errors.New("user not found")
+	// ErrUsernameTaken is returned when the username belongs to, is reserved for, or
+	// until recently belonged to another user.
+	ErrUsernameTaken = errors.New("username is already taken")
+	// ErrUsernameUnchanged is returned when the new username is the current one.
+	ErrUsernameUnchanged = errors.New("username is unchanged")
+	// ErrUsernameChangeConflict is returned when the username was changed by another
+	// request at the same time.
+	ErrUsernameChangeConflict = errors.New("username was changed by another request")
+)
+
+// ChangeUsernameRequest is the body of `PUT /users/me/username`.
+type ChangeUsernameRequest struct {
+	Username string `json:"username"`
+}
+
+// UsernameChange is one change of a user's username.
+type UsernameChange struct {
+	UserID      uuid.UUID `json:"user_id"`
+	OldUsername string    `json:"old_username"`
+	NewUsername string    `json:"new_username"`
+	ChangedAt   time.Time `json:"changed_at"`
+	// GraceUntil is when the old username stops pointing to the user.
+	GraceUntil time.Time `json:"grace_until"`
+	// NextChangeAt is when the user may change their username again.
+	NextChangeAt time.Time `json:"next_change_at"`
+}
+
+// InvalidUsernameError is returned for a username that breaks the username rules.
+type InvalidUsernameError struct {
+	// Code is one of the codes of `shared/username`.
+	Code    string
+	Message string
+}
+
+func (e *InvalidUsernameError) Error() string {
+	return e.Message
+}
+
+// CooldownError is returned when the user changed their username too recently.
+type CooldownError struct {
+	NextChangeAt time.Time
+}
+
+func (e *CooldownError) Error() string {
+	return fmt.Sprintf("username can be changed again at %s", e.NextChangeAt.Format(time.RFC3339))
+}
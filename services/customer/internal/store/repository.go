@@ -5,20 +5,32 @@
  *
  * @dependencies
  * - "context": For passing request-scoped data and cancellation signals.
- * - "fmt": For formatting error messages.
+ * - "errors", "fmt": For error values and formatting error messages.
  * - "github.com/google/uuid": For user identifiers.
+ * - "github.com/jackc/pgx/v5": For checking specific database errors.
  * - "github.com/jackc/pgx/v5/pgxpool": The PostgreSQL driver and connection pool.
+ * - "log": For logging skipped onboarding transitions.
+ * - "transfa/services/customer/internal/domain": For the domain errors.
+ * - "transfa/shared/onboarding": For advancing the user's onboarding status.
  */
 package store
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"transfa/services/customer/internal/domain"
+	"transfa/shared/onboarding"
 )
 
+// ErrUserNotFound is returned for unknown users.
+var ErrUserNotFound = domain.ErrUserNotFound
+
 // PostgresRepository is the concrete implementation for database operations.
 type PostgresRepository struct {
 	db *pgxpool.Pool
@@ -31,15 +43,42 @@ func NewPostgresRepository(db *pgxpool.Pool) *PostgresRepository {
 	}
 }
 
-// UpdateUserWithAnchorID updates a user's record with their new Anchor Customer ID.
+// GetAnchorCustomerID returns the user's Anchor Customer ID, or an empty string if no
+// Anchor customer has been created for them yet.
+func (r *PostgresRepository) GetAnchorCustomerID(ctx context.Context, userID uuid.UUID) (string, error) {
+	query := `SELECT anchor_customer_id FROM public.users WHERE id = $1`
+
+	var anchorCustomerID *string
+	err := r.db.QueryRow(ctx, query, userID).Scan(&anchorCustomerID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", fmt.Errorf("%w: with id %s", ErrUserNotFound, userID)
+		}
+		return "", fmt.Errorf("failed to query user by id: %w", err)
+	}
+
+	if anchorCustomerID == nil {
+		return "", nil
+	}
+	return *anchorCustomerID, nil
+}
+
+// UpdateUserWithAnchorID updates a user's record with their new Anchor Customer ID and
+// advances their onboarding to customer_created in the same transaction.
 // This is a critical step after successfully creating the customer in the BaaS.
 func (r *PostgresRepository) UpdateUserWithAnchorID(ctx context.Context, userID uuid.UUID, anchorCustomerID string) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
 	query := `
         UPDATE public.users
         SET anchor_customer_id = $1
         WHERE id = $2
     `
-	cmdTag, err := r.db.Exec(ctx, query, anchorCustomerID, userID)
+	cmdTag, err := tx.Exec(ctx, query, anchorCustomerID, userID)
 	if err != nil {
 		return fmt.Errorf("failed to execute update user query: %w", err)
 	}
@@ -48,5 +87,25 @@ func (r *PostgresRepository) UpdateUserWithAnchorID(ctx context.Context, userID
 		return fmt.Errorf("expected 1 row to be affected, but got %d", cmdTag.RowsAffected())
 	}
 
+	if _, err := onboarding.Advance(ctx, tx, userID, onboarding.StatusCustomerCreated, ""); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// AdvanceOnboarding moves the user's onboarding to status if the state machine allows
+// it from their current status.
+func (r *PostgresRepository) AdvanceOnboarding(ctx context.Context, userID uuid.UUID, status onboarding.Status, reason string) error {
+	advanced, err := onboarding.Advance(ctx, r.db, userID, status, reason)
+	if err != nil {
+		return err
+	}
+	if !advanced {
+		log.Printf("Onboarding of user %s is already past %s; status unchanged", userID, status)
+	}
 	return nil
 }
\ No newline at end of file
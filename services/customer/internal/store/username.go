@@ -0,0 +1,129 @@
+/**
+ * @description
+ * This file contains the queries behind username changes.
+ *
+ * @dependencies
+ * - "context", "errors", "fmt", "time"
+ * - "github.com/jackc/pgx/v5": For detecting missing rows.
+ * - "github.com/jackc/pgx/v5/pgconn": To detect unique constraint violations.
+ * - "transfa/services/customer/internal/domain": For users and username changes.
+ * - "transfa/shared/outbox": For writing events in the same transaction as the change.
+ */
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"transfa/services/customer/internal/domain"
+	"transfa/shared/outbox"
+)
+
+// OutboxSource identifies the Customer service's rows in the event outbox.
+const OutboxSource = "customer"
+
+// uniqueViolation is the PostgreSQL error code of a unique constraint violation.
+const uniqueViolation = "23505"
+
+// GetUserByClerkID returns the active user with the given Clerk ID, or an error wrapping
+// domain.ErrUserNotFound.
+func (r *PostgresRepository) GetUserByClerkID(ctx context.Context, clerkID string) (*domain.User, error) {
+	query := `
+        SELECT id, clerk_id, username, account_type, allow_sending, username_changed_at, created_at, updated_at
+        FROM public.users
+        WHERE clerk_id = $1 AND deleted_at IS NULL
+    `
+
+	var user domain.User
+	err := r.db.QueryRow(ctx, query, clerkID).Scan(
+		&user.ID,
+		&user.ClerkID,
+		&user.Username,
+		&user.AccountType,
+		&user.AllowSending,
+		&user.UsernameChangedAt,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("%w: with clerk_id %s", domain.ErrUserNotFound, clerkID)
+		}
+		return nil, fmt.Errorf("failed to query user by clerk_id: %w", err)
+	}
+	return &user, nil
+}
+
+// ChangeUsername gives the user change.NewUsername, records the change in
+// username_history and writes event to the outbox, in one transaction. It returns
+// domain.ErrUsernameTaken if the username belongs to another user, is reserved for one
+// who is onboarding, or is an old username of another user still in its grace period.
+// It returns
+// domain.ErrUsernameChangeConflict if the user's username is no longer
+// change.OldUsername or was changed after notChangedSince.
+func (r *PostgresRepository) ChangeUsername(ctx context.Context, clerkID string, change domain.UsernameChange, notChangedSince time.Time, event outbox.Event) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Step 1: Make sure no one else holds the username. The unique constraint on
+	// users.username still guards against a user claiming it concurrently.
+	var held bool
+	err = tx.QueryRow(ctx, `
+        SELECT EXISTS (
+            SELECT 1 FROM public.username_reservations
+            WHERE username = $1 AND clerk_id <> $2 AND expires_at > now()
+        ) OR EXISTS (
+            SELECT 1 FROM public.username_history
+            WHERE old_username = $1 AND user_id <> $3 AND grace_until > now()
+        )
+    `, change.NewUsername, clerkID, change.UserID).Scan(&held)
+	if err != nil {
+		return fmt.Errorf("failed to check username: %w", err)
+	}
+	if held {
+		return domain.ErrUsernameTaken
+	}
+
+	// Step 2: Change the username, unless another request changed it first.
+	tag, err := tx.Exec(ctx, `
+        UPDATE public.users
+        SET username = $1, username_changed_at = $2
+        WHERE id = $3 AND username = $4
+          AND (username_changed_at IS NULL OR username_changed_at <= $5)
+    `, change.NewUsername, change.ChangedAt, change.UserID, change.OldUsername, notChangedSince)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+			return fmt.Errorf("%w: %v", domain.ErrUsernameTaken, err)
+		}
+		return fmt.Errorf("failed to update username: %w", err)
+	}
+	if tag.RowsAffected() != 1 {
+		return domain.ErrUsernameChangeConflict
+	}
+
+	// Step 3: Keep the old username pointing to the user for the grace period.
+	_, err = tx.Exec(ctx, `
+        INSERT INTO public.username_history (user_id, old_username, new_username, changed_at, grace_until)
+        VALUES ($1, $2, $3, $4, $5)
+    `, change.UserID, change.OldUsername, change.NewUsername, change.ChangedAt, change.GraceUntil)
+	if err != nil {
+		return fmt.Errorf("failed to record username change: %w", err)
+	}
+
+	if err := outbox.Insert(ctx, tx, OutboxSource, event); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
@@ -12,7 +12,7 @@
  *
  * @dependencies
  * - "bytes", "context", "encoding/json", "fmt", "io", "log", "net/http", "time"
- * - "transfa/services/customer/internal/domain": For event data structures.
+ * - "transfa/shared/events": For event data structures.
  */
 package anchor
 
@@ -26,7 +26,7 @@ import (
 	"net/http"
 	"time"
 
-	"transfa/services/customer/internal/domain"
+	"transfa/shared/events"
 )
 
 // Client is a client for interacting with the Anchor API.
@@ -48,7 +48,7 @@ func NewClient(baseURL, apiKey string) *Client {
 }
 
 // CreateIndividualCustomer sends a request to Anchor to create a new individual customer.
-func (c *Client) CreateIndividualCustomer(ctx context.Context, event domain.UserCreatedEvent) (string, error) {
+func (c *Client) CreateIndividualCustomer(ctx context.Context, event events.UserCreated) (string, error) {
 	// Minimal payload, assuming email and phone are not available from the initial event.
 	// In a real-world scenario, you might need to fetch these from your DB or Clerk.
 	payload := map[string]interface{}{
@@ -111,7 +111,7 @@ func (c *Client) CreateIndividualCustomer(ctx context.Context, event domain.User
 }
 
 // TriggerIndividualVerification sends a request to Anchor to start the KYC verification process.
-func (c *Client) TriggerIndividualVerification(ctx context.Context, anchorCustomerID string, kycDetails *domain.KYCDetails) error {
+func (c *Client) TriggerIndividualVerification(ctx context.Context, anchorCustomerID string, kycDetails *events.KYCDetails) error {
 	payload := map[string]interface{}{
 		"data": map[string]interface{}{
 			"type": "Verification",
@@ -162,5 +162,5 @@ func (c *Client) setHeaders(req *http.Request) {
 }
 
 // NOTE: CreateBusinessCustomer and TriggerBusinessVerification would be implemented here
-// in a similar fashion, but are omitted as the provided UserCreatedEvent does not
+// in a similar fashion, but are omitted as the `user.created` event does not
 // contain enough information to satisfy the Anchor API's requirements for business onboarding.
\ No newline at end of file
@@ -18,7 +18,6 @@ import (
 	"context"
 	"log"
 	"net/http"
-	"os"
 	"os/signal"
 	"syscall"
 	"time"
@@ -28,7 +27,7 @@ import (
 	"transfa/services/notification/internal/app"
 	"transfa/services/notification/internal/config"
 	"transfa/services/notification/internal/store"
-	"transfa/services/notification/pkg/rabbitmq"
+	"transfa/shared/messaging"
 )
 
 func main() {
@@ -51,7 +50,7 @@ func main() {
 	log.Println("Database connection pool established.")
 
 	// Initialize RabbitMQ publisher
-	publisher, err := rabbitmq.NewPublisher(cfg.RabbitMQURL)
+	publisher, err := messaging.NewPublisher(cfg.RabbitMQURL)
 	if err != nil {
 		log.Fatalf("unable to create RabbitMQ publisher: %v", err)
 	}
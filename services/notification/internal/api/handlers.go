@@ -9,6 +9,7 @@
  * - "log": For logging errors.
  * - "net/http": For standard HTTP handling.
  * - "transfa/services/notification/internal/app": Imports the application service layer.
+ * - "transfa/shared/apierror": For JSON:API error responses.
  */
 package api
 
@@ -18,6 +19,7 @@ import (
 	"net/http"
 
 	"transfa/services/notification/internal/app"
+	"transfa/shared/apierror"
 )
 
 // NotificationHandler holds dependencies for the notification-related HTTP handlers.
@@ -38,7 +40,7 @@ func (h *NotificationHandler) AnchorWebhookHandler(w http.ResponseWriter, r *htt
 	payload, err := io.ReadAll(r.Body)
 	if err != nil {
 		log.Printf("Error reading webhook body: %v", err)
-		http.Error(w, "Cannot read request body", http.StatusBadRequest)
+		apierror.Write(w, http.StatusBadRequest, "cannot read request body")
 		return
 	}
 	defer r.Body.Close()
@@ -47,7 +49,7 @@ func (h *NotificationHandler) AnchorWebhookHandler(w http.ResponseWriter, r *htt
 	signature := r.Header.Get("x-anchor-signature")
 	if signature == "" {
 		log.Println("Missing x-anchor-signature header")
-		http.Error(w, "Missing signature header", http.StatusBadRequest)
+		apierror.Write(w, http.StatusBadRequest, "missing signature header")
 		return
 	}
 
@@ -56,7 +58,7 @@ func (h *NotificationHandler) AnchorWebhookHandler(w http.ResponseWriter, r *htt
 		// Log the specific error, but return a generic error to the client.
 		// The error could be due to a bad signature or an internal processing failure.
 		log.Printf("Error processing Anchor webhook: %v", err)
-		http.Error(w, "Webhook processing failed", http.StatusInternalServerError)
+		apierror.Write(w, http.StatusInternalServerError, "webhook processing failed")
 		return
 	}
 
@@ -7,23 +7,30 @@
  * @dependencies
  * - "context": For passing request-scoped data and cancellation signals.
  * - "transfa/services/notification/internal/domain": Imports the core data models.
+ * - "github.com/google/uuid": For user identifiers.
+ * - "transfa/shared/messaging": For the messages being published.
+ * - "transfa/shared/onboarding": For onboarding statuses.
  */
 package app
 
 import (
 	"context"
 
+	"github.com/google/uuid"
 	"transfa/services/notification/internal/domain"
+	"transfa/shared/messaging"
+	"transfa/shared/onboarding"
 )
 
 // Repository defines the interface for data persistence operations.
 // It abstracts the database layer from the core application logic.
 type Repository interface {
 	GetUserByAnchorID(ctx context.Context, anchorID string) (*domain.User, error)
+	AdvanceOnboarding(ctx context.Context, userID uuid.UUID, status onboarding.Status, reason string) error
 }
 
 // Publisher defines the interface for publishing messages to a message broker.
 type Publisher interface {
-	Publish(ctx context.Context, body []byte, exchange, routingKey string) error
+	PublishMessage(ctx context.Context, exchange, routingKey string, msg messaging.Message) error
 	Close()
 }
\ No newline at end of file
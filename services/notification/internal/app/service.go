@@ -7,6 +7,9 @@
  * @dependencies
  * - Go standard libraries: "context", "crypto/hmac", "crypto/sha1", "crypto/subtle", "encoding/base64", "encoding/json", "fmt", "log"
  * - Internal packages: "config", "domain", "store" for application-specific logic and models.
+ * - "transfa/shared/events": For the events published to other services.
+ * - "transfa/shared/messaging": For recognising unroutable publishes.
+ * - "transfa/shared/onboarding": For recording KYC rejections in the user's onboarding status.
  */
 package app
 
@@ -24,8 +27,14 @@ import (
 	"transfa/services/notification/internal/config"
 	"transfa/services/notification/internal/domain"
 	"transfa/services/notification/internal/store"
+	"transfa/shared/events"
+	"transfa/shared/messaging"
+	"transfa/shared/onboarding"
 )
 
+// EventProducer names the Notification service in the events it publishes.
+const EventProducer = "notification"
+
 // Service provides the application's business logic for notifications and webhooks.
 type Service struct {
 	repo      Repository
@@ -107,22 +116,20 @@ func (s *Service) handleCustomerIdentificationApproved(ctx context.Context, webh
 	}
 
 	// Create and publish the internal event.
-	event := domain.CustomerVerifiedEvent{
+	envelope, err := events.New(EventProducer, events.CustomerVerified{
 		UserID:           user.ID,
 		AnchorCustomerID: anchorCustomerID,
-	}
-
-	eventBody, err := json.Marshal(event)
+	}, webhookMetadata(webhook))
 	if err != nil {
-		return fmt.Errorf("failed to marshal CustomerVerifiedEvent: %w", err)
+		return err
 	}
 
-	err = s.publisher.Publish(ctx, eventBody, s.config.CustomerVerifiedEx, s.config.CustomerVerifiedRK)
+	err = events.Publish(ctx, s.publisher, s.config.CustomerVerifiedEx, s.config.CustomerVerifiedRK, envelope)
 	if err != nil {
-		return fmt.Errorf("failed to publish CustomerVerifiedEvent: %w", err)
+		return fmt.Errorf("failed to publish customer.verified event: %w", err)
 	}
 
-	log.Printf("Successfully published CustomerVerifiedEvent for UserID: %s", user.ID)
+	log.Printf("Successfully published customer.verified event %s for UserID: %s", envelope.EventID, user.ID)
 	return nil
 }
 
@@ -145,22 +152,39 @@ func (s *Service) handleCustomerIdentificationRejected(ctx context.Context, webh
 	// For now, we'll use a generic reason.
 	rejectionReason := "KYC details could not be verified."
 
-	event := domain.CustomerVerificationRejectedEvent{
+	// Record the rejection before publishing, so a failure here is retried by Anchor
+	// redelivering the webhook.
+	if err := s.repo.AdvanceOnboarding(ctx, user.ID, onboarding.StatusKYCRejected, rejectionReason); err != nil {
+		return fmt.Errorf("failed to record KYC rejection for user %s: %w", user.ID, err)
+	}
+
+	envelope, err := events.New(EventProducer, events.CustomerVerificationRejected{
 		UserID:           user.ID,
 		AnchorCustomerID: anchorCustomerID,
 		Reason:           rejectionReason,
-	}
-	eventBody, err := json.Marshal(event)
+	}, webhookMetadata(webhook))
 	if err != nil {
-		return fmt.Errorf("failed to marshal CustomerVerificationRejectedEvent: %w", err)
+		return err
 	}
 
-	err = s.publisher.Publish(ctx, eventBody, s.config.CustomerVerificationRejectedEx, s.config.CustomerVerificationRejectedRK)
+	err = events.Publish(ctx, s.publisher, s.config.CustomerVerificationRejectedEx, s.config.CustomerVerificationRejectedRK, envelope)
+	if errors.Is(err, messaging.ErrUnroutable) {
+		// No service consumes this event yet, so the broker has nowhere to route it.
+		// Retrying the webhook would not change that.
+		log.Printf("WARNING: customer.verification.rejected event for UserID %s was not routed to any queue: %v", user.ID, err)
+		return nil
+	}
 	if err != nil {
-		return fmt.Errorf("failed to publish CustomerVerificationRejectedEvent: %w", err)
+		return fmt.Errorf("failed to publish customer.verification.rejected event: %w", err)
 	}
 
-	log.Printf("Successfully published CustomerVerificationRejectedEvent for UserID: %s", user.ID)
+	log.Printf("Successfully published customer.verification.rejected event %s for UserID: %s", envelope.EventID, user.ID)
 	// TODO: In a future step, consume this event to send a push notification to the user.
 	return nil
-}
\ No newline at end of file
+}
+
+// webhookMetadata correlates the events published for a webhook with the Anchor event
+// that delivered it, so they can be traced back to the webhook in Anchor's logs.
+func webhookMetadata(webhook domain.AnchorWebhookPayload) events.Metadata {
+	return events.Metadata{CorrelationID: webhook.Data.ID}
+}
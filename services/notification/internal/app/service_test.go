@@ -0,0 +1,130 @@
+package app
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"fmt"
+	"testing"
+
+	"github.com/google/uuid"
+	"transfa/services/notification/internal/config"
+	"transfa/services/notification/internal/domain"
+	"transfa/services/notification/internal/store"
+	"transfa/shared/events"
+	"transfa/shared/eventtest"
+	"transfa/shared/messaging"
+	"transfa/shared/messaging/memory"
+	"transfa/shared/onboarding"
+)
+
+const accountQueue = "account_service_customer_verified"
+
+// fakeRepository knows the users of the onboarding scenario and records the onboarding
+// statuses they are advanced to.
+type fakeRepository struct {
+	statuses []onboarding.State
+}
+
+func (r *fakeRepository) AdvanceOnboarding(ctx context.Context, userID uuid.UUID, status onboarding.Status, reason string) error {
+	r.statuses = append(r.statuses, onboarding.State{UserID: userID, Status: status, Reason: reason})
+	return nil
+}
+
+func (*fakeRepository) GetUserByAnchorID(ctx context.Context, anchorID string) (*domain.User, error) {
+	if anchorID != eventtest.Onboarding.AnchorCustomerID {
+		return nil, store.ErrUserNotFound
+	}
+	return &domain.User{ID: eventtest.Onboarding.UserID}, nil
+}
+
+// signedWebhook returns an Anchor webhook of the given type for the scenario's customer,
+// and its signature.
+func signedWebhook(secret, eventType string) ([]byte, string) {
+	payload := []byte(fmt.Sprintf(
+		`{"data":{"id":%q,"type":%q,"attributes":{},"relationships":{"customer":{"id":%q,"type":"IndividualCustomer"}}}}`,
+		eventtest.Onboarding.AnchorWebhookID, eventType, eventtest.Onboarding.AnchorCustomerID,
+	))
+	mac := hmac.New(sha1.New, []byte(secret))
+	mac.Write(payload)
+	return payload, base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// TestApprovedWebhookPublishesCustomerVerified is the Notification service's leg of the
+// onboarding scenario: Anchor's approval of the customer created for `user.created`
+// publishes the `customer.verified` event the Account service consumes.
+func TestApprovedWebhookPublishesCustomerVerified(t *testing.T) {
+	cfg := config.Config{
+		AnchorWebhookSecret: "whsec",
+		CustomerVerifiedEx:  "customer_events",
+		CustomerVerifiedRK:  "customer.verified",
+	}
+	broker := memory.NewBroker(messaging.RetryPolicy{})
+	broker.Bind(cfg.CustomerVerifiedEx, cfg.CustomerVerifiedRK, accountQueue)
+	service := NewService(&fakeRepository{}, broker, cfg)
+
+	payload, signature := signedWebhook(cfg.AnchorWebhookSecret, "customer.identification.approved")
+	if err := service.ProcessAnchorWebhook(context.Background(), payload, signature); err != nil {
+		t.Fatalf("ProcessAnchorWebhook() error = %v", err)
+	}
+
+	var got events.CustomerVerified
+	env := eventtest.Received(t, broker, accountQueue, &got)
+
+	if got != eventtest.CustomerVerified() {
+		t.Errorf("customer.verified = %+v, want %+v", got, eventtest.CustomerVerified())
+	}
+	if env.Producer != EventProducer {
+		t.Errorf("producer = %q, want %q", env.Producer, EventProducer)
+	}
+	if env.CorrelationID != eventtest.Onboarding.AnchorWebhookID {
+		t.Errorf("correlation ID = %q, want the webhook ID %q", env.CorrelationID, eventtest.Onboarding.AnchorWebhookID)
+	}
+}
+
+// TestWebhookWithBadSignatureIsRejected checks that nothing is published for a webhook
+// that was not signed with the shared secret.
+func TestWebhookWithBadSignatureIsRejected(t *testing.T) {
+	cfg := config.Config{
+		AnchorWebhookSecret: "whsec",
+		CustomerVerifiedEx:  "customer_events",
+		CustomerVerifiedRK:  "customer.verified",
+	}
+	broker := memory.NewBroker(messaging.RetryPolicy{})
+	broker.Bind(cfg.CustomerVerifiedEx, cfg.CustomerVerifiedRK, accountQueue)
+	service := NewService(&fakeRepository{}, broker, cfg)
+
+	payload, signature := signedWebhook("another secret", "customer.identification.approved")
+	if err := service.ProcessAnchorWebhook(context.Background(), payload, signature); err == nil {
+		t.Fatal("ProcessAnchorWebhook() succeeded, want a signature error")
+	}
+	if msgs := broker.Messages(accountQueue); len(msgs) != 0 {
+		t.Errorf("published %d messages, want 0", len(msgs))
+	}
+}
+
+// TestRejectedWebhookRecordsKYCRejection checks that Anchor's rejection of the scenario's
+// customer moves their onboarding to kyc_rejected with the reason.
+func TestRejectedWebhookRecordsKYCRejection(t *testing.T) {
+	cfg := config.Config{
+		AnchorWebhookSecret:            "whsec",
+		CustomerVerificationRejectedEx: "customer_events",
+		CustomerVerificationRejectedRK: "customer.verification.rejected",
+	}
+	repo := &fakeRepository{}
+	service := NewService(repo, memory.NewBroker(messaging.RetryPolicy{}), cfg)
+
+	payload, signature := signedWebhook(cfg.AnchorWebhookSecret, "customer.identification.rejected")
+	if err := service.ProcessAnchorWebhook(context.Background(), payload, signature); err != nil {
+		t.Fatalf("ProcessAnchorWebhook() error = %v", err)
+	}
+
+	if len(repo.statuses) != 1 {
+		t.Fatalf("advanced onboarding %d times, want 1", len(repo.statuses))
+	}
+	got := repo.statuses[0]
+	if got.UserID != eventtest.Onboarding.UserID || got.Status != onboarding.StatusKYCRejected || got.Reason == "" {
+		t.Errorf("onboarding advanced to %+v, want %s with a reason", got, onboarding.StatusKYCRejected)
+	}
+}
@@ -1,18 +1,17 @@
 /**
  * @description
  * This file defines the domain models and Data Transfer Objects (DTOs) for the
- * Notification service. It includes structures for parsing incoming webhooks from Anchor
- * and for creating outgoing events to be published to RabbitMQ.
+ * Notification service. It includes structures for parsing incoming webhooks from Anchor.
+ * The events published to RabbitMQ are defined in the shared events package.
  *
  * @dependencies
- * - "time": For timestamping.
+ * - "encoding/json": For raw JSON payloads.
  * - "github.com/google/uuid": For universally unique identifiers.
  */
 package domain
 
 import (
 	"encoding/json"
-	"time"
 
 	"github.com/google/uuid"
 )
@@ -41,19 +40,6 @@ type AnchorWebhookPayload struct {
 	Data AnchorWebhookData `json:"data"`
 }
 
-// CustomerVerifiedEvent is the payload published to RabbitMQ when a customer's KYC is approved.
-type CustomerVerifiedEvent struct {
-	UserID           uuid.UUID `json:"user_id"`
-	AnchorCustomerID string    `json:"anchor_customer_id"`
-}
-
-// CustomerVerificationRejectedEvent is the payload for when KYC fails.
-type CustomerVerificationRejectedEvent struct {
-	UserID           uuid.UUID `json:"user_id"`
-	AnchorCustomerID string    `json:"anchor_customer_id"`
-	Reason           string    `json:"reason"` // We can add more details from the webhook later
-}
-
 // User is a simplified representation of our user table, needed to find the
 // internal user ID from an Anchor customer ID.
 type User struct {
@@ -8,9 +8,12 @@
  * - "context": For passing request-scoped data and cancellation signals.
  * - "errors": For handling specific database errors like "no rows".
  * - "fmt": For formatting error messages.
+ * - "log": For logging skipped onboarding transitions.
+ * - "github.com/google/uuid": For user identifiers.
  * - "github.com/jackc/pgx/v5": For checking specific database errors.
  * - "github.com/jackc/pgx/v5/pgxpool": For managing the database connection pool.
  * - "transfa/services/notification/internal/domain": Imports the User model.
+ * - "transfa/shared/onboarding": For advancing the user's onboarding status.
  */
 package store
 
@@ -18,10 +21,13 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log"
 
+	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"transfa/services/notification/internal/domain"
+	"transfa/shared/onboarding"
 )
 
 var ErrUserNotFound = errors.New("user not found")
@@ -53,4 +59,17 @@ func (r *PostgresRepository) GetUserByAnchorID(ctx context.Context, anchorID str
 	}
 
 	return &user, nil
-}
\ No newline at end of file
+}
+
+// AdvanceOnboarding moves the user's onboarding to status if the state machine allows
+// it from their current status.
+func (r *PostgresRepository) AdvanceOnboarding(ctx context.Context, userID uuid.UUID, status onboarding.Status, reason string) error {
+	advanced, err := onboarding.Advance(ctx, r.db, userID, status, reason)
+	if err != nil {
+		return err
+	}
+	if !advanced {
+		log.Printf("Onboarding of user %s is already past %s; status unchanged", userID, status)
+	}
+	return nil
+}
@@ -2,42 +2,119 @@
  * @description
  * Main entry point for the Scheduler microservice.
  *
- * This file initializes and starts the service. Unlike other services, its primary
- * role is to run scheduled background tasks (cron jobs) rather than serving a
- * HTTP API. The included health check is for monitoring purposes.
- * In later steps, this will be expanded to include the cron job scheduler and job definitions.
+ * This file acts as the composition root for the application. Unlike other services, its
+ * primary role is to run scheduled background jobs rather than serving an HTTP API; the
+ * included health check is for monitoring purposes. It is responsible for:
+ * - Loading configuration from environment variables.
+ * - Establishing connections to external services (PostgreSQL).
+ * - Wiring together the job runner, its repository and the internal service clients.
+ * - Registering the scheduled jobs and starting the runner and the health check server.
  *
  * @dependencies
- * - Standard "fmt", "log", "net/http", "os" packages.
+ * - Standard library packages for context, logging, HTTP, OS signals.
+ * - External libraries for pgxpool.
+ * - All internal packages for the scheduler service.
  */
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net/http"
-	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"transfa/services/scheduler/internal/app"
+	"transfa/services/scheduler/internal/config"
+	"transfa/services/scheduler/internal/store"
+	"transfa/services/scheduler/pkg/subscription"
+	"transfa/services/scheduler/pkg/transaction"
 )
 
 func main() {
+	// Load configuration
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Fatalf("could not load config: %v", err)
+	}
+
+	// Create context that listens for the interrupt signal from the OS.
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	// Initialize database connection pool
+	dbpool, err := pgxpool.New(ctx, cfg.DatabaseURL)
+	if err != nil {
+		log.Fatalf("unable to create connection pool: %v", err)
+	}
+	defer dbpool.Close()
+	log.Println("Database connection pool established.")
+
+	// Wire application components
+	repository := store.NewPostgresRepository(dbpool)
+	subscriptionClient := subscription.NewClient(cfg.SubscriptionServiceURL, cfg.InternalAPIKey)
+	transactionClient := transaction.NewClient(cfg.TransactionServiceURL, cfg.InternalAPIKey)
+	runner := app.NewRunner(repository, cfg.PollInterval, cfg.RetryDelay)
+
+	// Register jobs
+	if cfg.SubscriptionFee > 0 {
+		if err := runner.Register(app.JobSubscriptionDebit, cfg.SubscriptionDebitSchedule, app.NewSubscriptionDebitJob(subscriptionClient, transactionClient, cfg.SubscriptionFee)); err != nil {
+			log.Fatalf("could not register job: %v", err)
+		}
+	} else {
+		log.Println("WARNING: SUBSCRIPTION_FEE is not set; subscription debits are disabled.")
+	}
+	if err := runner.Register(app.JobFreeTransferReset, cfg.FreeTransferResetSchedule, app.NewFreeTransferResetJob(subscriptionClient)); err != nil {
+		log.Fatalf("could not register job: %v", err)
+	}
+	if err := runner.Register(app.JobMoneyDropRefund, cfg.MoneyDropRefundSchedule, app.NewMoneyDropRefundJob(transactionClient)); err != nil {
+		log.Fatalf("could not register job: %v", err)
+	}
+	if err := runner.Register(app.JobReconciliation, cfg.ReconciliationSchedule, app.NewReconciliationJob(transactionClient, cfg.ReconcileAfter)); err != nil {
+		log.Fatalf("could not register job: %v", err)
+	}
+
+	runner.Start(ctx)
+
 	// A simple health check handler to verify the service is running.
-	http.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
 		fmt.Fprintln(w, `{"status": "ok"}`)
 	})
 
-	serviceName := "Scheduler Service"
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080" // Default port if not specified, mainly for health checks.
+	srv := &http.Server{
+		Addr:    ":" + cfg.Port,
+		Handler: mux,
 	}
 
-	log.Printf("%s is starting...", serviceName)
-	// In a real implementation, the cron scheduler would be started here.
-	// For now, we just start the health check server.
+	go func() {
+		log.Printf("Scheduler Service is starting on port %s...", cfg.Port)
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatalf("listen: %s\n", err)
+		}
+	}()
 
-	if err := http.ListenAndServe(":"+port, nil); err != nil {
-		log.Fatalf("Failed to start server for %s: %v", serviceName, err)
+	// Listen for the interrupt signal.
+	<-ctx.Done()
+
+	// Restore default behavior on the interrupt signal and notify user of shutdown.
+	stop()
+	log.Println("shutting down gracefully, press Ctrl+C again to force")
+
+	// Runs interrupted by the shutdown are resumed by the next leader.
+	runner.Wait()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Fatalf("Server forced to shutdown: %v", err)
 	}
+
+	log.Println("Server exiting")
 }
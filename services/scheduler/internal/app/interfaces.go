@@ -0,0 +1,52 @@
+/**
+ * @description
+ * This file defines the interfaces (ports) for the Scheduler service's application logic.
+ * These interfaces define the contracts for external dependencies, such as the database
+ * and the internal APIs of the services the jobs act on, allowing for a clean separation
+ * of concerns and easier testing.
+ *
+ * @dependencies
+ * - "context": For passing request-scoped data and cancellation signals.
+ * - "time": For time-based queries.
+ * - "github.com/google/uuid": For record identifiers.
+ * - "transfa/services/scheduler/internal/domain": For core data models.
+ * - "transfa/services/scheduler/pkg/subscription": For subscription results.
+ * - "transfa/services/scheduler/pkg/transaction": For transaction results.
+ */
+package app
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"transfa/services/scheduler/internal/domain"
+	"transfa/services/scheduler/pkg/subscription"
+	"transfa/services/scheduler/pkg/transaction"
+)
+
+// Repository defines the interface for job run persistence and leader election.
+type Repository interface {
+	TryLockJob(ctx context.Context, jobName string) (release func(), acquired bool, err error)
+	GetLatestRun(ctx context.Context, jobName string) (*domain.JobRun, error)
+	StartRun(ctx context.Context, jobName string, scheduledFor time.Time) (*domain.JobRun, error)
+	FinishRun(ctx context.Context, runID uuid.UUID, status string, lastError *string) error
+	IsItemSucceeded(ctx context.Context, runID uuid.UUID, itemKey string) (bool, error)
+	RecordItem(ctx context.Context, runID uuid.UUID, itemKey, status string, lastError *string) error
+}
+
+// SubscriptionClient defines the interface for communicating with the Subscription service.
+type SubscriptionClient interface {
+	ListDue(ctx context.Context, before time.Time) ([]subscription.Subscription, error)
+	SettlePeriod(ctx context.Context, userID uuid.UUID, periodEndsAt time.Time, outcome string, attempt int) (*subscription.Subscription, error)
+	ResetUsage(ctx context.Context, period time.Time) (int64, error)
+}
+
+// TransactionClient defines the interface for communicating with the Transaction service.
+type TransactionClient interface {
+	ChargeSubscriptionFee(ctx context.Context, userID uuid.UUID, amount int64, idempotencyKey string) (*transaction.Transaction, error)
+	ListExpiredMoneyDrops(ctx context.Context, before time.Time) ([]uuid.UUID, error)
+	RefundMoneyDrop(ctx context.Context, dropID uuid.UUID) (*transaction.MoneyDropRefund, error)
+	ListPendingTransactions(ctx context.Context, before time.Time) ([]uuid.UUID, error)
+	ReconcileTransaction(ctx context.Context, txID uuid.UUID) (*transaction.Transaction, error)
+}
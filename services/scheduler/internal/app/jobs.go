@@ -0,0 +1,199 @@
+/**
+ * @description
+ * This file contains the scheduled jobs run by the Scheduler service. Jobs hold no money
+ * logic of their own: they find due work and ask the owning service to perform it
+ * through its internal API, and every call they make is idempotent.
+ *
+ * Key features:
+ * - Subscription debit: bills each subscription whose period has ended, then renews it,
+ *   marks it past due, or lets it lapse if auto-renewal was turned off.
+ * - Free transfer reset: resets monthly free external transfer counts.
+ * - Money Drop refund: expires Money Drops past their expiry and refunds unclaimed funds.
+ * - Transfer reconciliation: settles transfers whose outcome was not known when they were
+ *   made, from the status Anchor reports for them.
+ *
+ * @dependencies
+ * - Go standard libraries: "context", "fmt", "log", "time"
+ * - "transfa/services/scheduler/pkg/subscription": For subscription records.
+ * - "transfa/services/scheduler/pkg/transaction": For transaction statuses.
+ */
+package app
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"transfa/services/scheduler/pkg/subscription"
+	"transfa/services/scheduler/pkg/transaction"
+)
+
+// Names of the jobs registered by the Scheduler service.
+const (
+	JobSubscriptionDebit = "subscription_debit"
+	JobFreeTransferReset = "free_transfer_reset"
+	JobMoneyDropRefund   = "money_drop_refund"
+	JobReconciliation    = "transfer_reconciliation"
+)
+
+// NewSubscriptionDebitJob returns the job that bills subscriptions whose current period
+// ended at or before the run's scheduled time, charging fee (in kobo) for each renewal.
+func NewSubscriptionDebitJob(subs SubscriptionClient, txs TransactionClient, fee int64) Job {
+	return JobFunc(func(ctx context.Context, run *Run) error {
+		due, err := subs.ListDue(ctx, run.ScheduledFor)
+		if err != nil {
+			return err
+		}
+
+		var errs itemErrors
+		for _, sub := range due {
+			sub := sub
+			if !sub.CurrentPeriodEndsAt.Valid {
+				log.Printf("WARNING: Subscription %s is due but has no period end; skipping", sub.ID)
+				continue
+			}
+			periodEnd := sub.CurrentPeriodEndsAt.Time
+			key := fmt.Sprintf("%s:%d", sub.UserID, periodEnd.Unix())
+
+			errs.add(run.Item(ctx, key, func(ctx context.Context) error {
+				return billPeriod(ctx, subs, txs, fee, sub)
+			}))
+		}
+		return errs.err(len(due))
+	})
+}
+
+// billPeriod settles one ended billing period of a subscription.
+func billPeriod(ctx context.Context, subs SubscriptionClient, txs TransactionClient, fee int64, sub subscription.Subscription) error {
+	periodEnd := sub.CurrentPeriodEndsAt.Time
+
+	if !sub.AutoRenew {
+		_, err := subs.SettlePeriod(ctx, sub.UserID, periodEnd, subscription.PeriodLapsed, 0)
+		return err
+	}
+
+	// The key is unique per period and per payment attempt, so every run until the
+	// attempt is settled reuses the same debit. A new attempt, and with it a new debit,
+	// only starts once the previous one has been recorded as failed.
+	attempt := sub.PaymentAttempts + 1
+	idempotencyKey := fmt.Sprintf("subscription_fee:%s:%d:%d", sub.UserID, periodEnd.Unix(), attempt)
+	tx, err := txs.ChargeSubscriptionFee(ctx, sub.UserID, fee, idempotencyKey)
+	if err != nil {
+		return err
+	}
+
+	// A debit left pending by an earlier run is settled against Anchor before the period
+	// is; while its outcome is unknown the period stays due and is retried on this key.
+	if tx.Status == transaction.StatusPending {
+		if tx, err = txs.ReconcileTransaction(ctx, tx.ID); err != nil {
+			return err
+		}
+	}
+
+	switch tx.Status {
+	case transaction.StatusCompleted:
+		_, err = subs.SettlePeriod(ctx, sub.UserID, periodEnd, subscription.PeriodRenewed, 0)
+	case transaction.StatusFailed:
+		log.Printf("Subscription fee debit %s failed for user %s on attempt %d; marking past due", tx.ID, sub.UserID, attempt)
+		_, err = subs.SettlePeriod(ctx, sub.UserID, periodEnd, subscription.PeriodPaymentFailed, attempt)
+	default:
+		err = fmt.Errorf("subscription fee debit %s for user %s is still %s", tx.ID, sub.UserID, tx.Status)
+	}
+	return err
+}
+
+// NewFreeTransferResetJob returns the job that resets every user's free external
+// transfer count for the month the run was scheduled in. A retried or late run resets
+// the same month, which leaves usage already counted in it alone.
+func NewFreeTransferResetJob(subs SubscriptionClient) Job {
+	return JobFunc(func(ctx context.Context, run *Run) error {
+		reset, err := subs.ResetUsage(ctx, run.ScheduledFor)
+		if err != nil {
+			return err
+		}
+		log.Printf("Reset free transfer usage for %s on %d subscriptions", run.ScheduledFor.UTC().Format("2006-01"), reset)
+		return nil
+	})
+}
+
+// NewMoneyDropRefundJob returns the job that refunds Money Drops that expired at or
+// before the run's scheduled time. A drop whose claim payouts are still pending fails its item
+// and is refunded by a later run, once they have settled.
+func NewMoneyDropRefundJob(txs TransactionClient) Job {
+	return JobFunc(func(ctx context.Context, run *Run) error {
+		ids, err := txs.ListExpiredMoneyDrops(ctx, run.ScheduledFor)
+		if err != nil {
+			return err
+		}
+
+		var errs itemErrors
+		for _, id := range ids {
+			id := id
+			errs.add(run.Item(ctx, id.String(), func(ctx context.Context) error {
+				_, err := txs.RefundMoneyDrop(ctx, id)
+				return err
+			}))
+		}
+		return errs.err(len(ids))
+	})
+}
+
+// NewReconciliationJob returns the job that reconciles transactions still pending after
+// minAge, which leaves transfers that are still being made alone. A transaction Anchor
+// is still processing stays pending for a later run, and one that could not be looked
+// up, for example because Anchor was unavailable, fails its item.
+func NewReconciliationJob(txs TransactionClient, minAge time.Duration) Job {
+	return JobFunc(func(ctx context.Context, run *Run) error {
+		ids, err := txs.ListPendingTransactions(ctx, run.ScheduledFor.Add(-minAge))
+		if err != nil {
+			return err
+		}
+
+		var errs itemErrors
+		var stillPending int
+		for _, id := range ids {
+			id := id
+			errs.add(run.Item(ctx, id.String(), func(ctx context.Context) error {
+				tx, err := txs.ReconcileTransaction(ctx, id)
+				if err != nil {
+					return err
+				}
+				if tx.Status == transaction.StatusPending {
+					stillPending++
+				}
+				return nil
+			}))
+		}
+		if stillPending > 0 {
+			log.Printf("%d of %d pending transactions are still being processed by Anchor", stillPending, len(ids))
+		}
+		return errs.err(len(ids))
+	})
+}
+
+// itemErrors collects the failures of the items in a run, so that one failing item does
+// not stop the others from being processed.
+type itemErrors struct {
+	failed int
+	first  error
+}
+
+// add records an item's outcome.
+func (e *itemErrors) add(err error) {
+	if err == nil {
+		return
+	}
+	if e.first == nil {
+		e.first = err
+	}
+	e.failed++
+}
+
+// err summarises the failures out of total items, or returns nil if every item succeeded.
+func (e *itemErrors) err(total int) error {
+	if e.failed == 0 {
+		return nil
+	}
+	return fmt.Errorf("%d of %d items failed, first error: %w", e.failed, total, e.first)
+}
@@ -0,0 +1,127 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"transfa/services/scheduler/internal/domain"
+	"transfa/services/scheduler/pkg/subscription"
+	"transfa/services/scheduler/pkg/transaction"
+)
+
+// fakeSubscriptionClient holds a single subscription and applies settled periods to it
+// as the Subscription service does. Methods a test does not need are left to the
+// embedded SubscriptionClient and panic if called.
+type fakeSubscriptionClient struct {
+	SubscriptionClient
+
+	sub subscription.Subscription
+}
+
+func (c *fakeSubscriptionClient) ListDue(ctx context.Context, before time.Time) ([]subscription.Subscription, error) {
+	if c.sub.Status == "cancelled" || c.sub.CurrentPeriodEndsAt.Time.After(before) {
+		return nil, nil
+	}
+	return []subscription.Subscription{c.sub}, nil
+}
+
+func (c *fakeSubscriptionClient) SettlePeriod(ctx context.Context, userID uuid.UUID, periodEndsAt time.Time, outcome string, attempt int) (*subscription.Subscription, error) {
+	if periodEndsAt.Equal(c.sub.CurrentPeriodEndsAt.Time) {
+		switch outcome {
+		case subscription.PeriodRenewed:
+			c.sub.Status, c.sub.PaymentAttempts = "active", 0
+			c.sub.CurrentPeriodEndsAt.Time = periodEndsAt.AddDate(0, 1, 0)
+		case subscription.PeriodPaymentFailed:
+			if c.sub.PaymentAttempts == attempt-1 {
+				c.sub.Status, c.sub.PaymentAttempts = "past_due", attempt
+			}
+		case subscription.PeriodLapsed:
+			c.sub.Status, c.sub.PaymentAttempts = "cancelled", 0
+		}
+	}
+	copied := c.sub
+	return &copied, nil
+}
+
+// fakeTransactionClient answers fee charges with status, remembering the transaction of
+// each idempotency key as the Transaction service does. Reconciling a transaction
+// reports its current status.
+type fakeTransactionClient struct {
+	TransactionClient
+
+	status  string
+	charges map[string]*transaction.Transaction
+}
+
+func (c *fakeTransactionClient) ChargeSubscriptionFee(ctx context.Context, userID uuid.UUID, amount int64, idempotencyKey string) (*transaction.Transaction, error) {
+	if c.charges == nil {
+		c.charges = make(map[string]*transaction.Transaction)
+	}
+	if tx, ok := c.charges[idempotencyKey]; ok {
+		return tx, nil
+	}
+	tx := &transaction.Transaction{ID: uuid.New(), Type: "subscription_fee", Amount: amount, Status: c.status}
+	c.charges[idempotencyKey] = tx
+	return tx, nil
+}
+
+func (c *fakeTransactionClient) ReconcileTransaction(ctx context.Context, txID uuid.UUID) (*transaction.Transaction, error) {
+	for _, tx := range c.charges {
+		if tx.ID == txID {
+			copied := *tx
+			return &copied, nil
+		}
+	}
+	return nil, fmt.Errorf("transaction %s not found", txID)
+}
+
+func TestSubscriptionDebitChargesEachAttemptOnce(t *testing.T) {
+	periodEnd := time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)
+	subs := &fakeSubscriptionClient{sub: subscription.Subscription{
+		ID: uuid.New(), UserID: uuid.New(), Status: "active", AutoRenew: true,
+		CurrentPeriodEndsAt: sql.NullTime{Time: periodEnd, Valid: true},
+	}}
+	txs := &fakeTransactionClient{status: transaction.StatusPending}
+	job := NewSubscriptionDebitJob(subs, txs, 150000)
+	repo := &fakeRepository{}
+
+	// runDay runs the job for the slot of the given day of March, as a new run each time,
+	// which is what happens when a failed run is abandoned for the next day's slot.
+	runDay := func(d int) error {
+		run := &Run{JobRun: &domain.JobRun{ID: uuid.New(), JobName: JobSubscriptionDebit, ScheduledFor: periodEnd.AddDate(0, 0, d-1).Add(2 * time.Hour)}, repo: repo}
+		return job.Run(context.Background(), run)
+	}
+
+	// The debit stays pending over several daily runs: each of them waits on it.
+	for d := 1; d <= 3; d++ {
+		if err := runDay(d); err == nil {
+			t.Fatalf("run on day %d with a pending debit succeeded, want it to fail", d)
+		}
+	}
+	if len(txs.charges) != 1 || subs.sub.Status != "active" {
+		t.Fatalf("made %d charges and left the subscription %s, want 1 charge and active", len(txs.charges), subs.sub.Status)
+	}
+
+	// The debit fails, which is the first failed attempt; the next run is a new attempt.
+	for _, tx := range txs.charges {
+		tx.Status = transaction.StatusFailed
+	}
+	if err := runDay(4); err != nil {
+		t.Fatalf("run on day 4 error = %v", err)
+	}
+	if subs.sub.Status != "past_due" || subs.sub.PaymentAttempts != 1 {
+		t.Fatalf("subscription is %s after %d attempts, want past_due after 1", subs.sub.Status, subs.sub.PaymentAttempts)
+	}
+
+	txs.status = transaction.StatusCompleted
+	if err := runDay(5); err != nil {
+		t.Fatalf("run on day 5 error = %v", err)
+	}
+	if len(txs.charges) != 2 || subs.sub.Status != "active" || !subs.sub.CurrentPeriodEndsAt.Time.Equal(periodEnd.AddDate(0, 1, 0)) {
+		t.Errorf("made %d charges and left the subscription %s until %v, want 2 charges and active until %v", len(txs.charges), subs.sub.Status, subs.sub.CurrentPeriodEndsAt.Time, periodEnd.AddDate(0, 1, 0))
+	}
+}
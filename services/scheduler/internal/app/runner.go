@@ -0,0 +1,253 @@
+/**
+ * @description
+ * This file contains the job runner for the Scheduler service: a registry of named jobs
+ * with cron schedules, and the loop that decides when each job runs.
+ *
+ * Key features:
+ * - Leader election: every replica polls every job, but a job only runs on the replica
+ *   that holds its PostgreSQL advisory lock, so each slot runs on one replica at a time.
+ * - Persistent runs: each scheduled slot is recorded in `scheduler_job_runs` and runs at
+ *   most once successfully. Missed slots (e.g. after downtime) are coalesced into the
+ *   latest one, which is safe because every job catches up on all outstanding work.
+ * - Resumption: a run left `running` by a crashed replica is resumed by the next leader,
+ *   and a failed run is retried after a delay. Items that already succeeded within the
+ *   run are skipped, so a resumed run picks up where the previous attempt stopped.
+ *
+ * @dependencies
+ * - Go standard libraries: "context", "errors", "fmt", "log", "sync", "time"
+ * - "transfa/services/scheduler/internal/domain": For job run models.
+ * - "transfa/services/scheduler/internal/store": For repository error values.
+ * - "transfa/services/scheduler/pkg/cron": For parsing and evaluating schedules.
+ */
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"transfa/services/scheduler/internal/domain"
+	"transfa/services/scheduler/internal/store"
+	"transfa/services/scheduler/pkg/cron"
+)
+
+// Job is a unit of scheduled work. Implementations must be idempotent: a run may be
+// retried or resumed after a partial failure.
+type Job interface {
+	Run(ctx context.Context, run *Run) error
+}
+
+// JobFunc adapts an ordinary function to the Job interface.
+type JobFunc func(ctx context.Context, run *Run) error
+
+// Run calls f(ctx, run).
+func (f JobFunc) Run(ctx context.Context, run *Run) error {
+	return f(ctx, run)
+}
+
+// Run is the execution of one scheduled slot of a job, passed to the job's Run method.
+type Run struct {
+	*domain.JobRun
+	repo Repository
+}
+
+// Item processes one unit of work within the run, identified by a key that is stable
+// across attempts. Items that succeeded in an earlier attempt of the same run are
+// skipped; the outcome of fn is recorded and returned.
+func (r *Run) Item(ctx context.Context, key string, fn func(ctx context.Context) error) error {
+	done, err := r.repo.IsItemSucceeded(ctx, r.ID, key)
+	if err != nil {
+		return err
+	}
+	if done {
+		return nil
+	}
+
+	itemErr := fn(ctx)
+
+	status, lastError := domain.ItemStatusSucceeded, (*string)(nil)
+	if itemErr != nil {
+		msg := itemErr.Error()
+		status, lastError = domain.ItemStatusFailed, &msg
+	}
+	if err := r.repo.RecordItem(ctx, r.ID, key, status, lastError); err != nil {
+		log.Printf("WARNING: Failed to record item %s of %s run %s: %v", key, r.JobName, r.ID, err)
+	}
+	return itemErr
+}
+
+// registeredJob is a job in the runner's registry.
+type registeredJob struct {
+	name     string
+	schedule *cron.Schedule
+	job      Job
+}
+
+// Runner runs registered jobs on their schedules.
+type Runner struct {
+	repo         Repository
+	pollInterval time.Duration
+	retryDelay   time.Duration
+	jobs         []*registeredJob
+	startedAt    time.Time
+	wg           sync.WaitGroup
+}
+
+// NewRunner creates a runner that checks for due jobs every pollInterval and retries
+// failed runs after retryDelay.
+func NewRunner(repo Repository, pollInterval, retryDelay time.Duration) *Runner {
+	return &Runner{
+		repo:         repo,
+		pollInterval: pollInterval,
+		retryDelay:   retryDelay,
+	}
+}
+
+// Register adds a job to the registry under a unique name, to run on the given five-field
+// cron schedule evaluated in UTC. Jobs must be registered before Start is called.
+func (r *Runner) Register(name, spec string, job Job) error {
+	for _, existing := range r.jobs {
+		if existing.name == name {
+			return fmt.Errorf("job %s is already registered", name)
+		}
+	}
+
+	schedule, err := cron.Parse(spec)
+	if err != nil {
+		return fmt.Errorf("invalid schedule for job %s: %w", name, err)
+	}
+
+	r.jobs = append(r.jobs, &registeredJob{name: name, schedule: schedule, job: job})
+	log.Printf("Registered job %s with schedule %q", name, spec)
+	return nil
+}
+
+// Start begins polling every registered job in the background until ctx is cancelled.
+func (r *Runner) Start(ctx context.Context) {
+	r.startedAt = time.Now().UTC()
+	for _, j := range r.jobs {
+		r.wg.Add(1)
+		go r.loop(ctx, j)
+	}
+}
+
+// Wait blocks until every job loop has stopped, including any run in progress.
+func (r *Runner) Wait() {
+	r.wg.Wait()
+}
+
+// loop polls a single job until ctx is cancelled.
+func (r *Runner) loop(ctx context.Context, j *registeredJob) {
+	defer r.wg.Done()
+
+	ticker := time.NewTicker(r.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		r.tick(ctx, j)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// tick runs the job if this replica is its leader and a slot is due.
+func (r *Runner) tick(ctx context.Context, j *registeredJob) {
+	// Step 1: Only the replica holding the job's lock may run it.
+	release, acquired, err := r.repo.TryLockJob(ctx, j.name)
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Printf("ERROR: Failed to take lock for job %s: %v", j.name, err)
+		}
+		return
+	}
+	if !acquired {
+		return
+	}
+	defer release()
+
+	// Step 2: Decide which slot, if any, to run.
+	slot, err := r.dueSlot(ctx, j, time.Now().UTC())
+	if err != nil {
+		log.Printf("ERROR: Failed to determine due slot for job %s: %v", j.name, err)
+		return
+	}
+	if slot.IsZero() {
+		return
+	}
+
+	// Step 3: Record the run and execute it.
+	jobRun, err := r.repo.StartRun(ctx, j.name, slot)
+	if err != nil {
+		if !errors.Is(err, store.ErrRunAlreadySucceeded) {
+			log.Printf("ERROR: Failed to start job %s for %s: %v", j.name, slot.Format(time.RFC3339), err)
+		}
+		return
+	}
+	log.Printf("Job %s run %s for %s started (attempt %d)", j.name, jobRun.ID, slot.Format(time.RFC3339), jobRun.Attempts)
+
+	runErr := j.job.Run(ctx, &Run{JobRun: jobRun, repo: r.repo})
+
+	status, lastError := domain.RunStatusSucceeded, (*string)(nil)
+	if runErr != nil {
+		msg := runErr.Error()
+		status, lastError = domain.RunStatusFailed, &msg
+		log.Printf("ERROR: Job %s run %s failed: %v", j.name, jobRun.ID, runErr)
+	}
+	if err := r.repo.FinishRun(ctx, jobRun.ID, status, lastError); err != nil {
+		// The run stays recorded as running and will be resumed by the next leader.
+		log.Printf("WARNING: Failed to record outcome of job %s run %s: %v", j.name, jobRun.ID, err)
+		return
+	}
+	if runErr == nil {
+		log.Printf("Job %s run %s succeeded", j.name, jobRun.ID)
+	}
+}
+
+// dueSlot returns the scheduled time the job should run for now, or the zero time if
+// nothing is due. It must be called while holding the job's lock.
+func (r *Runner) dueSlot(ctx context.Context, j *registeredJob, now time.Time) (time.Time, error) {
+	latest, err := r.repo.GetLatestRun(ctx, j.name)
+	if err != nil {
+		if !errors.Is(err, store.ErrRunNotFound) {
+			return time.Time{}, err
+		}
+		// A job that has never run starts with the first slot after the runner started,
+		// rather than running immediately for a slot in the past.
+		return latestSlot(j.schedule, r.startedAt.Add(-time.Minute), now), nil
+	}
+
+	newer := latestSlot(j.schedule, latest.ScheduledFor, now)
+	switch {
+	case latest.Status != domain.RunStatusSucceeded && !newer.IsZero():
+		log.Printf("WARNING: Job %s abandoning %s run for %s in favour of %s", j.name, latest.Status, latest.ScheduledFor.Format(time.RFC3339), newer.Format(time.RFC3339))
+		return newer, nil
+	case latest.Status == domain.RunStatusRunning:
+		// We hold the lock, so no other replica is executing this run: its leader died.
+		log.Printf("WARNING: Job %s resuming interrupted run %s", j.name, latest.ID)
+		return latest.ScheduledFor, nil
+	case latest.Status == domain.RunStatusFailed:
+		if latest.FinishedAt.Valid && now.Before(latest.FinishedAt.Time.Add(r.retryDelay)) {
+			return time.Time{}, nil
+		}
+		return latest.ScheduledFor, nil
+	default:
+		return newer, nil
+	}
+}
+
+// latestSlot returns the latest scheduled time after `after` and not after now, or the
+// zero time if there is none.
+func latestSlot(schedule *cron.Schedule, after, now time.Time) time.Time {
+	var slot time.Time
+	for next := schedule.Next(after); !next.IsZero() && !next.After(now); next = schedule.Next(next) {
+		slot = next
+	}
+	return slot
+}
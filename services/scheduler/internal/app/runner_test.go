@@ -0,0 +1,216 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"transfa/services/scheduler/internal/domain"
+	"transfa/services/scheduler/internal/store"
+	"transfa/services/scheduler/pkg/cron"
+)
+
+// fakeRepository keeps job runs and their items in memory. A job listed in heldLocks is
+// locked by another replica.
+type fakeRepository struct {
+	mu        sync.Mutex
+	heldLocks map[string]bool
+	runs      []*domain.JobRun
+	items     map[uuid.UUID]map[string]string
+}
+
+func (r *fakeRepository) TryLockJob(ctx context.Context, jobName string) (func(), bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.heldLocks[jobName] {
+		return nil, false, nil
+	}
+	return func() {}, true, nil
+}
+
+func (r *fakeRepository) GetLatestRun(ctx context.Context, jobName string) (*domain.JobRun, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var latest *domain.JobRun
+	for _, run := range r.runs {
+		if run.JobName == jobName && (latest == nil || run.ScheduledFor.After(latest.ScheduledFor)) {
+			latest = run
+		}
+	}
+	if latest == nil {
+		return nil, store.ErrRunNotFound
+	}
+	copied := *latest
+	return &copied, nil
+}
+
+func (r *fakeRepository) StartRun(ctx context.Context, jobName string, scheduledFor time.Time) (*domain.JobRun, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, run := range r.runs {
+		if run.JobName == jobName && run.ScheduledFor.Equal(scheduledFor) {
+			if run.Status == domain.RunStatusSucceeded {
+				return nil, store.ErrRunAlreadySucceeded
+			}
+			run.Status, run.Attempts, run.FinishedAt = domain.RunStatusRunning, run.Attempts+1, sql.NullTime{}
+			copied := *run
+			return &copied, nil
+		}
+	}
+	run := &domain.JobRun{ID: uuid.New(), JobName: jobName, ScheduledFor: scheduledFor, Status: domain.RunStatusRunning, Attempts: 1}
+	r.runs = append(r.runs, run)
+	copied := *run
+	return &copied, nil
+}
+
+func (r *fakeRepository) FinishRun(ctx context.Context, runID uuid.UUID, status string, lastError *string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, run := range r.runs {
+		if run.ID == runID {
+			run.Status, run.LastError = status, lastError
+			run.FinishedAt = sql.NullTime{Time: time.Now().UTC(), Valid: true}
+			return nil
+		}
+	}
+	return store.ErrRunNotFound
+}
+
+func (r *fakeRepository) IsItemSucceeded(ctx context.Context, runID uuid.UUID, itemKey string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.items[runID][itemKey] == domain.ItemStatusSucceeded, nil
+}
+
+func (r *fakeRepository) RecordItem(ctx context.Context, runID uuid.UUID, itemKey, status string, lastError *string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.items == nil {
+		r.items = make(map[uuid.UUID]map[string]string)
+	}
+	if r.items[runID] == nil {
+		r.items[runID] = make(map[string]string)
+	}
+	r.items[runID][itemKey] = status
+	return nil
+}
+
+func TestDueSlot(t *testing.T) {
+	const retryDelay = 10 * time.Minute
+	day := func(d, hour, minute int) time.Time {
+		return time.Date(2024, time.March, d, hour, minute, 0, 0, time.UTC)
+	}
+	finished := func(at time.Time) sql.NullTime { return sql.NullTime{Time: at, Valid: true} }
+
+	tests := []struct {
+		name      string
+		startedAt time.Time
+		latest    *domain.JobRun
+		now       time.Time
+		want      time.Time
+	}{
+		{"first slot after start", day(10, 1, 0), nil, day(10, 2, 5), day(10, 2, 0)},
+		{"slots before start are skipped", day(10, 2, 30), nil, day(10, 2, 35), time.Time{}},
+		{"nothing due after success", day(1, 0, 0), &domain.JobRun{ScheduledFor: day(10, 2, 0), Status: domain.RunStatusSucceeded}, day(10, 23, 0), time.Time{}},
+		{"next slot after success", day(1, 0, 0), &domain.JobRun{ScheduledFor: day(9, 2, 0), Status: domain.RunStatusSucceeded}, day(10, 2, 5), day(10, 2, 0)},
+		{"missed slots coalesce", day(1, 0, 0), &domain.JobRun{ScheduledFor: day(6, 2, 0), Status: domain.RunStatusSucceeded}, day(10, 2, 5), day(10, 2, 0)},
+		{"failed run waits for the retry delay", day(1, 0, 0), &domain.JobRun{ScheduledFor: day(10, 2, 0), Status: domain.RunStatusFailed, FinishedAt: finished(day(10, 2, 1))}, day(10, 2, 5), time.Time{}},
+		{"failed run is retried", day(1, 0, 0), &domain.JobRun{ScheduledFor: day(10, 2, 0), Status: domain.RunStatusFailed, FinishedAt: finished(day(10, 2, 1))}, day(10, 2, 11), day(10, 2, 0)},
+		{"interrupted run is resumed", day(1, 0, 0), &domain.JobRun{ScheduledFor: day(10, 2, 0), Status: domain.RunStatusRunning}, day(10, 2, 5), day(10, 2, 0)},
+		{"failed run is abandoned for a newer slot", day(1, 0, 0), &domain.JobRun{ScheduledFor: day(9, 2, 0), Status: domain.RunStatusFailed, FinishedAt: finished(day(10, 1, 59))}, day(10, 2, 5), day(10, 2, 0)},
+		{"interrupted run is abandoned for a newer slot", day(1, 0, 0), &domain.JobRun{ScheduledFor: day(9, 2, 0), Status: domain.RunStatusRunning}, day(10, 2, 5), day(10, 2, 0)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeRepository{}
+			if tt.latest != nil {
+				tt.latest.ID, tt.latest.JobName = uuid.New(), "daily"
+				repo.runs = append(repo.runs, tt.latest)
+			}
+			runner := NewRunner(repo, time.Minute, retryDelay)
+			runner.startedAt = tt.startedAt
+			job := &registeredJob{name: "daily", schedule: cron.MustParse("0 2 * * *")}
+
+			got, err := runner.dueSlot(context.Background(), job, tt.now)
+			if err != nil {
+				t.Fatalf("dueSlot() error = %v", err)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("dueSlot() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// newYearlyRunner returns a runner with job registered to run on 1 January, started just
+// before this year's slot, so that exactly that slot is due for the rest of the year.
+func newYearlyRunner(t *testing.T, repo *fakeRepository, retryDelay time.Duration, job Job) (*Runner, *registeredJob) {
+	t.Helper()
+	runner := NewRunner(repo, time.Minute, retryDelay)
+	if err := runner.Register("yearly", "@yearly", job); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	now := time.Now().UTC()
+	runner.startedAt = time.Date(now.Year(), time.January, 1, 0, 0, 0, 0, time.UTC).Add(-time.Minute)
+	return runner, runner.jobs[0]
+}
+
+func TestTickRunsOnlyOnTheLeader(t *testing.T) {
+	repo := &fakeRepository{heldLocks: map[string]bool{"yearly": true}}
+	runs := 0
+	runner, job := newYearlyRunner(t, repo, 0, JobFunc(func(ctx context.Context, run *Run) error {
+		runs++
+		return nil
+	}))
+
+	runner.tick(context.Background(), job)
+	if runs != 0 || len(repo.runs) != 0 {
+		t.Fatalf("job ran %d times and recorded %d runs without the lock, want none", runs, len(repo.runs))
+	}
+
+	delete(repo.heldLocks, "yearly")
+	runner.tick(context.Background(), job)
+	runner.tick(context.Background(), job)
+	if runs != 1 {
+		t.Errorf("job ran %d times as leader, want once per slot", runs)
+	}
+}
+
+func TestTickRetriesAFailedRunSkippingSucceededItems(t *testing.T) {
+	repo := &fakeRepository{}
+	calls := map[string]int{}
+	failB := true
+	runner, job := newYearlyRunner(t, repo, 0, JobFunc(func(ctx context.Context, run *Run) error {
+		var errs itemErrors
+		for _, key := range []string{"a", "b"} {
+			key := key
+			errs.add(run.Item(ctx, key, func(ctx context.Context) error {
+				calls[key]++
+				if key == "b" && failB {
+					return errors.New("item b is unavailable")
+				}
+				return nil
+			}))
+		}
+		return errs.err(2)
+	}))
+
+	runner.tick(context.Background(), job)
+	if len(repo.runs) != 1 || repo.runs[0].Status != domain.RunStatusFailed {
+		t.Fatalf("first attempt recorded %d runs, want one failed run", len(repo.runs))
+	}
+
+	failB = false
+	runner.tick(context.Background(), job)
+	run := repo.runs[0]
+	if len(repo.runs) != 1 || run.Status != domain.RunStatusSucceeded || run.Attempts != 2 {
+		t.Fatalf("retry left run %s after %d attempts (%d runs), want one succeeded run after 2", run.Status, run.Attempts, len(repo.runs))
+	}
+	if calls["a"] != 1 || calls["b"] != 2 {
+		t.Errorf("items ran a=%d b=%d times, want a once and b twice", calls["a"], calls["b"])
+	}
+}
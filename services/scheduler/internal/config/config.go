@@ -0,0 +1,76 @@
+/**
+ * @description
+ * This file handles configuration management for the Scheduler service.
+ * It uses the Viper library to read configuration from environment variables
+ * and a local .env file, making the service easily configurable across
+ * different environments (development, staging, production).
+ *
+ * @dependencies
+ * - "time": For polling and retry intervals.
+ * - "github.com/spf13/viper": A popular library for handling application configuration.
+ */
+package config
+
+import (
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// Config stores all configuration for the application.
+// The values are read by viper from a config file or environment variable.
+type Config struct {
+	DatabaseURL            string        `mapstructure:"DATABASE_URL"`
+	TransactionServiceURL  string        `mapstructure:"TRANSACTION_SERVICE_URL"`
+	SubscriptionServiceURL string        `mapstructure:"SUBSCRIPTION_SERVICE_URL"`
+	InternalAPIKey         string        `mapstructure:"INTERNAL_API_KEY"`
+	Port                   string        `mapstructure:"PORT"`
+	PollInterval           time.Duration `mapstructure:"POLL_INTERVAL"`
+	RetryDelay             time.Duration `mapstructure:"RETRY_DELAY"`
+
+	// SubscriptionFee is the monthly subscription fee in kobo.
+	SubscriptionFee int64 `mapstructure:"SUBSCRIPTION_FEE"`
+
+	// Job schedules, as five-field cron expressions evaluated in UTC.
+	SubscriptionDebitSchedule string `mapstructure:"SUBSCRIPTION_DEBIT_SCHEDULE"`
+	FreeTransferResetSchedule string `mapstructure:"FREE_TRANSFER_RESET_SCHEDULE"`
+	MoneyDropRefundSchedule   string `mapstructure:"MONEY_DROP_REFUND_SCHEDULE"`
+	ReconciliationSchedule    string `mapstructure:"RECONCILIATION_SCHEDULE"`
+
+	// ReconcileAfter is how long a transaction must have been pending before it is
+	// reconciled, so that transfers still being made are left alone.
+	ReconcileAfter time.Duration `mapstructure:"RECONCILE_AFTER"`
+}
+
+// LoadConfig reads configuration from file or environment variables.
+func LoadConfig() (config Config, err error) {
+	viper.AddConfigPath("./")
+	viper.SetConfigName(".env")
+	viper.SetConfigType("env")
+
+	viper.AutomaticEnv()
+
+	// Set default values for robust startup
+	viper.SetDefault("PORT", "8086") // Use a different default port from other services
+	viper.SetDefault("TRANSACTION_SERVICE_URL", "http://localhost:8084")
+	viper.SetDefault("SUBSCRIPTION_SERVICE_URL", "http://localhost:8085")
+	viper.SetDefault("POLL_INTERVAL", "30s")
+	viper.SetDefault("RETRY_DELAY", "5m")
+	// Subscriptions are billed monthly on their own anniversary, so the debit job runs daily.
+	viper.SetDefault("SUBSCRIPTION_DEBIT_SCHEDULE", "0 2 * * *")
+	viper.SetDefault("FREE_TRANSFER_RESET_SCHEDULE", "0 0 1 * *")
+	viper.SetDefault("MONEY_DROP_REFUND_SCHEDULE", "*/5 * * * *")
+	viper.SetDefault("RECONCILIATION_SCHEDULE", "*/10 * * * *")
+	viper.SetDefault("RECONCILE_AFTER", "5m")
+
+	err = viper.ReadInConfig()
+	// It's okay if the config file is not found, we can rely on env vars.
+	if err != nil {
+		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
+			return
+		}
+	}
+
+	err = viper.Unmarshal(&config)
+	return
+}
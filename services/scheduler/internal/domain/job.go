@@ -0,0 +1,51 @@
+/**
+ * @description
+ * This file defines the domain models for scheduled job runs within the Scheduler service.
+ *
+ * Key features:
+ * - `JobRun`: One execution slot of a scheduled job. Runs are unique per job and
+ *   scheduled time, which is what makes a slot execute at most once.
+ * - Run and item statuses, matching the CHECK constraints on `scheduler_job_runs` and
+ *   `scheduler_job_run_items`.
+ *
+ * @dependencies
+ * - "database/sql": Used for nullable time types.
+ * - "time": Used for timestamping records.
+ * - "github.com/google/uuid": Used for universally unique identifiers as primary keys.
+ */
+package domain
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Job run statuses as defined by the `scheduler_job_runs.status` check constraint.
+const (
+	RunStatusRunning   = "running"
+	RunStatusSucceeded = "succeeded"
+	RunStatusFailed    = "failed"
+)
+
+// Job run item statuses as defined by the `scheduler_job_run_items.status` check constraint.
+const (
+	ItemStatusSucceeded = "succeeded"
+	ItemStatusFailed    = "failed"
+)
+
+// JobRun represents one execution slot of a scheduled job.
+// It maps directly to the `scheduler_job_runs` table in the database.
+type JobRun struct {
+	ID           uuid.UUID    `json:"id" db:"id"`
+	JobName      string       `json:"job_name" db:"job_name"`
+	ScheduledFor time.Time    `json:"scheduled_for" db:"scheduled_for"`
+	Status       string       `json:"status" db:"status"`
+	Attempts     int          `json:"attempts" db:"attempts"`
+	LastError    *string      `json:"last_error,omitempty" db:"last_error"`
+	StartedAt    time.Time    `json:"started_at" db:"started_at"`
+	FinishedAt   sql.NullTime `json:"finished_at,omitempty" db:"finished_at"`
+	CreatedAt    time.Time    `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time    `json:"updated_at" db:"updated_at"`
+}
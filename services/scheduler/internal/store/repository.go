@@ -0,0 +1,201 @@
+/**
+ * @description
+ * This file provides the PostgreSQL implementation of the Repository interface for the
+ * Scheduler service. It persists job runs and their per-item progress, and implements
+ * leader election with PostgreSQL session-level advisory locks.
+ *
+ * Advisory locks are held on a dedicated connection for the duration of a run. If the
+ * replica holding a lock dies, PostgreSQL drops its connection and releases the lock, so
+ * another replica can take over and resume the run.
+ *
+ * @dependencies
+ * - Go standard library packages: "context", "errors", "fmt", "log", "time"
+ * - "github.com/google/uuid": For record identifiers.
+ * - "github.com/jackc/pgx/v5": For checking specific database errors.
+ * - "github.com/jackc/pgx/v5/pgxpool": The PostgreSQL driver and connection pool.
+ * - "transfa/services/scheduler/internal/domain": For core data models.
+ */
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"transfa/services/scheduler/internal/domain"
+)
+
+// lockNamespace prefixes job names when deriving advisory lock keys, to avoid clashing
+// with advisory locks taken by other services on the shared database.
+const lockNamespace = "transfa_scheduler:"
+
+var (
+	ErrRunNotFound = errors.New("job run not found")
+	// ErrRunAlreadySucceeded is returned when starting a run for a slot that has already
+	// completed successfully.
+	ErrRunAlreadySucceeded = errors.New("job run already succeeded")
+)
+
+// PostgresRepository is the concrete implementation for database operations.
+type PostgresRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewPostgresRepository creates a new repository with a database connection pool.
+func NewPostgresRepository(db *pgxpool.Pool) *PostgresRepository {
+	return &PostgresRepository{
+		db: db,
+	}
+}
+
+// TryLockJob attempts to become the leader for a job by taking its advisory lock without
+// waiting. On success, the returned function releases the lock and must be called.
+func (r *PostgresRepository) TryLockJob(ctx context.Context, jobName string) (func(), bool, error) {
+	conn, err := r.db.Acquire(ctx)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to acquire connection for job lock: %w", err)
+	}
+
+	var acquired bool
+	err = conn.QueryRow(ctx, `SELECT pg_try_advisory_lock(hashtext($1))`, lockNamespace+jobName).Scan(&acquired)
+	if err != nil {
+		conn.Release()
+		return nil, false, fmt.Errorf("failed to take job lock: %w", err)
+	}
+	if !acquired {
+		conn.Release()
+		return nil, false, nil
+	}
+
+	release := func() {
+		// Use a fresh context so the lock is released even if the run's context was cancelled.
+		unlockCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if _, err := conn.Exec(unlockCtx, `SELECT pg_advisory_unlock(hashtext($1))`, lockNamespace+jobName); err != nil {
+			// Closing the connection releases any session-level locks it holds.
+			log.Printf("WARNING: Failed to release lock for job %s, closing connection: %v", jobName, err)
+			conn.Conn().Close(unlockCtx)
+		}
+		conn.Release()
+	}
+	return release, true, nil
+}
+
+// jobRunColumns is the column list scanned by scanJobRun.
+const jobRunColumns = `id, job_name, scheduled_for, status, attempts, last_error, started_at, finished_at, created_at, updated_at`
+
+// scanJobRun scans a row selected with jobRunColumns into a domain.JobRun.
+func scanJobRun(row pgx.Row) (*domain.JobRun, error) {
+	var run domain.JobRun
+	err := row.Scan(
+		&run.ID,
+		&run.JobName,
+		&run.ScheduledFor,
+		&run.Status,
+		&run.Attempts,
+		&run.LastError,
+		&run.StartedAt,
+		&run.FinishedAt,
+		&run.CreatedAt,
+		&run.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+// GetLatestRun retrieves the run with the most recent scheduled time for a job.
+func (r *PostgresRepository) GetLatestRun(ctx context.Context, jobName string) (*domain.JobRun, error) {
+	query := `
+        SELECT ` + jobRunColumns + `
+        FROM public.scheduler_job_runs
+        WHERE job_name = $1
+        ORDER BY scheduled_for DESC
+        LIMIT 1
+    `
+	run, err := scanJobRun(r.db.QueryRow(ctx, query, jobName))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("%w: for job %s", ErrRunNotFound, jobName)
+		}
+		return nil, fmt.Errorf("failed to get latest job run: %w", err)
+	}
+	return run, nil
+}
+
+// StartRun records that a job run is starting. A slot that was started before (and
+// crashed or failed) is resumed with its attempt count incremented; a slot that already
+// succeeded is never started again and ErrRunAlreadySucceeded is returned.
+func (r *PostgresRepository) StartRun(ctx context.Context, jobName string, scheduledFor time.Time) (*domain.JobRun, error) {
+	query := `
+        INSERT INTO public.scheduler_job_runs (job_name, scheduled_for, status)
+        VALUES ($1, $2, 'running')
+        ON CONFLICT (job_name, scheduled_for) DO UPDATE
+        SET status = 'running',
+            attempts = public.scheduler_job_runs.attempts + 1,
+            last_error = NULL,
+            started_at = now(),
+            finished_at = NULL
+        WHERE public.scheduler_job_runs.status <> 'succeeded'
+        RETURNING ` + jobRunColumns
+
+	run, err := scanJobRun(r.db.QueryRow(ctx, query, jobName, scheduledFor))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrRunAlreadySucceeded
+		}
+		return nil, fmt.Errorf("failed to start job run: %w", err)
+	}
+	return run, nil
+}
+
+// FinishRun records the final status of a job run.
+func (r *PostgresRepository) FinishRun(ctx context.Context, runID uuid.UUID, status string, lastError *string) error {
+	cmdTag, err := r.db.Exec(ctx, `
+        UPDATE public.scheduler_job_runs
+        SET status = $1, last_error = $2, finished_at = now()
+        WHERE id = $3
+    `, status, lastError, runID)
+	if err != nil {
+		return fmt.Errorf("failed to finish job run: %w", err)
+	}
+	if cmdTag.RowsAffected() != 1 {
+		return fmt.Errorf("%w: with id %s", ErrRunNotFound, runID)
+	}
+	return nil
+}
+
+// IsItemSucceeded reports whether an item was already processed successfully in a run.
+func (r *PostgresRepository) IsItemSucceeded(ctx context.Context, runID uuid.UUID, itemKey string) (bool, error) {
+	var succeeded bool
+	err := r.db.QueryRow(ctx, `
+        SELECT EXISTS (
+            SELECT 1 FROM public.scheduler_job_run_items
+            WHERE run_id = $1 AND item_key = $2 AND status = 'succeeded'
+        )
+    `, runID, itemKey).Scan(&succeeded)
+	if err != nil {
+		return false, fmt.Errorf("failed to check job run item: %w", err)
+	}
+	return succeeded, nil
+}
+
+// RecordItem records the outcome of processing one item in a run.
+func (r *PostgresRepository) RecordItem(ctx context.Context, runID uuid.UUID, itemKey, status string, lastError *string) error {
+	_, err := r.db.Exec(ctx, `
+        INSERT INTO public.scheduler_job_run_items (run_id, item_key, status, last_error)
+        VALUES ($1, $2, $3, $4)
+        ON CONFLICT (run_id, item_key) DO UPDATE
+        SET status = EXCLUDED.status, last_error = EXCLUDED.last_error
+    `, runID, itemKey, status, lastError)
+	if err != nil {
+		return fmt.Errorf("failed to record job run item: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,217 @@
+/**
+ * @description
+ * This file implements a parser and evaluator for standard five-field cron expressions,
+ * used by the Scheduler service to decide when each job is due.
+ *
+ * Supported syntax, per field (minute, hour, day of month, month, day of week):
+ * - `*` for every value, `a` for a single value, `a-b` for a range, `a,b,c` for lists
+ * - `/n` steps on `*`, a range or a start value, e.g. `0-30/10` or `5/15`
+ * - Day of week accepts 0-7, where both 0 and 7 are Sunday.
+ * - The descriptors @yearly, @monthly, @weekly, @daily and @hourly.
+ *
+ * As in standard cron, when both day of month and day of week are restricted, a time
+ * matches if either of them matches.
+ *
+ * @dependencies
+ * - "fmt", "strconv", "strings", "time"
+ */
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxSearchYears bounds the search for the next matching time, so that expressions that
+// can never match (such as 30 February) do not loop forever.
+const maxSearchYears = 5
+
+// Schedule is a parsed cron expression.
+type Schedule struct {
+	minute, hour, dom, month, dow uint64
+	// domStar and dowStar record whether the day fields were unrestricted.
+	domStar, dowStar bool
+}
+
+// fieldBounds describes the allowed values of a cron field.
+type fieldBounds struct {
+	name     string
+	min, max uint
+}
+
+var (
+	minuteBounds = fieldBounds{"minute", 0, 59}
+	hourBounds   = fieldBounds{"hour", 0, 23}
+	domBounds    = fieldBounds{"day of month", 1, 31}
+	monthBounds  = fieldBounds{"month", 1, 12}
+	dowBounds    = fieldBounds{"day of week", 0, 7}
+)
+
+// descriptors maps the supported shorthand expressions to their five-field form.
+var descriptors = map[string]string{
+	"@yearly":  "0 0 1 1 *",
+	"@monthly": "0 0 1 * *",
+	"@weekly":  "0 0 * * 0",
+	"@daily":   "0 0 * * *",
+	"@hourly":  "0 * * * *",
+}
+
+// Parse parses a five-field cron expression or a supported descriptor.
+func Parse(expr string) (*Schedule, error) {
+	expr = strings.TrimSpace(expr)
+	if d, ok := descriptors[expr]; ok {
+		expr = d
+	}
+
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron expression %q must have 5 fields, got %d", expr, len(fields))
+	}
+
+	var s Schedule
+	var err error
+	if s.minute, err = parseField(fields[0], minuteBounds); err != nil {
+		return nil, err
+	}
+	if s.hour, err = parseField(fields[1], hourBounds); err != nil {
+		return nil, err
+	}
+	if s.dom, err = parseField(fields[2], domBounds); err != nil {
+		return nil, err
+	}
+	if s.month, err = parseField(fields[3], monthBounds); err != nil {
+		return nil, err
+	}
+	if s.dow, err = parseField(fields[4], dowBounds); err != nil {
+		return nil, err
+	}
+	// Fold 7 (Sunday) onto 0 so that time.Weekday can be used directly.
+	if s.dow&(1<<7) != 0 {
+		s.dow = s.dow&^(1<<7) | 1
+	}
+	s.domStar = fields[2] == "*" || strings.HasPrefix(fields[2], "*/")
+	s.dowStar = fields[4] == "*" || strings.HasPrefix(fields[4], "*/")
+
+	return &s, nil
+}
+
+// MustParse is like Parse but panics if the expression is invalid. It is intended for
+// expressions that are constants in the code.
+func MustParse(expr string) *Schedule {
+	s, err := Parse(expr)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// Next returns the first time strictly after t that matches the schedule, in t's
+// location, or the zero time if there is no match within the next few years.
+func (s *Schedule) Next(t time.Time) time.Time {
+	loc := t.Location()
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.Year() + maxSearchYears
+
+	for t.Year() <= limit {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute()+1, 0, 0, loc)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// dayMatches applies the cron rule for combining the day-of-month and day-of-week fields.
+func (s *Schedule) dayMatches(t time.Time) bool {
+	domMatch := s.dom&(1<<uint(t.Day())) != 0
+	dowMatch := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domStar || s.dowStar {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
+
+// parseField parses one comma-separated cron field into a bitset of allowed values.
+func parseField(field string, b fieldBounds) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		partBits, err := parseRange(part, b)
+		if err != nil {
+			return 0, err
+		}
+		bits |= partBits
+	}
+	return bits, nil
+}
+
+// parseRange parses a single `*`, `a`, `a-b` term with an optional `/step`.
+func parseRange(term string, b fieldBounds) (uint64, error) {
+	rangePart, stepPart, hasStep := strings.Cut(term, "/")
+
+	step := uint(1)
+	if hasStep {
+		n, err := strconv.ParseUint(stepPart, 10, 8)
+		if err != nil || n == 0 {
+			return 0, fmt.Errorf("invalid step %q in %s field", stepPart, b.name)
+		}
+		step = uint(n)
+	}
+
+	var lo, hi uint
+	switch {
+	case rangePart == "*":
+		lo, hi = b.min, b.max
+	case strings.Contains(rangePart, "-"):
+		loStr, hiStr, _ := strings.Cut(rangePart, "-")
+		var err error
+		if lo, err = parseValue(loStr, b); err != nil {
+			return 0, err
+		}
+		if hi, err = parseValue(hiStr, b); err != nil {
+			return 0, err
+		}
+		if lo > hi {
+			return 0, fmt.Errorf("invalid range %q in %s field", rangePart, b.name)
+		}
+	default:
+		v, err := parseValue(rangePart, b)
+		if err != nil {
+			return 0, err
+		}
+		lo, hi = v, v
+		if hasStep {
+			// `a/n` means from a to the end of the range, every n.
+			hi = b.max
+		}
+	}
+
+	var bits uint64
+	for v := lo; v <= hi; v += step {
+		bits |= 1 << v
+	}
+	return bits, nil
+}
+
+// parseValue parses a single number and checks it against the field's bounds.
+func parseValue(s string, b fieldBounds) (uint, error) {
+	n, err := strconv.ParseUint(s, 10, 8)
+	if err != nil || uint(n) < b.min || uint(n) > b.max {
+		return 0, fmt.Errorf("invalid value %q in %s field (allowed %d-%d)", s, b.name, b.min, b.max)
+	}
+	return uint(n), nil
+}
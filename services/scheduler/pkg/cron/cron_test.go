@@ -0,0 +1,103 @@
+package cron
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		expr    string
+		wantErr bool
+	}{
+		{"* * * * *", false},
+		{"0 2 * * *", false},
+		{"*/10 * * * *", false},
+		{"0-30/10 9-17 * * 1-5", false},
+		{"5/15 * * * *", false},
+		{"0 0 1,15 * *", false},
+		{"0 0 * * 7", false},
+		{"@monthly", false},
+		{"  @daily  ", false},
+		{"* * * *", true},
+		{"* * * * * *", true},
+		{"60 * * * *", true},
+		{"* 24 * * *", true},
+		{"* * 0 * *", true},
+		{"* * * 13 *", true},
+		{"* * * * 8", true},
+		{"*/0 * * * *", true},
+		{"30-10 * * * *", true},
+		{"a * * * *", true},
+		{"@fortnightly", true},
+	}
+	for _, tt := range tests {
+		_, err := Parse(tt.expr)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Parse(%q) error = %v, want error %t", tt.expr, err, tt.wantErr)
+		}
+	}
+}
+
+func TestNext(t *testing.T) {
+	at := func(value string) time.Time {
+		t.Helper()
+		parsed, err := time.Parse("2006-01-02 15:04", value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return parsed
+	}
+
+	tests := []struct {
+		name string
+		expr string
+		from string
+		want string
+	}{
+		{"every minute is strictly after", "* * * * *", "2024-03-10 12:00", "2024-03-10 12:01"},
+		{"daily later today", "0 2 * * *", "2024-03-10 01:59", "2024-03-10 02:00"},
+		{"daily tomorrow", "0 2 * * *", "2024-03-10 02:00", "2024-03-11 02:00"},
+		{"step", "*/10 * * * *", "2024-03-10 12:31", "2024-03-10 12:40"},
+		{"step wraps the hour", "*/10 * * * *", "2024-03-10 12:55", "2024-03-10 13:00"},
+		{"step from a start value", "5/15 * * * *", "2024-03-10 12:21", "2024-03-10 12:35"},
+		{"stepped range", "0-30/10 * * * *", "2024-03-10 12:31", "2024-03-10 13:00"},
+		{"hour range", "0 9-17 * * *", "2024-03-10 17:30", "2024-03-11 09:00"},
+		{"list", "0 0 1,15 * *", "2024-03-02 00:00", "2024-03-15 00:00"},
+		{"month rollover", "0 0 1 * *", "2024-03-31 23:59", "2024-04-01 00:00"},
+		{"year rollover", "@yearly", "2024-12-31 23:59", "2025-01-01 00:00"},
+		{"day 31 skips short months", "0 0 31 * *", "2024-04-01 00:00", "2024-05-31 00:00"},
+		{"29 February", "0 0 29 2 *", "2024-03-01 00:00", "2028-02-29 00:00"},
+		// 10 March 2024 is a Sunday.
+		{"day of week", "0 0 * * 1", "2024-03-10 12:00", "2024-03-11 00:00"},
+		{"weekday range", "0 9 * * 1-5", "2024-03-08 10:00", "2024-03-11 09:00"},
+		{"7 is Sunday", "0 0 * * 7", "2024-03-11 00:00", "2024-03-17 00:00"},
+		{"day of month or day of week", "0 0 15 * 1", "2024-03-12 00:00", "2024-03-15 00:00"},
+		{"day of week or day of month", "0 0 15 * 1", "2024-03-15 00:00", "2024-03-18 00:00"},
+		// A stepped `*` counts as unrestricted, so both day fields must match.
+		{"stepped day of month and day of week", "0 0 */2 * 1", "2024-03-11 00:00", "2024-03-25 00:00"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := MustParse(tt.expr).Next(at(tt.from))
+			if want := at(tt.want); !got.Equal(want) {
+				t.Errorf("Next(%s) of %q = %s, want %s", tt.from, tt.expr, got.Format("2006-01-02 15:04"), tt.want)
+			}
+		})
+	}
+}
+
+func TestNextKeepsTheLocationAndSecondsAreIgnored(t *testing.T) {
+	lagos := time.FixedZone("WAT", 60*60)
+	from := time.Date(2024, time.March, 10, 1, 59, 30, 0, lagos)
+	got := MustParse("0 2 * * *").Next(from)
+	if want := time.Date(2024, time.March, 10, 2, 0, 0, 0, lagos); !got.Equal(want) || got.Location() != lagos {
+		t.Errorf("Next() = %v, want %v", got, want)
+	}
+}
+
+func TestNextWithoutAMatch(t *testing.T) {
+	if got := MustParse("0 0 30 2 *").Next(time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)); !got.IsZero() {
+		t.Errorf("Next() of 30 February = %v, want the zero time", got)
+	}
+}
@@ -0,0 +1,162 @@
+/**
+ * @description
+ * This file provides an HTTP client for the Subscription service's internal API. The
+ * Scheduler uses it to find subscriptions whose billing period has ended, to record the
+ * outcome of billing them, and to reset free transfer usage at the start of each month.
+ *
+ * Key features:
+ * - `ListDue`: Lists paid-tier subscriptions whose current period has ended.
+ * - `SettlePeriod`: Records a renewal, a failed payment or a lapse for one period.
+ * - `ResetUsage`: Resets every user's free external transfer count for a new month.
+ *
+ * @dependencies
+ * - "bytes", "context", "database/sql", "encoding/json", "fmt", "io", "net/http", "net/url", "time"
+ * - "github.com/google/uuid": For user identifiers.
+ */
+package subscription
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Billing period outcomes accepted by SettlePeriod.
+const (
+	PeriodRenewed       = "renewed"
+	PeriodPaymentFailed = "payment_failed"
+	PeriodLapsed        = "lapsed"
+)
+
+// Client is a client for the Subscription service's internal API.
+type Client struct {
+	baseURL    string
+	apiKey     string
+	httpClient *http.Client
+}
+
+// Subscription is the Subscription service's record of a user's subscription.
+type Subscription struct {
+	ID                  uuid.UUID    `json:"id"`
+	UserID              uuid.UUID    `json:"user_id"`
+	Status              string       `json:"status"`
+	AutoRenew           bool         `json:"auto_renew"`
+	CurrentPeriodEndsAt sql.NullTime `json:"current_period_ends_at"`
+	// PaymentAttempts is the number of failed attempts to collect the current period's fee.
+	PaymentAttempts int `json:"payment_attempts"`
+}
+
+// NewClient creates a new Subscription service client. The apiKey is sent in the
+// X-Internal-API-Key header on every request.
+func NewClient(baseURL, apiKey string) *Client {
+	return &Client{
+		baseURL: baseURL,
+		apiKey:  apiKey,
+		httpClient: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+	}
+}
+
+// ListDue returns paid-tier subscriptions whose current period ended at or before the given time.
+func (c *Client) ListDue(ctx context.Context, before time.Time) ([]Subscription, error) {
+	endpoint := c.baseURL + "/subscriptions/due?before=" + url.QueryEscape(before.UTC().Format(time.RFC3339))
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create due subscriptions request: %w", err)
+	}
+
+	var subs []Subscription
+	if err := c.do(req, &subs); err != nil {
+		return nil, err
+	}
+	return subs, nil
+}
+
+// SettlePeriod records the outcome of billing the period that ends at periodEndsAt.
+// attempt is the number of the payment attempt, counting from one, and is required for
+// PeriodPaymentFailed. Settling a period or an attempt that was already settled returns
+// the subscription unchanged.
+func (c *Client) SettlePeriod(ctx context.Context, userID uuid.UUID, periodEndsAt time.Time, outcome string, attempt int) (*Subscription, error) {
+	payload := map[string]interface{}{
+		"user_id":        userID,
+		"period_ends_at": periodEndsAt,
+		"outcome":        outcome,
+		"attempt":        attempt,
+	}
+	req, err := c.newJSONRequest(ctx, "/subscriptions/settle-period", payload)
+	if err != nil {
+		return nil, err
+	}
+
+	var sub Subscription
+	if err := c.do(req, &sub); err != nil {
+		return nil, err
+	}
+	return &sub, nil
+}
+
+// ResetUsage resets every user's free external transfer count for the month containing
+// period and returns the number of subscriptions reset. Resetting the same month again
+// resets nothing.
+func (c *Client) ResetUsage(ctx context.Context, period time.Time) (int64, error) {
+	req, err := c.newJSONRequest(ctx, "/subscriptions/reset-usage", map[string]interface{}{
+		"period": period.UTC(),
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	var resp struct {
+		SubscriptionsReset int64 `json:"subscriptions_reset"`
+	}
+	if err := c.do(req, &resp); err != nil {
+		return 0, err
+	}
+	return resp.SubscriptionsReset, nil
+}
+
+// newJSONRequest builds a POST request with a JSON body.
+func (c *Client) newJSONRequest(ctx context.Context, path string, payload interface{}) (*http.Request, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request payload for %s: %w", path, err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+path, bytes.NewBuffer(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request for %s: %w", path, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return req, nil
+}
+
+// do sends the request with the internal API key and decodes the response into out.
+func (c *Client) do(req *http.Request, out interface{}) error {
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("X-Internal-API-Key", c.apiKey)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call subscription service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("subscription service returned non-200 status: %d - %s", resp.StatusCode, string(respBody))
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode subscription service response: %w", err)
+	}
+	return nil
+}
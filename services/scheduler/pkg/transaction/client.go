@@ -0,0 +1,182 @@
+/**
+ * @description
+ * This file provides an HTTP client for the Transaction service's internal API. The
+ * Scheduler uses it for the money movements its jobs trigger; the Transaction service
+ * remains the only component that talks to Anchor.
+ *
+ * Key features:
+ * - `ChargeSubscriptionFee`: Debits a subscription fee. Requests are idempotent by key.
+ * - `ListExpiredMoneyDrops`: Lists active Money Drops past their expiry.
+ * - `RefundMoneyDrop`: Expires a Money Drop and refunds its unclaimed funds.
+ * - `ListPendingTransactions`: Lists transactions whose transfer outcome is not known yet.
+ * - `ReconcileTransaction`: Settles a pending transaction from its Anchor transfer status.
+ *
+ * @dependencies
+ * - "bytes", "context", "encoding/json", "fmt", "io", "net/http", "net/url", "time"
+ * - "github.com/google/uuid": For record identifiers.
+ */
+package transaction
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Transaction statuses reported by the Transaction service.
+const (
+	StatusPending   = "pending"
+	StatusCompleted = "completed"
+	StatusFailed    = "failed"
+)
+
+// Client is a client for the Transaction service's internal API.
+type Client struct {
+	baseURL    string
+	apiKey     string
+	httpClient *http.Client
+}
+
+// Transaction is the Transaction service's record of a money movement.
+type Transaction struct {
+	ID     uuid.UUID `json:"id"`
+	Type   string    `json:"type"`
+	Amount int64     `json:"amount"`
+	Status string    `json:"status"`
+}
+
+// MoneyDropRefund is the result of expiring a Money Drop. Transaction is nil when every
+// share was claimed and there was nothing to refund.
+type MoneyDropRefund struct {
+	Transaction *Transaction `json:"transaction,omitempty"`
+}
+
+// NewClient creates a new Transaction service client. The apiKey is sent in the
+// X-Internal-API-Key header on every request.
+func NewClient(baseURL, apiKey string) *Client {
+	return &Client{
+		baseURL: baseURL,
+		apiKey:  apiKey,
+		httpClient: &http.Client{
+			Timeout: 30 * time.Second, // Requests wait for Anchor transfers to complete.
+		},
+	}
+}
+
+// ChargeSubscriptionFee debits a subscription fee from the user's main wallet. Calls with
+// the same idempotencyKey return the same transaction instead of debiting again. A
+// declined debit is not an error; the returned transaction has status failed.
+func (c *Client) ChargeSubscriptionFee(ctx context.Context, userID uuid.UUID, amount int64, idempotencyKey string) (*Transaction, error) {
+	payload := map[string]interface{}{
+		"user_id":         userID,
+		"amount":          amount,
+		"idempotency_key": idempotencyKey,
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal subscription fee payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/internal/subscription-fees", bytes.NewBuffer(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create subscription fee request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	var tx Transaction
+	if err := c.do(req, &tx); err != nil {
+		return nil, err
+	}
+	return &tx, nil
+}
+
+// ListExpiredMoneyDrops returns the IDs of active Money Drops that expired at or before
+// the given time.
+func (c *Client) ListExpiredMoneyDrops(ctx context.Context, before time.Time) ([]uuid.UUID, error) {
+	endpoint := c.baseURL + "/internal/money-drops/expired?before=" + url.QueryEscape(before.UTC().Format(time.RFC3339))
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create expired money drops request: %w", err)
+	}
+
+	var ids []uuid.UUID
+	if err := c.do(req, &ids); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// RefundMoneyDrop expires a Money Drop and refunds its unclaimed funds to the creator.
+// It is safe to call repeatedly for the same drop.
+func (c *Client) RefundMoneyDrop(ctx context.Context, dropID uuid.UUID) (*MoneyDropRefund, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/internal/money-drops/"+dropID.String()+"/refund", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create money drop refund request: %w", err)
+	}
+
+	var refund MoneyDropRefund
+	if err := c.do(req, &refund); err != nil {
+		return nil, err
+	}
+	return &refund, nil
+}
+
+// ListPendingTransactions returns the IDs of transactions created at or before the given
+// time that are still pending.
+func (c *Client) ListPendingTransactions(ctx context.Context, before time.Time) ([]uuid.UUID, error) {
+	endpoint := c.baseURL + "/internal/transactions/pending?before=" + url.QueryEscape(before.UTC().Format(time.RFC3339))
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create pending transactions request: %w", err)
+	}
+
+	var ids []uuid.UUID
+	if err := c.do(req, &ids); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// ReconcileTransaction settles a pending transaction from the status of its Anchor
+// transfer and returns it. It stays pending while Anchor is still processing it.
+func (c *Client) ReconcileTransaction(ctx context.Context, txID uuid.UUID) (*Transaction, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/internal/transactions/"+txID.String()+"/reconcile", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create reconcile transaction request: %w", err)
+	}
+
+	var tx Transaction
+	if err := c.do(req, &tx); err != nil {
+		return nil, err
+	}
+	return &tx, nil
+}
+
+// do sends the request with the internal API key and decodes the response into out.
+func (c *Client) do(req *http.Request, out interface{}) error {
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("X-Internal-API-Key", c.apiKey)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call transaction service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("transaction service returned non-200 status: %d - %s", resp.StatusCode, string(respBody))
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode transaction service response: %w", err)
+	}
+	return nil
+}
@@ -2,44 +2,108 @@
  * @description
  * Main entry point for the Subscription microservice.
  *
- * This file initializes and starts the HTTP server for the service.
- * For this boilerplate setup, it only includes a basic health check endpoint.
- * In later steps, this will be expanded to include configuration loading,
- * database connections, and route initialization for managing subscriptions.
+ * This file acts as the composition root for the application. It is responsible for:
+ * - Loading configuration from environment variables.
+ * - Establishing connections to external services (PostgreSQL, Transaction service).
+ * - Wiring together all the application layers (repository, service, handlers, router).
+ * - Starting the HTTP server to listen for requests.
  *
  * @dependencies
- * - Standard "fmt", "log", "net/http", "os" packages.
+ * - Standard library packages for context, logging, HTTP, OS signals.
+ * - External libraries for pgxpool.
+ * - All internal packages for the subscription service.
  */
 package main
 
 import (
-	"fmt"
+	"context"
 	"log"
 	"net/http"
-	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"transfa/services/subscription/internal/api"
+	"transfa/services/subscription/internal/app"
+	"transfa/services/subscription/internal/config"
+	"transfa/services/subscription/internal/store"
+	"transfa/services/subscription/pkg/transaction"
+	"transfa/shared/authn"
 )
 
 func main() {
-	// A simple health check handler to verify the service is running.
-	// This is essential for container orchestration systems like Kubernetes.
-	http.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusOK)
-		// Simple JSON response indicating the service is healthy.
-		fmt.Fprintln(w, `{"status": "ok"}`)
+	// Load configuration
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Fatalf("could not load config: %v", err)
+	}
+
+	// Create context that listens for the interrupt signal from the OS.
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	// Verify Clerk session tokens offline against the instance's cached signing keys.
+	verifier, err := authn.NewJWKSVerifier(authn.Config{
+		Issuer:   cfg.ClerkIssuer,
+		JWKSURL:  cfg.ClerkJWKSURL,
+		Audience: cfg.ClerkAudience,
+		Leeway:   cfg.ClerkClockSkew,
 	})
+	if err != nil {
+		log.Fatalf("could not create session token verifier: %v", err)
+	}
+	if err := verifier.Refresh(ctx); err != nil {
+		log.Printf("WARNING: could not fetch Clerk signing keys, will retry on the first request: %v", err)
+	}
 
-	serviceName := "Subscription Service"
-	// Read the port from the environment variable for configurability in different environments.
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080" // Default to port 8080 if not specified.
+	// Initialize database connection pool
+	dbpool, err := pgxpool.New(ctx, cfg.DatabaseURL)
+	if err != nil {
+		log.Fatalf("unable to create connection pool: %v", err)
 	}
+	defer dbpool.Close()
+	log.Println("Database connection pool established.")
 
-	log.Printf("%s is starting on port %s...", serviceName, port)
+	if cfg.SubscriptionFee <= 0 {
+		log.Println("WARNING: SUBSCRIPTION_FEE is not set; users will not be able to subscribe")
+	}
 
-	// Start the HTTP server and listen for incoming requests.
-	if err := http.ListenAndServe(":"+port, nil); err != nil {
-		log.Fatalf("Failed to start server for %s: %v", serviceName, err)
+	// Wire application components
+	repository := store.NewPostgresRepository(dbpool)
+	transactionClient := transaction.NewClient(cfg.TransactionServiceURL, cfg.InternalAPIKey)
+	service := app.NewService(repository, transactionClient, cfg.SubscriptionFee, cfg.MaxPaymentAttempts)
+	handler := api.NewSubscriptionHandler(service)
+	router := api.NewRouter(handler, verifier, cfg.InternalAPIKey)
+
+	// Set up and start HTTP server
+	srv := &http.Server{
+		Addr:    ":" + cfg.Port,
+		Handler: router,
 	}
+
+	go func() {
+		log.Printf("Subscription Service is starting on port %s...", cfg.Port)
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatalf("listen: %s\n", err)
+		}
+	}()
+
+	// Listen for the interrupt signal.
+	<-ctx.Done()
+
+	// Restore default behavior on the interrupt signal and notify user of shutdown.
+	stop()
+	log.Println("shutting down gracefully, press Ctrl+C again to force")
+
+	// The context is used to inform the server it has 5 seconds to finish
+	// the requests it is currently handling
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Fatalf("Server forced to shutdown: %v", err)
+	}
+
+	log.Println("Server exiting")
 }
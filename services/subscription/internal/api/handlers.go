@@ -0,0 +1,249 @@
+/**
+ * @description
+ * This file contains the HTTP handlers for the Subscription service. Handlers are responsible
+ * for parsing incoming requests, calling the appropriate application service method,
+ * and writing the HTTP response.
+ *
+ * @dependencies
+ * - "encoding/json": For JSON serialization and deserialization.
+ * - "errors": For mapping service errors to HTTP status codes.
+ * - "log": For logging.
+ * - "net/http": For standard HTTP handling.
+ * - "time": For parsing timestamps in query parameters.
+ * - "github.com/google/uuid": For parsing user identifiers.
+ * - "transfa/services/subscription/internal/app": Imports the application service layer.
+ * - "transfa/services/subscription/internal/domain": Imports the data models/DTOs.
+ * - "transfa/shared/apierror": For JSON:API error responses.
+ * - "transfa/shared/authn": For the Clerk User ID of the caller.
+ */
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"transfa/services/subscription/internal/app"
+	"transfa/services/subscription/internal/domain"
+	"transfa/shared/apierror"
+	"transfa/shared/authn"
+)
+
+// SubscriptionHandler holds dependencies for the subscription-related HTTP handlers.
+type SubscriptionHandler struct {
+	service *app.Service
+}
+
+// NewSubscriptionHandler creates a new handler with the given application service.
+func NewSubscriptionHandler(service *app.Service) *SubscriptionHandler {
+	return &SubscriptionHandler{
+		service: service,
+	}
+}
+
+// GetMySubscriptionHandler handles the `GET /subscriptions/me` request.
+func (h *SubscriptionHandler) GetMySubscriptionHandler(w http.ResponseWriter, r *http.Request) {
+	clerkID, ok := authn.SubjectFromContext(r.Context())
+	if !ok {
+		apierror.Write(w, http.StatusUnauthorized, "could not retrieve claims")
+		return
+	}
+
+	sub, err := h.service.GetMySubscription(r.Context(), clerkID)
+	if err != nil {
+		log.Printf("Failed to get subscription for clerk_id %s: %v", clerkID, err)
+		writeServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, sub)
+}
+
+// SubscribeHandler handles the `POST /subscriptions/subscribe` request.
+func (h *SubscriptionHandler) SubscribeHandler(w http.ResponseWriter, r *http.Request) {
+	clerkID, ok := authn.SubjectFromContext(r.Context())
+	if !ok {
+		apierror.Write(w, http.StatusUnauthorized, "could not retrieve claims")
+		return
+	}
+
+	sub, err := h.service.Subscribe(r.Context(), clerkID)
+	if err != nil {
+		log.Printf("Subscribe failed for clerk_id %s: %v", clerkID, err)
+		writeServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, sub)
+}
+
+// CancelHandler handles the `POST /subscriptions/cancel` request.
+func (h *SubscriptionHandler) CancelHandler(w http.ResponseWriter, r *http.Request) {
+	clerkID, ok := authn.SubjectFromContext(r.Context())
+	if !ok {
+		apierror.Write(w, http.StatusUnauthorized, "could not retrieve claims")
+		return
+	}
+
+	sub, err := h.service.Cancel(r.Context(), clerkID)
+	if err != nil {
+		log.Printf("Cancel failed for clerk_id %s: %v", clerkID, err)
+		writeServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, sub)
+}
+
+// GetStatusHandler handles the internal `GET /subscriptions/status?user_id=` request.
+func (h *SubscriptionHandler) GetStatusHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(r.URL.Query().Get("user_id"))
+	if err != nil {
+		apierror.Write(w, http.StatusBadRequest, "invalid user_id")
+		return
+	}
+
+	status, err := h.service.GetStatus(r.Context(), userID)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, status)
+}
+
+// ReserveUsageHandler handles the internal `POST /subscriptions/reserve-usage` request.
+func (h *SubscriptionHandler) ReserveUsageHandler(w http.ResponseWriter, r *http.Request) {
+	var req domain.ReserveUsageRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.Write(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+
+	reservation, err := h.service.ReserveUsage(r.Context(), req)
+	if err != nil {
+		log.Printf("Failed to reserve usage for user %s: %v", req.UserID, err)
+		writeServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, reservation)
+}
+
+// ReleaseUsageHandler handles the internal `POST /subscriptions/release-usage` request.
+func (h *SubscriptionHandler) ReleaseUsageHandler(w http.ResponseWriter, r *http.Request) {
+	var req domain.ReleaseUsageRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.Write(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+
+	resp, err := h.service.ReleaseUsage(r.Context(), req)
+	if err != nil {
+		log.Printf("Failed to release usage for user %s: %v", req.UserID, err)
+		writeServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// ListDueHandler handles the internal `GET /subscriptions/due?before=` request.
+// The optional `before` query parameter (RFC 3339) defaults to the current time.
+func (h *SubscriptionHandler) ListDueHandler(w http.ResponseWriter, r *http.Request) {
+	before := time.Now()
+	if raw := r.URL.Query().Get("before"); raw != "" {
+		parsed, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			apierror.Write(w, http.StatusBadRequest, "invalid before timestamp")
+			return
+		}
+		before = parsed
+	}
+
+	subs, err := h.service.ListDueSubscriptions(r.Context(), before)
+	if err != nil {
+		log.Printf("Listing due subscriptions failed: %v", err)
+		writeServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, subs)
+}
+
+// SettlePeriodHandler handles the internal `POST /subscriptions/settle-period` request.
+func (h *SubscriptionHandler) SettlePeriodHandler(w http.ResponseWriter, r *http.Request) {
+	var req domain.SettlePeriodRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.Write(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+
+	sub, err := h.service.SettlePeriod(r.Context(), req)
+	if err != nil {
+		log.Printf("Settling billing period failed for user %s: %v", req.UserID, err)
+		writeServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, sub)
+}
+
+// ResetUsageHandler handles the internal `POST /subscriptions/reset-usage` request.
+func (h *SubscriptionHandler) ResetUsageHandler(w http.ResponseWriter, r *http.Request) {
+	var req domain.ResetUsageRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.Write(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+
+	resp, err := h.service.ResetMonthlyUsage(r.Context(), req.Period)
+	if err != nil {
+		log.Printf("Resetting monthly usage failed: %v", err)
+		writeServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// writeServiceError maps application errors to HTTP status codes.
+func writeServiceError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, app.ErrUserIDRequired),
+		errors.Is(err, app.ErrPeriodRequired),
+		errors.Is(err, app.ErrPeriodInFuture),
+		errors.Is(err, app.ErrAttemptRequired),
+		errors.Is(err, app.ErrLimitRequired),
+		errors.Is(err, app.ErrUsagePeriodRequired),
+		errors.Is(err, app.ErrInvalidOutcome):
+		apierror.Write(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, app.ErrUserNotFound):
+		apierror.Write(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, app.ErrAlreadySubscribed),
+		errors.Is(err, app.ErrNotSubscribed),
+		errors.Is(err, app.ErrAlreadyCancelled),
+		errors.Is(err, app.ErrSubscriptionPastDue),
+		errors.Is(err, app.ErrConcurrentUpdate),
+		errors.Is(err, app.ErrSubscriptionFeePending):
+		apierror.Write(w, http.StatusConflict, err.Error())
+	case errors.Is(err, app.ErrSubscriptionFeeDeclined):
+		apierror.Write(w, http.StatusPaymentRequired, err.Error())
+	case errors.Is(err, app.ErrBillingNotConfigured):
+		apierror.Write(w, http.StatusServiceUnavailable, err.Error())
+	default:
+		apierror.Write(w, http.StatusInternalServerError, "")
+	}
+}
+
+// writeJSON writes a JSON response with the given status code.
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		log.Printf("Failed to write response: %v", err)
+	}
+}
@@ -0,0 +1,74 @@
+/**
+ * @description
+ * This file sets up the HTTP router for the Subscription service using the Chi router.
+ * It defines all the API routes, applies middleware like CORS and authentication,
+ * and connects the routes to their respective handlers.
+ *
+ * @dependencies
+ * - "net/http": For standard HTTP handling.
+ * - "github.com/go-chi/chi/v5": The Chi router library.
+ * - "github.com/go-chi/chi/v5/middleware": For standard Chi middleware.
+ * - "github.com/go-chi/cors": For CORS middleware.
+ * - "transfa/shared/authn": For the Clerk session token middleware.
+ */
+package api
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/cors"
+	"transfa/shared/authn"
+)
+
+// NewRouter creates and configures a new Chi router for the Subscription service.
+func NewRouter(handler *SubscriptionHandler, verifier authn.TokenVerifier, internalAPIKey string) http.Handler {
+	r := chi.NewRouter()
+
+	// A good base middleware stack
+	r.Use(middleware.RequestID)
+	r.Use(middleware.RealIP)
+	r.Use(middleware.Logger)
+	r.Use(middleware.Recoverer)
+
+	// Basic CORS configuration. This should be more restrictive in production.
+	r.Use(cors.Handler(cors.Options{
+		AllowedOrigins:   []string{"*"},
+		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
+		ExposedHeaders:   []string{"Link"},
+		AllowCredentials: true,
+		MaxAge:           300,
+	}))
+
+	// Health check endpoint - does not require authentication
+	r.Get("/health", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"status": "ok"}`))
+	})
+
+	// Protected routes
+	r.Group(func(r chi.Router) {
+		// Require a valid Clerk session token on this group.
+		r.Use(authn.Middleware(verifier))
+
+		r.Get("/subscriptions/me", handler.GetMySubscriptionHandler)
+		r.Post("/subscriptions/subscribe", handler.SubscribeHandler)
+		r.Post("/subscriptions/cancel", handler.CancelHandler)
+	})
+
+	// Internal routes, called by other backend services
+	r.Group(func(r chi.Router) {
+		r.Use(InternalAuth(internalAPIKey))
+
+		r.Get("/subscriptions/status", handler.GetStatusHandler)
+		r.Post("/subscriptions/reserve-usage", handler.ReserveUsageHandler)
+		r.Post("/subscriptions/release-usage", handler.ReleaseUsageHandler)
+		r.Get("/subscriptions/due", handler.ListDueHandler)
+		r.Post("/subscriptions/settle-period", handler.SettlePeriodHandler)
+		r.Post("/subscriptions/reset-usage", handler.ResetUsageHandler)
+	})
+
+	return r
+}
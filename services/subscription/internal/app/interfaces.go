@@ -0,0 +1,45 @@
+/**
+ * @description
+ * This file defines the interfaces (ports) for the Subscription service's application logic.
+ * These interfaces define the contracts for external dependencies, such as the database
+ * and the Transaction service, allowing for a clean separation of concerns and easier testing.
+ *
+ * @dependencies
+ * - "context": For passing request-scoped data and cancellation signals.
+ * - "time": For billing period boundaries.
+ * - "github.com/google/uuid": For record identifiers.
+ * - "transfa/services/subscription/internal/domain": For core data models.
+ * - "transfa/services/subscription/pkg/transaction": For Transaction service data models.
+ */
+package app
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"transfa/services/subscription/internal/domain"
+	"transfa/services/subscription/pkg/transaction"
+)
+
+// Repository defines the interface for data persistence operations.
+type Repository interface {
+	GetUserIDByClerkID(ctx context.Context, clerkID string) (uuid.UUID, error)
+	GetOrCreateSubscription(ctx context.Context, userID uuid.UUID) (*domain.Subscription, error)
+	GetSubscriptionStatus(ctx context.Context, userID uuid.UUID) (*domain.SubscriptionStatus, error)
+	BeginActivation(ctx context.Context, userID uuid.UUID, feeKey string) (*domain.Subscription, error)
+	AbandonActivation(ctx context.Context, userID uuid.UUID, feeKey string) error
+	ActivateSubscription(ctx context.Context, userID uuid.UUID, feeKey string) (*domain.Subscription, error)
+	SetAutoRenew(ctx context.Context, userID uuid.UUID, autoRenew bool) (*domain.Subscription, error)
+	ReserveExternalTransfer(ctx context.Context, userID uuid.UUID, limit int) (*domain.UsageReservation, error)
+	ReleaseExternalTransfer(ctx context.Context, userID uuid.UUID, period time.Time) (bool, error)
+	ListDueSubscriptions(ctx context.Context, before time.Time) ([]domain.Subscription, error)
+	SettlePeriod(ctx context.Context, userID uuid.UUID, periodEndsAt time.Time, outcome string, attempt int) (*domain.Subscription, error)
+	ResetMonthlyUsage(ctx context.Context, period time.Time) (int64, error)
+}
+
+// TransactionClient defines the interface for the Transaction service, which moves the
+// money for subscription fees.
+type TransactionClient interface {
+	ChargeSubscriptionFee(ctx context.Context, userID uuid.UUID, amount int64, idempotencyKey string) (*transaction.Transaction, error)
+}
@@ -0,0 +1,346 @@
+/**
+ * @description
+ * This file contains the core business logic for the Subscription service. The Service
+ * struct is the source of truth for whether a user is on the free or paid tier and how
+ * many of their free monthly external transfers they have used.
+ *
+ * Key features:
+ * - Subscribe: moves a free or cancelled user onto the paid tier once the fee for the
+ *   first period is collected through the Transaction service, or turns renewal back on
+ *   for a subscription that was cancelled but has not lapsed yet.
+ * - Cancel: turns off renewal. The user keeps the paid tier until the period ends, when
+ *   the Scheduler service lapses the subscription. A past-due subscription lapses on the
+ *   next billing run instead of being charged again.
+ * - Internal status and usage metering for the Transaction service's routing decision:
+ *   free external transfers are reserved against the monthly limit before a transfer is
+ *   made, and released if it does not go ahead.
+ * - Internal billing hooks for the Scheduler service: listing subscriptions due for
+ *   renewal, settling a billing period, and the monthly reset of free transfer usage. A
+ *   period whose fee failed on the last allowed attempt lapses the subscription.
+ *
+ * @dependencies
+ * - Go standard libraries: "context", "errors", "fmt", "log", "time"
+ * - "transfa/services/subscription/internal/domain": For core data models.
+ * - "transfa/services/subscription/internal/store": For repository error values.
+ * - "transfa/services/subscription/pkg/transaction": For subscription fee outcomes.
+ */
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"transfa/services/subscription/internal/domain"
+	"transfa/services/subscription/internal/store"
+	"transfa/services/subscription/pkg/transaction"
+)
+
+var (
+	ErrUserNotFound        = errors.New("user not found")
+	ErrUserIDRequired      = errors.New("user_id is required")
+	ErrAlreadySubscribed   = errors.New("user is already subscribed")
+	ErrNotSubscribed       = errors.New("user does not have an active subscription")
+	ErrAlreadyCancelled    = errors.New("subscription renewal is already cancelled")
+	ErrSubscriptionPastDue = errors.New("subscription has an unpaid fee")
+	ErrConcurrentUpdate    = errors.New("subscription was modified by another request, please retry")
+	ErrInvalidOutcome      = errors.New("outcome must be one of renewed, payment_failed or lapsed")
+	ErrPeriodRequired      = errors.New("period_ends_at is required")
+	ErrPeriodInFuture      = errors.New("period must not be after the current month")
+	ErrAttemptRequired     = errors.New("attempt is required for a failed payment")
+	ErrLimitRequired       = errors.New("limit must be a positive number of transfers")
+	ErrUsagePeriodRequired = errors.New("usage_period is required")
+
+	ErrBillingNotConfigured    = errors.New("subscription fee is not configured")
+	ErrSubscriptionFeeDeclined = errors.New("subscription fee could not be collected from your wallet")
+	ErrSubscriptionFeePending  = errors.New("subscription fee is still being processed, please retry shortly")
+)
+
+// Service provides the application's business logic for subscriptions.
+type Service struct {
+	repo         Repository
+	transactions TransactionClient
+	// fee is the monthly subscription fee in kobo.
+	fee int64
+	// maxPaymentAttempts is how many failed attempts to collect a period's fee are
+	// allowed before the subscription lapses.
+	maxPaymentAttempts int
+}
+
+// NewService creates a new application service.
+func NewService(repo Repository, transactions TransactionClient, fee int64, maxPaymentAttempts int) *Service {
+	return &Service{
+		repo:               repo,
+		transactions:       transactions,
+		fee:                fee,
+		maxPaymentAttempts: maxPaymentAttempts,
+	}
+}
+
+// GetMySubscription returns the authenticated user's subscription.
+func (s *Service) GetMySubscription(ctx context.Context, clerkID string) (*domain.Subscription, error) {
+	userID, err := s.resolveUserID(ctx, clerkID)
+	if err != nil {
+		return nil, err
+	}
+	return s.getOrCreate(ctx, userID)
+}
+
+// Subscribe moves the authenticated user onto the paid tier. A free or cancelled user is
+// charged the fee for the first period before their subscription is activated. A
+// past-due subscription that was cancelled has its renewal, and with it the collection
+// of the unpaid fee, turned back on.
+func (s *Service) Subscribe(ctx context.Context, clerkID string) (*domain.Subscription, error) {
+	userID, err := s.resolveUserID(ctx, clerkID)
+	if err != nil {
+		return nil, err
+	}
+
+	sub, err := s.getOrCreate(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch sub.Status {
+	case domain.StatusActive:
+		if sub.AutoRenew {
+			return nil, ErrAlreadySubscribed
+		}
+		// Cancelled within the current period: resume renewal instead of starting a new period.
+		sub, err = s.repo.SetAutoRenew(ctx, userID, true)
+	case domain.StatusPastDue:
+		if sub.AutoRenew {
+			return nil, ErrSubscriptionPastDue
+		}
+		sub, err = s.repo.SetAutoRenew(ctx, userID, true)
+	default:
+		sub, err = s.activate(ctx, userID)
+	}
+	if err != nil {
+		if errors.Is(err, store.ErrStateChanged) {
+			return nil, ErrConcurrentUpdate
+		}
+		return nil, err
+	}
+
+	log.Printf("User %s subscribed (period ends %v)", userID, sub.CurrentPeriodEndsAt.Time)
+	return sub, nil
+}
+
+// activate charges the fee for the first period and moves the user onto the paid tier
+// once it is collected. The charge is idempotent by a key stored on the subscription:
+// subscribing again while it is unsettled returns the same charge instead of debiting
+// again, and a declined charge clears the key so the next attempt is a new charge.
+func (s *Service) activate(ctx context.Context, userID uuid.UUID) (*domain.Subscription, error) {
+	if s.fee <= 0 {
+		return nil, ErrBillingNotConfigured
+	}
+
+	// Step 1: Record the key of a new charge, or pick up the key of an unsettled one.
+	sub, err := s.repo.BeginActivation(ctx, userID, "subscription_activation:"+uuid.NewString())
+	if err != nil {
+		return nil, err
+	}
+	feeKey := *sub.ActivationFeeKey
+
+	// Step 2: Charge the fee for the first period.
+	tx, err := s.transactions.ChargeSubscriptionFee(ctx, userID, s.fee, feeKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to charge subscription fee: %w", err)
+	}
+
+	// Step 3: Activate the subscription only once the fee is collected.
+	switch tx.Status {
+	case transaction.StatusCompleted:
+		return s.repo.ActivateSubscription(ctx, userID, feeKey)
+	case transaction.StatusFailed:
+		if err := s.repo.AbandonActivation(ctx, userID, feeKey); err != nil {
+			log.Printf("WARNING: Failed to clear declined subscription fee %s for user %s: %v", tx.ID, userID, err)
+		}
+		return nil, ErrSubscriptionFeeDeclined
+	default:
+		log.Printf("Subscription fee %s for user %s is %s; not activating yet", tx.ID, userID, tx.Status)
+		return nil, ErrSubscriptionFeePending
+	}
+}
+
+// Cancel turns off renewal of the authenticated user's subscription. An active
+// subscription keeps the paid tier until its period ends; a past-due one is no longer
+// charged and lapses on the next billing run.
+func (s *Service) Cancel(ctx context.Context, clerkID string) (*domain.Subscription, error) {
+	userID, err := s.resolveUserID(ctx, clerkID)
+	if err != nil {
+		return nil, err
+	}
+
+	sub, err := s.getOrCreate(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if sub.Status != domain.StatusActive && sub.Status != domain.StatusPastDue {
+		return nil, ErrNotSubscribed
+	}
+	if !sub.AutoRenew {
+		return nil, ErrAlreadyCancelled
+	}
+
+	sub, err = s.repo.SetAutoRenew(ctx, userID, false)
+	if err != nil {
+		if errors.Is(err, store.ErrStateChanged) {
+			return nil, ErrConcurrentUpdate
+		}
+		return nil, err
+	}
+
+	log.Printf("User %s cancelled subscription renewal", userID)
+	return sub, nil
+}
+
+// GetStatus returns the routing view of a user's subscription for internal callers.
+func (s *Service) GetStatus(ctx context.Context, userID uuid.UUID) (*domain.SubscriptionStatus, error) {
+	if userID == uuid.Nil {
+		return nil, ErrUserIDRequired
+	}
+	status, err := s.repo.GetSubscriptionStatus(ctx, userID)
+	if err != nil {
+		if errors.Is(err, store.ErrUserNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	return status, nil
+}
+
+// ReserveUsage records one free external transfer received by the user this month if
+// fewer than limit have been received so far. The reservation is made before the
+// transfer, and is released if the transfer does not go ahead.
+func (s *Service) ReserveUsage(ctx context.Context, req domain.ReserveUsageRequest) (*domain.UsageReservation, error) {
+	if req.UserID == uuid.Nil {
+		return nil, ErrUserIDRequired
+	}
+	if req.Limit < 1 {
+		return nil, ErrLimitRequired
+	}
+	reservation, err := s.repo.ReserveExternalTransfer(ctx, req.UserID, req.Limit)
+	if err != nil {
+		if errors.Is(err, store.ErrUserNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	return reservation, nil
+}
+
+// ReleaseUsage gives back a free external transfer reserved in the given month for a
+// transfer that did not go ahead.
+func (s *Service) ReleaseUsage(ctx context.Context, req domain.ReleaseUsageRequest) (*domain.ReleaseUsageResponse, error) {
+	if req.UserID == uuid.Nil {
+		return nil, ErrUserIDRequired
+	}
+	if req.UsagePeriod.IsZero() {
+		return nil, ErrUsagePeriodRequired
+	}
+	released, err := s.repo.ReleaseExternalTransfer(ctx, req.UserID, req.UsagePeriod.UTC())
+	if err != nil {
+		return nil, err
+	}
+	if !released {
+		log.Printf("No external transfer of user %s to release in %s", req.UserID, req.UsagePeriod.Format("2006-01"))
+	}
+	return &domain.ReleaseUsageResponse{Released: released}, nil
+}
+
+// ListDueSubscriptions returns the paid-tier subscriptions whose billing period ended at
+// or before the given time.
+func (s *Service) ListDueSubscriptions(ctx context.Context, before time.Time) ([]domain.Subscription, error) {
+	return s.repo.ListDueSubscriptions(ctx, before)
+}
+
+// SettlePeriod records the outcome of billing a subscription period. A failed payment on
+// the last allowed attempt lapses the subscription. Settling a period that is no longer
+// current, or a failed payment attempt that was already recorded, is a no-op that
+// returns the subscription as it is now.
+func (s *Service) SettlePeriod(ctx context.Context, req domain.SettlePeriodRequest) (*domain.Subscription, error) {
+	if req.UserID == uuid.Nil {
+		return nil, ErrUserIDRequired
+	}
+	if req.PeriodEndsAt.IsZero() {
+		return nil, ErrPeriodRequired
+	}
+	switch req.Outcome {
+	case domain.PeriodRenewed, domain.PeriodLapsed:
+	case domain.PeriodPaymentFailed:
+		if req.Attempt < 1 {
+			return nil, ErrAttemptRequired
+		}
+	default:
+		return nil, ErrInvalidOutcome
+	}
+
+	outcome := req.Outcome
+	if outcome == domain.PeriodPaymentFailed && req.Attempt >= s.maxPaymentAttempts {
+		log.Printf("Subscription fee for user %s failed on attempt %d of %d; lapsing subscription", req.UserID, req.Attempt, s.maxPaymentAttempts)
+		outcome = domain.PeriodLapsed
+	}
+
+	sub, err := s.repo.SettlePeriod(ctx, req.UserID, req.PeriodEndsAt, outcome, req.Attempt)
+	if errors.Is(err, store.ErrStateChanged) {
+		log.Printf("Billing period ending %v for user %s was already settled", req.PeriodEndsAt, req.UserID)
+		return s.getOrCreate(ctx, req.UserID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Billing period ending %v for user %s settled as %s", req.PeriodEndsAt, req.UserID, outcome)
+	return sub, nil
+}
+
+// ResetMonthlyUsage gives every user their free external transfers for the month
+// containing period, or for the current month if period is zero. Usage already counted
+// in that month is kept, so a repeated or late reset does not hand out extra transfers.
+func (s *Service) ResetMonthlyUsage(ctx context.Context, period time.Time) (*domain.ResetUsageResponse, error) {
+	now := time.Now().UTC()
+	if period.IsZero() {
+		period = now
+	}
+	period = period.UTC()
+	period = time.Date(period.Year(), period.Month(), 1, 0, 0, 0, 0, time.UTC)
+	if period.After(now) {
+		return nil, ErrPeriodInFuture
+	}
+
+	n, err := s.repo.ResetMonthlyUsage(ctx, period)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Reset monthly external transfer usage for %s on %d subscriptions", period.Format("2006-01"), n)
+	return &domain.ResetUsageResponse{Period: period, SubscriptionsReset: n}, nil
+}
+
+// resolveUserID looks up the internal user ID of the authenticated user.
+func (s *Service) resolveUserID(ctx context.Context, clerkID string) (uuid.UUID, error) {
+	userID, err := s.repo.GetUserIDByClerkID(ctx, clerkID)
+	if err != nil {
+		if errors.Is(err, store.ErrUserNotFound) {
+			return uuid.Nil, ErrUserNotFound
+		}
+		return uuid.Nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	return userID, nil
+}
+
+// getOrCreate returns the user's subscription, creating a free-tier row if needed.
+func (s *Service) getOrCreate(ctx context.Context, userID uuid.UUID) (*domain.Subscription, error) {
+	sub, err := s.repo.GetOrCreateSubscription(ctx, userID)
+	if err != nil {
+		if errors.Is(err, store.ErrUserNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	return sub, nil
+}
@@ -0,0 +1,308 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"transfa/services/subscription/internal/domain"
+	"transfa/services/subscription/internal/store"
+	"transfa/services/subscription/pkg/transaction"
+)
+
+const (
+	testFee                = 150000
+	testMaxPaymentAttempts = 3
+)
+
+// fakeRepository keeps a single user's subscription in memory. Methods a test does not
+// need are left to the embedded Repository and panic if called.
+type fakeRepository struct {
+	Repository
+
+	userID uuid.UUID
+	sub    domain.Subscription
+}
+
+func newFakeRepository() *fakeRepository {
+	userID := uuid.New()
+	return &fakeRepository{userID: userID, sub: domain.Subscription{ID: uuid.New(), UserID: userID, Status: domain.StatusFree}}
+}
+
+func (r *fakeRepository) GetUserIDByClerkID(ctx context.Context, clerkID string) (uuid.UUID, error) {
+	return r.userID, nil
+}
+
+func (r *fakeRepository) GetOrCreateSubscription(ctx context.Context, userID uuid.UUID) (*domain.Subscription, error) {
+	copied := r.sub
+	return &copied, nil
+}
+
+func (r *fakeRepository) BeginActivation(ctx context.Context, userID uuid.UUID, feeKey string) (*domain.Subscription, error) {
+	if r.sub.Status != domain.StatusFree && r.sub.Status != domain.StatusCancelled {
+		return nil, store.ErrStateChanged
+	}
+	if r.sub.ActivationFeeKey == nil {
+		r.sub.ActivationFeeKey = &feeKey
+	}
+	copied := r.sub
+	return &copied, nil
+}
+
+func (r *fakeRepository) AbandonActivation(ctx context.Context, userID uuid.UUID, feeKey string) error {
+	if r.sub.ActivationFeeKey != nil && *r.sub.ActivationFeeKey == feeKey {
+		r.sub.ActivationFeeKey = nil
+	}
+	return nil
+}
+
+func (r *fakeRepository) ActivateSubscription(ctx context.Context, userID uuid.UUID, feeKey string) (*domain.Subscription, error) {
+	if r.sub.ActivationFeeKey == nil || *r.sub.ActivationFeeKey != feeKey {
+		return nil, store.ErrStateChanged
+	}
+	r.sub.Status, r.sub.AutoRenew, r.sub.ActivationFeeKey = domain.StatusActive, true, nil
+	copied := r.sub
+	return &copied, nil
+}
+
+func (r *fakeRepository) SetAutoRenew(ctx context.Context, userID uuid.UUID, autoRenew bool) (*domain.Subscription, error) {
+	if (r.sub.Status != domain.StatusActive && r.sub.Status != domain.StatusPastDue) || r.sub.AutoRenew == autoRenew {
+		return nil, store.ErrStateChanged
+	}
+	r.sub.AutoRenew = autoRenew
+	copied := r.sub
+	return &copied, nil
+}
+
+func (r *fakeRepository) SettlePeriod(ctx context.Context, userID uuid.UUID, periodEndsAt time.Time, outcome string, attempt int) (*domain.Subscription, error) {
+	if !r.sub.CurrentPeriodEndsAt.Time.Equal(periodEndsAt) {
+		return nil, store.ErrStateChanged
+	}
+	switch outcome {
+	case domain.PeriodPaymentFailed:
+		if r.sub.PaymentAttempts != attempt-1 {
+			return nil, store.ErrStateChanged
+		}
+		r.sub.Status, r.sub.PaymentAttempts = domain.StatusPastDue, attempt
+	case domain.PeriodLapsed:
+		if attempt > 0 && r.sub.PaymentAttempts != attempt-1 {
+			return nil, store.ErrStateChanged
+		}
+		r.sub.Status, r.sub.AutoRenew, r.sub.PaymentAttempts = domain.StatusCancelled, false, 0
+	}
+	copied := r.sub
+	return &copied, nil
+}
+
+func (r *fakeRepository) ResetMonthlyUsage(ctx context.Context, period time.Time) (int64, error) {
+	if !r.sub.UsagePeriod.Before(period) {
+		return 0, nil
+	}
+	r.sub.MonthlyExternalTransfersUsed, r.sub.UsagePeriod = 0, period
+	return 1, nil
+}
+
+// fakeTransactionClient answers fee charges with status, remembering the outcome of each
+// idempotency key as the Transaction service does.
+type fakeTransactionClient struct {
+	status string
+	err    error
+	// charges maps each idempotency key to the transaction it created.
+	charges map[string]*transaction.Transaction
+}
+
+func (c *fakeTransactionClient) ChargeSubscriptionFee(ctx context.Context, userID uuid.UUID, amount int64, idempotencyKey string) (*transaction.Transaction, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	if c.charges == nil {
+		c.charges = make(map[string]*transaction.Transaction)
+	}
+	if tx, ok := c.charges[idempotencyKey]; ok {
+		return tx, nil
+	}
+	tx := &transaction.Transaction{ID: uuid.New(), Type: "subscription_fee", Amount: amount, Status: c.status}
+	c.charges[idempotencyKey] = tx
+	return tx, nil
+}
+
+func TestSubscribeChargesTheFirstPeriod(t *testing.T) {
+	errUnreachable := errors.New("failed to call transaction service")
+	tests := []struct {
+		name       string
+		status     string
+		err        error
+		wantErr    error
+		wantStatus string
+		wantKey    bool
+	}{
+		{"completed", transaction.StatusCompleted, nil, nil, domain.StatusActive, false},
+		{"declined", transaction.StatusFailed, nil, ErrSubscriptionFeeDeclined, domain.StatusFree, false},
+		{"pending", transaction.StatusPending, nil, ErrSubscriptionFeePending, domain.StatusFree, true},
+		{"unreachable", "", errUnreachable, errUnreachable, domain.StatusFree, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeRepository()
+			transactions := &fakeTransactionClient{status: tt.status, err: tt.err}
+			service := NewService(repo, transactions, testFee, testMaxPaymentAttempts)
+
+			_, err := service.Subscribe(context.Background(), "clerk_lovelace")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Subscribe() error = %v, want %v", err, tt.wantErr)
+			}
+			if repo.sub.Status != tt.wantStatus {
+				t.Errorf("subscription status = %q, want %q", repo.sub.Status, tt.wantStatus)
+			}
+			if hasKey := repo.sub.ActivationFeeKey != nil; hasKey != tt.wantKey {
+				t.Errorf("subscription has an activation fee key = %t, want %t", hasKey, tt.wantKey)
+			}
+		})
+	}
+}
+
+func TestSubscribeRetriesReuseAnUnsettledCharge(t *testing.T) {
+	repo := newFakeRepository()
+	transactions := &fakeTransactionClient{status: transaction.StatusPending}
+	service := NewService(repo, transactions, testFee, testMaxPaymentAttempts)
+
+	for i := 0; i < 2; i++ {
+		if _, err := service.Subscribe(context.Background(), "clerk_lovelace"); !errors.Is(err, ErrSubscriptionFeePending) {
+			t.Fatalf("Subscribe() error = %v, want ErrSubscriptionFeePending", err)
+		}
+	}
+	if len(transactions.charges) != 1 {
+		t.Fatalf("made %d charges, want 1", len(transactions.charges))
+	}
+
+	// The charge completes, and the next attempt activates the subscription without
+	// charging again.
+	for _, tx := range transactions.charges {
+		tx.Status = transaction.StatusCompleted
+	}
+	sub, err := service.Subscribe(context.Background(), "clerk_lovelace")
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+	if sub.Status != domain.StatusActive || len(transactions.charges) != 1 {
+		t.Errorf("subscription %q after %d charges, want active after 1", sub.Status, len(transactions.charges))
+	}
+}
+
+func TestSubscribeAfterADeclinedChargeChargesAgain(t *testing.T) {
+	repo := newFakeRepository()
+	transactions := &fakeTransactionClient{status: transaction.StatusFailed}
+	service := NewService(repo, transactions, testFee, testMaxPaymentAttempts)
+
+	if _, err := service.Subscribe(context.Background(), "clerk_lovelace"); !errors.Is(err, ErrSubscriptionFeeDeclined) {
+		t.Fatalf("Subscribe() error = %v, want ErrSubscriptionFeeDeclined", err)
+	}
+
+	transactions.status = transaction.StatusCompleted
+	sub, err := service.Subscribe(context.Background(), "clerk_lovelace")
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+	if sub.Status != domain.StatusActive || len(transactions.charges) != 2 {
+		t.Errorf("subscription %q after %d charges, want active after 2", sub.Status, len(transactions.charges))
+	}
+}
+
+func TestSubscribeWithoutAFeeIsRefused(t *testing.T) {
+	repo := newFakeRepository()
+	service := NewService(repo, &fakeTransactionClient{status: transaction.StatusCompleted}, 0, testMaxPaymentAttempts)
+
+	if _, err := service.Subscribe(context.Background(), "clerk_lovelace"); !errors.Is(err, ErrBillingNotConfigured) {
+		t.Errorf("Subscribe() error = %v, want ErrBillingNotConfigured", err)
+	}
+	if repo.sub.Status != domain.StatusFree {
+		t.Errorf("subscription status = %q, want free", repo.sub.Status)
+	}
+}
+
+func TestCancelAndResumeAPastDueSubscription(t *testing.T) {
+	repo := newFakeRepository()
+	repo.sub.Status, repo.sub.AutoRenew, repo.sub.PaymentAttempts = domain.StatusPastDue, true, 1
+	service := NewService(repo, &fakeTransactionClient{}, testFee, testMaxPaymentAttempts)
+
+	if _, err := service.Subscribe(context.Background(), "clerk_lovelace"); !errors.Is(err, ErrSubscriptionPastDue) {
+		t.Fatalf("Subscribe() error = %v, want ErrSubscriptionPastDue", err)
+	}
+
+	sub, err := service.Cancel(context.Background(), "clerk_lovelace")
+	if err != nil {
+		t.Fatalf("Cancel() error = %v", err)
+	}
+	if sub.Status != domain.StatusPastDue || sub.AutoRenew {
+		t.Errorf("subscription %q with auto-renew %t after cancelling, want past_due without", sub.Status, sub.AutoRenew)
+	}
+
+	// Subscribing again before the next billing run turns renewal back on instead of
+	// charging a new first period.
+	sub, err = service.Subscribe(context.Background(), "clerk_lovelace")
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+	if sub.Status != domain.StatusPastDue || !sub.AutoRenew {
+		t.Errorf("subscription %q with auto-renew %t after resuming, want past_due with", sub.Status, sub.AutoRenew)
+	}
+}
+
+func TestSettlePeriodLapsesAfterTheLastPaymentAttempt(t *testing.T) {
+	repo := newFakeRepository()
+	periodEnd := time.Now().UTC().Truncate(time.Second)
+	repo.sub.Status, repo.sub.AutoRenew = domain.StatusActive, true
+	repo.sub.CurrentPeriodEndsAt.Time, repo.sub.CurrentPeriodEndsAt.Valid = periodEnd, true
+	service := NewService(repo, &fakeTransactionClient{}, testFee, testMaxPaymentAttempts)
+
+	want := []string{domain.StatusPastDue, domain.StatusPastDue, domain.StatusCancelled}
+	for i, wantStatus := range want {
+		attempt := i + 1
+		req := domain.SettlePeriodRequest{UserID: repo.userID, PeriodEndsAt: periodEnd, Outcome: domain.PeriodPaymentFailed, Attempt: attempt}
+		// Each attempt is recorded twice, as a retried billing run would.
+		for j := 0; j < 2; j++ {
+			sub, err := service.SettlePeriod(context.Background(), req)
+			if err != nil {
+				t.Fatalf("SettlePeriod() for attempt %d error = %v", attempt, err)
+			}
+			if sub.Status != wantStatus {
+				t.Errorf("subscription %q after attempt %d failed, want %q", sub.Status, attempt, wantStatus)
+			}
+		}
+	}
+	if repo.sub.AutoRenew || repo.sub.PaymentAttempts != 0 {
+		t.Errorf("lapsed subscription has auto-renew %t and %d payment attempts, want neither", repo.sub.AutoRenew, repo.sub.PaymentAttempts)
+	}
+}
+
+func TestResetMonthlyUsageStartsTheMonthOnce(t *testing.T) {
+	repo := newFakeRepository()
+	service := NewService(repo, &fakeTransactionClient{}, testFee, testMaxPaymentAttempts)
+	now := time.Now().UTC()
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	repo.sub.UsagePeriod, repo.sub.MonthlyExternalTransfersUsed = month.AddDate(0, -1, 0), 5
+
+	resp, err := service.ResetMonthlyUsage(context.Background(), now)
+	if err != nil {
+		t.Fatalf("ResetMonthlyUsage() error = %v", err)
+	}
+	if !resp.Period.Equal(month) || resp.SubscriptionsReset != 1 {
+		t.Errorf("reset %d subscriptions for %v, want 1 for %v", resp.SubscriptionsReset, resp.Period, month)
+	}
+
+	// Transfers counted after the reset survive a retried reset of the same month.
+	repo.sub.MonthlyExternalTransfersUsed = 2
+	if _, err := service.ResetMonthlyUsage(context.Background(), month); err != nil {
+		t.Fatalf("ResetMonthlyUsage() error = %v", err)
+	}
+	if repo.sub.MonthlyExternalTransfersUsed != 2 {
+		t.Errorf("external transfers used = %d after a repeated reset, want 2", repo.sub.MonthlyExternalTransfersUsed)
+	}
+
+	if _, err := service.ResetMonthlyUsage(context.Background(), month.AddDate(0, 1, 0)); !errors.Is(err, ErrPeriodInFuture) {
+		t.Errorf("ResetMonthlyUsage() for next month error = %v, want ErrPeriodInFuture", err)
+	}
+}
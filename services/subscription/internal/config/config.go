@@ -0,0 +1,68 @@
+/**
+ * @description
+ * This file handles configuration management for the Subscription service.
+ * It uses the Viper library to read configuration from environment variables
+ * and a local .env file, making the service easily configurable across
+ * different environments (development, staging, production).
+ *
+ * @dependencies
+ * - "github.com/spf13/viper": A popular library for handling application configuration.
+ */
+package config
+
+import (
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// Config stores all configuration for the application.
+// The values are read by viper from a config file or environment variable.
+type Config struct {
+	DatabaseURL string `mapstructure:"DATABASE_URL"`
+	// ClerkIssuer is the Clerk instance's Frontend API URL, the `iss` of its session tokens.
+	ClerkIssuer string `mapstructure:"CLERK_ISSUER"`
+	// ClerkJWKSURL overrides where Clerk's signing keys are fetched from.
+	ClerkJWKSURL string `mapstructure:"CLERK_JWKS_URL"`
+	// ClerkAudience, if set, must be an audience of every session token.
+	ClerkAudience string `mapstructure:"CLERK_AUDIENCE"`
+	// ClerkClockSkew is the clock skew allowed when checking session token times.
+	ClerkClockSkew time.Duration `mapstructure:"CLERK_CLOCK_SKEW"`
+	InternalAPIKey string        `mapstructure:"INTERNAL_API_KEY"`
+	Port           string        `mapstructure:"PORT"`
+
+	// TransactionServiceURL is where subscription fees are charged.
+	TransactionServiceURL string `mapstructure:"TRANSACTION_SERVICE_URL"`
+	// SubscriptionFee is the monthly subscription fee in kobo, charged when a user
+	// subscribes. Subscribing is refused while it is unset.
+	SubscriptionFee int64 `mapstructure:"SUBSCRIPTION_FEE"`
+	// MaxPaymentAttempts is how many times the fee for a period is tried before the
+	// subscription lapses.
+	MaxPaymentAttempts int `mapstructure:"MAX_PAYMENT_ATTEMPTS"`
+}
+
+// LoadConfig reads configuration from file or environment variables.
+func LoadConfig() (config Config, err error) {
+	viper.AddConfigPath("./")
+	viper.SetConfigName(".env")
+	viper.SetConfigType("env")
+
+	viper.AutomaticEnv()
+
+	// Set default values for robust startup
+	viper.SetDefault("PORT", "8085") // Use a different default port from other services
+	viper.SetDefault("CLERK_CLOCK_SKEW", "5s")
+	viper.SetDefault("TRANSACTION_SERVICE_URL", "http://localhost:8084")
+	viper.SetDefault("MAX_PAYMENT_ATTEMPTS", 3)
+
+	err = viper.ReadInConfig()
+	// It's okay if the config file is not found, we can rely on env vars.
+	if err != nil {
+		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
+			return
+		}
+	}
+
+	err = viper.Unmarshal(&config)
+	return
+}
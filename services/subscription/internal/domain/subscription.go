@@ -12,21 +12,111 @@
 package domain
 
 import (
-"database/sql"
-"time"
+	"database/sql"
+	"time"
 
-"github.com/google/uuid"
+	"github.com/google/uuid"
+)
+
+// Subscription statuses, matching the CHECK constraint on `subscriptions.status`.
+const (
+	StatusFree      = "free"
+	StatusActive    = "active"
+	StatusPastDue   = "past_due"
+	StatusCancelled = "cancelled"
 )
 
 // Subscription represents a user's subscription status and entitlements.
 // It maps directly to the `subscriptions` table in the database.
 type Subscription struct {
-ID                           uuid.UUID    `json:"id" db:"id"`
-UserID                       uuid.UUID    `json:"user_id" db:"user_id"`
-Status                       string       `json:"status" db:"status"`
-AutoRenew                    bool         `json:"auto_renew" db:"auto_renew"`
-CurrentPeriodEndsAt          sql.NullTime `json:"current_period_ends_at,omitempty" db:"current_period_ends_at"`
-MonthlyExternalTransfersUsed int          `json:"monthly_external_transfers_used" db:"monthly_external_transfers_used"`
-CreatedAt                    time.Time    `json:"created_at" db:"created_at"`
-UpdatedAt                    time.Time    `json:"updated_at" db:"updated_at"`
-}
\ No newline at end of file
+	ID                           uuid.UUID    `json:"id" db:"id"`
+	UserID                       uuid.UUID    `json:"user_id" db:"user_id"`
+	Status                       string       `json:"status" db:"status"`
+	AutoRenew                    bool         `json:"auto_renew" db:"auto_renew"`
+	CurrentPeriodEndsAt          sql.NullTime `json:"current_period_ends_at,omitempty" db:"current_period_ends_at"`
+	MonthlyExternalTransfersUsed int          `json:"monthly_external_transfers_used" db:"monthly_external_transfers_used"`
+	// UsagePeriod is the first day (UTC) of the month MonthlyExternalTransfersUsed counts.
+	UsagePeriod time.Time `json:"usage_period" db:"usage_period"`
+	// PaymentAttempts is the number of failed attempts to collect the fee for the
+	// current period.
+	PaymentAttempts int `json:"payment_attempts" db:"payment_attempts"`
+	// ActivationFeeKey is the idempotency key of the fee being charged to activate the
+	// subscription. It is only set while that charge is unsettled.
+	ActivationFeeKey *string   `json:"-" db:"activation_fee_key"`
+	CreatedAt        time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// SubscriptionStatus is the view of a subscription served to other services over the
+// internal API. It carries only what is needed for transfer routing decisions.
+type SubscriptionStatus struct {
+	UserID                       uuid.UUID `json:"user_id"`
+	Status                       string    `json:"status"`
+	IsActive                     bool      `json:"is_active"`
+	MonthlyExternalTransfersUsed int       `json:"monthly_external_transfers_used"`
+}
+
+// ReserveUsageRequest is the expected JSON body for the internal
+// `POST /subscriptions/reserve-usage` endpoint. Limit is the number of free external
+// transfers a user may receive each month.
+type ReserveUsageRequest struct {
+	UserID uuid.UUID `json:"user_id"`
+	Limit  int       `json:"limit"`
+}
+
+// UsageReservation is the response body of the internal `POST /subscriptions/reserve-usage`
+// endpoint. Reserved reports whether a free external transfer was counted; UsagePeriod
+// is the month it was counted in, which is needed to release it again.
+type UsageReservation struct {
+	SubscriptionStatus
+	Reserved    bool      `json:"reserved"`
+	UsagePeriod time.Time `json:"usage_period,omitempty"`
+}
+
+// ReleaseUsageRequest is the expected JSON body for the internal
+// `POST /subscriptions/release-usage` endpoint. UsagePeriod is the month the released
+// transfer was reserved in.
+type ReleaseUsageRequest struct {
+	UserID      uuid.UUID `json:"user_id"`
+	UsagePeriod time.Time `json:"usage_period"`
+}
+
+// ReleaseUsageResponse is the response body of the internal `POST /subscriptions/release-usage` endpoint.
+type ReleaseUsageResponse struct {
+	Released bool `json:"released"`
+}
+
+// Billing period outcomes accepted by the internal `POST /subscriptions/settle-period` endpoint.
+const (
+	// PeriodRenewed starts the next billing period after the fee was collected.
+	PeriodRenewed = "renewed"
+	// PeriodPaymentFailed marks the subscription past due after the fee could not be collected.
+	PeriodPaymentFailed = "payment_failed"
+	// PeriodLapsed ends a subscription whose renewal was cancelled.
+	PeriodLapsed = "lapsed"
+)
+
+// SettlePeriodRequest is the expected JSON body for the internal
+// `POST /subscriptions/settle-period` endpoint. PeriodEndsAt must match the subscription's
+// current period end, which makes repeated requests for the same period harmless.
+// Attempt is the number of the failed payment attempt, counting from one, and is
+// required for PeriodPaymentFailed; recording the same attempt twice counts it once.
+type SettlePeriodRequest struct {
+	UserID       uuid.UUID `json:"user_id"`
+	PeriodEndsAt time.Time `json:"period_ends_at"`
+	Outcome      string    `json:"outcome"`
+	Attempt      int       `json:"attempt,omitempty"`
+}
+
+// ResetUsageRequest is the expected JSON body for the internal
+// `POST /subscriptions/reset-usage` endpoint. Period is the month to start, and defaults
+// to the current one; resetting the same month twice changes nothing the second time.
+type ResetUsageRequest struct {
+	Period time.Time `json:"period"`
+}
+
+// ResetUsageResponse is the response body of the internal `POST /subscriptions/reset-usage` endpoint.
+type ResetUsageResponse struct {
+	Period             time.Time `json:"period"`
+	SubscriptionsReset int64     `json:"subscriptions_reset"`
+}
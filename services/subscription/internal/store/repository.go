@@ -0,0 +1,358 @@
+/**
+ * @description
+ * This file provides the PostgreSQL implementation of the Repository interface for the
+ * Subscription service. It encapsulates all database-specific logic for reading and
+ * changing subscriptions, and for metering free external transfers.
+ *
+ * Users without a `subscriptions` row are on the free tier with no usage; rows are
+ * created lazily the first time a subscription is read by its owner or written.
+ *
+ * @dependencies
+ * - Go standard library packages: "context", "errors", "fmt", "time"
+ * - "github.com/google/uuid": For record identifiers.
+ * - "github.com/jackc/pgx/v5": For checking specific database errors.
+ * - "github.com/jackc/pgx/v5/pgxpool": The PostgreSQL driver and connection pool.
+ * - "transfa/services/subscription/internal/domain": For core data models.
+ */
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"transfa/services/subscription/internal/domain"
+)
+
+var (
+	ErrUserNotFound = errors.New("user not found")
+	// ErrStateChanged is returned when a conditional update matched no rows because the
+	// subscription was changed concurrently.
+	ErrStateChanged = errors.New("subscription was modified concurrently")
+)
+
+// PostgresRepository is the concrete implementation for database operations.
+type PostgresRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewPostgresRepository creates a new repository with a database connection pool.
+func NewPostgresRepository(db *pgxpool.Pool) *PostgresRepository {
+	return &PostgresRepository{
+		db: db,
+	}
+}
+
+// subscriptionColumns is the column list scanned by scanSubscription.
+const subscriptionColumns = `id, user_id, status, auto_renew, current_period_ends_at,
+        monthly_external_transfers_used, usage_period, payment_attempts, activation_fee_key,
+        created_at, updated_at`
+
+// currentUsagePeriod is the first day (UTC) of the current month, the usage period that
+// external transfers made now are counted in.
+const currentUsagePeriod = `date_trunc('month', now() AT TIME ZONE 'UTC')::date`
+
+// scanSubscription scans a row selected with subscriptionColumns into a domain.Subscription.
+func scanSubscription(row pgx.Row) (*domain.Subscription, error) {
+	var sub domain.Subscription
+	err := row.Scan(
+		&sub.ID,
+		&sub.UserID,
+		&sub.Status,
+		&sub.AutoRenew,
+		&sub.CurrentPeriodEndsAt,
+		&sub.MonthlyExternalTransfersUsed,
+		&sub.UsagePeriod,
+		&sub.PaymentAttempts,
+		&sub.ActivationFeeKey,
+		&sub.CreatedAt,
+		&sub.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &sub, nil
+}
+
+// GetUserIDByClerkID resolves the internal user ID for a Clerk User ID.
+func (r *PostgresRepository) GetUserIDByClerkID(ctx context.Context, clerkID string) (uuid.UUID, error) {
+	var userID uuid.UUID
+	err := r.db.QueryRow(ctx, `SELECT id FROM public.users WHERE clerk_id = $1`, clerkID).Scan(&userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return uuid.Nil, fmt.Errorf("%w: with clerk_id %s", ErrUserNotFound, clerkID)
+		}
+		return uuid.Nil, fmt.Errorf("failed to query user by clerk id: %w", err)
+	}
+	return userID, nil
+}
+
+// GetOrCreateSubscription returns the user's subscription, creating a free-tier row if
+// the user does not have one yet.
+func (r *PostgresRepository) GetOrCreateSubscription(ctx context.Context, userID uuid.UUID) (*domain.Subscription, error) {
+	_, err := r.db.Exec(ctx, `
+        INSERT INTO public.subscriptions (user_id)
+        SELECT id FROM public.users WHERE id = $1
+        ON CONFLICT (user_id) DO NOTHING
+    `, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create subscription: %w", err)
+	}
+
+	sub, err := scanSubscription(r.db.QueryRow(ctx, `SELECT `+subscriptionColumns+` FROM public.subscriptions WHERE user_id = $1`, userID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			// The insert selected nothing, so the user does not exist.
+			return nil, fmt.Errorf("%w: with id %s", ErrUserNotFound, userID)
+		}
+		return nil, fmt.Errorf("failed to get subscription: %w", err)
+	}
+	return sub, nil
+}
+
+// GetSubscriptionStatus returns the routing view of a user's subscription without
+// creating a row. Users without a subscription row are reported on the free tier, and
+// usage counted in an earlier month is reported as none.
+func (r *PostgresRepository) GetSubscriptionStatus(ctx context.Context, userID uuid.UUID) (*domain.SubscriptionStatus, error) {
+	query := `
+        SELECT u.id, COALESCE(s.status, 'free'),
+            CASE WHEN s.usage_period < ` + currentUsagePeriod + ` THEN 0
+                 ELSE COALESCE(s.monthly_external_transfers_used, 0) END
+        FROM public.users u
+        LEFT JOIN public.subscriptions s ON s.user_id = u.id
+        WHERE u.id = $1
+    `
+	var status domain.SubscriptionStatus
+	err := r.db.QueryRow(ctx, query, userID).Scan(&status.UserID, &status.Status, &status.MonthlyExternalTransfersUsed)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("%w: with id %s", ErrUserNotFound, userID)
+		}
+		return nil, fmt.Errorf("failed to get subscription status: %w", err)
+	}
+	status.IsActive = status.Status == domain.StatusActive
+	return &status, nil
+}
+
+// BeginActivation records the idempotency key of the fee charged to activate a free or
+// cancelled subscription. If an earlier charge is still unsettled its key is kept, so
+// the returned subscription carries the key to charge with.
+func (r *PostgresRepository) BeginActivation(ctx context.Context, userID uuid.UUID, feeKey string) (*domain.Subscription, error) {
+	query := `
+        UPDATE public.subscriptions
+        SET activation_fee_key = COALESCE(activation_fee_key, $2)
+        WHERE user_id = $1 AND status IN ('free', 'cancelled')
+        RETURNING ` + subscriptionColumns
+
+	sub, err := scanSubscription(r.db.QueryRow(ctx, query, userID, feeKey))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrStateChanged
+		}
+		return nil, fmt.Errorf("failed to begin subscription activation: %w", err)
+	}
+	return sub, nil
+}
+
+// AbandonActivation clears the activation fee key after its charge was declined, so that
+// the next attempt to subscribe is a new charge. A key that was already replaced or
+// cleared is left alone.
+func (r *PostgresRepository) AbandonActivation(ctx context.Context, userID uuid.UUID, feeKey string) error {
+	_, err := r.db.Exec(ctx, `
+        UPDATE public.subscriptions
+        SET activation_fee_key = NULL
+        WHERE user_id = $1 AND activation_fee_key = $2
+    `, userID, feeKey)
+	if err != nil {
+		return fmt.Errorf("failed to abandon subscription activation: %w", err)
+	}
+	return nil
+}
+
+// ActivateSubscription moves a free or cancelled subscription whose activation fee was
+// charged with feeKey onto the paid tier, with auto-renewal enabled and a new one-month
+// billing period.
+func (r *PostgresRepository) ActivateSubscription(ctx context.Context, userID uuid.UUID, feeKey string) (*domain.Subscription, error) {
+	query := `
+        UPDATE public.subscriptions
+        SET status = 'active', auto_renew = true, current_period_ends_at = now() + interval '1 month',
+            activation_fee_key = NULL
+        WHERE user_id = $1 AND status IN ('free', 'cancelled') AND activation_fee_key = $2
+        RETURNING ` + subscriptionColumns
+
+	sub, err := scanSubscription(r.db.QueryRow(ctx, query, userID, feeKey))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrStateChanged
+		}
+		return nil, fmt.Errorf("failed to activate subscription: %w", err)
+	}
+	return sub, nil
+}
+
+// SetAutoRenew turns renewal of an active or past-due subscription on or off. An active
+// subscription stays active until the end of the current period either way.
+func (r *PostgresRepository) SetAutoRenew(ctx context.Context, userID uuid.UUID, autoRenew bool) (*domain.Subscription, error) {
+	query := `
+        UPDATE public.subscriptions
+        SET auto_renew = $2
+        WHERE user_id = $1 AND status IN ('active', 'past_due') AND auto_renew <> $2
+        RETURNING ` + subscriptionColumns
+
+	sub, err := scanSubscription(r.db.QueryRow(ctx, query, userID, autoRenew))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrStateChanged
+		}
+		return nil, fmt.Errorf("failed to update subscription auto-renewal: %w", err)
+	}
+	return sub, nil
+}
+
+// ReserveExternalTransfer atomically adds one to the user's monthly external transfer
+// count if it is below limit, creating the subscription row if needed. The check and the
+// increment are one statement, so concurrent reservations cannot exceed the limit. The
+// first transfer counted in a new month starts the count again at one, even if the
+// monthly reset has not run yet. If the limit is reached, the current status is returned
+// with Reserved false.
+func (r *PostgresRepository) ReserveExternalTransfer(ctx context.Context, userID uuid.UUID, limit int) (*domain.UsageReservation, error) {
+	query := `
+        INSERT INTO public.subscriptions (user_id, monthly_external_transfers_used, usage_period)
+        SELECT id, 1, ` + currentUsagePeriod + ` FROM public.users WHERE id = $1
+        ON CONFLICT (user_id) DO UPDATE
+        SET monthly_external_transfers_used = CASE
+                WHEN public.subscriptions.usage_period < EXCLUDED.usage_period THEN 1
+                ELSE public.subscriptions.monthly_external_transfers_used + 1
+            END,
+            usage_period = GREATEST(public.subscriptions.usage_period, EXCLUDED.usage_period)
+        WHERE public.subscriptions.usage_period < EXCLUDED.usage_period
+            OR public.subscriptions.monthly_external_transfers_used < $2
+        RETURNING user_id, status, monthly_external_transfers_used, usage_period
+    `
+	var reservation domain.UsageReservation
+	err := r.db.QueryRow(ctx, query, userID, limit).Scan(
+		&reservation.UserID,
+		&reservation.Status,
+		&reservation.MonthlyExternalTransfersUsed,
+		&reservation.UsagePeriod,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		// Either the limit is reached or the user does not exist.
+		status, err := r.GetSubscriptionStatus(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		return &domain.UsageReservation{SubscriptionStatus: *status}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to reserve external transfer: %w", err)
+	}
+	reservation.IsActive = reservation.Status == domain.StatusActive
+	reservation.Reserved = true
+	return &reservation, nil
+}
+
+// ReleaseExternalTransfer gives back a free external transfer reserved in period. Once a
+// later month has started the count is left alone, since the release would otherwise be
+// taken from the new month. It reports whether a transfer was released.
+func (r *PostgresRepository) ReleaseExternalTransfer(ctx context.Context, userID uuid.UUID, period time.Time) (bool, error) {
+	cmdTag, err := r.db.Exec(ctx, `
+        UPDATE public.subscriptions
+        SET monthly_external_transfers_used = monthly_external_transfers_used - 1
+        WHERE user_id = $1 AND usage_period = $2 AND monthly_external_transfers_used > 0
+    `, userID, period)
+	if err != nil {
+		return false, fmt.Errorf("failed to release external transfer: %w", err)
+	}
+	return cmdTag.RowsAffected() == 1, nil
+}
+
+// ListDueSubscriptions returns paid-tier subscriptions, including past-due ones, whose
+// current period ended at or before the given time, oldest first.
+func (r *PostgresRepository) ListDueSubscriptions(ctx context.Context, before time.Time) ([]domain.Subscription, error) {
+	query := `
+        SELECT ` + subscriptionColumns + `
+        FROM public.subscriptions
+        WHERE status IN ('active', 'past_due') AND current_period_ends_at <= $1
+        ORDER BY current_period_ends_at
+    `
+	rows, err := r.db.Query(ctx, query, before)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list due subscriptions: %w", err)
+	}
+	defer rows.Close()
+
+	subs := []domain.Subscription{}
+	for rows.Next() {
+		sub, err := scanSubscription(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan subscription: %w", err)
+		}
+		subs = append(subs, *sub)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list due subscriptions: %w", err)
+	}
+	return subs, nil
+}
+
+// SettlePeriod applies the outcome of billing the period that ends at periodEndsAt. The
+// update only matches while that period is still the current one, and a failed payment,
+// or a lapse on a failed payment when attempt is set, only while attempt is the next
+// attempt for it, so settling a period or an attempt twice changes nothing;
+// ErrStateChanged is returned in that case.
+func (r *PostgresRepository) SettlePeriod(ctx context.Context, userID uuid.UUID, periodEndsAt time.Time, outcome string, attempt int) (*domain.Subscription, error) {
+	var set, guard string
+	switch outcome {
+	case domain.PeriodRenewed:
+		set = `status = 'active', current_period_ends_at = current_period_ends_at + interval '1 month', payment_attempts = 0`
+	case domain.PeriodPaymentFailed:
+		set, guard = `status = 'past_due', payment_attempts = $3`, ` AND payment_attempts = $3 - 1`
+	case domain.PeriodLapsed:
+		set = `status = 'cancelled', auto_renew = false, payment_attempts = 0`
+		if attempt > 0 {
+			guard = ` AND payment_attempts = $3 - 1`
+		}
+	default:
+		return nil, fmt.Errorf("unknown billing period outcome %q", outcome)
+	}
+
+	query := `
+        UPDATE public.subscriptions
+        SET ` + set + `
+        WHERE user_id = $1 AND current_period_ends_at = $2 AND status IN ('active', 'past_due')` + guard + `
+        RETURNING ` + subscriptionColumns
+
+	args := []interface{}{userID, periodEndsAt}
+	if guard != "" {
+		args = append(args, attempt)
+	}
+	sub, err := scanSubscription(r.db.QueryRow(ctx, query, args...))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrStateChanged
+		}
+		return nil, fmt.Errorf("failed to settle subscription period: %w", err)
+	}
+	return sub, nil
+}
+
+// ResetMonthlyUsage starts the usage period beginning on the given first day of a month:
+// every count from an earlier period is set back to zero. Counts already in that period
+// or a later one are left alone, so resetting the same period twice changes nothing.
+// It returns the number of subscriptions that changed.
+func (r *PostgresRepository) ResetMonthlyUsage(ctx context.Context, period time.Time) (int64, error) {
+	cmdTag, err := r.db.Exec(ctx, `
+        UPDATE public.subscriptions
+        SET monthly_external_transfers_used = 0, usage_period = $1
+        WHERE usage_period < $1
+    `, period)
+	if err != nil {
+		return 0, fmt.Errorf("failed to reset monthly usage: %w", err)
+	}
+	return cmdTag.RowsAffected(), nil
+}
@@ -0,0 +1,100 @@
+/**
+ * @description
+ * This file provides an HTTP client for the Transaction service's internal API. The
+ * Subscription service uses it to charge the fee for the first period of a subscription;
+ * the Transaction service remains the only component that talks to Anchor.
+ *
+ * Key features:
+ * - `ChargeSubscriptionFee`: Debits a subscription fee. Requests are idempotent by key.
+ *
+ * @dependencies
+ * - "bytes", "context", "encoding/json", "fmt", "io", "net/http", "time"
+ * - "github.com/google/uuid": For record identifiers.
+ */
+package transaction
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Transaction statuses reported by the Transaction service.
+const (
+	StatusPending   = "pending"
+	StatusCompleted = "completed"
+	StatusFailed    = "failed"
+)
+
+// Client is a client for the Transaction service's internal API.
+type Client struct {
+	baseURL    string
+	apiKey     string
+	httpClient *http.Client
+}
+
+// Transaction is the Transaction service's record of a money movement.
+type Transaction struct {
+	ID     uuid.UUID `json:"id"`
+	Type   string    `json:"type"`
+	Amount int64     `json:"amount"`
+	Status string    `json:"status"`
+}
+
+// NewClient creates a new Transaction service client. The apiKey is sent in the
+// X-Internal-API-Key header on every request.
+func NewClient(baseURL, apiKey string) *Client {
+	return &Client{
+		baseURL: baseURL,
+		apiKey:  apiKey,
+		httpClient: &http.Client{
+			Timeout: 30 * time.Second, // Requests wait for Anchor transfers to complete.
+		},
+	}
+}
+
+// ChargeSubscriptionFee debits a subscription fee from the user's main wallet. Calls with
+// the same idempotencyKey return the same transaction instead of debiting again. A
+// declined debit is not an error; the returned transaction has status failed.
+func (c *Client) ChargeSubscriptionFee(ctx context.Context, userID uuid.UUID, amount int64, idempotencyKey string) (*Transaction, error) {
+	payload := map[string]interface{}{
+		"user_id":         userID,
+		"amount":          amount,
+		"idempotency_key": idempotencyKey,
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal subscription fee payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/internal/subscription-fees", bytes.NewBuffer(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create subscription fee request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("X-Internal-API-Key", c.apiKey)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call transaction service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("transaction service returned non-200 status: %d - %s", resp.StatusCode, string(respBody))
+	}
+
+	var tx Transaction
+	if err := json.NewDecoder(resp.Body).Decode(&tx); err != nil {
+		return nil, fmt.Errorf("failed to decode transaction service response: %w", err)
+	}
+	return &tx, nil
+}
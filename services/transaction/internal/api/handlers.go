@@ -0,0 +1,424 @@
+/**
+ * @description
+ * This file contains the HTTP handlers for the Transaction service. Handlers are responsible
+ * for parsing incoming requests, calling the appropriate application service method,
+ * and writing the HTTP response.
+ *
+ * @dependencies
+ * - "encoding/json": For JSON serialization and deserialization.
+ * - "errors": For mapping service errors to HTTP status codes.
+ * - "log": For logging.
+ * - "net/http": For standard HTTP handling.
+ * - "time": For parsing timestamps in query parameters.
+ * - "github.com/go-chi/chi/v5": For reading URL parameters.
+ * - "github.com/google/uuid": For parsing resource identifiers.
+ * - "transfa/services/transaction/internal/app": Imports the application service layer.
+ * - "transfa/services/transaction/internal/domain": Imports the data models/DTOs.
+ * - "transfa/shared/apierror": For JSON:API error responses.
+ * - "transfa/shared/authn": For the Clerk User ID of the caller.
+ * - "transfa/shared/stepup": For the header carrying step-up tokens.
+ */
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"transfa/services/transaction/internal/app"
+	"transfa/services/transaction/internal/domain"
+	"transfa/shared/apierror"
+	"transfa/shared/authn"
+	"transfa/shared/stepup"
+)
+
+// TransactionHandler holds dependencies for the transaction-related HTTP handlers.
+type TransactionHandler struct {
+	service *app.Service
+}
+
+// NewTransactionHandler creates a new handler with the given application service.
+func NewTransactionHandler(service *app.Service) *TransactionHandler {
+	return &TransactionHandler{
+		service: service,
+	}
+}
+
+// P2PTransferHandler handles the `POST /transactions/p2p` request.
+func (h *TransactionHandler) P2PTransferHandler(w http.ResponseWriter, r *http.Request) {
+	clerkID, ok := authn.SubjectFromContext(r.Context())
+	if !ok {
+		apierror.Write(w, http.StatusUnauthorized, "could not retrieve claims")
+		return
+	}
+
+	var req domain.P2PTransferRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.Write(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+	req.StepUpToken = r.Header.Get(stepup.Header)
+
+	tx, err := h.service.ProcessP2PTransfer(r.Context(), clerkID, req)
+	if err != nil {
+		log.Printf("P2P transfer failed for clerk_id %s: %v", clerkID, err)
+		writeServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, transferStatusCode(tx), tx)
+}
+
+// SelfTransferHandler handles the `POST /transactions/self-transfer` request.
+func (h *TransactionHandler) SelfTransferHandler(w http.ResponseWriter, r *http.Request) {
+	clerkID, ok := authn.SubjectFromContext(r.Context())
+	if !ok {
+		apierror.Write(w, http.StatusUnauthorized, "could not retrieve claims")
+		return
+	}
+
+	var req domain.SelfTransferRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.Write(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+	req.StepUpToken = r.Header.Get(stepup.Header)
+
+	tx, err := h.service.ProcessSelfTransfer(r.Context(), clerkID, req)
+	if err != nil {
+		log.Printf("Self-transfer failed for clerk_id %s: %v", clerkID, err)
+		writeServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, transferStatusCode(tx), tx)
+}
+
+// CreateMoneyDropHandler handles the `POST /money-drops` request.
+func (h *TransactionHandler) CreateMoneyDropHandler(w http.ResponseWriter, r *http.Request) {
+	clerkID, ok := authn.SubjectFromContext(r.Context())
+	if !ok {
+		apierror.Write(w, http.StatusUnauthorized, "could not retrieve claims")
+		return
+	}
+
+	var req domain.CreateMoneyDropRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.Write(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+	req.StepUpToken = r.Header.Get(stepup.Header)
+
+	drop, err := h.service.CreateMoneyDrop(r.Context(), clerkID, req)
+	if err != nil {
+		log.Printf("Money Drop creation failed for clerk_id %s: %v", clerkID, err)
+		writeServiceError(w, err)
+		return
+	}
+
+	// A drop whose funding outcome is not known yet is accepted, not created.
+	status := http.StatusCreated
+	if drop.Status == domain.MoneyDropStatusFunding {
+		status = http.StatusAccepted
+	}
+	writeJSON(w, status, drop)
+}
+
+// ClaimMoneyDropHandler handles the `POST /money-drops/{id}/claim` request.
+func (h *TransactionHandler) ClaimMoneyDropHandler(w http.ResponseWriter, r *http.Request) {
+	clerkID, ok := authn.SubjectFromContext(r.Context())
+	if !ok {
+		apierror.Write(w, http.StatusUnauthorized, "could not retrieve claims")
+		return
+	}
+
+	dropID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		apierror.Write(w, http.StatusBadRequest, "invalid money drop id")
+		return
+	}
+
+	tx, err := h.service.ClaimMoneyDrop(r.Context(), clerkID, dropID)
+	if err != nil {
+		log.Printf("Money Drop %s claim failed for clerk_id %s: %v", dropID, clerkID, err)
+		writeServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, transferStatusCode(tx), tx)
+}
+
+// CreatePaymentRequestHandler handles the `POST /payment-requests` request.
+func (h *TransactionHandler) CreatePaymentRequestHandler(w http.ResponseWriter, r *http.Request) {
+	clerkID, ok := authn.SubjectFromContext(r.Context())
+	if !ok {
+		apierror.Write(w, http.StatusUnauthorized, "could not retrieve claims")
+		return
+	}
+
+	var req domain.CreatePaymentRequestRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.Write(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+
+	pr, err := h.service.CreatePaymentRequest(r.Context(), clerkID, req)
+	if err != nil {
+		log.Printf("Payment request creation failed for clerk_id %s: %v", clerkID, err)
+		writeServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, pr)
+}
+
+// ListPaymentRequestsHandler handles the `GET /payment-requests` request.
+// An optional `status` query parameter filters the results.
+func (h *TransactionHandler) ListPaymentRequestsHandler(w http.ResponseWriter, r *http.Request) {
+	clerkID, ok := authn.SubjectFromContext(r.Context())
+	if !ok {
+		apierror.Write(w, http.StatusUnauthorized, "could not retrieve claims")
+		return
+	}
+
+	requests, err := h.service.ListPaymentRequests(r.Context(), clerkID, r.URL.Query().Get("status"))
+	if err != nil {
+		log.Printf("Listing payment requests failed for clerk_id %s: %v", clerkID, err)
+		writeServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, requests)
+}
+
+// GetPaymentRequestHandler handles the `GET /payment-requests/{id}` request.
+func (h *TransactionHandler) GetPaymentRequestHandler(w http.ResponseWriter, r *http.Request) {
+	clerkID, ok := authn.SubjectFromContext(r.Context())
+	if !ok {
+		apierror.Write(w, http.StatusUnauthorized, "could not retrieve claims")
+		return
+	}
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		apierror.Write(w, http.StatusBadRequest, "invalid payment request id")
+		return
+	}
+
+	pr, err := h.service.GetPaymentRequest(r.Context(), clerkID, id)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, pr)
+}
+
+// CancelPaymentRequestHandler handles the `POST /payment-requests/{id}/cancel` request.
+func (h *TransactionHandler) CancelPaymentRequestHandler(w http.ResponseWriter, r *http.Request) {
+	clerkID, ok := authn.SubjectFromContext(r.Context())
+	if !ok {
+		apierror.Write(w, http.StatusUnauthorized, "could not retrieve claims")
+		return
+	}
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		apierror.Write(w, http.StatusBadRequest, "invalid payment request id")
+		return
+	}
+
+	pr, err := h.service.CancelPaymentRequest(r.Context(), clerkID, id)
+	if err != nil {
+		log.Printf("Payment request %s cancellation failed for clerk_id %s: %v", id, clerkID, err)
+		writeServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, pr)
+}
+
+// GetPublicPaymentRequestHandler handles the `GET /payment-requests/public/{token}` request,
+// which resolves a shared payment request link for the payer.
+func (h *TransactionHandler) GetPublicPaymentRequestHandler(w http.ResponseWriter, r *http.Request) {
+	pr, err := h.service.GetPublicPaymentRequest(r.Context(), chi.URLParam(r, "token"))
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, pr)
+}
+
+// ChargeSubscriptionFeeHandler handles the internal `POST /internal/subscription-fees` request.
+// The response is 200 with the transaction whether or not the debit succeeded; callers
+// inspect the transaction status.
+func (h *TransactionHandler) ChargeSubscriptionFeeHandler(w http.ResponseWriter, r *http.Request) {
+	var req domain.SubscriptionFeeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.Write(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+
+	tx, err := h.service.ChargeSubscriptionFee(r.Context(), req)
+	if err != nil {
+		log.Printf("Subscription fee debit failed for user %s: %v", req.UserID, err)
+		writeServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, tx)
+}
+
+// ListExpiredMoneyDropsHandler handles the internal `GET /internal/money-drops/expired` request.
+// The optional `before` query parameter (RFC 3339) defaults to the current time.
+func (h *TransactionHandler) ListExpiredMoneyDropsHandler(w http.ResponseWriter, r *http.Request) {
+	before := time.Now()
+	if raw := r.URL.Query().Get("before"); raw != "" {
+		parsed, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			apierror.Write(w, http.StatusBadRequest, "invalid before timestamp")
+			return
+		}
+		before = parsed
+	}
+
+	ids, err := h.service.ListExpiredMoneyDrops(r.Context(), before)
+	if err != nil {
+		log.Printf("Listing expired money drops failed: %v", err)
+		writeServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, ids)
+}
+
+// RefundMoneyDropHandler handles the internal `POST /internal/money-drops/{id}/refund` request.
+func (h *TransactionHandler) RefundMoneyDropHandler(w http.ResponseWriter, r *http.Request) {
+	dropID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		apierror.Write(w, http.StatusBadRequest, "invalid money drop id")
+		return
+	}
+
+	refund, err := h.service.RefundExpiredMoneyDrop(r.Context(), dropID)
+	if err != nil {
+		log.Printf("Money Drop %s refund failed: %v", dropID, err)
+		writeServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, refund)
+}
+
+// ListPendingTransactionsHandler handles the internal `GET /internal/transactions/pending`
+// request. The optional `before` query parameter (RFC 3339) defaults to the current time.
+func (h *TransactionHandler) ListPendingTransactionsHandler(w http.ResponseWriter, r *http.Request) {
+	before := time.Now()
+	if raw := r.URL.Query().Get("before"); raw != "" {
+		parsed, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			apierror.Write(w, http.StatusBadRequest, "invalid before timestamp")
+			return
+		}
+		before = parsed
+	}
+
+	ids, err := h.service.ListPendingTransactions(r.Context(), before)
+	if err != nil {
+		log.Printf("Listing pending transactions failed: %v", err)
+		writeServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, ids)
+}
+
+// ReconcileTransactionHandler handles the internal `POST /internal/transactions/{id}/reconcile`
+// request. The response is 200 with the transaction, whether or not it could be settled.
+func (h *TransactionHandler) ReconcileTransactionHandler(w http.ResponseWriter, r *http.Request) {
+	txID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		apierror.Write(w, http.StatusBadRequest, "invalid transaction id")
+		return
+	}
+
+	tx, err := h.service.ReconcileTransaction(r.Context(), txID)
+	if err != nil {
+		log.Printf("Reconciling transaction %s failed: %v", txID, err)
+		writeServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, tx)
+}
+
+// transferStatusCode returns the status code for a transfer the user made: 201 Created,
+// or 202 Accepted while its outcome is not known yet.
+func transferStatusCode(tx *domain.Transaction) int {
+	if tx.Status == domain.TransactionStatusPending {
+		return http.StatusAccepted
+	}
+	return http.StatusCreated
+}
+
+// writeServiceError maps application errors to the appropriate HTTP status code.
+func writeServiceError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, app.ErrInvalidAmount),
+		errors.Is(err, app.ErrRecipientRequired),
+		errors.Is(err, app.ErrSelfTransfer),
+		errors.Is(err, app.ErrBeneficiaryRequired),
+		errors.Is(err, app.ErrInvalidClaimCount),
+		errors.Is(err, app.ErrInvalidExpiry),
+		errors.Is(err, app.ErrInvalidPaymentRequestStatus),
+		errors.Is(err, app.ErrUserIDRequired),
+		errors.Is(err, app.ErrIdempotencyKeyRequired):
+		apierror.Write(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, app.ErrSenderNotFound),
+		errors.Is(err, app.ErrSendingNotAllowed),
+		errors.Is(err, domain.ErrMoneyDropOwnClaim),
+		errors.Is(err, app.ErrStepUpRequired),
+		errors.Is(err, app.ErrStepUpInvalid):
+		apierror.Write(w, http.StatusForbidden, err.Error())
+	case errors.Is(err, app.ErrRecipientNotFound),
+		errors.Is(err, app.ErrBeneficiaryNotFound),
+		errors.Is(err, domain.ErrMoneyDropNotFound),
+		errors.Is(err, domain.ErrPaymentRequestNotFound),
+		errors.Is(err, app.ErrTransactionNotFound):
+		apierror.Write(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, app.ErrSenderWalletNotReady),
+		errors.Is(err, domain.ErrMoneyDropAlreadyClaimed),
+		errors.Is(err, domain.ErrPaymentRequestNotPending),
+		errors.Is(err, domain.ErrMoneyDropNotExpired),
+		errors.Is(err, domain.ErrMoneyDropPayoutsPending),
+		errors.Is(err, app.ErrIdempotencyKeyReused):
+		apierror.Write(w, http.StatusConflict, err.Error())
+	case errors.Is(err, app.ErrRecipientUsernameChanged),
+		errors.Is(err, domain.ErrMoneyDropNotActive),
+		errors.Is(err, domain.ErrMoneyDropExpired),
+		errors.Is(err, domain.ErrMoneyDropFullyClaimed):
+		apierror.Write(w, http.StatusGone, err.Error())
+	case errors.Is(err, app.ErrRecipientCannotReceive),
+		errors.Is(err, app.ErrPaymentRequestAmountMismatch),
+		errors.Is(err, app.ErrPaymentRequestRecipientMismatch):
+		apierror.Write(w, http.StatusUnprocessableEntity, err.Error())
+	case errors.Is(err, app.ErrTransferFailed):
+		apierror.Write(w, http.StatusBadGateway, err.Error())
+	default:
+		apierror.Write(w, http.StatusInternalServerError, "")
+	}
+}
+
+// writeJSON writes a JSON response with the given status code.
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		log.Printf("Failed to write response: %v", err)
+	}
+}
@@ -0,0 +1,42 @@
+/**
+ * @description
+ * This file contains the middleware that authenticates calls to the internal endpoints
+ * of the Transaction service's API from other backend services with a shared API key.
+ * Client requests are authenticated by `authn.Middleware` (see router.go).
+ *
+ * @dependencies
+ * - "crypto/subtle": For constant-time comparison of the internal API key.
+ * - "log": For logging misconfiguration.
+ * - "net/http": For standard HTTP handling.
+ * - "transfa/shared/apierror": For JSON:API error responses.
+ */
+package api
+
+import (
+	"crypto/subtle"
+	"log"
+	"net/http"
+
+	"transfa/shared/apierror"
+)
+
+// internalAPIKeyHeader carries the shared key on service-to-service requests.
+const internalAPIKeyHeader = "X-Internal-API-Key"
+
+// InternalAuth is a middleware that only admits requests carrying the shared internal API
+// key. If no key is configured, every internal request is rejected.
+func InternalAuth(apiKey string) func(http.Handler) http.Handler {
+	if apiKey == "" {
+		log.Println("WARNING: INTERNAL_API_KEY is not set; internal endpoints will reject all requests")
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			provided := r.Header.Get(internalAPIKeyHeader)
+			if apiKey == "" || subtle.ConstantTimeCompare([]byte(provided), []byte(apiKey)) != 1 {
+				apierror.Write(w, http.StatusUnauthorized, "invalid internal API key")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
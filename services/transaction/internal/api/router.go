@@ -0,0 +1,83 @@
+/**
+ * @description
+ * This file sets up the HTTP router for the Transaction service using the Chi router.
+ * It defines all the API routes, applies middleware like CORS and authentication,
+ * and connects the routes to their respective handlers.
+ *
+ * @dependencies
+ * - "net/http": For standard HTTP handling.
+ * - "github.com/go-chi/chi/v5": The Chi router library.
+ * - "github.com/go-chi/chi/v5/middleware": For standard Chi middleware.
+ * - "github.com/go-chi/cors": For CORS middleware.
+ * - "transfa/shared/authn": For the Clerk session token middleware.
+ * - "transfa/shared/stepup": For the header carrying step-up tokens.
+ */
+package api
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/cors"
+	"transfa/shared/authn"
+	"transfa/shared/stepup"
+)
+
+// NewRouter creates and configures a new Chi router for the Transaction service.
+func NewRouter(handler *TransactionHandler, verifier authn.TokenVerifier, internalAPIKey string) http.Handler {
+	r := chi.NewRouter()
+
+	// A good base middleware stack
+	r.Use(middleware.RequestID)
+	r.Use(middleware.RealIP)
+	r.Use(middleware.Logger)
+	r.Use(middleware.Recoverer)
+
+	// Basic CORS configuration. This should be more restrictive in production.
+	r.Use(cors.Handler(cors.Options{
+		AllowedOrigins:   []string{"*"},
+		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", stepup.Header},
+		ExposedHeaders:   []string{"Link"},
+		AllowCredentials: true,
+		MaxAge:           300,
+	}))
+
+	// Health check endpoint - does not require authentication
+	r.Get("/health", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"status": "ok"}`))
+	})
+
+	// Protected routes
+	r.Group(func(r chi.Router) {
+		// Require a valid Clerk session token on this group.
+		r.Use(authn.Middleware(verifier))
+
+		r.Post("/transactions/p2p", handler.P2PTransferHandler)
+		r.Post("/transactions/self-transfer", handler.SelfTransferHandler)
+
+		r.Post("/money-drops", handler.CreateMoneyDropHandler)
+		r.Post("/money-drops/{id}/claim", handler.ClaimMoneyDropHandler)
+
+		r.Post("/payment-requests", handler.CreatePaymentRequestHandler)
+		r.Get("/payment-requests", handler.ListPaymentRequestsHandler)
+		r.Get("/payment-requests/public/{token}", handler.GetPublicPaymentRequestHandler)
+		r.Get("/payment-requests/{id}", handler.GetPaymentRequestHandler)
+		r.Post("/payment-requests/{id}/cancel", handler.CancelPaymentRequestHandler)
+	})
+
+	// Internal routes, called by other backend services
+	r.Group(func(r chi.Router) {
+		r.Use(InternalAuth(internalAPIKey))
+
+		r.Post("/internal/subscription-fees", handler.ChargeSubscriptionFeeHandler)
+		r.Get("/internal/money-drops/expired", handler.ListExpiredMoneyDropsHandler)
+		r.Post("/internal/money-drops/{id}/refund", handler.RefundMoneyDropHandler)
+		r.Get("/internal/transactions/pending", handler.ListPendingTransactionsHandler)
+		r.Post("/internal/transactions/{id}/reconcile", handler.ReconcileTransactionHandler)
+	})
+
+	return r
+}
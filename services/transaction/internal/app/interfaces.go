@@ -0,0 +1,96 @@
+/**
+ * @description
+ * This file defines the interfaces (ports) for the Transaction service's application logic.
+ * These interfaces define the contracts for external dependencies, such as the database,
+ * the Anchor API client and the Subscription service, allowing for a clean separation of concerns and easier testing.
+ *
+ * @dependencies
+ * - "context": For passing request-scoped data and cancellation signals.
+ * - "time": For time-based queries.
+ * - "github.com/google/uuid": For record identifiers.
+ * - "transfa/services/transaction/internal/domain": For core data models.
+ * - "transfa/services/transaction/pkg/anchor": For Anchor transfer results.
+ * - "transfa/services/transaction/pkg/subscription": For subscription status results.
+ * - "transfa/shared/messaging": For the messages being published.
+ * - "transfa/shared/stepup": For the money movement a step-up token approves.
+ */
+package app
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"transfa/services/transaction/internal/domain"
+	"transfa/services/transaction/pkg/anchor"
+	"transfa/services/transaction/pkg/subscription"
+	"transfa/shared/messaging"
+	"transfa/shared/stepup"
+)
+
+// Repository defines the interface for data persistence operations.
+type Repository interface {
+	GetUserByClerkID(ctx context.Context, clerkID string) (*domain.User, error)
+	GetUserByUsername(ctx context.Context, username string) (*domain.User, error)
+	// GetUserByOldUsername returns the user who gave up username, while it is still in
+	// its grace period.
+	GetUserByOldUsername(ctx context.Context, username string) (*domain.User, error)
+	GetUserByID(ctx context.Context, userID uuid.UUID) (*domain.User, error)
+	GetAccountByUserID(ctx context.Context, userID uuid.UUID, purpose string) (*domain.Account, error)
+	GetAccountByID(ctx context.Context, accountID uuid.UUID) (*domain.Account, error)
+	CreateAccount(ctx context.Context, account *domain.Account) (*domain.Account, error)
+	GetDefaultBeneficiary(ctx context.Context, userID uuid.UUID) (*domain.Beneficiary, error)
+	GetBeneficiaryByID(ctx context.Context, userID, beneficiaryID uuid.UUID) (*domain.Beneficiary, error)
+	CreateTransaction(ctx context.Context, tx *domain.Transaction) (*domain.Transaction, error)
+	GetTransactionByIdempotencyKey(ctx context.Context, key string) (*domain.Transaction, error)
+	GetTransactionByID(ctx context.Context, id uuid.UUID) (*domain.Transaction, error)
+	UpdateTransactionStatus(ctx context.Context, id uuid.UUID, status string, anchorTransferID *string) error
+	// ListPendingTransactions returns the IDs of transactions created at or before the
+	// given time that are still pending.
+	ListPendingTransactions(ctx context.Context, before time.Time) ([]uuid.UUID, error)
+	// CompleteTransaction and FailTransaction settle a pending transaction, together with
+	// the payment request, Money Drop or claim its transfer holds. They report false if
+	// the transaction was already settled.
+	CompleteTransaction(ctx context.Context, txID uuid.UUID, anchorTransferID string) (bool, error)
+	FailTransaction(ctx context.Context, txID uuid.UUID, anchorTransferID *string) (bool, error)
+	// CreateMoneyDropFunding records a Money Drop in the funding status together with the
+	// pending transaction tx funding it.
+	CreateMoneyDropFunding(ctx context.Context, drop *domain.MoneyDrop, tx *domain.Transaction) error
+	ClaimMoneyDrop(ctx context.Context, dropID, claimantID, destinationAccountID uuid.UUID) (*domain.MoneyDropPayout, error)
+	ListExpiredMoneyDrops(ctx context.Context, before time.Time) ([]uuid.UUID, error)
+	ExpireMoneyDrop(ctx context.Context, dropID uuid.UUID, refundKey string) (*domain.MoneyDropRefund, error)
+	CreatePaymentRequest(ctx context.Context, pr *domain.PaymentRequest) (*domain.PaymentRequest, error)
+	GetPaymentRequestByID(ctx context.Context, id uuid.UUID) (*domain.PaymentRequest, error)
+	GetPublicPaymentRequestByToken(ctx context.Context, token string) (*domain.PublicPaymentRequest, error)
+	ListPaymentRequestsByCreator(ctx context.Context, creatorID uuid.UUID, status string) ([]domain.PaymentRequest, error)
+	CancelPaymentRequest(ctx context.Context, id, creatorID uuid.UUID) (*domain.PaymentRequest, error)
+	// CreateTransactionPayingRequest records tx and reserves the pending payment request
+	// it pays atomically. It returns domain.ErrPaymentRequestNotPending if the request is
+	// no longer pending.
+	CreateTransactionPayingRequest(ctx context.Context, tx *domain.Transaction, paymentRequestID uuid.UUID) error
+	// ConsumeStepUpToken spends the user's step-up token with the given hash if it approves
+	// binding. It returns store.ErrStepUpTokenInvalid otherwise.
+	ConsumeStepUpToken(ctx context.Context, userID uuid.UUID, tokenHash string, binding stepup.Binding) error
+}
+
+// AnchorClient defines the interface for communicating with the Anchor BaaS API.
+type AnchorClient interface {
+	InitiateBookTransfer(ctx context.Context, sourceAccountID, destinationAccountID string, amount int64, reason, reference string) (*anchor.TransferResult, error)
+	InitiateNIPTransfer(ctx context.Context, sourceAccountID, counterPartyID string, amount int64, reason, reference string) (*anchor.TransferResult, error)
+	VerifyTransfer(ctx context.Context, transferID string) (*anchor.TransferResult, error)
+	FindTransferByReference(ctx context.Context, reference string) (*anchor.TransferResult, error)
+	CreateDepositAccount(ctx context.Context, anchorCustomerID, customerType, productName string) (string, error)
+}
+
+// SubscriptionClient defines the interface for communicating with the Subscription service,
+// the source of truth for transfer routing eligibility.
+type SubscriptionClient interface {
+	GetStatus(ctx context.Context, userID uuid.UUID) (*subscription.Status, error)
+	ReserveUsage(ctx context.Context, userID uuid.UUID, limit int) (*subscription.Reservation, error)
+	ReleaseUsage(ctx context.Context, userID uuid.UUID, period time.Time) error
+}
+
+// Publisher defines the interface for publishing messages to a message broker.
+type Publisher interface {
+	PublishMessage(ctx context.Context, exchange, routingKey string, msg messaging.Message) error
+}
@@ -0,0 +1,258 @@
+/**
+ * @description
+ * This file contains the business logic for the Money Drop feature. A Money Drop is a
+ * time-bound pool of funds that other users claim in equal shares via a shared link.
+ *
+ * Key features:
+ * - Creation: provisions (or reuses) the creator's persistent money_drop_wallet and moves
+ *   the full pool into it with a BookTransfer. The drop is recorded in the funding status
+ *   with its funding transfer, and only becomes claimable once that transfer completes.
+ * - Claims: the claim is validated and reserved atomically in the store, then paid out
+ *   from the drop wallet to the claimant's main wallet. Only a payout Anchor rejects or
+ *   reports as failed releases the claim; one whose outcome is unknown keeps it reserved
+ *   and stays pending until it is reconciled.
+ * - Refunds: once a drop expires, the Scheduler service has its unclaimed funds returned
+ *   to the creator's main wallet.
+ *
+ * @dependencies
+ * - Go standard libraries: "context", "errors", "fmt", "log", "math", "time"
+ * - "transfa/services/transaction/internal/domain": For core data models.
+ * - "transfa/services/transaction/internal/store": For repository error values.
+ * - "transfa/shared/stepup": For the step-up token approving the funding.
+ */
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"transfa/services/transaction/internal/domain"
+	"transfa/services/transaction/internal/store"
+	"transfa/shared/stepup"
+)
+
+var (
+	ErrInvalidClaimCount = errors.New("total_claims_allowed must be greater than zero")
+	ErrInvalidExpiry     = errors.New("expiry_timestamp must be in the future")
+)
+
+// CreateMoneyDrop funds a new Money Drop from the creator's main wallet. The returned drop
+// is still funding if the outcome of the funding transfer is not known yet.
+func (s *Service) CreateMoneyDrop(ctx context.Context, clerkID string, req domain.CreateMoneyDropRequest) (*domain.MoneyDrop, error) {
+	if req.AmountPerClaim <= 0 {
+		return nil, ErrInvalidAmount
+	}
+	if req.TotalClaimsAllowed <= 0 {
+		return nil, ErrInvalidClaimCount
+	}
+	if req.AmountPerClaim > math.MaxInt64/int64(req.TotalClaimsAllowed) {
+		return nil, ErrInvalidAmount
+	}
+	if !req.ExpiryTimestamp.After(time.Now()) {
+		return nil, ErrInvalidExpiry
+	}
+	totalAmount := req.AmountPerClaim * int64(req.TotalClaimsAllowed)
+
+	// Step 1: Resolve the creator. Creating a drop is an outbound money movement.
+	creator, err := s.repo.GetUserByClerkID(ctx, clerkID)
+	if err != nil {
+		if errors.Is(err, store.ErrUserNotFound) {
+			return nil, ErrSenderNotFound
+		}
+		return nil, fmt.Errorf("failed to get creator: %w", err)
+	}
+	if !creator.AllowSending {
+		return nil, ErrSendingNotAllowed
+	}
+
+	mainWallet, err := s.repo.GetAccountByUserID(ctx, creator.ID, domain.AccountPurposeMainWallet)
+	if err != nil {
+		if errors.Is(err, store.ErrAccountNotFound) {
+			return nil, ErrSenderWalletNotReady
+		}
+		return nil, fmt.Errorf("failed to get creator wallet: %w", err)
+	}
+
+	// Step 2: Spend the step-up token approving the funding, then get or provision the
+	// creator's Money Drop wallet.
+	binding := stepup.Binding{Purpose: stepup.PurposeMoneyDrop, Amount: totalAmount, Recipient: stepup.MoneyDropRecipient}
+	if err := s.consumeStepUpToken(ctx, creator, req.StepUpToken, binding); err != nil {
+		return nil, err
+	}
+
+	dropWallet, err := s.getOrCreateMoneyDropWallet(ctx, creator)
+	if err != nil {
+		return nil, err
+	}
+
+	// Step 3: Record the drop, which is not claimable until it is funded, together with
+	// the transfer funding it.
+	tx := &domain.Transaction{
+		SenderUserID:         uuid.NullUUID{UUID: creator.ID, Valid: true},
+		RecipientUserID:      uuid.NullUUID{UUID: creator.ID, Valid: true},
+		SourceAccountID:      uuid.NullUUID{UUID: mainWallet.ID, Valid: true},
+		DestinationAccountID: uuid.NullUUID{UUID: dropWallet.ID, Valid: true},
+		Type:                 domain.TransactionTypeMoneyDropFunding,
+		Amount:               totalAmount,
+		Status:               domain.TransactionStatusPending,
+	}
+	drop := &domain.MoneyDrop{
+		CreatorUserID:      creator.ID,
+		FundingAccountID:   dropWallet.ID,
+		TotalAmount:        totalAmount,
+		AmountPerClaim:     req.AmountPerClaim,
+		TotalClaimsAllowed: req.TotalClaimsAllowed,
+		ExpiryTimestamp:    req.ExpiryTimestamp,
+	}
+	if err := s.repo.CreateMoneyDropFunding(ctx, drop, tx); err != nil {
+		return nil, err
+	}
+
+	// Step 4: Fund the drop. Completing the transfer makes the drop claimable.
+	result, err := s.anchorClient.InitiateBookTransfer(ctx, mainWallet.AnchorAccountID, dropWallet.AnchorAccountID, totalAmount, "Transfa Money Drop funding", tx.ID.String())
+	if err := s.settleTransfer(ctx, tx, result, err); err != nil {
+		return nil, err
+	}
+	if tx.Status == domain.TransactionStatusCompleted {
+		drop.Status = domain.MoneyDropStatusActive
+	}
+
+	log.Printf("Money Drop %s created, funding transaction %s %s", drop.ID, tx.ID, tx.Status)
+	return drop, nil
+}
+
+// ClaimMoneyDrop pays the authenticated user one share of a Money Drop. The returned
+// payout transaction is pending if its outcome is not known yet.
+func (s *Service) ClaimMoneyDrop(ctx context.Context, clerkID string, dropID uuid.UUID) (*domain.Transaction, error) {
+	// Step 1: Resolve the claimant and the wallet the share will be paid into.
+	claimant, err := s.repo.GetUserByClerkID(ctx, clerkID)
+	if err != nil {
+		if errors.Is(err, store.ErrUserNotFound) {
+			return nil, ErrSenderNotFound
+		}
+		return nil, fmt.Errorf("failed to get claimant: %w", err)
+	}
+
+	claimantWallet, err := s.repo.GetAccountByUserID(ctx, claimant.ID, domain.AccountPurposeMainWallet)
+	if err != nil {
+		if errors.Is(err, store.ErrAccountNotFound) {
+			return nil, ErrRecipientCannotReceive
+		}
+		return nil, fmt.Errorf("failed to get claimant wallet: %w", err)
+	}
+
+	// Step 2: Atomically validate and reserve the claim.
+	payout, err := s.repo.ClaimMoneyDrop(ctx, dropID, claimant.ID, claimantWallet.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	tx := &payout.Transaction
+	dropWallet, err := s.repo.GetAccountByID(ctx, payout.Drop.FundingAccountID)
+	if err != nil {
+		// Nothing was sent, so failing the payout releases the claim.
+		s.failTransaction(ctx, tx, nil)
+		return nil, fmt.Errorf("failed to get money drop wallet: %w", err)
+	}
+
+	// Step 3: Pay out the share. A payout that fails releases the claim. One whose
+	// outcome is unknown keeps it: the drop wallet holds the funds of all the creator's
+	// drops, so giving the share back to the pool while the payout may still go through
+	// could pay it out twice from another drop's funds.
+	result, err := s.anchorClient.InitiateBookTransfer(ctx, dropWallet.AnchorAccountID, claimantWallet.AnchorAccountID, tx.Amount, "Transfa Money Drop claim", tx.ID.String())
+	if err := s.settleTransfer(ctx, tx, result, err); err != nil {
+		return nil, err
+	}
+
+	log.Printf("Money Drop %s claim %s paid out by transaction %s (%s)", dropID, payout.Claim.ID, tx.ID, tx.Status)
+	return tx, nil
+}
+
+// getOrCreateMoneyDropWallet returns the user's persistent Money Drop wallet, creating
+// it in Anchor and locally the first time the user creates a drop.
+func (s *Service) getOrCreateMoneyDropWallet(ctx context.Context, user *domain.User) (*domain.Account, error) {
+	wallet, err := s.repo.GetAccountByUserID(ctx, user.ID, domain.AccountPurposeMoneyDropWallet)
+	if err == nil {
+		return wallet, nil
+	}
+	if !errors.Is(err, store.ErrAccountNotFound) {
+		return nil, fmt.Errorf("failed to get money drop wallet: %w", err)
+	}
+	if user.AnchorCustomerID == "" {
+		return nil, ErrSenderWalletNotReady
+	}
+
+	productName, customerType := "SAVINGS", "IndividualCustomer"
+	if user.AccountType == "merchant" {
+		productName, customerType = "CURRENT", "BusinessCustomer"
+	}
+
+	anchorAccountID, err := s.anchorClient.CreateDepositAccount(ctx, user.AnchorCustomerID, customerType, productName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create money drop wallet in anchor: %w", err)
+	}
+
+	wallet, err = s.repo.CreateAccount(ctx, &domain.Account{
+		UserID:          user.ID,
+		AnchorAccountID: anchorAccountID,
+		AccountPurpose:  domain.AccountPurposeMoneyDropWallet,
+		Status:          "active",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("CRITICAL: failed to save money drop wallet for user %s with anchor_account_id %s: %w", user.ID, anchorAccountID, err)
+	}
+
+	log.Printf("Provisioned Money Drop wallet %s for user %s", wallet.ID, user.ID)
+	return wallet, nil
+}
+
+// ListExpiredMoneyDrops returns the IDs of active Money Drops that expired at or before
+// the given time and are waiting to be refunded.
+func (s *Service) ListExpiredMoneyDrops(ctx context.Context, before time.Time) ([]uuid.UUID, error) {
+	return s.repo.ListExpiredMoneyDrops(ctx, before)
+}
+
+// RefundExpiredMoneyDrop expires a Money Drop and returns its unclaimed funds from the
+// drop wallet to the creator's main wallet. It is safe to call repeatedly: only the call
+// that expires the drop moves money, and later calls return the recorded refund.
+func (s *Service) RefundExpiredMoneyDrop(ctx context.Context, dropID uuid.UUID) (*domain.MoneyDropRefund, error) {
+	// Step 1: Expire the drop and record the pending refund atomically.
+	refund, err := s.repo.ExpireMoneyDrop(ctx, dropID, "money_drop_refund:"+dropID.String())
+	if err != nil {
+		return nil, err
+	}
+	tx := refund.Transaction
+	if tx == nil {
+		return refund, nil
+	}
+	if !refund.Created {
+		if tx.Status == domain.TransactionStatusPending {
+			log.Printf("WARNING: Refund %s for Money Drop %s is still pending and needs reconciliation", tx.ID, dropID)
+		}
+		return refund, nil
+	}
+
+	// Step 2: Move the unclaimed funds back to the creator.
+	dropWallet, err := s.repo.GetAccountByID(ctx, tx.SourceAccountID.UUID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get money drop wallet: %w", err)
+	}
+	creatorWallet, err := s.repo.GetAccountByID(ctx, tx.DestinationAccountID.UUID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get creator wallet: %w", err)
+	}
+
+	result, err := s.anchorClient.InitiateBookTransfer(ctx, dropWallet.AnchorAccountID, creatorWallet.AnchorAccountID, tx.Amount, "Transfa Money Drop refund", tx.ID.String())
+	if err := s.settleTransfer(ctx, tx, result, err); err != nil {
+		log.Printf("CRITICAL: Refund %s for expired Money Drop %s failed and needs manual resolution", tx.ID, dropID)
+		return nil, err
+	}
+
+	log.Printf("Money Drop %s expired and refunded by transaction %s (%s)", dropID, tx.ID, tx.Status)
+	return refund, nil
+}
@@ -0,0 +1,103 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"transfa/services/transaction/internal/domain"
+	"transfa/services/transaction/pkg/anchor"
+)
+
+func TestConcurrentClaimsExhaustTheMoneyDrop(t *testing.T) {
+	repo := newFakeRepository()
+	creator := repo.addUser("lovelace")
+	drop := repo.addMoneyDrop(creator, 50000, 3)
+	var claimants []*domain.User
+	for i := 0; i < 10; i++ {
+		claimants = append(claimants, repo.addUser(fmt.Sprintf("claimant%d", i)))
+	}
+	service, anchorClient := newTransferTestService(repo)
+
+	errs := make([]error, len(claimants))
+	var wg sync.WaitGroup
+	for i, claimant := range claimants {
+		wg.Add(1)
+		go func(i int, claimant *domain.User) {
+			defer wg.Done()
+			_, errs[i] = service.ClaimMoneyDrop(context.Background(), claimant.ClerkID, drop.ID)
+		}(i, claimant)
+	}
+	wg.Wait()
+
+	var paid, exhausted int
+	for _, err := range errs {
+		switch {
+		case err == nil:
+			paid++
+		// The last claim completes the drop, so later claims find it no longer active.
+		case errors.Is(err, domain.ErrMoneyDropNotActive), errors.Is(err, domain.ErrMoneyDropFullyClaimed):
+			exhausted++
+		default:
+			t.Errorf("ClaimMoneyDrop() error = %v, want nil or a claimed-out error", err)
+		}
+	}
+	if paid != 3 || exhausted != 7 {
+		t.Errorf("paid %d claims and turned away %d, want 3 and 7", paid, exhausted)
+	}
+	if len(anchorClient.transfers) != 3 {
+		t.Errorf("made %d payouts, want 3", len(anchorClient.transfers))
+	}
+	if got := repo.moneyDrops[drop.ID]; got.ClaimsMadeCount != 3 || got.Status != domain.MoneyDropStatusCompleted {
+		t.Errorf("drop has %d claims and status %q, want 3 and completed", got.ClaimsMadeCount, got.Status)
+	}
+
+	if _, err := service.ClaimMoneyDrop(context.Background(), claimants[0].ClerkID, drop.ID); err == nil {
+		t.Error("ClaimMoneyDrop() on a claimed drop succeeded, want an error")
+	}
+}
+
+func TestClaimIsReleasedOnlyWhenThePayoutWasNotMade(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      string
+		err         error
+		wantErr     error
+		wantStatus  string
+		wantClaimed bool
+	}{
+		{"completed", anchor.TransferStatusCompleted, nil, nil, domain.TransactionStatusCompleted, true},
+		{"failed", anchor.TransferStatusFailed, nil, ErrTransferFailed, domain.TransactionStatusFailed, false},
+		{"rejected", "", &anchor.RejectedError{StatusCode: 400, Body: "insufficient balance"}, ErrTransferFailed, domain.TransactionStatusFailed, false},
+		{"timed out", "", context.DeadlineExceeded, nil, domain.TransactionStatusPending, true},
+		{"server error", "", errors.New("anchor transfer api returned non-2xx status: 502"), nil, domain.TransactionStatusPending, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeRepository()
+			creator, claimant := repo.addUser("lovelace"), repo.addUser("babbage")
+			drop := repo.addMoneyDrop(creator, 50000, 1)
+			service, anchorClient := newTransferTestService(repo)
+			anchorClient.status, anchorClient.err = tt.status, tt.err
+
+			tx, err := service.ClaimMoneyDrop(context.Background(), claimant.ClerkID, drop.ID)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ClaimMoneyDrop() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && tx.Status != tt.wantStatus {
+				t.Errorf("returned transaction status = %q, want %q", tx.Status, tt.wantStatus)
+			}
+			for _, recorded := range repo.transactions {
+				if recorded.Status != tt.wantStatus {
+					t.Errorf("recorded transaction status = %q, want %q", recorded.Status, tt.wantStatus)
+				}
+			}
+			if claimed := repo.moneyDrops[drop.ID].ClaimsMadeCount == 1; claimed != tt.wantClaimed {
+				t.Errorf("claim held = %t, want %t", claimed, tt.wantClaimed)
+			}
+		})
+	}
+}
@@ -0,0 +1,149 @@
+/**
+ * @description
+ * This file contains the business logic for payment requests. A user creates a request
+ * for a specific amount and shares its public token; the payer resolves the token and
+ * pays it with a P2P transfer that names the request.
+ *
+ * Key features:
+ * - Create, get, list and cancel for the request's creator.
+ * - Public resolution by opaque token, without exposing the creator's internal ID.
+ * - Payment, in two steps: the request is reserved (`paying`) when the P2P transfer
+ *   paying it is recorded, before Anchor is called, so only one payer can pay it. It is
+ *   fulfilled in the same database transaction that completes the transfer, or made
+ *   pending again if the transfer fails.
+ *
+ * @dependencies
+ * - Go standard libraries: "context", "crypto/rand", "encoding/base64", "errors", "fmt", "log"
+ * - "transfa/services/transaction/internal/domain": For core data models.
+ * - "transfa/services/transaction/internal/store": For repository error values.
+ */
+package app
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/google/uuid"
+	"transfa/services/transaction/internal/domain"
+	"transfa/services/transaction/internal/store"
+)
+
+// publicTokenBytes is the amount of randomness in a payment request's public token.
+const publicTokenBytes = 16
+
+var ErrInvalidPaymentRequestStatus = errors.New("status must be one of pending, paying, fulfilled or cancelled")
+
+// CreatePaymentRequest creates a pending payment request owned by the authenticated user.
+func (s *Service) CreatePaymentRequest(ctx context.Context, clerkID string, req domain.CreatePaymentRequestRequest) (*domain.PaymentRequest, error) {
+	if req.Amount <= 0 {
+		return nil, ErrInvalidAmount
+	}
+
+	creator, err := s.resolveUser(ctx, clerkID)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := generatePublicToken()
+	if err != nil {
+		return nil, err
+	}
+
+	pr, err := s.repo.CreatePaymentRequest(ctx, &domain.PaymentRequest{
+		CreatorUserID: creator.ID,
+		Amount:        req.Amount,
+		Description:   req.Description,
+		ImageURL:      req.ImageURL,
+		Status:        domain.PaymentRequestStatusPending,
+		PublicToken:   token,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Payment request %s created by user %s", pr.ID, creator.ID)
+	return pr, nil
+}
+
+// GetPaymentRequest returns one of the authenticated user's payment requests.
+// Requests owned by other users are reported as not found.
+func (s *Service) GetPaymentRequest(ctx context.Context, clerkID string, id uuid.UUID) (*domain.PaymentRequest, error) {
+	creator, err := s.resolveUser(ctx, clerkID)
+	if err != nil {
+		return nil, err
+	}
+
+	pr, err := s.repo.GetPaymentRequestByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if pr.CreatorUserID != creator.ID {
+		return nil, fmt.Errorf("%w: with id %s", domain.ErrPaymentRequestNotFound, id)
+	}
+	return pr, nil
+}
+
+// ListPaymentRequests returns the authenticated user's payment requests, optionally
+// filtered by status.
+func (s *Service) ListPaymentRequests(ctx context.Context, clerkID, status string) ([]domain.PaymentRequest, error) {
+	switch status {
+	case "", domain.PaymentRequestStatusPending, domain.PaymentRequestStatusPaying, domain.PaymentRequestStatusFulfilled, domain.PaymentRequestStatusCancelled:
+	default:
+		return nil, ErrInvalidPaymentRequestStatus
+	}
+
+	creator, err := s.resolveUser(ctx, clerkID)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.ListPaymentRequestsByCreator(ctx, creator.ID, status)
+}
+
+// CancelPaymentRequest cancels one of the authenticated user's pending payment requests.
+func (s *Service) CancelPaymentRequest(ctx context.Context, clerkID string, id uuid.UUID) (*domain.PaymentRequest, error) {
+	creator, err := s.resolveUser(ctx, clerkID)
+	if err != nil {
+		return nil, err
+	}
+
+	pr, err := s.repo.CancelPaymentRequest(ctx, id, creator.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Payment request %s cancelled by user %s", pr.ID, creator.ID)
+	return pr, nil
+}
+
+// GetPublicPaymentRequest resolves a shared payment request link.
+func (s *Service) GetPublicPaymentRequest(ctx context.Context, token string) (*domain.PublicPaymentRequest, error) {
+	if token == "" {
+		return nil, domain.ErrPaymentRequestNotFound
+	}
+	return s.repo.GetPublicPaymentRequestByToken(ctx, token)
+}
+
+// resolveUser looks up the authenticated user.
+func (s *Service) resolveUser(ctx context.Context, clerkID string) (*domain.User, error) {
+	user, err := s.repo.GetUserByClerkID(ctx, clerkID)
+	if err != nil {
+		if errors.Is(err, store.ErrUserNotFound) {
+			return nil, ErrSenderNotFound
+		}
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	return user, nil
+}
+
+// generatePublicToken returns a URL-safe random token for sharing a payment request.
+func generatePublicToken() (string, error) {
+	b := make([]byte, publicTokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate payment request token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,83 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"transfa/services/transaction/internal/domain"
+	"transfa/shared/stepup"
+)
+
+// addPaymentRequest adds a pending payment request by creator for amount.
+func (r *fakeRepository) addPaymentRequest(creator *domain.User, amount int64) *domain.PaymentRequest {
+	pr := &domain.PaymentRequest{ID: uuid.New(), CreatorUserID: creator.ID, Amount: amount, Status: domain.PaymentRequestStatusPending}
+	r.paymentRequests[pr.ID] = pr
+	return pr
+}
+
+// payRequest pays pr from payer with a step-up token issued for it.
+func payRequest(service *Service, repo *fakeRepository, payer *domain.User, pr *domain.PaymentRequest) (*domain.Transaction, error) {
+	token := repo.issueStepUpToken(payer, stepup.Binding{Purpose: stepup.PurposeP2PTransfer, Amount: pr.Amount, Recipient: stepup.PaymentRequestRecipient(pr.ID)})
+	req := domain.P2PTransferRequest{PaymentRequestID: &pr.ID, StepUpToken: token}
+	return service.ProcessP2PTransfer(context.Background(), payer.ClerkID, req)
+}
+
+func TestPayingReservesThePaymentRequest(t *testing.T) {
+	repo := newFakeRepository()
+	creator := repo.addUser("lovelace")
+	payer, rival := repo.addUser("babbage"), repo.addUser("hopper")
+	pr := repo.addPaymentRequest(creator, 150000)
+	service, anchorClient := newTransferTestService(repo)
+
+	// While the first payment is with Anchor, the request is reserved and a second
+	// payer is turned away before any of their money moves.
+	var rivalErr error
+	anchorClient.onTransfer = func(fakeTransfer) {
+		if status := repo.paymentRequests[pr.ID].Status; status != domain.PaymentRequestStatusPaying {
+			t.Errorf("payment request status during the transfer = %q, want paying", status)
+		}
+		anchorClient.onTransfer = nil
+		_, rivalErr = payRequest(service, repo, rival, pr)
+	}
+
+	tx, err := payRequest(service, repo, payer, pr)
+	if err != nil {
+		t.Fatalf("ProcessP2PTransfer() error = %v", err)
+	}
+	if !errors.Is(rivalErr, domain.ErrPaymentRequestNotPending) {
+		t.Errorf("second payment error = %v, want ErrPaymentRequestNotPending", rivalErr)
+	}
+	if len(anchorClient.transfers) != 1 {
+		t.Errorf("made %d transfers, want 1", len(anchorClient.transfers))
+	}
+	got := repo.paymentRequests[pr.ID]
+	if got.Status != domain.PaymentRequestStatusFulfilled || got.FulfilledByTransactionID.UUID != tx.ID {
+		t.Errorf("payment request = %+v, want fulfilled by %s", got, tx.ID)
+	}
+}
+
+func TestFailedPaymentReleasesThePaymentRequest(t *testing.T) {
+	repo := newFakeRepository()
+	creator, payer := repo.addUser("lovelace"), repo.addUser("babbage")
+	pr := repo.addPaymentRequest(creator, 150000)
+	service, anchorClient := newTransferTestService(repo)
+	anchorClient.status = "FAILED"
+
+	if _, err := payRequest(service, repo, payer, pr); !errors.Is(err, ErrTransferFailed) {
+		t.Fatalf("ProcessP2PTransfer() error = %v, want ErrTransferFailed", err)
+	}
+	if got := repo.paymentRequests[pr.ID].Status; got != domain.PaymentRequestStatusPending {
+		t.Errorf("payment request status = %q, want pending again", got)
+	}
+
+	// It can still be paid.
+	anchorClient.status = "COMPLETED"
+	if _, err := payRequest(service, repo, payer, pr); err != nil {
+		t.Fatalf("second ProcessP2PTransfer() error = %v", err)
+	}
+	if got := repo.paymentRequests[pr.ID].Status; got != domain.PaymentRequestStatusFulfilled {
+		t.Errorf("payment request status = %q, want fulfilled", got)
+	}
+}
@@ -0,0 +1,99 @@
+/**
+ * @description
+ * This file contains the reconciliation of transfers whose outcome was not known when
+ * they were made: calls to Anchor that timed out or failed with a server error, and
+ * transfers Anchor accepted but had not finished. Their transactions stay pending, and
+ * the Scheduler service asks for them to be reconciled through the internal API.
+ *
+ * Key features:
+ * - A pending transaction is settled from the status Anchor reports for its transfer,
+ *   exactly as it would have been had that status come back when the transfer was made.
+ * - A transaction whose call to Anchor never returned a transfer ID is looked up by its
+ *   own ID, which every transfer is made with as its reference. If Anchor has no such
+ *   transfer once unknownTransferGrace has passed, the transfer was never made and the
+ *   transaction is failed, releasing what it held.
+ *
+ * @dependencies
+ * - Go standard libraries: "context", "errors", "fmt", "log", "time"
+ * - "github.com/google/uuid": For transaction identifiers.
+ * - "transfa/services/transaction/internal/domain": For core data models.
+ * - "transfa/services/transaction/internal/store": For repository error values.
+ * - "transfa/services/transaction/pkg/anchor": For transfer lookup results.
+ */
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"transfa/services/transaction/internal/domain"
+	"transfa/services/transaction/internal/store"
+	"transfa/services/transaction/pkg/anchor"
+)
+
+var ErrTransactionNotFound = errors.New("transaction not found")
+
+// unknownTransferGrace is how long after a transaction was created Anchor is given to
+// record its transfer. A transfer Anchor has no record of after that was never made.
+const unknownTransferGrace = 5 * time.Minute
+
+// ListPendingTransactions returns the IDs of transactions created at or before the given
+// time that are still pending.
+func (s *Service) ListPendingTransactions(ctx context.Context, before time.Time) ([]uuid.UUID, error) {
+	return s.repo.ListPendingTransactions(ctx, before)
+}
+
+// ReconcileTransaction settles a pending transaction from the current status of its
+// Anchor transfer and returns it. A transaction that is already settled is returned as
+// it is, and one whose transfer is still in progress stays pending.
+func (s *Service) ReconcileTransaction(ctx context.Context, id uuid.UUID) (*domain.Transaction, error) {
+	// Step 1: Find the transaction to reconcile.
+	tx, err := s.repo.GetTransactionByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, store.ErrTransactionNotFound) {
+			return nil, ErrTransactionNotFound
+		}
+		return nil, err
+	}
+	if tx.Status != domain.TransactionStatusPending {
+		return tx, nil
+	}
+
+	// Step 2: Look up the status Anchor reports, by transfer ID if the call that made the
+	// transfer returned one and by reference otherwise.
+	var result *anchor.TransferResult
+	if tx.AnchorTransferID != nil {
+		result, err = s.anchorClient.VerifyTransfer(ctx, *tx.AnchorTransferID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to verify anchor transfer %s: %w", *tx.AnchorTransferID, err)
+		}
+	} else {
+		result, err = s.anchorClient.FindTransferByReference(ctx, tx.ID.String())
+		if errors.Is(err, anchor.ErrTransferNotFound) {
+			if time.Since(tx.CreatedAt) < unknownTransferGrace {
+				return tx, nil
+			}
+			log.Printf("Anchor has no transfer for pending transaction %s; it was never made", tx.ID)
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to find anchor transfer for transaction %s: %w", tx.ID, err)
+		}
+	}
+
+	// Step 3: Settle the transaction from that status, or fail it if there is no
+	// transfer. A failed transfer is an outcome here, not an error.
+	if result == nil {
+		s.failTransaction(ctx, tx, nil)
+	} else if err := s.settleTransfer(ctx, tx, result, nil); err != nil && !errors.Is(err, ErrTransferFailed) {
+		return nil, err
+	}
+
+	if tx.Type == domain.TransactionTypeMoneyDropRefund && tx.Status == domain.TransactionStatusFailed {
+		log.Printf("CRITICAL: Money Drop refund %s failed and needs manual resolution", tx.ID)
+	}
+	log.Printf("Reconciled transaction %s: %s", tx.ID, tx.Status)
+	return tx, nil
+}
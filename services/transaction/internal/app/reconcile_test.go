@@ -0,0 +1,208 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"transfa/services/transaction/internal/domain"
+	"transfa/services/transaction/pkg/anchor"
+	"transfa/shared/stepup"
+)
+
+// sendP2P sends amount from sender to the recipient username with a step-up token
+// issued for it.
+func sendP2P(service *Service, repo *fakeRepository, sender *domain.User, recipient string, amount int64) (*domain.Transaction, error) {
+	token := repo.issueStepUpToken(sender, stepup.Binding{Purpose: stepup.PurposeP2PTransfer, Amount: amount, Recipient: stepup.UserRecipient(recipient)})
+	req := domain.P2PTransferRequest{RecipientUsername: recipient, Amount: amount, StepUpToken: token}
+	return service.ProcessP2PTransfer(context.Background(), sender.ClerkID, req)
+}
+
+func TestTransferSettlesOnlyOnAFinalStatus(t *testing.T) {
+	tests := []struct {
+		name         string
+		status       string
+		err          error
+		wantErr      error
+		wantStatus   string
+		wantAnchorID bool
+	}{
+		{"completed", anchor.TransferStatusCompleted, nil, nil, domain.TransactionStatusCompleted, true},
+		{"pending", "PENDING", nil, nil, domain.TransactionStatusPending, true},
+		{"processing", "PROCESSING", nil, nil, domain.TransactionStatusPending, true},
+		{"failed", anchor.TransferStatusFailed, nil, ErrTransferFailed, domain.TransactionStatusFailed, true},
+		{"rejected", "", &anchor.RejectedError{StatusCode: 422, Body: "insufficient balance"}, ErrTransferFailed, domain.TransactionStatusFailed, false},
+		{"timed out", "", context.DeadlineExceeded, nil, domain.TransactionStatusPending, false},
+		{"conflict", "", errors.New("anchor transfer api returned non-2xx status: 409"), nil, domain.TransactionStatusPending, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeRepository()
+			sender := repo.addUser("lovelace")
+			repo.addUser("babbage")
+			service, anchorClient := newTransferTestService(repo)
+			anchorClient.status, anchorClient.err = tt.status, tt.err
+
+			_, err := sendP2P(service, repo, sender, "babbage", 250000)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ProcessP2PTransfer() error = %v, want %v", err, tt.wantErr)
+			}
+			if len(repo.transactions) != 1 {
+				t.Fatalf("recorded %d transactions, want 1", len(repo.transactions))
+			}
+			for _, tx := range repo.transactions {
+				if tx.Status != tt.wantStatus {
+					t.Errorf("transaction status = %q, want %q", tx.Status, tt.wantStatus)
+				}
+				if hasID := tx.AnchorTransferID != nil; hasID != tt.wantAnchorID {
+					t.Errorf("transaction has anchor transfer ID = %t, want %t", hasID, tt.wantAnchorID)
+				}
+			}
+
+			wantEvents := 0
+			if tt.wantStatus == domain.TransactionStatusCompleted {
+				wantEvents = 1
+			}
+			if got := len(service.publisher.(*fakePublisher).messages); got != wantEvents {
+				t.Errorf("published %d events, want %d", got, wantEvents)
+			}
+		})
+	}
+}
+
+func TestReconcileTransactionFulfilsThePaymentRequest(t *testing.T) {
+	repo := newFakeRepository()
+	creator, payer := repo.addUser("lovelace"), repo.addUser("babbage")
+	pr := repo.addPaymentRequest(creator, 150000)
+	service, anchorClient := newTransferTestService(repo)
+	anchorClient.status = "PENDING"
+
+	tx, err := payRequest(service, repo, payer, pr)
+	if err != nil {
+		t.Fatalf("ProcessP2PTransfer() error = %v", err)
+	}
+	if tx.Status != domain.TransactionStatusPending || pr.Status != domain.PaymentRequestStatusPaying {
+		t.Fatalf("transaction %q and payment request %q, want pending and paying", tx.Status, pr.Status)
+	}
+
+	// Reconciling while Anchor is still processing the transfer changes nothing.
+	anchorClient.verified = map[string]string{*tx.AnchorTransferID: "PROCESSING"}
+	reconciled, err := service.ReconcileTransaction(context.Background(), tx.ID)
+	if err != nil {
+		t.Fatalf("ReconcileTransaction() error = %v", err)
+	}
+	if reconciled.Status != domain.TransactionStatusPending || pr.Status != domain.PaymentRequestStatusPaying {
+		t.Errorf("transaction %q and payment request %q, want pending and paying", reconciled.Status, pr.Status)
+	}
+
+	anchorClient.verified[*tx.AnchorTransferID] = anchor.TransferStatusCompleted
+	for i := 0; i < 2; i++ {
+		reconciled, err = service.ReconcileTransaction(context.Background(), tx.ID)
+		if err != nil {
+			t.Fatalf("ReconcileTransaction() error = %v", err)
+		}
+	}
+	if reconciled.Status != domain.TransactionStatusCompleted {
+		t.Errorf("transaction status = %q, want completed", reconciled.Status)
+	}
+	if pr.Status != domain.PaymentRequestStatusFulfilled || pr.FulfilledByTransactionID.UUID != tx.ID {
+		t.Errorf("payment request %q fulfilled by %v, want fulfilled by %s", pr.Status, pr.FulfilledByTransactionID, tx.ID)
+	}
+	if got := len(service.publisher.(*fakePublisher).messages); got != 1 {
+		t.Errorf("published %d events, want 1", got)
+	}
+}
+
+func TestReconcileTransactionFailsTheMoneyDrop(t *testing.T) {
+	repo := newFakeRepository()
+	creator := repo.addUser("lovelace")
+	repo.addMoneyDropWallet(creator)
+	service, anchorClient := newTransferTestService(repo)
+	anchorClient.status = "PENDING"
+
+	req := domain.CreateMoneyDropRequest{AmountPerClaim: 50000, TotalClaimsAllowed: 4, ExpiryTimestamp: time.Now().Add(time.Hour)}
+	req.StepUpToken = repo.issueStepUpToken(creator, stepup.Binding{Purpose: stepup.PurposeMoneyDrop, Amount: 200000, Recipient: stepup.MoneyDropRecipient})
+	drop, err := service.CreateMoneyDrop(context.Background(), creator.ClerkID, req)
+	if err != nil {
+		t.Fatalf("CreateMoneyDrop() error = %v", err)
+	}
+	if drop.Status != domain.MoneyDropStatusFunding {
+		t.Fatalf("drop status = %q, want funding", drop.Status)
+	}
+
+	// A drop that is still funding cannot be claimed.
+	claimant := repo.addUser("babbage")
+	if _, err := service.ClaimMoneyDrop(context.Background(), claimant.ClerkID, drop.ID); !errors.Is(err, domain.ErrMoneyDropNotActive) {
+		t.Errorf("ClaimMoneyDrop() error = %v, want ErrMoneyDropNotActive", err)
+	}
+
+	funding := repo.transactions[drop.FundingTransactionID.UUID]
+	anchorClient.verified = map[string]string{*funding.AnchorTransferID: anchor.TransferStatusFailed}
+	reconciled, err := service.ReconcileTransaction(context.Background(), funding.ID)
+	if err != nil {
+		t.Fatalf("ReconcileTransaction() error = %v", err)
+	}
+	if reconciled.Status != domain.TransactionStatusFailed {
+		t.Errorf("transaction status = %q, want failed", reconciled.Status)
+	}
+	if got := repo.moneyDrops[drop.ID].Status; got != domain.MoneyDropStatusFailed {
+		t.Errorf("drop status = %q, want failed", got)
+	}
+}
+
+func TestReconcileTransactionWithoutAnAnchorTransferID(t *testing.T) {
+	tests := []struct {
+		name       string
+		verified   string // the status Anchor has for the transfer; empty if it has none
+		createdAt  time.Time
+		wantStatus string
+	}{
+		{"completed", anchor.TransferStatusCompleted, time.Now(), domain.TransactionStatusCompleted},
+		{"still processing", "PROCESSING", time.Now(), domain.TransactionStatusPending},
+		{"failed", anchor.TransferStatusFailed, time.Now(), domain.TransactionStatusFailed},
+		{"not made yet", "", time.Now(), domain.TransactionStatusPending},
+		{"never made", "", time.Now().Add(-time.Hour), domain.TransactionStatusFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeRepository()
+			creator, payer := repo.addUser("lovelace"), repo.addUser("babbage")
+			pr := repo.addPaymentRequest(creator, 150000)
+			service, anchorClient := newTransferTestService(repo)
+			anchorClient.err = context.DeadlineExceeded
+
+			tx, err := payRequest(service, repo, payer, pr)
+			if err != nil {
+				t.Fatalf("ProcessP2PTransfer() error = %v", err)
+			}
+			if tx.AnchorTransferID != nil {
+				t.Fatalf("transaction has anchor transfer ID %q, want none", *tx.AnchorTransferID)
+			}
+			repo.transactions[tx.ID].CreatedAt = tt.createdAt
+			if tt.verified != "" {
+				anchorClient.verified = map[string]string{"anchor_transfer_1": tt.verified}
+			}
+
+			reconciled, err := service.ReconcileTransaction(context.Background(), tx.ID)
+			if err != nil {
+				t.Fatalf("ReconcileTransaction() error = %v", err)
+			}
+			if reconciled.Status != tt.wantStatus {
+				t.Errorf("transaction status = %q, want %q", reconciled.Status, tt.wantStatus)
+			}
+
+			// The payment request is held only while the transfer is pending.
+			wantRequest := map[string]string{
+				domain.TransactionStatusCompleted: domain.PaymentRequestStatusFulfilled,
+				domain.TransactionStatusPending:   domain.PaymentRequestStatusPaying,
+				domain.TransactionStatusFailed:    domain.PaymentRequestStatusPending,
+			}[tt.wantStatus]
+			if pr.Status != wantRequest {
+				t.Errorf("payment request status = %q, want %q", pr.Status, wantRequest)
+			}
+		})
+	}
+}
@@ -0,0 +1,472 @@
+/**
+ * @description
+ * This file contains the core business logic for the Transaction service. The Service struct
+ * orchestrates money movement by resolving the parties involved, deciding how a transfer
+ * should be routed, calling Anchor, and recording every step in the `transactions` table.
+ *
+ * Key features:
+ * - P2P transfers with subscription-aware routing: recipients on the paid tier, or with
+ *   free external transfers left this month, are paid straight into their default
+ *   beneficiary via an NIPTransfer; everyone else is paid into their in-app wallet via
+ *   a BookTransfer.
+ * - Self-transfers (withdrawals) from the user's wallet to one of their own beneficiaries.
+ * - Every outbound money movement must be approved with a step-up token, checked in
+ *   stepup.go.
+ * - Money Drops, implemented in money_drop.go.
+ * - Subscription fee debits for the Scheduler service, implemented in subscription_fee.go.
+ * - Recipient usernames are resolved in username.go, including usernames their owners
+ *   changed within the grace period.
+ * - Payment requests, implemented in payment_request.go. A P2P transfer that names a
+ *   payment request reserves it before any money moves and fulfils it in the same
+ *   database transaction that completes the transfer.
+ * - Only a transfer Anchor reports as completed is recorded as completed, and only one
+ *   it rejected or reports as failed is recorded as failed. Any other outcome leaves the
+ *   transaction pending until it is reconciled, in reconcile.go.
+ * - A `transaction.completed` event is published for every transfer once it is recorded
+ *   as completed, for the Analytics service.
+ *
+ * @dependencies
+ * - Go standard libraries: "context", "errors", "fmt", "log", "time"
+ * - "transfa/services/transaction/internal/config": For event routing configuration.
+ * - "transfa/services/transaction/internal/domain": For core data models.
+ * - "transfa/services/transaction/internal/store": For repository error values.
+ * - "transfa/services/transaction/pkg/anchor": For Anchor transfer results.
+ * - "transfa/services/transaction/pkg/subscription": For recipient subscription status.
+ * - "transfa/shared/events": For the `transaction.completed` event.
+ * - "transfa/shared/stepup": For the money movements step-up tokens approve.
+ */
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"transfa/services/transaction/internal/config"
+	"transfa/services/transaction/internal/domain"
+	"transfa/services/transaction/internal/store"
+	"transfa/services/transaction/pkg/anchor"
+	"transfa/services/transaction/pkg/subscription"
+	"transfa/shared/events"
+	"transfa/shared/stepup"
+)
+
+// freeExternalTransferLimit is the number of external (NIP) transfers a free-tier
+// user can receive each month before payments are rerouted to their wallet.
+const freeExternalTransferLimit = 5
+
+var (
+	ErrInvalidAmount          = errors.New("amount must be greater than zero")
+	ErrRecipientRequired      = errors.New("recipient_username is required")
+	ErrSelfTransfer           = errors.New("cannot send money to yourself")
+	ErrSenderNotFound         = errors.New("sender has not completed onboarding")
+	ErrSenderWalletNotReady   = errors.New("sender does not have an active wallet")
+	ErrSendingNotAllowed      = errors.New("this account is not allowed to send money")
+	ErrRecipientNotFound      = errors.New("recipient not found")
+	ErrRecipientCannotReceive = errors.New("recipient does not have an account that can receive payments")
+	ErrBeneficiaryRequired    = errors.New("beneficiary_id is required")
+	ErrBeneficiaryNotFound    = errors.New("beneficiary not found")
+	ErrTransferFailed         = errors.New("transfer could not be completed")
+
+	ErrPaymentRequestAmountMismatch    = errors.New("amount does not match the payment request")
+	ErrPaymentRequestRecipientMismatch = errors.New("recipient_username does not match the payment request creator")
+)
+
+// EventProducer names the Transaction service in the events it publishes.
+const EventProducer = "transaction"
+
+// Service provides the application's business logic for money movement.
+type Service struct {
+	repo               Repository
+	anchorClient       AnchorClient
+	subscriptionClient SubscriptionClient
+	publisher          Publisher
+	config             config.Config
+	usernames          *usernameCache
+}
+
+// NewService creates a new application service.
+func NewService(repo Repository, anchorClient AnchorClient, subscriptionClient SubscriptionClient, publisher Publisher, cfg config.Config) *Service {
+	return &Service{
+		repo:               repo,
+		anchorClient:       anchorClient,
+		subscriptionClient: subscriptionClient,
+		publisher:          publisher,
+		config:             cfg,
+		usernames:          newUsernameCache(cfg.UsernameCacheTTL),
+	}
+}
+
+// p2pRoute describes where a P2P payment will land. Exactly one of beneficiary
+// (external NIPTransfer) or account (internal BookTransfer) is set. reservation is the
+// free external transfer reserved for the payment, if the recipient is on the free tier.
+type p2pRoute struct {
+	subscription *subscription.Status
+	beneficiary  *domain.Beneficiary
+	account      *domain.Account
+	reservation  *subscription.Reservation
+}
+
+// isExternal reports whether the payment is routed to the recipient's bank account.
+func (r p2pRoute) isExternal() bool {
+	return r.beneficiary != nil
+}
+
+// ProcessP2PTransfer sends money from the authenticated user to another user by username.
+// If the request names a payment request, the transfer fulfils it on success. The
+// returned transaction is pending if the outcome of the transfer is not known yet.
+func (s *Service) ProcessP2PTransfer(ctx context.Context, senderClerkID string, req domain.P2PTransferRequest) (*domain.Transaction, error) {
+	// Step 1: Resolve the sender and make sure they are allowed to send money.
+	sender, err := s.repo.GetUserByClerkID(ctx, senderClerkID)
+	if err != nil {
+		if errors.Is(err, store.ErrUserNotFound) {
+			return nil, ErrSenderNotFound
+		}
+		return nil, fmt.Errorf("failed to get sender: %w", err)
+	}
+	if !sender.AllowSending {
+		return nil, ErrSendingNotAllowed
+	}
+
+	// Step 2: Resolve the recipient, either by username or from the payment request.
+	recipient, paymentRequest, err := s.resolveP2PRecipient(ctx, &req)
+	if err != nil {
+		return nil, err
+	}
+	if req.Amount <= 0 {
+		return nil, ErrInvalidAmount
+	}
+	if recipient.ID == sender.ID {
+		return nil, ErrSelfTransfer
+	}
+
+	sourceAccount, err := s.repo.GetAccountByUserID(ctx, sender.ID, domain.AccountPurposeMainWallet)
+	if err != nil {
+		if errors.Is(err, store.ErrAccountNotFound) {
+			return nil, ErrSenderWalletNotReady
+		}
+		return nil, fmt.Errorf("failed to get sender wallet: %w", err)
+	}
+
+	// Step 3: Decide whether the payment goes to the recipient's bank or wallet. A free
+	// external transfer is reserved here and released if the payment does not go ahead.
+	route, err := s.resolveP2PRoute(ctx, recipient.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Step 4: Spend the step-up token approving this transfer, then record the pending
+	// transaction before any money moves.
+	binding := stepup.Binding{Purpose: stepup.PurposeP2PTransfer, Amount: req.Amount, Recipient: stepup.UserRecipient(req.RecipientUsername)}
+	if paymentRequest != nil {
+		binding.Recipient = stepup.PaymentRequestRecipient(paymentRequest.ID)
+	}
+	if err := s.consumeStepUpToken(ctx, sender, req.StepUpToken, binding); err != nil {
+		s.releaseUsage(ctx, route)
+		return nil, err
+	}
+
+	tx := &domain.Transaction{
+		SenderUserID:    uuid.NullUUID{UUID: sender.ID, Valid: true},
+		RecipientUserID: uuid.NullUUID{UUID: recipient.ID, Valid: true},
+		SourceAccountID: uuid.NullUUID{UUID: sourceAccount.ID, Valid: true},
+		Type:            domain.TransactionTypeP2P,
+		Amount:          req.Amount,
+		Status:          domain.TransactionStatusPending,
+		Description:     req.Description,
+	}
+	if route.isExternal() {
+		tx.DestinationBeneficiaryID = uuid.NullUUID{UUID: route.beneficiary.ID, Valid: true}
+	} else {
+		tx.DestinationAccountID = uuid.NullUUID{UUID: route.account.ID, Valid: true}
+	}
+
+	// A payment request is reserved with the transaction, so that no one else can pay it
+	// while this transfer is in flight.
+	if paymentRequest != nil {
+		err = s.repo.CreateTransactionPayingRequest(ctx, tx, paymentRequest.ID)
+	} else {
+		_, err = s.repo.CreateTransaction(ctx, tx)
+	}
+	if err != nil {
+		s.releaseUsage(ctx, route)
+		return nil, err
+	}
+
+	// Step 5: Execute the transfer with Anchor. A pending transfer keeps its reserved
+	// free external transfer, since it is most likely to go through.
+	reason := fmt.Sprintf("Transfa payment to @%s", recipient.Username)
+	if req.Description != nil && *req.Description != "" {
+		reason = *req.Description
+	}
+
+	var result *anchor.TransferResult
+	if route.isExternal() {
+		result, err = s.anchorClient.InitiateNIPTransfer(ctx, sourceAccount.AnchorAccountID, route.beneficiary.AnchorCounterpartyID, req.Amount, reason, tx.ID.String())
+	} else {
+		result, err = s.anchorClient.InitiateBookTransfer(ctx, sourceAccount.AnchorAccountID, route.account.AnchorAccountID, req.Amount, reason, tx.ID.String())
+	}
+	if err := s.settleTransfer(ctx, tx, result, err); err != nil {
+		s.releaseUsage(ctx, route)
+		return nil, err
+	}
+
+	log.Printf("P2P transaction %s %s (external=%t)", tx.ID, tx.Status, route.isExternal())
+	return tx, nil
+}
+
+// ProcessSelfTransfer withdraws money from the authenticated user's wallet to one of
+// their own saved beneficiaries. Merchants in "Receive Only" mode may still withdraw,
+// so the allow_sending flag is deliberately not checked here. The returned transaction
+// is pending if the outcome of the withdrawal is not known yet.
+func (s *Service) ProcessSelfTransfer(ctx context.Context, clerkID string, req domain.SelfTransferRequest) (*domain.Transaction, error) {
+	if req.Amount <= 0 {
+		return nil, ErrInvalidAmount
+	}
+	if req.BeneficiaryID == uuid.Nil {
+		return nil, ErrBeneficiaryRequired
+	}
+
+	// Step 1: Resolve the user and their wallet.
+	user, err := s.repo.GetUserByClerkID(ctx, clerkID)
+	if err != nil {
+		if errors.Is(err, store.ErrUserNotFound) {
+			return nil, ErrSenderNotFound
+		}
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	sourceAccount, err := s.repo.GetAccountByUserID(ctx, user.ID, domain.AccountPurposeMainWallet)
+	if err != nil {
+		if errors.Is(err, store.ErrAccountNotFound) {
+			return nil, ErrSenderWalletNotReady
+		}
+		return nil, fmt.Errorf("failed to get user wallet: %w", err)
+	}
+
+	// Step 2: Make sure the beneficiary belongs to the user.
+	beneficiary, err := s.repo.GetBeneficiaryByID(ctx, user.ID, req.BeneficiaryID)
+	if err != nil {
+		if errors.Is(err, store.ErrBeneficiaryNotFound) {
+			return nil, ErrBeneficiaryNotFound
+		}
+		return nil, fmt.Errorf("failed to get beneficiary: %w", err)
+	}
+
+	// Step 3: Spend the step-up token approving this withdrawal, then record the pending
+	// withdrawal before any money moves.
+	binding := stepup.Binding{Purpose: stepup.PurposeSelfTransfer, Amount: req.Amount, Recipient: stepup.BeneficiaryRecipient(beneficiary.ID)}
+	if err := s.consumeStepUpToken(ctx, user, req.StepUpToken, binding); err != nil {
+		return nil, err
+	}
+
+	tx := &domain.Transaction{
+		SenderUserID:             uuid.NullUUID{UUID: user.ID, Valid: true},
+		SourceAccountID:          uuid.NullUUID{UUID: sourceAccount.ID, Valid: true},
+		DestinationBeneficiaryID: uuid.NullUUID{UUID: beneficiary.ID, Valid: true},
+		Type:                     domain.TransactionTypeSelfTransfer,
+		Amount:                   req.Amount,
+		Status:                   domain.TransactionStatusPending,
+	}
+	if _, err := s.repo.CreateTransaction(ctx, tx); err != nil {
+		return nil, err
+	}
+
+	// Step 4: Execute the NIP transfer to the beneficiary's bank account.
+	result, err := s.anchorClient.InitiateNIPTransfer(ctx, sourceAccount.AnchorAccountID, beneficiary.AnchorCounterpartyID, req.Amount, "Transfa withdrawal", tx.ID.String())
+	if err := s.settleTransfer(ctx, tx, result, err); err != nil {
+		return nil, err
+	}
+
+	log.Printf("Self-transfer transaction %s %s", tx.ID, tx.Status)
+	return tx, nil
+}
+
+// resolveP2PRecipient resolves the recipient of a P2P transfer. When the transfer pays a
+// payment request, the request must be pending, the recipient defaults to its creator and
+// the amount defaults to the requested amount; explicit values must match the request.
+func (s *Service) resolveP2PRecipient(ctx context.Context, req *domain.P2PTransferRequest) (*domain.User, *domain.PaymentRequest, error) {
+	recipientUsername := normalizeUsername(req.RecipientUsername)
+
+	if req.PaymentRequestID == nil {
+		if recipientUsername == "" {
+			return nil, nil, ErrRecipientRequired
+		}
+		recipient, err := s.resolveUsername(ctx, recipientUsername)
+		if err != nil {
+			return nil, nil, err
+		}
+		return recipient, nil, nil
+	}
+
+	paymentRequest, err := s.repo.GetPaymentRequestByID(ctx, *req.PaymentRequestID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if paymentRequest.Status != domain.PaymentRequestStatusPending {
+		return nil, nil, domain.ErrPaymentRequestNotPending
+	}
+	if req.Amount == 0 {
+		req.Amount = paymentRequest.Amount
+	} else if req.Amount != paymentRequest.Amount {
+		return nil, nil, ErrPaymentRequestAmountMismatch
+	}
+
+	recipient, err := s.repo.GetUserByID(ctx, paymentRequest.CreatorUserID)
+	if err != nil {
+		if errors.Is(err, store.ErrUserNotFound) {
+			return nil, nil, ErrRecipientNotFound
+		}
+		return nil, nil, fmt.Errorf("failed to get payment request creator: %w", err)
+	}
+	if recipientUsername != "" && recipientUsername != recipient.Username {
+		// The payer may know the creator by a username they have since changed.
+		named, err := s.resolveUsername(ctx, recipientUsername)
+		if errors.Is(err, ErrRecipientNotFound) || (err == nil && named.ID != recipient.ID) {
+			return nil, nil, ErrPaymentRequestRecipientMismatch
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return recipient, paymentRequest, nil
+}
+
+// resolveP2PRoute applies the subscription-aware routing rule for a recipient.
+// A recipient is eligible for an external transfer if their subscription is active
+// or they have not yet used their free external transfers this month. Eligible
+// recipients without a default beneficiary fall back to their in-app wallet. For a
+// free-tier recipient, the external transfer is reserved against the monthly limit
+// before it is made, so that concurrent payments cannot exceed the limit together.
+func (s *Service) resolveP2PRoute(ctx context.Context, recipientID uuid.UUID) (p2pRoute, error) {
+	status, err := s.subscriptionClient.GetStatus(ctx, recipientID)
+	if err != nil {
+		return p2pRoute{}, fmt.Errorf("failed to get recipient subscription status: %w", err)
+	}
+	route := p2pRoute{subscription: status}
+
+	eligible := status.IsActive || status.MonthlyExternalTransfersUsed < freeExternalTransferLimit
+	if eligible {
+		beneficiary, err := s.repo.GetDefaultBeneficiary(ctx, recipientID)
+		switch {
+		case err == nil && status.IsActive:
+			route.beneficiary = beneficiary
+			return route, nil
+		case err == nil:
+			reservation, err := s.subscriptionClient.ReserveUsage(ctx, recipientID, freeExternalTransferLimit)
+			if err != nil {
+				return p2pRoute{}, fmt.Errorf("failed to reserve free external transfer: %w", err)
+			}
+			if reservation.Reserved {
+				route.beneficiary, route.reservation = beneficiary, reservation
+				return route, nil
+			}
+			log.Printf("Recipient %s has used their free external transfers, routing payment to wallet", recipientID)
+		case errors.Is(err, store.ErrBeneficiaryNotFound):
+			log.Printf("Recipient %s has no default beneficiary, routing payment to wallet", recipientID)
+		default:
+			return p2pRoute{}, fmt.Errorf("failed to get recipient default beneficiary: %w", err)
+		}
+	}
+
+	account, err := s.repo.GetAccountByUserID(ctx, recipientID, domain.AccountPurposeMainWallet)
+	if err != nil {
+		if errors.Is(err, store.ErrAccountNotFound) {
+			return p2pRoute{}, ErrRecipientCannotReceive
+		}
+		return p2pRoute{}, fmt.Errorf("failed to get recipient wallet: %w", err)
+	}
+	route.account = account
+	return route, nil
+}
+
+// releaseUsage gives back the free external transfer reserved for route, if any, after
+// the payment was not made.
+func (s *Service) releaseUsage(ctx context.Context, route p2pRoute) {
+	if route.reservation == nil {
+		return
+	}
+	if err := s.subscriptionClient.ReleaseUsage(ctx, route.reservation.UserID, route.reservation.UsagePeriod); err != nil {
+		log.Printf("ERROR: Failed to release free external transfer of user %s reserved in %s: %v", route.reservation.UserID, route.reservation.UsagePeriod.Format("2006-01"), err)
+	}
+}
+
+// settleTransfer records the outcome of an Anchor transfer call on tx. Only a transfer
+// Anchor reports as completed is recorded as completed, and only one Anchor rejected or
+// reports as failed is recorded as failed, returning ErrTransferFailed. Any other
+// outcome, such as a timeout, a 5xx response or a transfer Anchor is still processing,
+// may yet move the money, so tx is left pending for ReconcileTransaction to settle.
+// Settling tx also settles the payment request, Money Drop or claim its transfer holds.
+func (s *Service) settleTransfer(ctx context.Context, tx *domain.Transaction, result *anchor.TransferResult, transferErr error) error {
+	switch {
+	case transferErr != nil && !anchor.IsRejected(transferErr):
+		log.Printf("WARNING: Anchor transfer for transaction %s has an unknown outcome and needs reconciliation: %v", tx.ID, transferErr)
+		return nil
+	case transferErr != nil:
+		log.Printf("Anchor transfer for transaction %s failed: %v", tx.ID, transferErr)
+		s.failTransaction(ctx, tx, nil)
+		return ErrTransferFailed
+	case result.Failed():
+		s.failTransaction(ctx, tx, &result.ID)
+		return ErrTransferFailed
+	case !result.Completed():
+		tx.AnchorTransferID = &result.ID
+		if err := s.repo.UpdateTransactionStatus(ctx, tx.ID, tx.Status, tx.AnchorTransferID); err != nil {
+			log.Printf("ERROR: Failed to record anchor transfer %s on pending transaction %s: %v", result.ID, tx.ID, err)
+		}
+		log.Printf("Anchor transfer %s for transaction %s is %s; waiting for reconciliation", result.ID, tx.ID, result.Status)
+		return nil
+	}
+
+	tx.Status = domain.TransactionStatusCompleted
+	tx.AnchorTransferID = &result.ID
+	completed, err := s.repo.CompleteTransaction(ctx, tx.ID, result.ID)
+	if err != nil {
+		// The money has moved, so we must not report failure to the caller.
+		// The record can be reconciled from the Anchor transfer ID in the logs.
+		log.Printf("CRITICAL: Failed to mark transaction %s as completed (anchor transfer %s): %v", tx.ID, result.ID, err)
+		return nil
+	}
+	if completed {
+		s.publishTransactionCompleted(ctx, tx)
+	}
+	return nil
+}
+
+// failTransaction records tx as failed, releasing what its transfer held.
+func (s *Service) failTransaction(ctx context.Context, tx *domain.Transaction, anchorTransferID *string) {
+	tx.Status = domain.TransactionStatusFailed
+	if anchorTransferID != nil {
+		tx.AnchorTransferID = anchorTransferID
+	}
+	if _, err := s.repo.FailTransaction(ctx, tx.ID, anchorTransferID); err != nil {
+		log.Printf("CRITICAL: Failed to mark transaction %s as failed: %v", tx.ID, err)
+	}
+}
+
+// publishTransactionCompleted publishes the `transaction.completed` event for a
+// transaction that was just recorded as completed. Failures are logged, not returned,
+// because the transfer itself has already succeeded.
+func (s *Service) publishTransactionCompleted(ctx context.Context, tx *domain.Transaction) {
+	envelope, err := events.New(EventProducer, events.TransactionCompleted{
+		TransactionID:   tx.ID,
+		Type:            tx.Type,
+		Amount:          tx.Amount,
+		Fee:             tx.Fee,
+		Category:        tx.Category,
+		SenderUserID:    tx.SenderUserID,
+		RecipientUserID: tx.RecipientUserID,
+		CompletedAt:     time.Now().UTC(),
+	}, events.Metadata{})
+	if err != nil {
+		log.Printf("ERROR: Failed to create transaction.completed event for transaction %s: %v", tx.ID, err)
+		return
+	}
+
+	if err := events.Publish(ctx, s.publisher, s.config.TransactionCompletedEx, s.config.TransactionCompletedRK, envelope); err != nil {
+		log.Printf("ERROR: Failed to publish transaction.completed event %s for transaction %s: %v", envelope.EventID, tx.ID, err)
+	}
+}
@@ -0,0 +1,703 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"transfa/services/transaction/internal/domain"
+	"transfa/services/transaction/internal/store"
+	"transfa/services/transaction/pkg/anchor"
+	"transfa/services/transaction/pkg/subscription"
+	"transfa/shared/messaging"
+	"transfa/shared/stepup"
+)
+
+// fakeRepository keeps users and payment requests in memory. Methods a test does not
+// need are left to the embedded Repository and panic if called. Each method holds mu,
+// as a database transaction holding its row locks would.
+type fakeRepository struct {
+	Repository
+
+	mu sync.Mutex
+
+	users map[uuid.UUID]*domain.User
+	// oldUsernames maps usernames in their grace period to the users who changed them.
+	oldUsernames    map[string]uuid.UUID
+	paymentRequests map[uuid.UUID]*domain.PaymentRequest
+	// usernameLookups counts the username queries, to observe the username cache.
+	usernameLookups int
+
+	// wallets holds each user's main wallet and beneficiaries their bank accounts.
+	wallets       map[uuid.UUID]*domain.Account
+	beneficiaries map[uuid.UUID]*domain.Beneficiary
+	transactions  map[uuid.UUID]*domain.Transaction
+	// stepUpTokens maps the hashes of unused step-up tokens to what they approve.
+	stepUpTokens map[string]fakeStepUpToken
+	// payingTransactions maps reserved payment requests to the transactions paying them.
+	payingTransactions map[uuid.UUID]uuid.UUID
+	// dropWallets holds each user's Money Drop wallet.
+	dropWallets map[uuid.UUID]*domain.Account
+	moneyDrops  map[uuid.UUID]*domain.MoneyDrop
+	claims      map[uuid.UUID]domain.MoneyDropClaim
+}
+
+// fakeStepUpToken is an unused step-up token issued to userID.
+type fakeStepUpToken struct {
+	userID  uuid.UUID
+	binding stepup.Binding
+}
+
+func newFakeRepository() *fakeRepository {
+	return &fakeRepository{
+		users:           make(map[uuid.UUID]*domain.User),
+		oldUsernames:    make(map[string]uuid.UUID),
+		paymentRequests: make(map[uuid.UUID]*domain.PaymentRequest),
+		wallets:         make(map[uuid.UUID]*domain.Account),
+		beneficiaries:   make(map[uuid.UUID]*domain.Beneficiary),
+		transactions:    make(map[uuid.UUID]*domain.Transaction),
+		stepUpTokens:    make(map[string]fakeStepUpToken),
+
+		payingTransactions: make(map[uuid.UUID]uuid.UUID),
+		dropWallets:        make(map[uuid.UUID]*domain.Account),
+		moneyDrops:         make(map[uuid.UUID]*domain.MoneyDrop),
+		claims:             make(map[uuid.UUID]domain.MoneyDropClaim),
+	}
+}
+
+// addUser adds a user with the given username and an active main wallet.
+func (r *fakeRepository) addUser(username string) *domain.User {
+	user := &domain.User{ID: uuid.New(), ClerkID: "clerk_" + username, Username: username, AllowSending: true}
+	r.users[user.ID] = user
+	r.wallets[user.ID] = &domain.Account{
+		ID:              uuid.New(),
+		UserID:          user.ID,
+		AnchorAccountID: "anchor_" + username,
+		AccountPurpose:  domain.AccountPurposeMainWallet,
+		Status:          "active",
+	}
+	return user
+}
+
+// addBeneficiary adds a default bank account for the user.
+func (r *fakeRepository) addBeneficiary(user *domain.User) *domain.Beneficiary {
+	beneficiary := &domain.Beneficiary{ID: uuid.New(), UserID: user.ID, AnchorCounterpartyID: "counterparty_" + user.Username, IsDefault: true}
+	r.beneficiaries[beneficiary.ID] = beneficiary
+	return beneficiary
+}
+
+// issueStepUpToken issues the user a step-up token that approves binding, as the Auth
+// service does once they enter their PIN.
+func (r *fakeRepository) issueStepUpToken(user *domain.User, binding stepup.Binding) string {
+	token, hash, err := stepup.NewToken()
+	if err != nil {
+		panic(err)
+	}
+	r.stepUpTokens[hash] = fakeStepUpToken{userID: user.ID, binding: binding}
+	return token
+}
+
+// changeUsername changes the user's username and keeps the old one in its grace period.
+func (r *fakeRepository) changeUsername(user *domain.User, username string) {
+	r.oldUsernames[user.Username] = user.ID
+	user.Username = username
+}
+
+func (r *fakeRepository) GetUserByClerkID(ctx context.Context, clerkID string) (*domain.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, user := range r.users {
+		if user.ClerkID == clerkID {
+			copied := *user
+			return &copied, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: with clerk_id %s", store.ErrUserNotFound, clerkID)
+}
+
+func (r *fakeRepository) GetUserByUsername(ctx context.Context, username string) (*domain.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.usernameLookups++
+	for _, user := range r.users {
+		if user.Username == username {
+			copied := *user
+			return &copied, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: with username %s", store.ErrUserNotFound, username)
+}
+
+func (r *fakeRepository) GetUserByOldUsername(ctx context.Context, username string) (*domain.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	id, ok := r.oldUsernames[username]
+	if !ok {
+		return nil, fmt.Errorf("%w: with old username %s", store.ErrUserNotFound, username)
+	}
+	return r.getUserByID(id)
+}
+
+func (r *fakeRepository) GetUserByID(ctx context.Context, userID uuid.UUID) (*domain.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.getUserByID(userID)
+}
+
+func (r *fakeRepository) getUserByID(userID uuid.UUID) (*domain.User, error) {
+	user, ok := r.users[userID]
+	if !ok {
+		return nil, fmt.Errorf("%w: with id %s", store.ErrUserNotFound, userID)
+	}
+	copied := *user
+	return &copied, nil
+}
+
+func (r *fakeRepository) GetPaymentRequestByID(ctx context.Context, id uuid.UUID) (*domain.PaymentRequest, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	pr, ok := r.paymentRequests[id]
+	if !ok {
+		return nil, domain.ErrPaymentRequestNotFound
+	}
+	copied := *pr
+	return &copied, nil
+}
+
+func (r *fakeRepository) GetAccountByUserID(ctx context.Context, userID uuid.UUID, purpose string) (*domain.Account, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	wallets := r.wallets
+	if purpose == domain.AccountPurposeMoneyDropWallet {
+		wallets = r.dropWallets
+	}
+	account, ok := wallets[userID]
+	if !ok {
+		return nil, fmt.Errorf("%w: for user %s", store.ErrAccountNotFound, userID)
+	}
+	copied := *account
+	return &copied, nil
+}
+
+func (r *fakeRepository) GetDefaultBeneficiary(ctx context.Context, userID uuid.UUID) (*domain.Beneficiary, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, beneficiary := range r.beneficiaries {
+		if beneficiary.UserID == userID && beneficiary.IsDefault {
+			copied := *beneficiary
+			return &copied, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: default for user %s", store.ErrBeneficiaryNotFound, userID)
+}
+
+func (r *fakeRepository) GetBeneficiaryByID(ctx context.Context, userID, beneficiaryID uuid.UUID) (*domain.Beneficiary, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	beneficiary, ok := r.beneficiaries[beneficiaryID]
+	if !ok || beneficiary.UserID != userID {
+		return nil, fmt.Errorf("%w: with id %s", store.ErrBeneficiaryNotFound, beneficiaryID)
+	}
+	copied := *beneficiary
+	return &copied, nil
+}
+
+func (r *fakeRepository) CreateTransaction(ctx context.Context, tx *domain.Transaction) (*domain.Transaction, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.insertTransaction(tx)
+	return tx, nil
+}
+
+func (r *fakeRepository) insertTransaction(tx *domain.Transaction) {
+	tx.ID = uuid.New()
+	copied := *tx
+	r.transactions[tx.ID] = &copied
+}
+
+func (r *fakeRepository) UpdateTransactionStatus(ctx context.Context, id uuid.UUID, status string, anchorTransferID *string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.updateTransactionStatus(id, status, anchorTransferID)
+}
+
+func (r *fakeRepository) updateTransactionStatus(id uuid.UUID, status string, anchorTransferID *string) error {
+	tx, ok := r.transactions[id]
+	if !ok {
+		return fmt.Errorf("%w: with id %s", store.ErrTransactionNotFound, id)
+	}
+	tx.Status = status
+	if anchorTransferID != nil {
+		tx.AnchorTransferID = anchorTransferID
+	}
+	return nil
+}
+
+func (r *fakeRepository) ConsumeStepUpToken(ctx context.Context, userID uuid.UUID, tokenHash string, binding stepup.Binding) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	token, ok := r.stepUpTokens[tokenHash]
+	if !ok || token.userID != userID || token.binding != binding {
+		return store.ErrStepUpTokenInvalid
+	}
+	delete(r.stepUpTokens, tokenHash)
+	return nil
+}
+
+func (r *fakeRepository) CreateTransactionPayingRequest(ctx context.Context, tx *domain.Transaction, paymentRequestID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	pr, ok := r.paymentRequests[paymentRequestID]
+	if !ok || pr.Status != domain.PaymentRequestStatusPending {
+		return domain.ErrPaymentRequestNotPending
+	}
+	r.insertTransaction(tx)
+	pr.Status = domain.PaymentRequestStatusPaying
+	r.payingTransactions[pr.ID] = tx.ID
+	return nil
+}
+
+func (r *fakeRepository) GetTransactionByID(ctx context.Context, id uuid.UUID) (*domain.Transaction, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	tx, ok := r.transactions[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: with id %s", store.ErrTransactionNotFound, id)
+	}
+	copied := *tx
+	return &copied, nil
+}
+
+func (r *fakeRepository) CompleteTransaction(ctx context.Context, txID uuid.UUID, anchorTransferID string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if settled, err := r.settle(txID, domain.TransactionStatusCompleted, &anchorTransferID); err != nil || !settled {
+		return false, err
+	}
+	for prID, payingTxID := range r.payingTransactions {
+		if payingTxID == txID {
+			pr := r.paymentRequests[prID]
+			pr.Status = domain.PaymentRequestStatusFulfilled
+			pr.FulfilledByTransactionID = uuid.NullUUID{UUID: txID, Valid: true}
+			delete(r.payingTransactions, prID)
+		}
+	}
+	for _, drop := range r.moneyDrops {
+		if drop.FundingTransactionID.UUID == txID && drop.Status == domain.MoneyDropStatusFunding {
+			drop.Status = domain.MoneyDropStatusActive
+		}
+	}
+	return true, nil
+}
+
+func (r *fakeRepository) FailTransaction(ctx context.Context, txID uuid.UUID, anchorTransferID *string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if settled, err := r.settle(txID, domain.TransactionStatusFailed, anchorTransferID); err != nil || !settled {
+		return false, err
+	}
+	for prID, payingTxID := range r.payingTransactions {
+		if payingTxID == txID {
+			r.paymentRequests[prID].Status = domain.PaymentRequestStatusPending
+			delete(r.payingTransactions, prID)
+		}
+	}
+	for _, drop := range r.moneyDrops {
+		if drop.FundingTransactionID.UUID == txID && drop.Status == domain.MoneyDropStatusFunding {
+			drop.Status = domain.MoneyDropStatusFailed
+		}
+	}
+	for id, claim := range r.claims {
+		if claim.PayoutTransactionID == txID {
+			delete(r.claims, id)
+			drop := r.moneyDrops[claim.MoneyDropID]
+			drop.ClaimsMadeCount--
+			if drop.Status == domain.MoneyDropStatusCompleted {
+				drop.Status = domain.MoneyDropStatusActive
+			}
+		}
+	}
+	return true, nil
+}
+
+// settle moves a pending transaction to status, reporting false if it was not pending.
+func (r *fakeRepository) settle(txID uuid.UUID, status string, anchorTransferID *string) (bool, error) {
+	tx, ok := r.transactions[txID]
+	if !ok {
+		return false, fmt.Errorf("%w: with id %s", store.ErrTransactionNotFound, txID)
+	}
+	if tx.Status != domain.TransactionStatusPending {
+		return false, nil
+	}
+	return true, r.updateTransactionStatus(txID, status, anchorTransferID)
+}
+
+// addMoneyDropWallet adds the user's Money Drop wallet.
+func (r *fakeRepository) addMoneyDropWallet(user *domain.User) *domain.Account {
+	wallet := &domain.Account{
+		ID:              uuid.New(),
+		UserID:          user.ID,
+		AnchorAccountID: "anchor_drop_" + user.Username,
+		AccountPurpose:  domain.AccountPurposeMoneyDropWallet,
+		Status:          "active",
+	}
+	r.dropWallets[user.ID] = wallet
+	return wallet
+}
+
+// addMoneyDrop adds an active Money Drop funded from the creator's Money Drop wallet.
+func (r *fakeRepository) addMoneyDrop(creator *domain.User, amountPerClaim int64, totalClaims int) *domain.MoneyDrop {
+	wallet := r.addMoneyDropWallet(creator)
+	drop := &domain.MoneyDrop{
+		ID:                 uuid.New(),
+		CreatorUserID:      creator.ID,
+		FundingAccountID:   wallet.ID,
+		TotalAmount:        amountPerClaim * int64(totalClaims),
+		AmountPerClaim:     amountPerClaim,
+		TotalClaimsAllowed: totalClaims,
+		Status:             domain.MoneyDropStatusActive,
+		ExpiryTimestamp:    time.Now().Add(time.Hour),
+	}
+	r.moneyDrops[drop.ID] = drop
+	return drop
+}
+
+func (r *fakeRepository) GetAccountByID(ctx context.Context, accountID uuid.UUID) (*domain.Account, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, wallets := range []map[uuid.UUID]*domain.Account{r.wallets, r.dropWallets} {
+		for _, account := range wallets {
+			if account.ID == accountID {
+				copied := *account
+				return &copied, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("%w: with id %s", store.ErrAccountNotFound, accountID)
+}
+
+func (r *fakeRepository) ClaimMoneyDrop(ctx context.Context, dropID, claimantID, destinationAccountID uuid.UUID) (*domain.MoneyDropPayout, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	drop, ok := r.moneyDrops[dropID]
+	switch {
+	case !ok:
+		return nil, domain.ErrMoneyDropNotFound
+	case drop.CreatorUserID == claimantID:
+		return nil, domain.ErrMoneyDropOwnClaim
+	case drop.Status != domain.MoneyDropStatusActive:
+		return nil, domain.ErrMoneyDropNotActive
+	case drop.ClaimsMadeCount >= drop.TotalClaimsAllowed:
+		return nil, domain.ErrMoneyDropFullyClaimed
+	}
+	for _, claim := range r.claims {
+		if claim.MoneyDropID == dropID && claim.ClaimantUserID == claimantID {
+			return nil, domain.ErrMoneyDropAlreadyClaimed
+		}
+	}
+
+	payout := &domain.MoneyDropPayout{
+		Transaction: domain.Transaction{
+			SenderUserID:         uuid.NullUUID{UUID: drop.CreatorUserID, Valid: true},
+			RecipientUserID:      uuid.NullUUID{UUID: claimantID, Valid: true},
+			SourceAccountID:      uuid.NullUUID{UUID: drop.FundingAccountID, Valid: true},
+			DestinationAccountID: uuid.NullUUID{UUID: destinationAccountID, Valid: true},
+			Type:                 domain.TransactionTypeMoneyDropClaim,
+			Amount:               drop.AmountPerClaim,
+			Status:               domain.TransactionStatusPending,
+		},
+	}
+	r.insertTransaction(&payout.Transaction)
+
+	payout.Claim = domain.MoneyDropClaim{ID: uuid.New(), MoneyDropID: dropID, ClaimantUserID: claimantID, PayoutTransactionID: payout.Transaction.ID, ClaimedAt: time.Now()}
+	r.claims[payout.Claim.ID] = payout.Claim
+	drop.ClaimsMadeCount++
+	if drop.ClaimsMadeCount >= drop.TotalClaimsAllowed {
+		drop.Status = domain.MoneyDropStatusCompleted
+	}
+	payout.Drop = *drop
+	return payout, nil
+}
+
+func (r *fakeRepository) CreateMoneyDropFunding(ctx context.Context, drop *domain.MoneyDrop, tx *domain.Transaction) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.insertTransaction(tx)
+	drop.ID = uuid.New()
+	drop.Status = domain.MoneyDropStatusFunding
+	drop.FundingTransactionID = uuid.NullUUID{UUID: tx.ID, Valid: true}
+	copied := *drop
+	r.moneyDrops[drop.ID] = &copied
+	return nil
+}
+
+// fakeAnchorClient records the transfers it is asked to make and answers each with
+// status, or fails it with err.
+type fakeAnchorClient struct {
+	AnchorClient
+
+	mu        sync.Mutex
+	status    string
+	err       error
+	transfers []fakeTransfer
+	// onTransfer, if set, is called with each transfer before it is answered.
+	onTransfer func(fakeTransfer)
+	// verified maps transfer IDs to the status VerifyTransfer reports for them.
+	verified map[string]string
+}
+
+// fakeTransfer is a transfer requested from fakeAnchorClient.
+type fakeTransfer struct {
+	kind        string // "book" or "nip"
+	source      string
+	destination string
+	amount      int64
+	reference   string
+}
+
+func (c *fakeAnchorClient) transfer(kind, source, destination string, amount int64, reference string) (*anchor.TransferResult, error) {
+	transfer := fakeTransfer{kind, source, destination, amount, reference}
+	c.mu.Lock()
+	c.transfers = append(c.transfers, transfer)
+	id := fmt.Sprintf("anchor_transfer_%d", len(c.transfers))
+	c.mu.Unlock()
+
+	if c.onTransfer != nil {
+		c.onTransfer(transfer)
+	}
+	if c.err != nil {
+		return nil, c.err
+	}
+	return &anchor.TransferResult{ID: id, Status: c.status}, nil
+}
+
+func (c *fakeAnchorClient) InitiateBookTransfer(ctx context.Context, sourceAccountID, destinationAccountID string, amount int64, reason, reference string) (*anchor.TransferResult, error) {
+	return c.transfer("book", sourceAccountID, destinationAccountID, amount, reference)
+}
+
+func (c *fakeAnchorClient) InitiateNIPTransfer(ctx context.Context, sourceAccountID, counterPartyID string, amount int64, reason, reference string) (*anchor.TransferResult, error) {
+	return c.transfer("nip", sourceAccountID, counterPartyID, amount, reference)
+}
+
+// FindTransferByReference finds a transfer made through the fake by its reference. Its
+// status is the one in verified, and a transfer without one is reported as never made.
+func (c *fakeAnchorClient) FindTransferByReference(ctx context.Context, reference string) (*anchor.TransferResult, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, transfer := range c.transfers {
+		id := fmt.Sprintf("anchor_transfer_%d", i+1)
+		if status, ok := c.verified[id]; ok && transfer.reference == reference {
+			return &anchor.TransferResult{ID: id, Status: status}, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", anchor.ErrTransferNotFound, reference)
+}
+
+func (c *fakeAnchorClient) VerifyTransfer(ctx context.Context, transferID string) (*anchor.TransferResult, error) {
+	status, ok := c.verified[transferID]
+	if !ok {
+		return nil, fmt.Errorf("anchor transfer %s not found", transferID)
+	}
+	return &anchor.TransferResult{ID: transferID, Status: status}, nil
+}
+
+// usagePeriod is the month fakeSubscriptionClient counts external transfers in.
+var usagePeriod = time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)
+
+// fakeSubscriptionClient keeps subscription statuses in memory. Users without a status
+// are on the free tier with no external transfers used.
+type fakeSubscriptionClient struct {
+	mu       sync.Mutex
+	statuses map[uuid.UUID]*subscription.Status
+}
+
+func (c *fakeSubscriptionClient) status(userID uuid.UUID) *subscription.Status {
+	if c.statuses == nil {
+		c.statuses = make(map[uuid.UUID]*subscription.Status)
+	}
+	if _, ok := c.statuses[userID]; !ok {
+		c.statuses[userID] = &subscription.Status{UserID: userID, Status: "inactive"}
+	}
+	return c.statuses[userID]
+}
+
+func (c *fakeSubscriptionClient) GetStatus(ctx context.Context, userID uuid.UUID) (*subscription.Status, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	copied := *c.status(userID)
+	return &copied, nil
+}
+
+func (c *fakeSubscriptionClient) ReserveUsage(ctx context.Context, userID uuid.UUID, limit int) (*subscription.Reservation, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	status := c.status(userID)
+	reservation := &subscription.Reservation{UsagePeriod: usagePeriod}
+	if status.MonthlyExternalTransfersUsed < limit {
+		status.MonthlyExternalTransfersUsed++
+		reservation.Reserved = true
+	}
+	reservation.Status = *status
+	return reservation, nil
+}
+
+func (c *fakeSubscriptionClient) ReleaseUsage(ctx context.Context, userID uuid.UUID, period time.Time) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if status := c.status(userID); period.Equal(usagePeriod) && status.MonthlyExternalTransfersUsed > 0 {
+		status.MonthlyExternalTransfersUsed--
+	}
+	return nil
+}
+
+// fakePublisher records the messages it publishes.
+type fakePublisher struct {
+	mu       sync.Mutex
+	messages []messaging.Message
+}
+
+func (p *fakePublisher) PublishMessage(ctx context.Context, exchange, routingKey string, msg messaging.Message) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.messages = append(p.messages, msg)
+	return nil
+}
+
+func TestFreeTierRecipientGetsFiveExternalTransfers(t *testing.T) {
+	tests := []struct {
+		name       string
+		subscribed bool
+		wantSixth  string
+	}{
+		{"free tier", false, "book"},
+		{"subscribed", true, "nip"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeRepository()
+			sender, recipient := repo.addUser("lovelace"), repo.addUser("babbage")
+			repo.addBeneficiary(recipient)
+			service, anchorClient := newTransferTestService(repo)
+			subscriptions := service.subscriptionClient.(*fakeSubscriptionClient)
+			subscriptions.status(recipient.ID).IsActive = tt.subscribed
+
+			for i := 1; i <= freeExternalTransferLimit+1; i++ {
+				if _, err := sendP2P(service, repo, sender, "babbage", 10000); err != nil {
+					t.Fatalf("transfer %d: ProcessP2PTransfer() error = %v", i, err)
+				}
+				want := "nip"
+				if i > freeExternalTransferLimit {
+					want = tt.wantSixth
+				}
+				if got := anchorClient.transfers[i-1].kind; got != want {
+					t.Errorf("transfer %d went by %s, want %s", i, got, want)
+				}
+			}
+
+			wantUsed := freeExternalTransferLimit
+			if tt.subscribed {
+				wantUsed = 0
+			}
+			if got := subscriptions.status(recipient.ID).MonthlyExternalTransfersUsed; got != wantUsed {
+				t.Errorf("recipient used %d external transfers, want %d", got, wantUsed)
+			}
+		})
+	}
+}
+
+func TestConcurrentTransfersShareTheFreeExternalTransfers(t *testing.T) {
+	repo := newFakeRepository()
+	sender, recipient := repo.addUser("lovelace"), repo.addUser("babbage")
+	repo.addBeneficiary(recipient)
+	service, anchorClient := newTransferTestService(repo)
+	subscriptions := service.subscriptionClient.(*fakeSubscriptionClient)
+
+	const transfers = 3 * freeExternalTransferLimit
+	reqs := make([]domain.P2PTransferRequest, transfers)
+	for i := range reqs {
+		binding := stepup.Binding{Purpose: stepup.PurposeP2PTransfer, Amount: 10000, Recipient: stepup.UserRecipient("babbage")}
+		reqs[i] = domain.P2PTransferRequest{RecipientUsername: "babbage", Amount: 10000, StepUpToken: repo.issueStepUpToken(sender, binding)}
+	}
+
+	errs := make([]error, transfers)
+	var wg sync.WaitGroup
+	for i, req := range reqs {
+		wg.Add(1)
+		go func(i int, req domain.P2PTransferRequest) {
+			defer wg.Done()
+			_, errs[i] = service.ProcessP2PTransfer(context.Background(), sender.ClerkID, req)
+		}(i, req)
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			t.Fatalf("transfer %d: ProcessP2PTransfer() error = %v", i, err)
+		}
+	}
+	var external int
+	for _, transfer := range anchorClient.transfers {
+		if transfer.kind == "nip" {
+			external++
+		}
+	}
+	if external != freeExternalTransferLimit {
+		t.Errorf("made %d external transfers, want %d", external, freeExternalTransferLimit)
+	}
+	if got := subscriptions.status(recipient.ID).MonthlyExternalTransfersUsed; got != freeExternalTransferLimit {
+		t.Errorf("recipient used %d external transfers, want %d", got, freeExternalTransferLimit)
+	}
+}
+
+func TestRejectedTransferReleasesTheFreeExternalTransfer(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   string
+		err      error
+		wantUsed int
+	}{
+		{"completed", anchor.TransferStatusCompleted, nil, 1},
+		{"pending", "PENDING", nil, 1},
+		{"unknown outcome", "", context.DeadlineExceeded, 1},
+		{"failed", anchor.TransferStatusFailed, nil, 0},
+		{"rejected", "", &anchor.RejectedError{StatusCode: 422, Body: "insufficient balance"}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeRepository()
+			sender, recipient := repo.addUser("lovelace"), repo.addUser("babbage")
+			repo.addBeneficiary(recipient)
+			service, anchorClient := newTransferTestService(repo)
+			anchorClient.status, anchorClient.err = tt.status, tt.err
+			subscriptions := service.subscriptionClient.(*fakeSubscriptionClient)
+
+			sendP2P(service, repo, sender, "babbage", 10000)
+			if got := subscriptions.status(recipient.ID).MonthlyExternalTransfersUsed; got != tt.wantUsed {
+				t.Errorf("recipient used %d external transfers, want %d", got, tt.wantUsed)
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+/**
+ * @description
+ * This file checks the step-up tokens that approve outbound money movements. A user gets
+ * a token from the Auth service by entering their transaction PIN; it is bound to one
+ * transfer, its amount and its recipient, and can be spent once.
+ *
+ * Key features:
+ * - P2P transfers, self-transfers and Money Drop funding spend a token before the pending
+ *   transaction is recorded, so no money moves without a fresh PIN entry.
+ * - A missing token and a token that does not approve the transfer are reported
+ *   separately, so clients know whether to prompt for the PIN at all.
+ *
+ * @dependencies
+ * - "context", "errors", "fmt"
+ * - "transfa/services/transaction/internal/domain": For core data models.
+ * - "transfa/services/transaction/internal/store": For repository error values.
+ * - "transfa/shared/stepup": For token hashes and bindings.
+ */
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"transfa/services/transaction/internal/domain"
+	"transfa/services/transaction/internal/store"
+	"transfa/shared/stepup"
+)
+
+var (
+	ErrStepUpRequired = errors.New("a step-up token is required; enter your transaction PIN to approve this transfer")
+	ErrStepUpInvalid  = errors.New("the step-up token is invalid, expired or was issued for a different transfer")
+)
+
+// consumeStepUpToken spends token on the money movement described by binding.
+func (s *Service) consumeStepUpToken(ctx context.Context, user *domain.User, token string, binding stepup.Binding) error {
+	if token == "" {
+		return ErrStepUpRequired
+	}
+	err := s.repo.ConsumeStepUpToken(ctx, user.ID, stepup.HashToken(token), binding)
+	if err != nil {
+		if errors.Is(err, store.ErrStepUpTokenInvalid) {
+			return ErrStepUpInvalid
+		}
+		return fmt.Errorf("failed to consume step-up token: %w", err)
+	}
+	return nil
+}
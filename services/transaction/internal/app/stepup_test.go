@@ -0,0 +1,152 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"transfa/services/transaction/internal/config"
+	"transfa/services/transaction/internal/domain"
+	"transfa/shared/stepup"
+)
+
+// newTransferTestService returns a service backed by repo and a fake Anchor client that
+// completes every transfer.
+func newTransferTestService(repo *fakeRepository) (*Service, *fakeAnchorClient) {
+	anchorClient := &fakeAnchorClient{status: "COMPLETED"}
+	service := NewService(repo, anchorClient, &fakeSubscriptionClient{}, &fakePublisher{}, config.Config{
+		OldUsernamePolicy: config.OldUsernamePolicyRedirect,
+		UsernameCacheTTL:  time.Minute,
+	})
+	return service, anchorClient
+}
+
+func TestP2PTransferRequiresStepUpToken(t *testing.T) {
+	repo := newFakeRepository()
+	sender := repo.addUser("lovelace")
+	repo.addUser("babbage")
+	binding := stepup.Binding{Purpose: stepup.PurposeP2PTransfer, Amount: 250000, Recipient: stepup.UserRecipient("babbage")}
+
+	tests := []struct {
+		name    string
+		token   func() string
+		wantErr error
+	}{
+		{"missing", func() string { return "" }, ErrStepUpRequired},
+		{"unknown", func() string { return "not-a-token" }, ErrStepUpInvalid},
+		{"other amount", func() string {
+			other := binding
+			other.Amount = 100
+			return repo.issueStepUpToken(sender, other)
+		}, ErrStepUpInvalid},
+		{"other recipient", func() string {
+			other := binding
+			other.Recipient = stepup.UserRecipient("hopper")
+			return repo.issueStepUpToken(sender, other)
+		}, ErrStepUpInvalid},
+		{"other purpose", func() string {
+			other := binding
+			other.Purpose = stepup.PurposeMoneyDrop
+			return repo.issueStepUpToken(sender, other)
+		}, ErrStepUpInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, anchorClient := newTransferTestService(repo)
+			req := domain.P2PTransferRequest{RecipientUsername: "babbage", Amount: 250000, StepUpToken: tt.token()}
+
+			if _, err := service.ProcessP2PTransfer(context.Background(), sender.ClerkID, req); !errors.Is(err, tt.wantErr) {
+				t.Errorf("ProcessP2PTransfer() error = %v, want %v", err, tt.wantErr)
+			}
+			if len(anchorClient.transfers) != 0 || len(repo.transactions) != 0 {
+				t.Errorf("moved money without a valid step-up token: %+v", anchorClient.transfers)
+			}
+		})
+	}
+}
+
+func TestP2PTransferSpendsStepUpTokenOnce(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakeRepository()
+	sender := repo.addUser("lovelace")
+	repo.addUser("babbage")
+	service, anchorClient := newTransferTestService(repo)
+
+	token := repo.issueStepUpToken(sender, stepup.Binding{Purpose: stepup.PurposeP2PTransfer, Amount: 250000, Recipient: stepup.UserRecipient("babbage")})
+	req := domain.P2PTransferRequest{RecipientUsername: "@Babbage", Amount: 250000, StepUpToken: token}
+
+	tx, err := service.ProcessP2PTransfer(ctx, sender.ClerkID, req)
+	if err != nil {
+		t.Fatalf("ProcessP2PTransfer() error = %v", err)
+	}
+	if tx.Status != domain.TransactionStatusCompleted || len(anchorClient.transfers) != 1 {
+		t.Errorf("transaction = %+v after %d transfers, want one completed transfer", tx, len(anchorClient.transfers))
+	}
+
+	if _, err := service.ProcessP2PTransfer(ctx, sender.ClerkID, req); !errors.Is(err, ErrStepUpInvalid) {
+		t.Errorf("replayed ProcessP2PTransfer() error = %v, want ErrStepUpInvalid", err)
+	}
+	if len(anchorClient.transfers) != 1 {
+		t.Errorf("the replayed token moved money again")
+	}
+}
+
+func TestStepUpTokenBelongsToItsUser(t *testing.T) {
+	repo := newFakeRepository()
+	sender := repo.addUser("lovelace")
+	other := repo.addUser("hopper")
+	repo.addUser("babbage")
+	service, anchorClient := newTransferTestService(repo)
+
+	// hopper's token approves the same transfer, but lovelace cannot spend it.
+	token := repo.issueStepUpToken(other, stepup.Binding{Purpose: stepup.PurposeP2PTransfer, Amount: 250000, Recipient: stepup.UserRecipient("babbage")})
+	req := domain.P2PTransferRequest{RecipientUsername: "babbage", Amount: 250000, StepUpToken: token}
+
+	if _, err := service.ProcessP2PTransfer(context.Background(), sender.ClerkID, req); !errors.Is(err, ErrStepUpInvalid) {
+		t.Errorf("ProcessP2PTransfer() error = %v, want ErrStepUpInvalid", err)
+	}
+	if len(anchorClient.transfers) != 0 {
+		t.Error("another user's token moved money")
+	}
+}
+
+func TestSelfTransferStepUpTokenIsBoundToTheBeneficiary(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakeRepository()
+	user := repo.addUser("lovelace")
+	first, second := repo.addBeneficiary(user), repo.addBeneficiary(user)
+	service, anchorClient := newTransferTestService(repo)
+
+	token := repo.issueStepUpToken(user, stepup.Binding{Purpose: stepup.PurposeSelfTransfer, Amount: 500000, Recipient: stepup.BeneficiaryRecipient(first.ID)})
+
+	req := domain.SelfTransferRequest{BeneficiaryID: second.ID, Amount: 500000, StepUpToken: token}
+	if _, err := service.ProcessSelfTransfer(ctx, user.ClerkID, req); !errors.Is(err, ErrStepUpInvalid) {
+		t.Fatalf("ProcessSelfTransfer() to another beneficiary error = %v, want ErrStepUpInvalid", err)
+	}
+
+	req.BeneficiaryID = first.ID
+	if _, err := service.ProcessSelfTransfer(ctx, user.ClerkID, req); err != nil {
+		t.Fatalf("ProcessSelfTransfer() error = %v", err)
+	}
+	if len(anchorClient.transfers) != 1 || anchorClient.transfers[0].destination != first.AnchorCounterpartyID {
+		t.Errorf("transfers = %+v, want one to %s", anchorClient.transfers, first.AnchorCounterpartyID)
+	}
+}
+
+func TestCreateMoneyDropRequiresStepUpToken(t *testing.T) {
+	repo := newFakeRepository()
+	creator := repo.addUser("lovelace")
+	service, anchorClient := newTransferTestService(repo)
+
+	// The token approves one share, not the whole pool of 3.
+	token := repo.issueStepUpToken(creator, stepup.Binding{Purpose: stepup.PurposeMoneyDrop, Amount: 100000, Recipient: stepup.MoneyDropRecipient})
+	req := domain.CreateMoneyDropRequest{AmountPerClaim: 100000, TotalClaimsAllowed: 3, ExpiryTimestamp: time.Now().Add(time.Hour), StepUpToken: token}
+
+	if _, err := service.CreateMoneyDrop(context.Background(), creator.ClerkID, req); !errors.Is(err, ErrStepUpInvalid) {
+		t.Errorf("CreateMoneyDrop() error = %v, want ErrStepUpInvalid", err)
+	}
+	if len(anchorClient.transfers) != 0 {
+		t.Error("funded a Money Drop without a valid step-up token")
+	}
+}
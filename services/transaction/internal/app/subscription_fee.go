@@ -0,0 +1,98 @@
+/**
+ * @description
+ * This file contains the business logic for debiting monthly subscription fees. Fees are
+ * charged by the Scheduler service through the internal API and moved with a BookTransfer
+ * from the subscriber's main wallet to Transfa's fee collection account.
+ *
+ * @dependencies
+ * - Go standard libraries: "context", "errors", "fmt", "log"
+ * - "transfa/services/transaction/internal/domain": For core data models.
+ * - "transfa/services/transaction/internal/store": For repository error values.
+ */
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/google/uuid"
+	"transfa/services/transaction/internal/domain"
+	"transfa/services/transaction/internal/store"
+)
+
+var (
+	ErrUserIDRequired          = errors.New("user_id is required")
+	ErrIdempotencyKeyRequired  = errors.New("idempotency_key is required")
+	ErrIdempotencyKeyReused    = errors.New("idempotency_key was already used for a different request")
+	ErrFeeAccountNotConfigured = errors.New("subscription fee account is not configured")
+)
+
+// ChargeSubscriptionFee debits a subscription fee from the user's main wallet.
+//
+// Requests are deduplicated by idempotency key: a repeated request returns the transaction
+// created by the first one without moving money again. A debit that Anchor rejects (for
+// example, for insufficient funds) is not an error; it is reported through the returned
+// transaction's status so that the caller can mark the subscription as past due. A debit
+// whose outcome is not known yet is returned pending until it is reconciled.
+func (s *Service) ChargeSubscriptionFee(ctx context.Context, req domain.SubscriptionFeeRequest) (*domain.Transaction, error) {
+	if req.UserID == uuid.Nil {
+		return nil, ErrUserIDRequired
+	}
+	if req.Amount <= 0 {
+		return nil, ErrInvalidAmount
+	}
+	if req.IdempotencyKey == "" {
+		return nil, ErrIdempotencyKeyRequired
+	}
+	if s.config.FeeAccountID == "" {
+		return nil, ErrFeeAccountNotConfigured
+	}
+
+	// Step 1: Return the earlier outcome if this debit was already attempted.
+	existing, err := s.repo.GetTransactionByIdempotencyKey(ctx, req.IdempotencyKey)
+	if err == nil {
+		if existing.Type != domain.TransactionTypeSubscriptionFee || existing.SenderUserID.UUID != req.UserID || existing.Amount != req.Amount {
+			return nil, ErrIdempotencyKeyReused
+		}
+		if existing.Status == domain.TransactionStatusPending {
+			log.Printf("WARNING: Subscription fee %s for user %s is still pending and needs reconciliation", existing.ID, req.UserID)
+		}
+		return existing, nil
+	}
+	if !errors.Is(err, store.ErrTransactionNotFound) {
+		return nil, err
+	}
+
+	// Step 2: Resolve the wallet to debit.
+	wallet, err := s.repo.GetAccountByUserID(ctx, req.UserID, domain.AccountPurposeMainWallet)
+	if err != nil {
+		if errors.Is(err, store.ErrAccountNotFound) {
+			return nil, ErrSenderWalletNotReady
+		}
+		return nil, fmt.Errorf("failed to get subscriber wallet: %w", err)
+	}
+
+	// Step 3: Record and execute the debit.
+	tx := &domain.Transaction{
+		SenderUserID:    uuid.NullUUID{UUID: req.UserID, Valid: true},
+		SourceAccountID: uuid.NullUUID{UUID: wallet.ID, Valid: true},
+		Type:            domain.TransactionTypeSubscriptionFee,
+		Amount:          req.Amount,
+		Status:          domain.TransactionStatusPending,
+		IdempotencyKey:  &req.IdempotencyKey,
+	}
+	if _, err := s.repo.CreateTransaction(ctx, tx); err != nil {
+		return nil, err
+	}
+
+	result, err := s.anchorClient.InitiateBookTransfer(ctx, wallet.AnchorAccountID, s.config.FeeAccountID, req.Amount, "Transfa subscription fee", tx.ID.String())
+	if err := s.settleTransfer(ctx, tx, result, err); err != nil {
+		log.Printf("Subscription fee %s for user %s was not collected", tx.ID, req.UserID)
+		return tx, nil
+	}
+
+	log.Printf("Subscription fee %s from user %s %s", tx.ID, req.UserID, tx.Status)
+	return tx, nil
+}
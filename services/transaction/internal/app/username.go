@@ -0,0 +1,158 @@
+/**
+ * @description
+ * This file resolves the recipient of a P2P transfer from a username.
+ *
+ * Key features:
+ * - Resolved usernames are cached for `UsernameCacheTTL`, and the `username.changed`
+ *   event evicts both the old and the new username. The TTL bounds how long a replica
+ *   that missed the event can resolve a stale username.
+ * - A username its owner changed keeps resolving to them for the grace period recorded
+ *   in `username_history`, so payments to a shared handle or QR code are not lost. The
+ *   `OLD_USERNAME_POLICY` decides whether such payments are redirected to the owner's
+ *   new username or rejected with ErrRecipientUsernameChanged.
+ *
+ * @dependencies
+ * - "context", "errors", "fmt", "log", "strings", "sync", "time"
+ * - "github.com/rabbitmq/amqp091-go": For the `username.changed` delivery.
+ * - "transfa/services/transaction/internal/config": For the old username policy.
+ * - "transfa/services/transaction/internal/domain": For users.
+ * - "transfa/services/transaction/internal/store": For repository error values.
+ * - "transfa/shared/events": For the `username.changed` event.
+ * - "transfa/shared/messaging": For rejecting malformed events.
+ */
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rabbitmq/amqp091-go"
+	"transfa/services/transaction/internal/config"
+	"transfa/services/transaction/internal/domain"
+	"transfa/services/transaction/internal/store"
+	"transfa/shared/events"
+	"transfa/shared/messaging"
+)
+
+// ErrRecipientUsernameChanged is returned, under the block policy, for a payment to a
+// username its owner has changed.
+var ErrRecipientUsernameChanged = errors.New("recipient has changed their username")
+
+// usernameCache caches the users that usernames resolve to, including old usernames
+// in their grace period.
+type usernameCache struct {
+	ttl     time.Duration
+	mu      sync.Mutex
+	entries map[string]usernameCacheEntry
+}
+
+type usernameCacheEntry struct {
+	user      domain.User
+	expiresAt time.Time
+}
+
+func newUsernameCache(ttl time.Duration) *usernameCache {
+	return &usernameCache{ttl: ttl, entries: make(map[string]usernameCacheEntry)}
+}
+
+// get returns the cached user for username, if there is an unexpired entry.
+func (c *usernameCache) get(username string) (*domain.User, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[username]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(entry.expiresAt) {
+		delete(c.entries, username)
+		return nil, false
+	}
+	user := entry.user
+	return &user, true
+}
+
+func (c *usernameCache) put(username string, user *domain.User) {
+	if c.ttl <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[username] = usernameCacheEntry{user: *user, expiresAt: time.Now().Add(c.ttl)}
+}
+
+func (c *usernameCache) evict(usernames ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, username := range usernames {
+		delete(c.entries, username)
+	}
+}
+
+// normalizeUsername returns username as stored: without a leading @, in lowercase.
+func normalizeUsername(username string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(username), "@"))
+}
+
+// resolveUsername returns the user that username currently points to: its owner, or,
+// during the grace period, the user who changed it. Payments to a changed username
+// return ErrRecipientUsernameChanged under the block policy.
+func (s *Service) resolveUsername(ctx context.Context, username string) (*domain.User, error) {
+	username = normalizeUsername(username)
+	user, ok := s.usernames.get(username)
+	if !ok {
+		var err error
+		user, err = s.lookupUsername(ctx, username)
+		if err != nil {
+			return nil, err
+		}
+		s.usernames.put(username, user)
+	}
+
+	if user.Username != username && s.config.OldUsernamePolicy == config.OldUsernamePolicyBlock {
+		return nil, ErrRecipientUsernameChanged
+	}
+	return user, nil
+}
+
+// lookupUsername looks username up among current usernames, then among old usernames
+// in their grace period.
+func (s *Service) lookupUsername(ctx context.Context, username string) (*domain.User, error) {
+	user, err := s.repo.GetUserByUsername(ctx, username)
+	if err == nil {
+		return user, nil
+	}
+	if !errors.Is(err, store.ErrUserNotFound) {
+		return nil, fmt.Errorf("failed to get recipient: %w", err)
+	}
+
+	user, err = s.repo.GetUserByOldUsername(ctx, username)
+	if err != nil {
+		if errors.Is(err, store.ErrUserNotFound) {
+			return nil, ErrRecipientNotFound
+		}
+		return nil, fmt.Errorf("failed to get recipient by old username: %w", err)
+	}
+	log.Printf("Username %s was changed to %s; resolved it to user %s during the grace period", username, user.Username, user.ID)
+	return user, nil
+}
+
+// HandleUsernameChangedEvent evicts the old and the new username of a `username.changed`
+// event from the username cache.
+func (s *Service) HandleUsernameChangedEvent(ctx context.Context, msg amqp091.Delivery) error {
+	var event events.UsernameChanged
+	if _, err := events.DecodeDelivery(msg, &event); err != nil {
+		return err
+	}
+	if event.OldUsername == "" || event.NewUsername == "" {
+		return messaging.Permanent(fmt.Errorf("username.changed event for user %s is missing a username", event.UserID))
+	}
+
+	s.usernames.evict(normalizeUsername(event.OldUsername), normalizeUsername(event.NewUsername))
+	log.Printf("Evicted usernames %s and %s of user %s from the username cache", event.OldUsername, event.NewUsername, event.UserID)
+	return nil
+}
@@ -0,0 +1,125 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"transfa/services/transaction/internal/config"
+	"transfa/services/transaction/internal/domain"
+	"transfa/shared/events"
+	"transfa/shared/eventtest"
+	"transfa/shared/messaging"
+	"transfa/shared/messaging/memory"
+)
+
+func newUsernameTestService(repo *fakeRepository, policy string) *Service {
+	return NewService(repo, nil, nil, nil, config.Config{
+		OldUsernamePolicy: policy,
+		UsernameCacheTTL:  time.Minute,
+	})
+}
+
+func TestResolveP2PRecipientOldUsername(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   string
+		username string
+		wantErr  error
+	}{
+		{name: "current username", policy: config.OldUsernamePolicyBlock, username: "@Lovelace"},
+		{name: "redirected old username", policy: config.OldUsernamePolicyRedirect, username: "ada"},
+		{name: "blocked old username", policy: config.OldUsernamePolicyBlock, username: "ada", wantErr: ErrRecipientUsernameChanged},
+		{name: "unknown username", policy: config.OldUsernamePolicyRedirect, username: "babbage", wantErr: ErrRecipientNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeRepository()
+			user := repo.addUser("ada")
+			repo.changeUsername(user, "lovelace")
+			service := newUsernameTestService(repo, tt.policy)
+
+			recipient, _, err := service.resolveP2PRecipient(context.Background(), &domain.P2PTransferRequest{RecipientUsername: tt.username})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("resolveP2PRecipient() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && recipient.ID != user.ID {
+				t.Errorf("recipient = %s, want %s", recipient.ID, user.ID)
+			}
+		})
+	}
+}
+
+// TestResolveP2PRecipientPaymentRequestOldUsername checks that a payer may name the
+// creator of a payment request by a username the creator has since changed.
+func TestResolveP2PRecipientPaymentRequestOldUsername(t *testing.T) {
+	repo := newFakeRepository()
+	creator := repo.addUser("ada")
+	repo.changeUsername(creator, "lovelace")
+	repo.addUser("babbage")
+	pr := &domain.PaymentRequest{ID: uuid.New(), CreatorUserID: creator.ID, Amount: 5000, Status: domain.PaymentRequestStatusPending}
+	repo.paymentRequests[pr.ID] = pr
+	service := newUsernameTestService(repo, config.OldUsernamePolicyRedirect)
+
+	for username, wantErr := range map[string]error{
+		"ada":      nil,
+		"LOVELACE": nil,
+		"babbage":  ErrPaymentRequestRecipientMismatch,
+		"hopper":   ErrPaymentRequestRecipientMismatch,
+	} {
+		req := &domain.P2PTransferRequest{RecipientUsername: username, PaymentRequestID: &pr.ID}
+		recipient, _, err := service.resolveP2PRecipient(context.Background(), req)
+		if !errors.Is(err, wantErr) {
+			t.Errorf("resolveP2PRecipient(%q) error = %v, want %v", username, err, wantErr)
+			continue
+		}
+		if wantErr == nil && recipient.ID != creator.ID {
+			t.Errorf("resolveP2PRecipient(%q) recipient = %s, want the creator", username, recipient.ID)
+		}
+	}
+}
+
+// TestHandleUsernameChangedEventEvictsCache checks that a cached username is looked up
+// again once the Customer service reports that it changed.
+func TestHandleUsernameChangedEventEvictsCache(t *testing.T) {
+	repo := newFakeRepository()
+	user := repo.addUser("ada")
+	other := repo.addUser("grace")
+	service := newUsernameTestService(repo, config.OldUsernamePolicyBlock)
+	ctx := context.Background()
+
+	if _, err := service.resolveUsername(ctx, "ada"); err != nil {
+		t.Fatalf("resolveUsername() error = %v", err)
+	}
+	if _, err := service.resolveUsername(ctx, "ada"); err != nil {
+		t.Fatalf("resolveUsername() error = %v", err)
+	}
+	if repo.usernameLookups != 1 {
+		t.Fatalf("username looked up %d times, want 1 (cached)", repo.usernameLookups)
+	}
+
+	repo.changeUsername(user, "lovelace")
+	broker := memory.NewBroker(messaging.RetryPolicy{MaxAttempts: 3})
+	err := broker.StartConsumer(ctx, "user_events", "transaction_service_username_changed", "username.changed", "test", service.HandleUsernameChangedEvent, messaging.Concurrency{})
+	if err != nil {
+		t.Fatalf("StartConsumer() error = %v", err)
+	}
+	eventtest.Publish(t, broker, "user_events", "username.changed", "customer", events.UsernameChanged{
+		UserID:      user.ID,
+		OldUsername: "ada",
+		NewUsername: "lovelace",
+		ChangedAt:   time.Now(),
+		GraceUntil:  time.Now().Add(14 * 24 * time.Hour),
+	})
+	broker.Drain()
+
+	if _, err := service.resolveUsername(ctx, "ada"); !errors.Is(err, ErrRecipientUsernameChanged) {
+		t.Errorf("resolveUsername(old) error = %v, want ErrRecipientUsernameChanged", err)
+	}
+	if got, err := service.resolveUsername(ctx, "grace"); err != nil || got.ID != other.ID {
+		t.Errorf("resolveUsername(other) = %v, %v, want the other user", got, err)
+	}
+}
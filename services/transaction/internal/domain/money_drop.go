@@ -6,8 +6,15 @@
  * - `MoneyDrop`: Represents a Money Drop event, including its funding, rules, and current state.
  * - `MoneyDropClaim`: Records an individual user's claim against a Money Drop to ensure the
  *   "one claim per person" rule is enforced.
+ * - `CreateMoneyDropRequest`: Defines the JSON structure for the POST /money-drops endpoint.
+ * - `MoneyDropPayout`: A validated claim, together with the transaction that pays it out.
+ * - `MoneyDropRefund`: An expired drop, together with the transaction that returns its
+ *   unclaimed funds to the creator.
+ * - Claim validation errors shared by the store (which enforces them under a row lock)
+ *   and the API layer (which maps them to HTTP responses).
  *
  * @dependencies
+ * - "errors": For claim validation error values.
  * - "time": Used for timestamping records.
  * - "github.com/google/uuid": Used for universally unique identifiers as primary keys.
  */
@@ -15,33 +22,87 @@
 package domain
 
 import (
-"time"
+	"errors"
+	"time"
 
-"github.com/google/uuid"
+	"github.com/google/uuid"
+)
+
+// Money Drop statuses as defined by the `money_drops.status` check constraint. A drop is
+// funding until the transfer funding it completes, when it becomes active, or fails.
+const (
+	MoneyDropStatusFunding   = "funding"
+	MoneyDropStatusActive    = "active"
+	MoneyDropStatusCompleted = "completed"
+	MoneyDropStatusExpired   = "expired"
+	MoneyDropStatusFailed    = "failed"
+)
+
+// Claim validation errors, checked atomically while the Money Drop row is locked.
+var (
+	ErrMoneyDropNotFound       = errors.New("money drop not found")
+	ErrMoneyDropNotActive      = errors.New("money drop is no longer active")
+	ErrMoneyDropExpired        = errors.New("money drop has expired")
+	ErrMoneyDropFullyClaimed   = errors.New("money drop has been fully claimed")
+	ErrMoneyDropAlreadyClaimed = errors.New("you have already claimed this money drop")
+	ErrMoneyDropOwnClaim       = errors.New("you cannot claim your own money drop")
+	ErrMoneyDropNotExpired     = errors.New("money drop has not expired yet")
+	ErrMoneyDropPayoutsPending = errors.New("money drop has claim payouts that are still pending")
 )
 
 // MoneyDrop represents a created Money Drop instance, containing its rules and current status.
 // It maps directly to the `money_drops` table in the database.
 type MoneyDrop struct {
-ID                  uuid.UUID `json:"id" db:"id"`
-CreatorUserID       uuid.UUID `json:"creator_user_id" db:"creator_user_id"`
-FundingAccountID    uuid.UUID `json:"funding_account_id" db:"funding_account_id"`
-TotalAmount         int64     `json:"total_amount" db:"total_amount"`                 // Stored in kobo
-AmountPerClaim      int64     `json:"amount_per_claim" db:"amount_per_claim"`         // Stored in kobo
-TotalClaimsAllowed  int       `json:"total_claims_allowed" db:"total_claims_allowed"`
-ClaimsMadeCount     int       `json:"claims_made_count" db:"claims_made_count"`
-Status              string    `json:"status" db:"status"`
-ExpiryTimestamp     time.Time `json:"expiry_timestamp" db:"expiry_timestamp"`
-CreatedAt           time.Time `json:"created_at" db:"created_at"`
-UpdatedAt           time.Time `json:"updated_at" db:"updated_at"`
+	ID                 uuid.UUID `json:"id" db:"id"`
+	CreatorUserID      uuid.UUID `json:"creator_user_id" db:"creator_user_id"`
+	FundingAccountID   uuid.UUID `json:"funding_account_id" db:"funding_account_id"`
+	TotalAmount        int64     `json:"total_amount" db:"total_amount"`         // Stored in kobo
+	AmountPerClaim     int64     `json:"amount_per_claim" db:"amount_per_claim"` // Stored in kobo
+	TotalClaimsAllowed int       `json:"total_claims_allowed" db:"total_claims_allowed"`
+	ClaimsMadeCount    int       `json:"claims_made_count" db:"claims_made_count"`
+	Status             string    `json:"status" db:"status"`
+	ExpiryTimestamp    time.Time `json:"expiry_timestamp" db:"expiry_timestamp"`
+	// FundingTransactionID is the transfer funding the drop.
+	FundingTransactionID uuid.NullUUID `json:"funding_transaction_id" db:"funding_transaction_id"`
+	CreatedAt            time.Time     `json:"created_at" db:"created_at"`
+	UpdatedAt            time.Time     `json:"updated_at" db:"updated_at"`
 }
 
 // MoneyDropClaim represents a record of a user claiming a share from a Money Drop.
 // This is used to prevent duplicate claims.
 // It maps directly to the `money_drop_claims` table.
 type MoneyDropClaim struct {
-ID             uuid.UUID `json:"id" db:"id"`
-MoneyDropID    uuid.UUID `json:"money_drop_id" db:"money_drop_id"`
-ClaimantUserID uuid.UUID `json:"claimant_user_id" db:"claimant_user_id"`
-ClaimedAt      time.Time `json:"claimed_at" db:"claimed_at"`
-}
\ No newline at end of file
+	ID             uuid.UUID `json:"id" db:"id"`
+	MoneyDropID    uuid.UUID `json:"money_drop_id" db:"money_drop_id"`
+	ClaimantUserID uuid.UUID `json:"claimant_user_id" db:"claimant_user_id"`
+	// PayoutTransactionID is the transfer paying out the claim.
+	PayoutTransactionID uuid.UUID `json:"payout_transaction_id" db:"payout_transaction_id"`
+	ClaimedAt           time.Time `json:"claimed_at" db:"claimed_at"`
+}
+
+// CreateMoneyDropRequest is the expected JSON body for the `POST /money-drops` endpoint.
+// The total amount funded is AmountPerClaim * TotalClaimsAllowed.
+type CreateMoneyDropRequest struct {
+	AmountPerClaim     int64     `json:"amount_per_claim"` // In kobo
+	TotalClaimsAllowed int       `json:"total_claims_allowed"`
+	ExpiryTimestamp    time.Time `json:"expiry_timestamp"`
+	// StepUpToken approves the funding. It is read from the X-Step-Up-Token header.
+	StepUpToken string `json:"-"`
+}
+
+// MoneyDropPayout is the result of a successful claim reservation. The claim and its
+// pending payout transaction are committed together before any money moves.
+type MoneyDropPayout struct {
+	Drop        MoneyDrop
+	Claim       MoneyDropClaim
+	Transaction Transaction
+}
+
+// MoneyDropRefund is the result of expiring a Money Drop. Transaction is nil when every
+// share was claimed and there is nothing to return to the creator. Created reports
+// whether this call expired the drop, as opposed to a previous one.
+type MoneyDropRefund struct {
+	Drop        MoneyDrop    `json:"money_drop"`
+	Transaction *Transaction `json:"transaction,omitempty"`
+	Created     bool         `json:"-"`
+}
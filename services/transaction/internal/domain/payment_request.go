@@ -4,31 +4,70 @@
  * A Payment Request is a user-generated request for a specific amount of money, which can
  * be fulfilled by another user.
  *
+ * Requests are shared through an opaque public token, which resolves to a
+ * PublicPaymentRequest that does not expose the creator's internal user ID.
+ *
  * @dependencies
  * - "database/sql": Used for nullable time types.
+ * - "errors": Used for payment request error values.
  * - "time": Used for timestamping records.
  * - "github.com/google/uuid": Used for universally unique identifiers and nullable UUIDs.
  */
 package domain
 
 import (
-"database/sql"
-"time"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
 
-"github.com/google/uuid"
+// Payment request statuses, matching the CHECK constraint on `payment_requests.status`.
+const (
+	PaymentRequestStatusPending = "pending"
+	// PaymentRequestStatusPaying is held while a transfer paying the request is in flight.
+	PaymentRequestStatusPaying    = "paying"
+	PaymentRequestStatusFulfilled = "fulfilled"
+	PaymentRequestStatusCancelled = "cancelled"
+)
+
+var (
+	ErrPaymentRequestNotFound   = errors.New("payment request not found")
+	ErrPaymentRequestNotPending = errors.New("payment request is no longer pending")
 )
 
 // PaymentRequest represents a user-created request for payment.
 // It maps directly to the `payment_requests` table in the database.
 type PaymentRequest struct {
-ID                      uuid.UUID     `json:"id" db:"id"`
-CreatorUserID           uuid.UUID     `json:"creator_user_id" db:"creator_user_id"`
-Amount                  int64         `json:"amount" db:"amount"` // Stored in kobo
-Description             *string       `json:"description,omitempty" db:"description"`
-ImageURL                *string       `json:"image_url,omitempty" db:"image_url"`
-Status                  string        `json:"status" db:"status"`
-FulfilledAt             sql.NullTime  `json:"fulfilled_at,omitempty" db:"fulfilled_at"`
-FulfilledByTransactionID uuid.NullUUID `json:"fulfilled_by_transaction_id,omitempty" db:"fulfilled_by_transaction_id"`
-CreatedAt               time.Time     `json:"created_at" db:"created_at"`
-UpdatedAt               time.Time     `json:"updated_at" db:"updated_at"`
-}
\ No newline at end of file
+	ID                       uuid.UUID     `json:"id" db:"id"`
+	CreatorUserID            uuid.UUID     `json:"creator_user_id" db:"creator_user_id"`
+	Amount                   int64         `json:"amount" db:"amount"` // Stored in kobo
+	Description              *string       `json:"description,omitempty" db:"description"`
+	ImageURL                 *string       `json:"image_url,omitempty" db:"image_url"`
+	Status                   string        `json:"status" db:"status"`
+	PublicToken              string        `json:"public_token" db:"public_token"`
+	FulfilledAt              sql.NullTime  `json:"fulfilled_at,omitempty" db:"fulfilled_at"`
+	FulfilledByTransactionID uuid.NullUUID `json:"fulfilled_by_transaction_id,omitempty" db:"fulfilled_by_transaction_id"`
+	CreatedAt                time.Time     `json:"created_at" db:"created_at"`
+	UpdatedAt                time.Time     `json:"updated_at" db:"updated_at"`
+}
+
+// PublicPaymentRequest is the view of a payment request returned to anyone holding its
+// public token. It identifies the creator by username only.
+type PublicPaymentRequest struct {
+	ID              uuid.UUID `json:"id"`
+	CreatorUsername string    `json:"creator_username"`
+	Amount          int64     `json:"amount"` // In kobo
+	Description     *string   `json:"description,omitempty"`
+	ImageURL        *string   `json:"image_url,omitempty"`
+	Status          string    `json:"status"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// CreatePaymentRequestRequest is the expected JSON body for the `POST /payment-requests` endpoint.
+type CreatePaymentRequestRequest struct {
+	Amount      int64   `json:"amount"` // In kobo
+	Description *string `json:"description,omitempty"`
+	ImageURL    *string `json:"image_url,omitempty"`
+}
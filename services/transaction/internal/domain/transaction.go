@@ -4,6 +4,12 @@
  * The Transaction struct is a comprehensive record of any money movement within the
  * Transfa application, covering everything from P2P payments to wallet funding.
  *
+ * Key features:
+ * - `Transaction`: The persisted record of a money movement.
+ * - `P2PTransferRequest`: Defines the JSON structure for the POST /transactions/p2p endpoint.
+ * - `SelfTransferRequest`: Defines the JSON structure for the POST /transactions/self-transfer endpoint.
+ * - `SubscriptionFeeRequest`: Defines the JSON structure for the internal POST /internal/subscription-fees endpoint.
+ *
  * @dependencies
  * - "time": Used for timestamping records.
  * - "github.com/google/uuid": Used for universally unique identifiers and nullable UUIDs.
@@ -11,9 +17,28 @@
 package domain
 
 import (
-"time"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Transaction types as defined by the `transactions.type` check constraint.
+const (
+	TransactionTypeP2P              = "p2p"
+	TransactionTypeSelfTransfer     = "self_transfer"
+	TransactionTypeMoneyDropFunding = "money_drop_funding"
+	TransactionTypeMoneyDropClaim   = "money_drop_claim"
+	TransactionTypeMoneyDropRefund  = "money_drop_refund"
+	TransactionTypeSubscriptionFee  = "subscription_fee"
+	TransactionTypeWalletFunding    = "wallet_funding"
+)
 
-"github.com/google/uuid"
+// Transaction statuses as defined by the `transactions.status` check constraint.
+const (
+	TransactionStatusPending   = "pending"
+	TransactionStatusCompleted = "completed"
+	TransactionStatusFailed    = "failed"
+	TransactionStatusReversed  = "reversed"
 )
 
 // Transaction represents a single financial transaction in the system.
@@ -21,19 +46,49 @@ import (
 // Note the use of `uuid.NullUUID` for optional foreign keys, accommodating
 // different transaction types (e.g., wallet funding has no sender).
 type Transaction struct {
-ID                       uuid.UUID     `json:"id" db:"id"`
-SenderUserID             uuid.NullUUID `json:"sender_user_id,omitempty" db:"sender_user_id"`
-RecipientUserID          uuid.NullUUID `json:"recipient_user_id,omitempty" db:"recipient_user_id"`
-SourceAccountID          uuid.NullUUID `json:"source_account_id,omitempty" db:"source_account_id"`
-DestinationAccountID     uuid.NullUUID `json:"destination_account_id,omitempty" db:"destination_account_id"`
-DestinationBeneficiaryID uuid.NullUUID `json:"destination_beneficiary_id,omitempty" db:"destination_beneficiary_id"`
-AnchorTransferID         *string       `json:"anchor_transfer_id,omitempty" db:"anchor_transfer_id"`
-Type                     string        `json:"type" db:"type"`
-Amount                   int64         `json:"amount" db:"amount"` // Stored in kobo
-Fee                      int64         `json:"fee" db:"fee"`       // Stored in kobo
-Status                   string        `json:"status" db:"status"`
-Description              *string       `json:"description,omitempty" db:"description"`
-Category                 *string       `json:"category,omitempty" db:"category"`
-CreatedAt                time.Time     `json:"created_at" db:"created_at"`
-UpdatedAt                time.Time     `json:"updated_at" db:"updated_at"`
-}
\ No newline at end of file
+	ID                       uuid.UUID     `json:"id" db:"id"`
+	SenderUserID             uuid.NullUUID `json:"sender_user_id,omitempty" db:"sender_user_id"`
+	RecipientUserID          uuid.NullUUID `json:"recipient_user_id,omitempty" db:"recipient_user_id"`
+	SourceAccountID          uuid.NullUUID `json:"source_account_id,omitempty" db:"source_account_id"`
+	DestinationAccountID     uuid.NullUUID `json:"destination_account_id,omitempty" db:"destination_account_id"`
+	DestinationBeneficiaryID uuid.NullUUID `json:"destination_beneficiary_id,omitempty" db:"destination_beneficiary_id"`
+	AnchorTransferID         *string       `json:"anchor_transfer_id,omitempty" db:"anchor_transfer_id"`
+	Type                     string        `json:"type" db:"type"`
+	Amount                   int64         `json:"amount" db:"amount"` // Stored in kobo
+	Fee                      int64         `json:"fee" db:"fee"`       // Stored in kobo
+	Status                   string        `json:"status" db:"status"`
+	Description              *string       `json:"description,omitempty" db:"description"`
+	Category                 *string       `json:"category,omitempty" db:"category"`
+	IdempotencyKey           *string       `json:"idempotency_key,omitempty" db:"idempotency_key"`
+	CreatedAt                time.Time     `json:"created_at" db:"created_at"`
+	UpdatedAt                time.Time     `json:"updated_at" db:"updated_at"`
+}
+
+// P2PTransferRequest is the expected JSON body for the `POST /transactions/p2p` endpoint.
+// When PaymentRequestID is set, the payment fulfils that request: the recipient and
+// amount default to the request's creator and amount, and must match them if given.
+type P2PTransferRequest struct {
+	RecipientUsername string     `json:"recipient_username"`
+	Amount            int64      `json:"amount"` // In kobo
+	Description       *string    `json:"description,omitempty"`
+	PaymentRequestID  *uuid.UUID `json:"payment_request_id,omitempty"`
+	// StepUpToken approves the transfer. It is read from the X-Step-Up-Token header.
+	StepUpToken string `json:"-"`
+}
+
+// SelfTransferRequest is the expected JSON body for the `POST /transactions/self-transfer` endpoint.
+type SelfTransferRequest struct {
+	BeneficiaryID uuid.UUID `json:"beneficiary_id"`
+	Amount        int64     `json:"amount"` // In kobo
+	// StepUpToken approves the withdrawal. It is read from the X-Step-Up-Token header.
+	StepUpToken string `json:"-"`
+}
+
+// SubscriptionFeeRequest is the expected JSON body for the internal
+// `POST /internal/subscription-fees` endpoint. Requests with the same IdempotencyKey
+// refer to the same debit.
+type SubscriptionFeeRequest struct {
+	UserID         uuid.UUID `json:"user_id"`
+	Amount         int64     `json:"amount"` // In kobo
+	IdempotencyKey string    `json:"idempotency_key"`
+}
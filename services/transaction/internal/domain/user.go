@@ -0,0 +1,50 @@
+/**
+ * @description
+ * This file defines the user, account and beneficiary models the Transaction service
+ * reads when deciding how to move money. The service does not own these tables; it only
+ * needs enough of each record to resolve sender and recipient funding sources.
+ *
+ * Key features:
+ * - `User`: Minimal user profile used to resolve senders and recipients.
+ * - `Account`: A user's Anchor DepositAccount (main wallet or money drop wallet).
+ * - `Beneficiary`: A user's saved external bank account (Anchor CounterParty).
+ *
+ * @dependencies
+ * - "github.com/google/uuid": Used for universally unique identifiers.
+ */
+package domain
+
+import "github.com/google/uuid"
+
+// Account purposes as defined by the `accounts.account_purpose` check constraint.
+const (
+	AccountPurposeMainWallet      = "main_wallet"
+	AccountPurposeMoneyDropWallet = "money_drop_wallet"
+)
+
+// User represents the subset of the `users` table needed by the Transaction service.
+type User struct {
+	ID               uuid.UUID `json:"id" db:"id"`
+	ClerkID          string    `json:"clerk_id" db:"clerk_id"`
+	Username         string    `json:"username" db:"username"`
+	AccountType      string    `json:"account_type" db:"account_type"`
+	AnchorCustomerID string    `json:"anchor_customer_id" db:"anchor_customer_id"`
+	AllowSending     bool      `json:"allow_sending" db:"allow_sending"`
+}
+
+// Account represents a user's wallet as stored in the `accounts` table.
+type Account struct {
+	ID              uuid.UUID `json:"id" db:"id"`
+	UserID          uuid.UUID `json:"user_id" db:"user_id"`
+	AnchorAccountID string    `json:"anchor_account_id" db:"anchor_account_id"`
+	AccountPurpose  string    `json:"account_purpose" db:"account_purpose"`
+	Status          string    `json:"status" db:"status"`
+}
+
+// Beneficiary represents a user's saved external bank account as stored in the `beneficiaries` table.
+type Beneficiary struct {
+	ID                   uuid.UUID `json:"id" db:"id"`
+	UserID               uuid.UUID `json:"user_id" db:"user_id"`
+	AnchorCounterpartyID string    `json:"anchor_counterparty_id" db:"anchor_counterparty_id"`
+	IsDefault            bool      `json:"is_default" db:"is_default"`
+}
@@ -0,0 +1,199 @@
+/**
+ * @description
+ * This file contains the PostgreSQL persistence logic for payment requests, including
+ * the reservation of a request by the P2P transaction paying it. The request is
+ * fulfilled or released when that transaction settles, in settlement.go.
+ *
+ * @dependencies
+ * - Go standard library packages: "context", "errors", "fmt"
+ * - "github.com/google/uuid": For record identifiers.
+ * - "github.com/jackc/pgx/v5": For checking specific database errors.
+ * - "transfa/services/transaction/internal/domain": For core data models.
+ */
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"transfa/services/transaction/internal/domain"
+)
+
+// paymentRequestColumns is the column list scanned by scanPaymentRequest.
+const paymentRequestColumns = `id, creator_user_id, amount, description, image_url, status, public_token,
+        fulfilled_at, fulfilled_by_transaction_id, created_at, updated_at`
+
+// scanPaymentRequest scans a row selected with paymentRequestColumns into a domain.PaymentRequest.
+func scanPaymentRequest(row pgx.Row) (*domain.PaymentRequest, error) {
+	var pr domain.PaymentRequest
+	err := row.Scan(
+		&pr.ID,
+		&pr.CreatorUserID,
+		&pr.Amount,
+		&pr.Description,
+		&pr.ImageURL,
+		&pr.Status,
+		&pr.PublicToken,
+		&pr.FulfilledAt,
+		&pr.FulfilledByTransactionID,
+		&pr.CreatedAt,
+		&pr.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &pr, nil
+}
+
+// CreatePaymentRequest inserts a new record into the public.payment_requests table.
+func (r *PostgresRepository) CreatePaymentRequest(ctx context.Context, pr *domain.PaymentRequest) (*domain.PaymentRequest, error) {
+	query := `
+        INSERT INTO public.payment_requests (creator_user_id, amount, description, image_url, status, public_token)
+        VALUES ($1, $2, $3, $4, $5, $6)
+        RETURNING ` + paymentRequestColumns
+
+	created, err := scanPaymentRequest(r.db.QueryRow(ctx, query,
+		pr.CreatorUserID,
+		pr.Amount,
+		pr.Description,
+		pr.ImageURL,
+		pr.Status,
+		pr.PublicToken,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert payment request into database: %w", err)
+	}
+	return created, nil
+}
+
+// GetPaymentRequestByID retrieves a payment request by its ID.
+func (r *PostgresRepository) GetPaymentRequestByID(ctx context.Context, id uuid.UUID) (*domain.PaymentRequest, error) {
+	pr, err := scanPaymentRequest(r.db.QueryRow(ctx, `SELECT `+paymentRequestColumns+` FROM public.payment_requests WHERE id = $1`, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("%w: with id %s", domain.ErrPaymentRequestNotFound, id)
+		}
+		return nil, fmt.Errorf("failed to get payment request: %w", err)
+	}
+	return pr, nil
+}
+
+// GetPublicPaymentRequestByToken resolves a shared payment request link. Links of a deleted
+// creator are not found.
+func (r *PostgresRepository) GetPublicPaymentRequestByToken(ctx context.Context, token string) (*domain.PublicPaymentRequest, error) {
+	query := `
+        SELECT pr.id, u.username, pr.amount, pr.description, pr.image_url, pr.status, pr.created_at
+        FROM public.payment_requests pr
+        JOIN public.users u ON u.id = pr.creator_user_id
+        WHERE pr.public_token = $1 AND u.deleted_at IS NULL
+    `
+	var pr domain.PublicPaymentRequest
+	err := r.db.QueryRow(ctx, query, token).Scan(
+		&pr.ID,
+		&pr.CreatorUsername,
+		&pr.Amount,
+		&pr.Description,
+		&pr.ImageURL,
+		&pr.Status,
+		&pr.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrPaymentRequestNotFound
+		}
+		return nil, fmt.Errorf("failed to get payment request by token: %w", err)
+	}
+	return &pr, nil
+}
+
+// ListPaymentRequestsByCreator returns the creator's payment requests, newest first.
+// An empty status returns requests in every status.
+func (r *PostgresRepository) ListPaymentRequestsByCreator(ctx context.Context, creatorID uuid.UUID, status string) ([]domain.PaymentRequest, error) {
+	query := `
+        SELECT ` + paymentRequestColumns + `
+        FROM public.payment_requests
+        WHERE creator_user_id = $1 AND ($2 = '' OR status = $2)
+        ORDER BY created_at DESC
+    `
+	rows, err := r.db.Query(ctx, query, creatorID, status)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list payment requests: %w", err)
+	}
+	defer rows.Close()
+
+	requests := []domain.PaymentRequest{}
+	for rows.Next() {
+		pr, err := scanPaymentRequest(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan payment request: %w", err)
+		}
+		requests = append(requests, *pr)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list payment requests: %w", err)
+	}
+	return requests, nil
+}
+
+// CancelPaymentRequest marks a pending payment request owned by creatorID as cancelled.
+func (r *PostgresRepository) CancelPaymentRequest(ctx context.Context, id, creatorID uuid.UUID) (*domain.PaymentRequest, error) {
+	query := `
+        UPDATE public.payment_requests
+        SET status = 'cancelled'
+        WHERE id = $1 AND creator_user_id = $2 AND status = 'pending'
+        RETURNING ` + paymentRequestColumns
+
+	pr, err := scanPaymentRequest(r.db.QueryRow(ctx, query, id, creatorID))
+	if err == nil {
+		return pr, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("failed to cancel payment request: %w", err)
+	}
+
+	// Nothing was updated: distinguish a missing request from one that is no longer pending.
+	existing, err := r.GetPaymentRequestByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if existing.CreatorUserID != creatorID {
+		return nil, fmt.Errorf("%w: with id %s", domain.ErrPaymentRequestNotFound, id)
+	}
+	return nil, domain.ErrPaymentRequestNotPending
+}
+
+// CreateTransactionPayingRequest inserts the pending transaction tx and reserves the
+// pending payment request it pays, in a single database transaction, so that no other
+// transfer can pay the request while this one is in flight. If the request is no longer
+// pending, nothing is written and ErrPaymentRequestNotPending is returned.
+func (r *PostgresRepository) CreateTransactionPayingRequest(ctx context.Context, tx *domain.Transaction, paymentRequestID uuid.UUID) error {
+	dbTx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin payment transaction: %w", err)
+	}
+	defer dbTx.Rollback(ctx)
+
+	if err := insertTransaction(ctx, dbTx, tx); err != nil {
+		return err
+	}
+
+	cmdTag, err := dbTx.Exec(ctx, `
+        UPDATE public.payment_requests
+        SET status = 'paying', paying_transaction_id = $1
+        WHERE id = $2 AND status = 'pending'
+    `, tx.ID, paymentRequestID)
+	if err != nil {
+		return fmt.Errorf("failed to reserve payment request: %w", err)
+	}
+	if cmdTag.RowsAffected() != 1 {
+		return domain.ErrPaymentRequestNotPending
+	}
+
+	if err := dbTx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit payment transaction: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,651 @@
+/**
+ * @description
+ * This file provides the PostgreSQL implementation of the Repository interface for the
+ * Transaction service. It encapsulates all database-specific logic, such as resolving
+ * senders and recipients, reading routing inputs, persisting transaction records, and
+ * the atomic Money Drop claim validation. Payment request queries live in payment_request.go.
+ *
+ * @dependencies
+ * - Go standard library packages: "context", "errors", "fmt", "time"
+ * - "github.com/google/uuid": For record identifiers.
+ * - "github.com/jackc/pgx/v5": For checking specific database errors.
+ * - "github.com/jackc/pgx/v5/pgxpool": The PostgreSQL driver and connection pool.
+ * - "transfa/services/transaction/internal/domain": For core data models.
+ */
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"transfa/services/transaction/internal/domain"
+)
+
+var (
+	ErrUserNotFound        = errors.New("user not found")
+	ErrAccountNotFound     = errors.New("account not found")
+	ErrBeneficiaryNotFound = errors.New("beneficiary not found")
+	ErrTransactionNotFound = errors.New("transaction not found")
+)
+
+// PostgresRepository is the concrete implementation for database operations.
+type PostgresRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewPostgresRepository creates a new instance of the repository.
+func NewPostgresRepository(db *pgxpool.Pool) *PostgresRepository {
+	return &PostgresRepository{
+		db: db,
+	}
+}
+
+// userColumns is the column list scanned by scanUser.
+const userColumns = `id, clerk_id, username, account_type, COALESCE(anchor_customer_id, ''), allow_sending`
+
+// scanUser scans a row selected with userColumns into a domain.User.
+func scanUser(row pgx.Row) (*domain.User, error) {
+	var user domain.User
+	err := row.Scan(&user.ID, &user.ClerkID, &user.Username, &user.AccountType, &user.AnchorCustomerID, &user.AllowSending)
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// GetUserByClerkID retrieves a user using the Clerk User ID from the session token.
+// Users deleted in Clerk are not found.
+func (r *PostgresRepository) GetUserByClerkID(ctx context.Context, clerkID string) (*domain.User, error) {
+	query := `SELECT ` + userColumns + ` FROM public.users WHERE clerk_id = $1 AND deleted_at IS NULL`
+
+	user, err := scanUser(r.db.QueryRow(ctx, query, clerkID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("%w: with clerk_id %s", ErrUserNotFound, clerkID)
+		}
+		return nil, fmt.Errorf("failed to query user by clerk id: %w", err)
+	}
+
+	return user, nil
+}
+
+// GetUserByUsername retrieves a user by their unique username. Usernames are stored in
+// lowercase, so the lookup is case-insensitive. Deleted users are not found.
+func (r *PostgresRepository) GetUserByUsername(ctx context.Context, username string) (*domain.User, error) {
+	query := `SELECT ` + userColumns + ` FROM public.users WHERE username = lower($1) AND deleted_at IS NULL`
+
+	user, err := scanUser(r.db.QueryRow(ctx, query, username))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("%w: with username %s", ErrUserNotFound, username)
+		}
+		return nil, fmt.Errorf("failed to query user by username: %w", err)
+	}
+
+	return user, nil
+}
+
+// GetUserByOldUsername retrieves the user who gave up username within its grace period,
+// most recently first. Once the grace period ends, or if the user was deleted,
+// ErrUserNotFound is returned.
+func (r *PostgresRepository) GetUserByOldUsername(ctx context.Context, username string) (*domain.User, error) {
+	query := `
+        SELECT u.id, u.clerk_id, u.username, u.account_type, COALESCE(u.anchor_customer_id, ''), u.allow_sending
+        FROM public.username_history h
+        JOIN public.users u ON u.id = h.user_id
+        WHERE h.old_username = lower($1) AND h.grace_until > now() AND u.deleted_at IS NULL
+        ORDER BY h.changed_at DESC
+        LIMIT 1
+    `
+
+	user, err := scanUser(r.db.QueryRow(ctx, query, username))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("%w: with old username %s", ErrUserNotFound, username)
+		}
+		return nil, fmt.Errorf("failed to query user by old username: %w", err)
+	}
+
+	return user, nil
+}
+
+// GetUserByID retrieves a user by their internal ID. Deleted users are not found.
+func (r *PostgresRepository) GetUserByID(ctx context.Context, userID uuid.UUID) (*domain.User, error) {
+	query := `SELECT ` + userColumns + ` FROM public.users WHERE id = $1 AND deleted_at IS NULL`
+
+	user, err := scanUser(r.db.QueryRow(ctx, query, userID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("%w: with id %s", ErrUserNotFound, userID)
+		}
+		return nil, fmt.Errorf("failed to query user by id: %w", err)
+	}
+
+	return user, nil
+}
+
+// GetAccountByUserID retrieves a user's active account for the given purpose (e.g. `main_wallet`).
+func (r *PostgresRepository) GetAccountByUserID(ctx context.Context, userID uuid.UUID, purpose string) (*domain.Account, error) {
+	query := `
+        SELECT id, user_id, anchor_account_id, account_purpose, status
+        FROM public.accounts
+        WHERE user_id = $1 AND account_purpose = $2 AND status = 'active'
+        ORDER BY created_at
+        LIMIT 1
+    `
+
+	var account domain.Account
+	err := r.db.QueryRow(ctx, query, userID, purpose).Scan(
+		&account.ID,
+		&account.UserID,
+		&account.AnchorAccountID,
+		&account.AccountPurpose,
+		&account.Status,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("%w: %s for user %s", ErrAccountNotFound, purpose, userID)
+		}
+		return nil, fmt.Errorf("failed to query account by user id: %w", err)
+	}
+
+	return &account, nil
+}
+
+// GetAccountByID retrieves an account by its internal ID.
+func (r *PostgresRepository) GetAccountByID(ctx context.Context, accountID uuid.UUID) (*domain.Account, error) {
+	query := `SELECT id, user_id, anchor_account_id, account_purpose, status FROM public.accounts WHERE id = $1`
+
+	var account domain.Account
+	err := r.db.QueryRow(ctx, query, accountID).Scan(
+		&account.ID,
+		&account.UserID,
+		&account.AnchorAccountID,
+		&account.AccountPurpose,
+		&account.Status,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("%w: with id %s", ErrAccountNotFound, accountID)
+		}
+		return nil, fmt.Errorf("failed to query account by id: %w", err)
+	}
+
+	return &account, nil
+}
+
+// CreateAccount inserts a new account record into the public.accounts table.
+func (r *PostgresRepository) CreateAccount(ctx context.Context, account *domain.Account) (*domain.Account, error) {
+	query := `
+        INSERT INTO public.accounts (user_id, anchor_account_id, account_purpose, status)
+        VALUES ($1, $2, $3, $4)
+        RETURNING id
+    `
+
+	err := r.db.QueryRow(ctx, query,
+		account.UserID,
+		account.AnchorAccountID,
+		account.AccountPurpose,
+		account.Status,
+	).Scan(&account.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert account into database: %w", err)
+	}
+
+	return account, nil
+}
+
+// GetDefaultBeneficiary retrieves the beneficiary a user receives external payments into.
+// The explicit choice in `user_settings` takes precedence over the `is_default` flag.
+func (r *PostgresRepository) GetDefaultBeneficiary(ctx context.Context, userID uuid.UUID) (*domain.Beneficiary, error) {
+	query := `
+        SELECT b.id, b.user_id, b.anchor_counterparty_id, b.is_default
+        FROM public.beneficiaries b
+        LEFT JOIN public.user_settings s ON s.user_id = b.user_id
+        WHERE b.user_id = $1 AND (b.id = s.default_beneficiary_id OR b.is_default)
+        ORDER BY (b.id = s.default_beneficiary_id) DESC NULLS LAST, b.created_at
+        LIMIT 1
+    `
+
+	var beneficiary domain.Beneficiary
+	err := r.db.QueryRow(ctx, query, userID).Scan(
+		&beneficiary.ID,
+		&beneficiary.UserID,
+		&beneficiary.AnchorCounterpartyID,
+		&beneficiary.IsDefault,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("%w: no default beneficiary for user %s", ErrBeneficiaryNotFound, userID)
+		}
+		return nil, fmt.Errorf("failed to query default beneficiary: %w", err)
+	}
+
+	return &beneficiary, nil
+}
+
+// GetBeneficiaryByID retrieves a beneficiary, scoped to the user that owns it.
+// A beneficiary belonging to another user is reported as not found.
+func (r *PostgresRepository) GetBeneficiaryByID(ctx context.Context, userID, beneficiaryID uuid.UUID) (*domain.Beneficiary, error) {
+	query := `
+        SELECT id, user_id, anchor_counterparty_id, is_default
+        FROM public.beneficiaries
+        WHERE id = $1 AND user_id = $2
+    `
+
+	var beneficiary domain.Beneficiary
+	err := r.db.QueryRow(ctx, query, beneficiaryID, userID).Scan(
+		&beneficiary.ID,
+		&beneficiary.UserID,
+		&beneficiary.AnchorCounterpartyID,
+		&beneficiary.IsDefault,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("%w: with id %s for user %s", ErrBeneficiaryNotFound, beneficiaryID, userID)
+		}
+		return nil, fmt.Errorf("failed to query beneficiary by id: %w", err)
+	}
+
+	return &beneficiary, nil
+}
+
+// CreateTransaction inserts a new record into the public.transactions table.
+func (r *PostgresRepository) CreateTransaction(ctx context.Context, tx *domain.Transaction) (*domain.Transaction, error) {
+	if err := insertTransaction(ctx, r.db, tx); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+// querier is satisfied by both *pgxpool.Pool and pgx.Tx, so that inserts can be
+// shared between standalone statements and multi-statement database transactions.
+type querier interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// insertTransaction inserts a transaction record and populates its generated fields.
+func insertTransaction(ctx context.Context, q querier, tx *domain.Transaction) error {
+	query := `
+        INSERT INTO public.transactions (
+            sender_user_id, recipient_user_id, source_account_id, destination_account_id,
+            destination_beneficiary_id, type, amount, fee, status, description, category, idempotency_key
+        )
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+        RETURNING id, created_at, updated_at
+    `
+
+	err := q.QueryRow(ctx, query,
+		tx.SenderUserID,
+		tx.RecipientUserID,
+		tx.SourceAccountID,
+		tx.DestinationAccountID,
+		tx.DestinationBeneficiaryID,
+		tx.Type,
+		tx.Amount,
+		tx.Fee,
+		tx.Status,
+		tx.Description,
+		tx.Category,
+		tx.IdempotencyKey,
+	).Scan(&tx.ID, &tx.CreatedAt, &tx.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to insert transaction into database: %w", err)
+	}
+
+	return nil
+}
+
+// transactionColumns is the column list scanned by scanTransaction.
+const transactionColumns = `id, sender_user_id, recipient_user_id, source_account_id, destination_account_id,
+        destination_beneficiary_id, anchor_transfer_id, type, amount, fee, status, description, category,
+        idempotency_key, created_at, updated_at`
+
+// scanTransaction scans a row selected with transactionColumns into a domain.Transaction.
+func scanTransaction(row pgx.Row) (*domain.Transaction, error) {
+	var tx domain.Transaction
+	err := row.Scan(
+		&tx.ID,
+		&tx.SenderUserID,
+		&tx.RecipientUserID,
+		&tx.SourceAccountID,
+		&tx.DestinationAccountID,
+		&tx.DestinationBeneficiaryID,
+		&tx.AnchorTransferID,
+		&tx.Type,
+		&tx.Amount,
+		&tx.Fee,
+		&tx.Status,
+		&tx.Description,
+		&tx.Category,
+		&tx.IdempotencyKey,
+		&tx.CreatedAt,
+		&tx.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &tx, nil
+}
+
+// GetTransactionByIdempotencyKey retrieves the transaction created for an idempotency key.
+func (r *PostgresRepository) GetTransactionByIdempotencyKey(ctx context.Context, key string) (*domain.Transaction, error) {
+	query := `SELECT ` + transactionColumns + ` FROM public.transactions WHERE idempotency_key = $1`
+
+	tx, err := scanTransaction(r.db.QueryRow(ctx, query, key))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("%w: with idempotency_key %s", ErrTransactionNotFound, key)
+		}
+		return nil, fmt.Errorf("failed to query transaction by idempotency key: %w", err)
+	}
+	return tx, nil
+}
+
+// UpdateTransactionStatus records the outcome of a transfer and the Anchor transfer ID, if any.
+func (r *PostgresRepository) UpdateTransactionStatus(ctx context.Context, id uuid.UUID, status string, anchorTransferID *string) error {
+	query := `
+        UPDATE public.transactions
+        SET status = $1, anchor_transfer_id = COALESCE($2, anchor_transfer_id)
+        WHERE id = $3
+    `
+	cmdTag, err := r.db.Exec(ctx, query, status, anchorTransferID, id)
+	if err != nil {
+		return fmt.Errorf("failed to update transaction status: %w", err)
+	}
+	if cmdTag.RowsAffected() != 1 {
+		return fmt.Errorf("%w: with id %s", ErrTransactionNotFound, id)
+	}
+	return nil
+}
+
+// moneyDropColumns is the column list scanned by scanMoneyDrop.
+const moneyDropColumns = `id, creator_user_id, funding_account_id, total_amount, amount_per_claim,
+        total_claims_allowed, claims_made_count, status, expiry_timestamp, funding_transaction_id,
+        created_at, updated_at`
+
+// scanMoneyDrop scans a row selected with moneyDropColumns into a domain.MoneyDrop.
+func scanMoneyDrop(row pgx.Row) (*domain.MoneyDrop, error) {
+	var drop domain.MoneyDrop
+	err := row.Scan(
+		&drop.ID,
+		&drop.CreatorUserID,
+		&drop.FundingAccountID,
+		&drop.TotalAmount,
+		&drop.AmountPerClaim,
+		&drop.TotalClaimsAllowed,
+		&drop.ClaimsMadeCount,
+		&drop.Status,
+		&drop.ExpiryTimestamp,
+		&drop.FundingTransactionID,
+		&drop.CreatedAt,
+		&drop.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &drop, nil
+}
+
+// CreateMoneyDropFunding inserts the pending transaction tx funding a Money Drop and the
+// drop itself, in the funding status, in a single database transaction. The drop becomes
+// claimable once CompleteTransaction completes tx.
+func (r *PostgresRepository) CreateMoneyDropFunding(ctx context.Context, drop *domain.MoneyDrop, tx *domain.Transaction) error {
+	dbTx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin money drop transaction: %w", err)
+	}
+	defer dbTx.Rollback(ctx)
+
+	if err := insertTransaction(ctx, dbTx, tx); err != nil {
+		return err
+	}
+
+	query := `
+        INSERT INTO public.money_drops (
+            creator_user_id, funding_account_id, total_amount, amount_per_claim,
+            total_claims_allowed, status, expiry_timestamp, funding_transaction_id
+        )
+        VALUES ($1, $2, $3, $4, $5, 'funding', $6, $7)
+        RETURNING ` + moneyDropColumns
+
+	created, err := scanMoneyDrop(dbTx.QueryRow(ctx, query,
+		drop.CreatorUserID,
+		drop.FundingAccountID,
+		drop.TotalAmount,
+		drop.AmountPerClaim,
+		drop.TotalClaimsAllowed,
+		drop.ExpiryTimestamp,
+		tx.ID,
+	))
+	if err != nil {
+		return fmt.Errorf("failed to insert money drop into database: %w", err)
+	}
+
+	if err := dbTx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit money drop transaction: %w", err)
+	}
+	*drop = *created
+	return nil
+}
+
+// ClaimMoneyDrop validates and records a claim in a single database transaction.
+// The Money Drop row is locked with SELECT ... FOR UPDATE so that concurrent claimers
+// are serialized: the drop must be active and unexpired, must have claims left, and
+// the claimant must not have claimed before. The claim, the incremented claim count
+// and the pending payout transaction are committed together.
+func (r *PostgresRepository) ClaimMoneyDrop(ctx context.Context, dropID, claimantID, destinationAccountID uuid.UUID) (*domain.MoneyDropPayout, error) {
+	dbTx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin claim transaction: %w", err)
+	}
+	// Rollback is a no-op once the transaction has been committed.
+	defer dbTx.Rollback(ctx)
+
+	drop, err := scanMoneyDrop(dbTx.QueryRow(ctx, `SELECT `+moneyDropColumns+` FROM public.money_drops WHERE id = $1 FOR UPDATE`, dropID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrMoneyDropNotFound
+		}
+		return nil, fmt.Errorf("failed to lock money drop: %w", err)
+	}
+
+	switch {
+	case drop.CreatorUserID == claimantID:
+		return nil, domain.ErrMoneyDropOwnClaim
+	case drop.Status != domain.MoneyDropStatusActive:
+		return nil, domain.ErrMoneyDropNotActive
+	case !time.Now().Before(drop.ExpiryTimestamp):
+		return nil, domain.ErrMoneyDropExpired
+	case drop.ClaimsMadeCount >= drop.TotalClaimsAllowed:
+		return nil, domain.ErrMoneyDropFullyClaimed
+	}
+
+	var alreadyClaimed bool
+	err = dbTx.QueryRow(ctx,
+		`SELECT EXISTS (SELECT 1 FROM public.money_drop_claims WHERE money_drop_id = $1 AND claimant_user_id = $2)`,
+		dropID, claimantID,
+	).Scan(&alreadyClaimed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check existing claims: %w", err)
+	}
+	if alreadyClaimed {
+		return nil, domain.ErrMoneyDropAlreadyClaimed
+	}
+
+	payout := &domain.MoneyDropPayout{
+		Transaction: domain.Transaction{
+			SenderUserID:         uuid.NullUUID{UUID: drop.CreatorUserID, Valid: true},
+			RecipientUserID:      uuid.NullUUID{UUID: claimantID, Valid: true},
+			SourceAccountID:      uuid.NullUUID{UUID: drop.FundingAccountID, Valid: true},
+			DestinationAccountID: uuid.NullUUID{UUID: destinationAccountID, Valid: true},
+			Type:                 domain.TransactionTypeMoneyDropClaim,
+			Amount:               drop.AmountPerClaim,
+			Status:               domain.TransactionStatusPending,
+		},
+	}
+	if err := insertTransaction(ctx, dbTx, &payout.Transaction); err != nil {
+		return nil, err
+	}
+
+	payout.Claim = domain.MoneyDropClaim{MoneyDropID: dropID, ClaimantUserID: claimantID, PayoutTransactionID: payout.Transaction.ID}
+	err = dbTx.QueryRow(ctx, `
+        INSERT INTO public.money_drop_claims (money_drop_id, claimant_user_id, payout_transaction_id)
+        VALUES ($1, $2, $3)
+        RETURNING id, claimed_at
+    `, dropID, claimantID, payout.Transaction.ID).Scan(&payout.Claim.ID, &payout.Claim.ClaimedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert money drop claim: %w", err)
+	}
+
+	// The drop is marked completed as soon as the last claim is taken.
+	err = dbTx.QueryRow(ctx, `
+        UPDATE public.money_drops
+        SET claims_made_count = claims_made_count + 1,
+            status = CASE WHEN claims_made_count + 1 >= total_claims_allowed THEN 'completed' ELSE status END
+        WHERE id = $1
+        RETURNING claims_made_count, status, updated_at
+    `, dropID).Scan(&drop.ClaimsMadeCount, &drop.Status, &drop.UpdatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update money drop claim count: %w", err)
+	}
+	payout.Drop = *drop
+
+	if err := dbTx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit claim transaction: %w", err)
+	}
+
+	return payout, nil
+}
+
+// ListExpiredMoneyDrops returns the IDs of active Money Drops whose expiry is at or before
+// the given time, oldest first.
+func (r *PostgresRepository) ListExpiredMoneyDrops(ctx context.Context, before time.Time) ([]uuid.UUID, error) {
+	rows, err := r.db.Query(ctx, `
+        SELECT id FROM public.money_drops
+        WHERE status = 'active' AND expiry_timestamp <= $1
+        ORDER BY expiry_timestamp
+    `, before)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list expired money drops: %w", err)
+	}
+	defer rows.Close()
+
+	ids := []uuid.UUID{}
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan money drop id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list expired money drops: %w", err)
+	}
+	return ids, nil
+}
+
+// ExpireMoneyDrop marks an active, expired Money Drop as expired and records the pending
+// refund of its unclaimed funds in a single database transaction. Calling it again for a
+// drop that is already expired returns the refund recorded the first time, so callers
+// can safely retry. The refund is paid into the creator's main wallet and carries
+// refundKey as its idempotency key. A drop with claim payouts still pending is not
+// expired and ErrMoneyDropPayoutsPending is returned: a payout that later fails returns
+// its share to the drop, and that share must be part of the refund.
+func (r *PostgresRepository) ExpireMoneyDrop(ctx context.Context, dropID uuid.UUID, refundKey string) (*domain.MoneyDropRefund, error) {
+	dbTx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin expiry transaction: %w", err)
+	}
+	defer dbTx.Rollback(ctx)
+
+	drop, err := scanMoneyDrop(dbTx.QueryRow(ctx, `SELECT `+moneyDropColumns+` FROM public.money_drops WHERE id = $1 FOR UPDATE`, dropID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrMoneyDropNotFound
+		}
+		return nil, fmt.Errorf("failed to lock money drop: %w", err)
+	}
+	refund := &domain.MoneyDropRefund{Drop: *drop}
+
+	switch drop.Status {
+	case domain.MoneyDropStatusCompleted:
+		// Every share was claimed; nothing is left to refund.
+		return refund, nil
+	case domain.MoneyDropStatusExpired:
+		tx, err := scanTransaction(dbTx.QueryRow(ctx, `SELECT `+transactionColumns+` FROM public.transactions WHERE idempotency_key = $1`, refundKey))
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("failed to get money drop refund: %w", err)
+		}
+		refund.Transaction = tx
+		return refund, nil
+	}
+	if time.Now().Before(drop.ExpiryTimestamp) {
+		return nil, domain.ErrMoneyDropNotExpired
+	}
+
+	var payoutsPending bool
+	err = dbTx.QueryRow(ctx, `
+        SELECT EXISTS (
+            SELECT 1 FROM public.money_drop_claims c
+            JOIN public.transactions t ON t.id = c.payout_transaction_id
+            WHERE c.money_drop_id = $1 AND t.status = 'pending'
+        )
+    `, dropID).Scan(&payoutsPending)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check money drop payouts: %w", err)
+	}
+	if payoutsPending {
+		return nil, domain.ErrMoneyDropPayoutsPending
+	}
+	refund.Created = true
+
+	err = dbTx.QueryRow(ctx,
+		`UPDATE public.money_drops SET status = 'expired' WHERE id = $1 RETURNING status, updated_at`,
+		dropID,
+	).Scan(&refund.Drop.Status, &refund.Drop.UpdatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to expire money drop: %w", err)
+	}
+
+	remaining := drop.TotalAmount - int64(drop.ClaimsMadeCount)*drop.AmountPerClaim
+	if remaining > 0 {
+		var creatorAccountID uuid.UUID
+		err = dbTx.QueryRow(ctx, `
+            SELECT id FROM public.accounts
+            WHERE user_id = $1 AND account_purpose = 'main_wallet' AND status = 'active'
+            ORDER BY created_at
+            LIMIT 1
+        `, drop.CreatorUserID).Scan(&creatorAccountID)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return nil, fmt.Errorf("%w: main_wallet for user %s", ErrAccountNotFound, drop.CreatorUserID)
+			}
+			return nil, fmt.Errorf("failed to get creator wallet: %w", err)
+		}
+
+		refund.Transaction = &domain.Transaction{
+			SenderUserID:         uuid.NullUUID{UUID: drop.CreatorUserID, Valid: true},
+			RecipientUserID:      uuid.NullUUID{UUID: drop.CreatorUserID, Valid: true},
+			SourceAccountID:      uuid.NullUUID{UUID: drop.FundingAccountID, Valid: true},
+			DestinationAccountID: uuid.NullUUID{UUID: creatorAccountID, Valid: true},
+			Type:                 domain.TransactionTypeMoneyDropRefund,
+			Amount:               remaining,
+			Status:               domain.TransactionStatusPending,
+			IdempotencyKey:       &refundKey,
+		}
+		if err := insertTransaction(ctx, dbTx, refund.Transaction); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := dbTx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit expiry transaction: %w", err)
+	}
+	return refund, nil
+}
@@ -0,0 +1,191 @@
+/**
+ * @description
+ * This file contains the queries that settle transfers: recording a pending transaction
+ * as completed or failed, together with whatever the transfer was paying for, and
+ * finding the transfers still waiting to be reconciled with Anchor.
+ *
+ * Key features:
+ * - A transaction is only settled while it is pending, so a transfer settled twice (for
+ *   example, once by the request that made it and once by reconciliation) takes effect once.
+ * - A transfer holds what it pays for until it settles: a payment request stays `paying`,
+ *   a Money Drop stays `funding` and a Money Drop claim stays reserved. Settling the
+ *   transfer fulfils or releases them in the same database transaction.
+ *
+ * @dependencies
+ * - Go standard library packages: "context", "errors", "fmt", "time"
+ * - "github.com/google/uuid": For record identifiers.
+ * - "github.com/jackc/pgx/v5": For checking specific database errors.
+ * - "transfa/services/transaction/internal/domain": For core data models.
+ */
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"transfa/services/transaction/internal/domain"
+)
+
+// GetTransactionByID retrieves a transaction by its ID.
+func (r *PostgresRepository) GetTransactionByID(ctx context.Context, id uuid.UUID) (*domain.Transaction, error) {
+	tx, err := scanTransaction(r.db.QueryRow(ctx, `SELECT `+transactionColumns+` FROM public.transactions WHERE id = $1`, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("%w: with id %s", ErrTransactionNotFound, id)
+		}
+		return nil, fmt.Errorf("failed to query transaction by id: %w", err)
+	}
+	return tx, nil
+}
+
+// ListPendingTransactions returns the IDs of transactions created at or before the given
+// time that are still pending, oldest first.
+func (r *PostgresRepository) ListPendingTransactions(ctx context.Context, before time.Time) ([]uuid.UUID, error) {
+	rows, err := r.db.Query(ctx, `
+        SELECT id FROM public.transactions
+        WHERE status = 'pending' AND created_at <= $1
+        ORDER BY created_at
+    `, before)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pending transactions: %w", err)
+	}
+	defer rows.Close()
+
+	ids := []uuid.UUID{}
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan transaction id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list pending transactions: %w", err)
+	}
+	return ids, nil
+}
+
+// CompleteTransaction marks a pending transaction as completed with its Anchor transfer
+// ID, fulfils the payment request it was paying and makes the Money Drop it was funding
+// claimable, in a single database transaction. It reports false, writing nothing, if
+// the transaction was no longer pending.
+func (r *PostgresRepository) CompleteTransaction(ctx context.Context, txID uuid.UUID, anchorTransferID string) (bool, error) {
+	dbTx, err := r.db.Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin settlement transaction: %w", err)
+	}
+	defer dbTx.Rollback(ctx)
+
+	settled, err := settleTransaction(ctx, dbTx, txID, domain.TransactionStatusCompleted, &anchorTransferID)
+	if err != nil || !settled {
+		return false, err
+	}
+
+	_, err = dbTx.Exec(ctx, `
+        UPDATE public.payment_requests
+        SET status = 'fulfilled', fulfilled_at = now(), fulfilled_by_transaction_id = $1,
+            paying_transaction_id = NULL
+        WHERE paying_transaction_id = $1 AND status = 'paying'
+    `, txID)
+	if err != nil {
+		return false, fmt.Errorf("failed to fulfill payment request: %w", err)
+	}
+
+	_, err = dbTx.Exec(ctx, `
+        UPDATE public.money_drops SET status = 'active'
+        WHERE funding_transaction_id = $1 AND status = 'funding'
+    `, txID)
+	if err != nil {
+		return false, fmt.Errorf("failed to activate money drop: %w", err)
+	}
+
+	if err := dbTx.Commit(ctx); err != nil {
+		return false, fmt.Errorf("failed to commit settlement transaction: %w", err)
+	}
+	return true, nil
+}
+
+// FailTransaction marks a pending transaction as failed, recording its Anchor transfer
+// ID if it has one, and releases what the transfer held in a single database
+// transaction: the payment request it was paying is pending again, the Money Drop it was
+// funding is failed, and the Money Drop claim it was paying out is deleted, returning
+// the share to the pool. It reports false, writing nothing, if the transaction was no
+// longer pending.
+func (r *PostgresRepository) FailTransaction(ctx context.Context, txID uuid.UUID, anchorTransferID *string) (bool, error) {
+	dbTx, err := r.db.Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin settlement transaction: %w", err)
+	}
+	defer dbTx.Rollback(ctx)
+
+	settled, err := settleTransaction(ctx, dbTx, txID, domain.TransactionStatusFailed, anchorTransferID)
+	if err != nil || !settled {
+		return false, err
+	}
+
+	_, err = dbTx.Exec(ctx, `
+        UPDATE public.payment_requests
+        SET status = 'pending', paying_transaction_id = NULL
+        WHERE paying_transaction_id = $1 AND status = 'paying'
+    `, txID)
+	if err != nil {
+		return false, fmt.Errorf("failed to release payment request: %w", err)
+	}
+
+	_, err = dbTx.Exec(ctx, `
+        UPDATE public.money_drops SET status = 'failed'
+        WHERE funding_transaction_id = $1 AND status = 'funding'
+    `, txID)
+	if err != nil {
+		return false, fmt.Errorf("failed to fail money drop: %w", err)
+	}
+
+	_, err = dbTx.Exec(ctx, `
+        WITH released AS (
+            DELETE FROM public.money_drop_claims WHERE payout_transaction_id = $1
+            RETURNING money_drop_id
+        )
+        UPDATE public.money_drops
+        SET claims_made_count = claims_made_count - 1,
+            status = CASE WHEN status = 'completed' THEN 'active' ELSE status END
+        WHERE id IN (SELECT money_drop_id FROM released)
+    `, txID)
+	if err != nil {
+		return false, fmt.Errorf("failed to release money drop claim: %w", err)
+	}
+
+	if err := dbTx.Commit(ctx); err != nil {
+		return false, fmt.Errorf("failed to commit settlement transaction: %w", err)
+	}
+	return true, nil
+}
+
+// settleTransaction moves a pending transaction to status. It reports false if the
+// transaction exists but is no longer pending.
+func settleTransaction(ctx context.Context, dbTx pgx.Tx, txID uuid.UUID, status string, anchorTransferID *string) (bool, error) {
+	var previous string
+	err := dbTx.QueryRow(ctx, `SELECT status FROM public.transactions WHERE id = $1 FOR UPDATE`, txID).Scan(&previous)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, fmt.Errorf("%w: with id %s", ErrTransactionNotFound, txID)
+		}
+		return false, fmt.Errorf("failed to lock transaction: %w", err)
+	}
+	if previous != domain.TransactionStatusPending {
+		return false, nil
+	}
+
+	_, err = dbTx.Exec(ctx, `
+        UPDATE public.transactions
+        SET status = $1, anchor_transfer_id = COALESCE($2, anchor_transfer_id)
+        WHERE id = $3
+    `, status, anchorTransferID, txID)
+	if err != nil {
+		return false, fmt.Errorf("failed to update transaction status: %w", err)
+	}
+	return true, nil
+}
@@ -0,0 +1,43 @@
+/**
+ * @description
+ * This file contains the query that spends the step-up tokens issued by the Auth service.
+ *
+ * @dependencies
+ * - "context", "errors", "fmt"
+ * - "github.com/google/uuid": For user identifiers.
+ * - "transfa/shared/stepup": For the binding a token must match.
+ */
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"transfa/shared/stepup"
+)
+
+// ErrStepUpTokenInvalid is returned for a step-up token that is unknown, expired, already
+// used, issued to another user or bound to a different money movement.
+var ErrStepUpTokenInvalid = errors.New("step-up token is invalid")
+
+// ConsumeStepUpToken marks the token with the given hash as used, provided it belongs to
+// the user, approves exactly binding and has neither expired nor been used. The check and
+// the update are one statement, so concurrent requests cannot spend a token twice.
+func (r *PostgresRepository) ConsumeStepUpToken(ctx context.Context, userID uuid.UUID, tokenHash string, binding stepup.Binding) error {
+	tag, err := r.db.Exec(ctx, `
+        UPDATE public.step_up_tokens
+        SET used_at = now()
+        WHERE token_hash = $1 AND user_id = $2
+          AND purpose = $3 AND amount = $4 AND recipient = $5
+          AND used_at IS NULL AND expires_at > now()
+    `, tokenHash, userID, binding.Purpose, binding.Amount, binding.Recipient)
+	if err != nil {
+		return fmt.Errorf("failed to consume step-up token: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrStepUpTokenInvalid
+	}
+	return nil
+}
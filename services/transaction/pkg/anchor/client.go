@@ -0,0 +1,356 @@
+/**
+ * @description
+ * This file provides a dedicated HTTP client for interacting with the Anchor BaaS API
+ * from the Transaction service. It encapsulates the construction of Anchor's JSON:API
+ * transfer payloads, authentication headers, and response parsing.
+ *
+ * Key features:
+ * - `InitiateBookTransfer`: Moves funds between two Anchor DepositAccounts (internal).
+ * - `InitiateNIPTransfer`: Sends funds from a DepositAccount to an external CounterParty.
+ * - `VerifyTransfer`: Fetches the current status of a transfer, to reconcile transfers
+ *   that were still in progress when they were initiated.
+ * - `FindTransferByReference`: Looks a transfer up by the reference it was made with, to
+ *   reconcile transfers whose initiating call never returned an Anchor ID.
+ * - `CreateDepositAccount`: Provisions special-purpose wallets such as the Money Drop wallet.
+ * - Transfer requests Anchor refuses outright are returned as a `*RejectedError`, so
+ *   callers can tell a transfer that was definitely not made from one whose outcome is
+ *   unknown (a timeout, a dropped connection or a 5xx response).
+ *
+ * @dependencies
+ * - "bytes", "context", "encoding/json", "errors", "fmt", "io", "net/http", "net/url", "strings", "time"
+ */
+package anchor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Client is a client for interacting with the Anchor API.
+type Client struct {
+	baseURL    string
+	apiKey     string
+	httpClient *http.Client
+}
+
+// Transfer statuses reported by Anchor that end a transfer. Any other status, such as
+// PENDING or PROCESSING, means the transfer is still in progress.
+const (
+	TransferStatusCompleted = "COMPLETED"
+	TransferStatusFailed    = "FAILED"
+)
+
+// ErrTransferNotFound is returned by FindTransferByReference when Anchor has no transfer
+// with the reference.
+var ErrTransferNotFound = errors.New("anchor has no transfer with this reference")
+
+// TransferResult holds the relevant fields from Anchor's response to a transfer request.
+type TransferResult struct {
+	ID     string
+	Status string
+}
+
+// Completed reports whether Anchor reported the transfer as completed.
+func (r *TransferResult) Completed() bool {
+	return strings.EqualFold(r.Status, TransferStatusCompleted)
+}
+
+// Failed reports whether Anchor reported the transfer as failed.
+func (r *TransferResult) Failed() bool {
+	return strings.EqualFold(r.Status, TransferStatusFailed)
+}
+
+// RejectedError is returned when Anchor refuses a transfer request with a 4xx response,
+// so the transfer was not made.
+type RejectedError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *RejectedError) Error() string {
+	return fmt.Sprintf("anchor transfer api rejected the transfer: %d - %s", e.StatusCode, e.Body)
+}
+
+// IsRejected reports whether err is a *RejectedError.
+func IsRejected(err error) bool {
+	var rejected *RejectedError
+	return errors.As(err, &rejected)
+}
+
+// relationshipData represents a single JSON:API relationship reference.
+type relationshipData struct {
+	ID   string `json:"id"`
+	Type string `json:"type"`
+}
+
+// relationship wraps a relationshipData in the `data` envelope required by JSON:API.
+type relationship struct {
+	Data relationshipData `json:"data"`
+}
+
+// transferRequest is the JSON:API payload for Anchor's `POST /api/v1/transfers` endpoint.
+type transferRequest struct {
+	Data struct {
+		Type       string `json:"type"`
+		Attributes struct {
+			Amount    int64  `json:"amount"`
+			Currency  string `json:"currency"`
+			Reason    string `json:"reason"`
+			Reference string `json:"reference"`
+		} `json:"attributes"`
+		Relationships map[string]relationship `json:"relationships"`
+	} `json:"data"`
+}
+
+// transferResponse is the subset of Anchor's transfer response that we care about.
+type transferResponse struct {
+	Data struct {
+		ID         string `json:"id"`
+		Attributes struct {
+			Status string `json:"status"`
+		} `json:"attributes"`
+	} `json:"data"`
+}
+
+// transferListResponse is the subset of Anchor's transfer list response that we care about.
+type transferListResponse struct {
+	Data []struct {
+		ID         string `json:"id"`
+		Attributes struct {
+			Status string `json:"status"`
+		} `json:"attributes"`
+	} `json:"data"`
+}
+
+// createAccountRequest is the JSON:API payload for Anchor's `POST /api/v1/accounts` endpoint.
+type createAccountRequest struct {
+	Data struct {
+		Type       string `json:"type"`
+		Attributes struct {
+			ProductName string `json:"productName"`
+		} `json:"attributes"`
+		Relationships struct {
+			Customer relationship `json:"customer"`
+		} `json:"relationships"`
+	} `json:"data"`
+}
+
+// NewClient creates a new Anchor API client.
+func NewClient(baseURL, apiKey string) *Client {
+	return &Client{
+		baseURL: baseURL,
+		apiKey:  apiKey,
+		httpClient: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+	}
+}
+
+// InitiateBookTransfer moves funds between two Anchor DepositAccounts.
+// The reference should be unique per transfer; we use our internal transaction ID.
+func (c *Client) InitiateBookTransfer(ctx context.Context, sourceAccountID, destinationAccountID string, amount int64, reason, reference string) (*TransferResult, error) {
+	payload := newTransferRequest("BookTransfer", amount, reason, reference)
+	payload.Data.Relationships = map[string]relationship{
+		"account":            {Data: relationshipData{ID: sourceAccountID, Type: "DepositAccount"}},
+		"destinationAccount": {Data: relationshipData{ID: destinationAccountID, Type: "DepositAccount"}},
+	}
+	return c.createTransfer(ctx, payload)
+}
+
+// InitiateNIPTransfer sends funds from an Anchor DepositAccount to an external bank
+// account represented by an Anchor CounterParty.
+func (c *Client) InitiateNIPTransfer(ctx context.Context, sourceAccountID, counterPartyID string, amount int64, reason, reference string) (*TransferResult, error) {
+	payload := newTransferRequest("NIPTransfer", amount, reason, reference)
+	payload.Data.Relationships = map[string]relationship{
+		"account":      {Data: relationshipData{ID: sourceAccountID, Type: "DepositAccount"}},
+		"counterParty": {Data: relationshipData{ID: counterPartyID, Type: "CounterParty"}},
+	}
+	return c.createTransfer(ctx, payload)
+}
+
+// VerifyTransfer returns the current status of the transfer with the given Anchor ID.
+func (c *Client) VerifyTransfer(ctx context.Context, transferID string) (*TransferResult, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/api/v1/transfers/verify/"+url.PathEscape(transferID), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create anchor verify transfer request: %w", err)
+	}
+	c.setHeaders(req)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call anchor verify transfer api: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("anchor verify transfer api returned non-200 status: %d - %s", resp.StatusCode, string(respBody))
+	}
+
+	var anchorResp transferResponse
+	if err := json.NewDecoder(resp.Body).Decode(&anchorResp); err != nil {
+		return nil, fmt.Errorf("failed to decode anchor verify transfer response: %w", err)
+	}
+
+	return &TransferResult{
+		ID:     transferID,
+		Status: anchorResp.Data.Attributes.Status,
+	}, nil
+}
+
+// FindTransferByReference returns the transfer made with the given reference, or
+// ErrTransferNotFound if Anchor has none. References are unique per transfer, so there
+// is at most one.
+func (c *Client) FindTransferByReference(ctx context.Context, reference string) (*TransferResult, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/api/v1/transfers?reference="+url.QueryEscape(reference), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create anchor list transfers request: %w", err)
+	}
+	c.setHeaders(req)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call anchor list transfers api: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("anchor list transfers api returned non-200 status: %d - %s", resp.StatusCode, string(respBody))
+	}
+
+	var anchorResp transferListResponse
+	if err := json.NewDecoder(resp.Body).Decode(&anchorResp); err != nil {
+		return nil, fmt.Errorf("failed to decode anchor list transfers response: %w", err)
+	}
+	if len(anchorResp.Data) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrTransferNotFound, reference)
+	}
+
+	transfer := anchorResp.Data[0]
+	return &TransferResult{
+		ID:     transfer.ID,
+		Status: transfer.Attributes.Status,
+	}, nil
+}
+
+// CreateDepositAccount creates a new DepositAccount in Anchor for a given customer.
+func (c *Client) CreateDepositAccount(ctx context.Context, anchorCustomerID, customerType, productName string) (string, error) {
+	var payload createAccountRequest
+	payload.Data.Type = "DepositAccount"
+	payload.Data.Attributes.ProductName = productName
+	payload.Data.Relationships.Customer.Data = relationshipData{ID: anchorCustomerID, Type: customerType}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal anchor create account payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/api/v1/accounts", bytes.NewBuffer(body))
+	if err != nil {
+		return "", fmt.Errorf("failed to create anchor account request: %w", err)
+	}
+	c.setHeaders(req)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to call anchor create account api: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		respBody, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("anchor create account api returned non-2xx status: %d - %s", resp.StatusCode, string(respBody))
+	}
+
+	var anchorResp struct {
+		Data struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&anchorResp); err != nil {
+		return "", fmt.Errorf("failed to decode anchor create account response: %w", err)
+	}
+
+	if anchorResp.Data.ID == "" {
+		return "", fmt.Errorf("anchor account id not found in response")
+	}
+
+	return anchorResp.Data.ID, nil
+}
+
+// newTransferRequest builds the common attributes shared by every transfer type.
+func newTransferRequest(transferType string, amount int64, reason, reference string) transferRequest {
+	var payload transferRequest
+	payload.Data.Type = transferType
+	payload.Data.Attributes.Amount = amount
+	payload.Data.Attributes.Currency = "NGN"
+	payload.Data.Attributes.Reason = reason
+	payload.Data.Attributes.Reference = reference
+	return payload
+}
+
+// createTransfer sends the transfer payload to Anchor and parses the result.
+func (c *Client) createTransfer(ctx context.Context, payload transferRequest) (*TransferResult, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal anchor transfer payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/api/v1/transfers", bytes.NewBuffer(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create anchor transfer request: %w", err)
+	}
+	c.setHeaders(req)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call anchor transfer api: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		respBody, _ := io.ReadAll(resp.Body)
+		if isRejection(resp.StatusCode) {
+			return nil, &RejectedError{StatusCode: resp.StatusCode, Body: string(respBody)}
+		}
+		return nil, fmt.Errorf("anchor transfer api returned non-2xx status: %d - %s", resp.StatusCode, string(respBody))
+	}
+
+	var anchorResp transferResponse
+	if err := json.NewDecoder(resp.Body).Decode(&anchorResp); err != nil {
+		return nil, fmt.Errorf("failed to decode anchor transfer response: %w", err)
+	}
+
+	if anchorResp.Data.ID == "" {
+		return nil, fmt.Errorf("anchor transfer id not found in response")
+	}
+
+	return &TransferResult{
+		ID:     anchorResp.Data.ID,
+		Status: anchorResp.Data.Attributes.Status,
+	}, nil
+}
+
+// isRejection reports whether a transfer response status means Anchor did not make the
+// transfer. A timeout (408) or a conflict (409, such as a reused reference) does not: the
+// transfer may exist.
+func isRejection(statusCode int) bool {
+	return statusCode >= 400 && statusCode < 500 &&
+		statusCode != http.StatusRequestTimeout && statusCode != http.StatusConflict
+}
+
+// setHeaders adds the necessary authentication and content-type headers to an HTTP request.
+func (c *Client) setHeaders(req *http.Request) {
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("x-anchor-key", c.apiKey)
+}
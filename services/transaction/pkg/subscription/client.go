@@ -0,0 +1,144 @@
+/**
+ * @description
+ * This file provides an HTTP client for the Subscription service's internal API. The
+ * Subscription service is the source of truth for a user's tier and their usage of free
+ * monthly external transfers, both of which drive P2P transfer routing.
+ *
+ * Key features:
+ * - `GetStatus`: Reads a user's subscription status and usage.
+ * - `ReserveUsage`: Atomically records one free external transfer for a user, if they
+ *   have not used up their monthly limit.
+ * - `ReleaseUsage`: Gives back a reserved transfer that did not go ahead.
+ *
+ * @dependencies
+ * - "bytes", "context", "encoding/json", "fmt", "io", "net/http", "net/url", "time"
+ * - "github.com/google/uuid": For user identifiers.
+ */
+package subscription
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Client is a client for the Subscription service's internal API.
+type Client struct {
+	baseURL    string
+	apiKey     string
+	httpClient *http.Client
+}
+
+// Status is the Subscription service's view of a user's subscription.
+type Status struct {
+	UserID                       uuid.UUID `json:"user_id"`
+	Status                       string    `json:"status"`
+	IsActive                     bool      `json:"is_active"`
+	MonthlyExternalTransfersUsed int       `json:"monthly_external_transfers_used"`
+}
+
+// Reservation is the outcome of reserving a free external transfer. Reserved is false
+// if the user had used up their limit; UsagePeriod is the month the transfer was
+// counted in.
+type Reservation struct {
+	Status
+	Reserved    bool      `json:"reserved"`
+	UsagePeriod time.Time `json:"usage_period"`
+}
+
+// NewClient creates a new Subscription service client. The apiKey is sent in the
+// X-Internal-API-Key header on every request.
+func NewClient(baseURL, apiKey string) *Client {
+	return &Client{
+		baseURL: baseURL,
+		apiKey:  apiKey,
+		httpClient: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+	}
+}
+
+// GetStatus retrieves a user's subscription status.
+func (c *Client) GetStatus(ctx context.Context, userID uuid.UUID) (*Status, error) {
+	endpoint := c.baseURL + "/subscriptions/status?user_id=" + url.QueryEscape(userID.String())
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create subscription status request: %w", err)
+	}
+
+	var status Status
+	if err := c.do(req, &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// ReserveUsage records one free external transfer received by the user if fewer than
+// limit were received this month. The check and the increment are atomic.
+func (c *Client) ReserveUsage(ctx context.Context, userID uuid.UUID, limit int) (*Reservation, error) {
+	body, err := json.Marshal(map[string]interface{}{"user_id": userID, "limit": limit})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal reserve usage payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/subscriptions/reserve-usage", bytes.NewBuffer(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create reserve usage request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	var reservation Reservation
+	if err := c.do(req, &reservation); err != nil {
+		return nil, err
+	}
+	return &reservation, nil
+}
+
+// ReleaseUsage gives back a free external transfer reserved in period whose transfer
+// did not go ahead.
+func (c *Client) ReleaseUsage(ctx context.Context, userID uuid.UUID, period time.Time) error {
+	body, err := json.Marshal(map[string]interface{}{"user_id": userID, "usage_period": period})
+	if err != nil {
+		return fmt.Errorf("failed to marshal release usage payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/subscriptions/release-usage", bytes.NewBuffer(body))
+	if err != nil {
+		return fmt.Errorf("failed to create release usage request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	var released struct {
+		Released bool `json:"released"`
+	}
+	return c.do(req, &released)
+}
+
+// do sends the request with the internal API key and decodes the response into out.
+func (c *Client) do(req *http.Request, out interface{}) error {
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("X-Internal-API-Key", c.apiKey)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call subscription service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("subscription service returned non-200 status: %d - %s", resp.StatusCode, string(respBody))
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode subscription service response: %w", err)
+	}
+	return nil
+}
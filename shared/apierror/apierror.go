@@ -0,0 +1,127 @@
+/**
+ * @description
+ * This package renders HTTP error responses in the JSON:API error format every Transfa
+ * service returns for 4xx and 5xx responses:
+ *
+ *   {"errors": [{"status": "409", "code": "username_taken", "title": "Conflict",
+ *                "detail": "username is already taken"}]}
+ *
+ * Handlers keep mapping their own application errors to status codes; this package
+ * only decides how the response looks, so clients can parse errors from any service
+ * the same way.
+ *
+ * @dependencies
+ * - "encoding/json", "log", "net/http", "strconv"
+ */
+package apierror
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+)
+
+// ContentType is the media type of JSON:API documents.
+const ContentType = "application/vnd.api+json"
+
+// Error is a JSON:API error object.
+type Error struct {
+	// Status is the HTTP status code, as a string.
+	Status string `json:"status"`
+	// Code is an optional machine-readable code clients can branch on.
+	Code string `json:"code,omitempty"`
+	// Title is the status text of Status.
+	Title string `json:"title"`
+	// Detail is a human-readable explanation of this occurrence of the error.
+	Detail string `json:"detail,omitempty"`
+	// Source points at the part of the request that caused the error, if any.
+	Source *Source `json:"source,omitempty"`
+}
+
+// Source identifies the part of the request an error refers to.
+type Source struct {
+	// Pointer is a JSON Pointer (RFC 6901) into the request body, e.g. "/username".
+	Pointer string `json:"pointer,omitempty"`
+	// Parameter names a query parameter.
+	Parameter string `json:"parameter,omitempty"`
+}
+
+// document is the top-level JSON:API error document.
+type document struct {
+	Errors []Error `json:"errors"`
+}
+
+// New returns an error object for status with the given detail.
+func New(status int, detail string) Error {
+	return Error{
+		Status: strconv.Itoa(status),
+		Title:  http.StatusText(status),
+		Detail: detail,
+	}
+}
+
+// WithCode returns a copy of e with its machine-readable code set.
+func (e Error) WithCode(code string) Error {
+	e.Code = code
+	return e
+}
+
+// WithPointer returns a copy of e pointing at a member of the request body.
+func (e Error) WithPointer(pointer string) Error {
+	e.Source = &Source{Pointer: pointer}
+	return e
+}
+
+// WithParameter returns a copy of e pointing at a query parameter.
+func (e Error) WithParameter(parameter string) Error {
+	e.Source = &Source{Parameter: parameter}
+	return e
+}
+
+// Write writes a response with a single error object for status.
+func Write(w http.ResponseWriter, status int, detail string) {
+	WriteErrors(w, New(status, detail))
+}
+
+// WriteErrors writes a response with the given error objects. The response status is
+// the status they share, or the most general one: 400 if they are all client errors,
+// 500 otherwise.
+func WriteErrors(w http.ResponseWriter, errs ...Error) {
+	status := http.StatusInternalServerError
+	if len(errs) > 0 {
+		status = responseStatus(errs)
+	}
+
+	w.Header().Set("Content-Type", ContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(document{Errors: errs}); err != nil {
+		log.Printf("Failed to write error response: %v", err)
+	}
+}
+
+// responseStatus returns the HTTP status of a response holding errs.
+func responseStatus(errs []Error) int {
+	first, err := strconv.Atoi(errs[0].Status)
+	if err != nil {
+		return http.StatusInternalServerError
+	}
+	same, clientErrors := true, true
+	for _, e := range errs {
+		status, err := strconv.Atoi(e.Status)
+		if err != nil {
+			return http.StatusInternalServerError
+		}
+		same = same && status == first
+		clientErrors = clientErrors && status >= 400 && status < 500
+	}
+	switch {
+	case same:
+		return first
+	case clientErrors:
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,65 @@
+package apierror
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWrite(t *testing.T) {
+	rec := httptest.NewRecorder()
+	Write(rec, http.StatusNotFound, "recipient not found")
+
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+	if got := rec.Header().Get("Content-Type"); got != ContentType {
+		t.Errorf("Content-Type = %q, want %q", got, ContentType)
+	}
+	want := `{"errors":[{"status":"404","title":"Not Found","detail":"recipient not found"}]}` + "\n"
+	if rec.Body.String() != want {
+		t.Errorf("body = %s, want %s", rec.Body.String(), want)
+	}
+}
+
+func TestWriteErrorsStatus(t *testing.T) {
+	tests := []struct {
+		name string
+		errs []Error
+		want int
+	}{
+		{"none", nil, http.StatusInternalServerError},
+		{"same", []Error{New(http.StatusConflict, "a"), New(http.StatusConflict, "b")}, http.StatusConflict},
+		{"client errors", []Error{New(http.StatusConflict, "a"), New(http.StatusNotFound, "b")}, http.StatusBadRequest},
+		{"server error", []Error{New(http.StatusConflict, "a"), New(http.StatusBadGateway, "b")}, http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			WriteErrors(rec, tt.errs...)
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteErrorsCodeAndSource(t *testing.T) {
+	rec := httptest.NewRecorder()
+	WriteErrors(rec, New(http.StatusConflict, "username is already taken").WithCode("username_taken").WithPointer("/username"))
+
+	var doc struct {
+		Errors []Error `json:"errors"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if len(doc.Errors) != 1 {
+		t.Fatalf("got %d errors, want 1", len(doc.Errors))
+	}
+	got := doc.Errors[0]
+	if got.Code != "username_taken" || got.Source == nil || got.Source.Pointer != "/username" {
+		t.Errorf("error = %+v, want code username_taken pointing at /username", got)
+	}
+}
@@ -0,0 +1,144 @@
+/**
+ * @description
+ * This package authenticates the users of Transfa's user-facing APIs by their Clerk
+ * session tokens. Every service that exposes such endpoints uses its middleware, so
+ * tokens are checked the same way everywhere.
+ *
+ * Key features:
+ * - `TokenVerifier`: The interface the middleware verifies tokens with. Services are
+ *   given one in their composition root instead of configuring a global key.
+ * - `JWKSVerifier`: Verifies Clerk's RS256 session tokens against the instance's JSON
+ *   Web Key Set, which it caches, so that tokens are verified without calling Clerk.
+ * - `Middleware`: Requires a valid `Authorization: Bearer <token>` header and stores the
+ *   token's claims in the request context.
+ * - `authntest`: A local issuer of signed tokens, so that routes can be tested offline.
+ *
+ * @dependencies
+ * - "context", "encoding/json", "errors", "log", "net/http", "strings"
+ * - "transfa/shared/apierror": For JSON:API error responses.
+ */
+package authn
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"transfa/shared/apierror"
+)
+
+// Errors returned for tokens that fail verification. The middleware answers all of them
+// with 401 Unauthorized.
+var (
+	ErrMalformedToken       = errors.New("malformed token")
+	ErrUnsupportedAlgorithm = errors.New("unsupported signing algorithm")
+	ErrUnknownKey           = errors.New("token signed with an unknown key")
+	ErrInvalidSignature     = errors.New("invalid token signature")
+	ErrExpired              = errors.New("token has expired")
+	ErrNotYetValid          = errors.New("token is not valid yet")
+	ErrInvalidIssuer        = errors.New("token has an unexpected issuer")
+	ErrInvalidAudience      = errors.New("token is not intended for this audience")
+)
+
+// TokenVerifier verifies a session token and returns its claims.
+type TokenVerifier interface {
+	Verify(ctx context.Context, token string) (*Claims, error)
+}
+
+// Claims are the registered claims of a Clerk session token that Transfa uses.
+type Claims struct {
+	Issuer   string   `json:"iss"`
+	Subject  string   `json:"sub"` // The Clerk User ID.
+	Audience Audience `json:"aud,omitempty"`
+	// ExpiresAt, NotBefore and IssuedAt are in Unix seconds.
+	ExpiresAt int64 `json:"exp"`
+	NotBefore int64 `json:"nbf,omitempty"`
+	IssuedAt  int64 `json:"iat,omitempty"`
+	// SessionID is the ID of the Clerk session the token belongs to.
+	SessionID string `json:"sid,omitempty"`
+	// AuthorizedParty is the origin of the frontend the token was issued to.
+	AuthorizedParty string `json:"azp,omitempty"`
+}
+
+// Audience is the `aud` claim, which JWTs may encode as a string or an array.
+type Audience []string
+
+// UnmarshalJSON accepts a single audience as well as an array of them.
+func (a *Audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = Audience{single}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(data, &many); err != nil {
+		return err
+	}
+	*a = many
+	return nil
+}
+
+// Contains reports whether audience is one of the token's audiences.
+func (a Audience) Contains(audience string) bool {
+	for _, aud := range a {
+		if aud == audience {
+			return true
+		}
+	}
+	return false
+}
+
+// claimsContextKey is a custom type to use as a key for storing claims in the request context.
+type claimsContextKey struct{}
+
+// Middleware validates the session token in the Authorization header with verifier and
+// stores its claims in the request context.
+func Middleware(verifier TokenVerifier) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Get the session token from the Authorization header
+			authHeader := r.Header.Get("Authorization")
+			if authHeader == "" {
+				apierror.Write(w, http.StatusUnauthorized, "missing Authorization header")
+				return
+			}
+
+			token := strings.TrimPrefix(authHeader, "Bearer ")
+
+			// Verify the token
+			claims, err := verifier.Verify(r.Context(), token)
+			if err != nil {
+				log.Printf("Rejected session token: %v", err)
+				apierror.Write(w, http.StatusUnauthorized, "invalid session token")
+				return
+			}
+
+			// Add the claims to the request context
+			next.ServeHTTP(w, r.WithContext(WithClaims(r.Context(), claims)))
+		})
+	}
+}
+
+// WithClaims returns a copy of ctx that carries claims. Handler tests use it to
+// authenticate requests without a token.
+func WithClaims(ctx context.Context, claims *Claims) context.Context {
+	return context.WithValue(ctx, claimsContextKey{}, claims)
+}
+
+// ClaimsFromContext returns the claims stored by Middleware.
+func ClaimsFromContext(ctx context.Context) (*Claims, bool) {
+	claims, ok := ctx.Value(claimsContextKey{}).(*Claims)
+	return claims, ok && claims != nil
+}
+
+// SubjectFromContext returns the Clerk User ID of the authenticated caller.
+func SubjectFromContext(ctx context.Context) (string, bool) {
+	claims, ok := ClaimsFromContext(ctx)
+	if !ok || claims.Subject == "" {
+		return "", false
+	}
+	return claims.Subject, true
+}
@@ -0,0 +1,213 @@
+package authn_test
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"transfa/shared/authn"
+	"transfa/shared/authn/authntest"
+)
+
+func TestVerifyClaims(t *testing.T) {
+	issuer := authntest.NewIssuer(t)
+	verifier := issuer.VerifierWith(t, authn.Config{Leeway: 5 * time.Second})
+	now := time.Now()
+
+	// valid returns valid claims of user_2abc, changed by edit.
+	valid := func(edit func(*authn.Claims)) authn.Claims {
+		claims := authn.Claims{
+			Issuer:    issuer.URL(),
+			Subject:   "user_2abc",
+			IssuedAt:  now.Unix(),
+			NotBefore: now.Unix(),
+			ExpiresAt: now.Add(time.Minute).Unix(),
+		}
+		if edit != nil {
+			edit(&claims)
+		}
+		return claims
+	}
+
+	tests := []struct {
+		name   string
+		claims authn.Claims
+		want   error
+	}{
+		{"valid", valid(nil), nil},
+		{"expired within leeway", valid(func(c *authn.Claims) { c.ExpiresAt = now.Add(-2 * time.Second).Unix() }), nil},
+		{"expired", valid(func(c *authn.Claims) { c.ExpiresAt = now.Add(-time.Minute).Unix() }), authn.ErrExpired},
+		{"without expiry", valid(func(c *authn.Claims) { c.ExpiresAt = 0 }), authn.ErrExpired},
+		{"not yet valid within leeway", valid(func(c *authn.Claims) { c.NotBefore = now.Add(2 * time.Second).Unix() }), nil},
+		{"not yet valid", valid(func(c *authn.Claims) { c.NotBefore = now.Add(time.Minute).Unix() }), authn.ErrNotYetValid},
+		{"issued in the future", valid(func(c *authn.Claims) { c.IssuedAt = now.Add(time.Minute).Unix() }), authn.ErrNotYetValid},
+		{"other issuer", valid(func(c *authn.Claims) { c.Issuer = "https://evil.example" }), authn.ErrInvalidIssuer},
+		{"without subject", valid(func(c *authn.Claims) { c.Subject = "" }), authn.ErrMalformedToken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := verifier.Verify(context.Background(), issuer.Sign(t, tt.claims))
+			if !errors.Is(err, tt.want) {
+				t.Errorf("Verify() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyAudience(t *testing.T) {
+	issuer := authntest.NewIssuer(t)
+	verifier := issuer.VerifierWith(t, authn.Config{Audience: "transfa-api"})
+	claims := authn.Claims{Issuer: issuer.URL(), Subject: "user_2abc", ExpiresAt: time.Now().Add(time.Minute).Unix()}
+
+	if _, err := verifier.Verify(context.Background(), issuer.Sign(t, claims)); !errors.Is(err, authn.ErrInvalidAudience) {
+		t.Errorf("Verify() without audience error = %v, want %v", err, authn.ErrInvalidAudience)
+	}
+	claims.Audience = authn.Audience{"other", "transfa-api"}
+	if _, err := verifier.Verify(context.Background(), issuer.Sign(t, claims)); err != nil {
+		t.Errorf("Verify() with audience error = %v", err)
+	}
+}
+
+func TestVerifyRejectsTamperedTokens(t *testing.T) {
+	issuer := authntest.NewIssuer(t)
+	verifier := issuer.Verifier(t)
+	token := issuer.Token(t, "user_2abc")
+	parts := strings.Split(token, ".")
+
+	// The same claims for another user, under the original signature.
+	forged := issuer.Token(t, "user_2xyz")
+	swapped := parts[0] + "." + strings.Split(forged, ".")[1] + "." + parts[2]
+
+	// A token that asks not to be verified.
+	none := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none"}`)) + "." + parts[1] + "."
+
+	tests := []struct {
+		name  string
+		token string
+		want  error
+	}{
+		{"swapped claims", swapped, authn.ErrInvalidSignature},
+		{"unsigned", none, authn.ErrUnsupportedAlgorithm},
+		{"not a JWT", "session", authn.ErrMalformedToken},
+		{"unknown key", issuer2Token(t), authn.ErrUnknownKey},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := verifier.Verify(context.Background(), tt.token); !errors.Is(err, tt.want) {
+				t.Errorf("Verify() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+// issuer2Token returns a token of another issuer, whose key the verifier does not know.
+func issuer2Token(t *testing.T) string {
+	return authntest.NewIssuer(t).Token(t, "user_2abc")
+}
+
+func TestVerifierPicksUpRotatedKeys(t *testing.T) {
+	issuer := authntest.NewIssuer(t)
+	verifier := issuer.VerifierWith(t, authn.Config{MinRefreshInterval: time.Nanosecond})
+	ctx := context.Background()
+
+	before := issuer.Token(t, "user_2abc")
+	if _, err := verifier.Verify(ctx, before); err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+
+	issuer.Rotate(t)
+	if _, err := verifier.Verify(ctx, issuer.Token(t, "user_2abc")); err != nil {
+		t.Fatalf("Verify() of a token signed with the new key error = %v", err)
+	}
+	if _, err := verifier.Verify(ctx, before); err != nil {
+		t.Errorf("Verify() of a token signed with the old key error = %v", err)
+	}
+	if got := issuer.Fetches(); got != 2 {
+		t.Errorf("key set fetched %d times, want 2", got)
+	}
+}
+
+func TestVerifierCachesKeys(t *testing.T) {
+	issuer := authntest.NewIssuer(t)
+	verifier := issuer.Verifier(t)
+	ctx := context.Background()
+
+	token := issuer.Token(t, "user_2abc")
+	for i := 0; i < 3; i++ {
+		if _, err := verifier.Verify(ctx, token); err != nil {
+			t.Fatalf("Verify() error = %v", err)
+		}
+	}
+	// Unknown keys only trigger a refetch once per MinRefreshInterval.
+	for i := 0; i < 3; i++ {
+		verifier.Verify(ctx, issuer2Token(t))
+	}
+	if got := issuer.Fetches(); got != 1 {
+		t.Errorf("key set fetched %d times, want 1", got)
+	}
+
+	// Tokens keep verifying when the key set cannot be fetched.
+	issuer.Stop()
+	if _, err := verifier.Verify(ctx, token); err != nil {
+		t.Errorf("Verify() during an outage error = %v", err)
+	}
+}
+
+func TestVerifierUsesStaleKeysDuringOutage(t *testing.T) {
+	issuer := authntest.NewIssuer(t)
+	verifier := issuer.VerifierWith(t, authn.Config{CacheTTL: time.Nanosecond, MinRefreshInterval: time.Nanosecond})
+	ctx := context.Background()
+
+	token := issuer.Token(t, "user_2abc")
+	if err := verifier.Refresh(ctx); err != nil {
+		t.Fatalf("Refresh() error = %v", err)
+	}
+	issuer.Stop()
+	if _, err := verifier.Verify(ctx, token); err != nil {
+		t.Errorf("Verify() with a stale key set error = %v", err)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	issuer := authntest.NewIssuer(t)
+	handler := authn.Middleware(issuer.Verifier(t))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		subject, ok := authn.SubjectFromContext(r.Context())
+		if !ok {
+			t.Error("no subject in the request context")
+		}
+		w.Write([]byte(subject))
+	}))
+
+	tests := []struct {
+		name          string
+		authorization string
+		wantStatus    int
+		wantBody      string
+	}{
+		{"valid token", "Bearer " + issuer.Token(t, "user_2abc"), http.StatusOK, "user_2abc"},
+		{"missing header", "", http.StatusUnauthorized, "missing Authorization header"},
+		{"invalid token", "Bearer session", http.StatusUnauthorized, "invalid session token"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if !strings.Contains(rec.Body.String(), tt.wantBody) {
+				t.Errorf("body = %s, want it to contain %q", rec.Body.String(), tt.wantBody)
+			}
+		})
+	}
+}
@@ -0,0 +1,183 @@
+/**
+ * @description
+ * This package is a local stand-in for a Clerk instance in tests. It signs session
+ * tokens with its own RSA keys and serves their key set over a local HTTP server, so a
+ * service's routes can be tested with real tokens and the real `authn.JWKSVerifier`
+ * without reaching Clerk.
+ *
+ *   issuer := authntest.NewIssuer(t)
+ *   router := api.NewRouter(handler, issuer.Verifier(t))
+ *   req.Header.Set("Authorization", "Bearer "+issuer.Token(t, "user_2abc"))
+ *
+ * @dependencies
+ * - "crypto", "crypto/rand", "crypto/rsa", "crypto/sha256", "encoding/base64",
+ *   "encoding/json", "fmt", "math/big", "net/http", "net/http/httptest", "sync",
+ *   "testing", "time"
+ * - "transfa/shared/authn": For the claims and the verifier.
+ */
+package authntest
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"transfa/shared/authn"
+)
+
+// TokenLifetime is how long the tokens returned by Token are valid, as for Clerk's
+// session tokens.
+const TokenLifetime = time.Minute
+
+// signingKey is one of the issuer's keys.
+type signingKey struct {
+	id  string
+	key *rsa.PrivateKey
+}
+
+// Issuer issues session tokens like a Clerk instance.
+type Issuer struct {
+	server *httptest.Server
+
+	mu      sync.Mutex
+	keys    []signingKey // The last one signs new tokens.
+	fetches int
+}
+
+// NewIssuer starts an issuer with one signing key. It is stopped when the test ends.
+func NewIssuer(t testing.TB) *Issuer {
+	t.Helper()
+
+	i := &Issuer{}
+	i.Rotate(t)
+	i.server = httptest.NewServer(http.HandlerFunc(i.serveJWKS))
+	t.Cleanup(i.server.Close)
+	return i
+}
+
+// URL is the issuer's URL, which its tokens carry as their `iss`.
+func (i *Issuer) URL() string {
+	return i.server.URL
+}
+
+// Verifier returns a verifier of the issuer's tokens with the default configuration.
+func (i *Issuer) Verifier(t testing.TB) *authn.JWKSVerifier {
+	t.Helper()
+	return i.VerifierWith(t, authn.Config{})
+}
+
+// VerifierWith returns a verifier of the issuer's tokens configured with cfg. The
+// issuer's URL is used if cfg has no issuer.
+func (i *Issuer) VerifierWith(t testing.TB, cfg authn.Config) *authn.JWKSVerifier {
+	t.Helper()
+	if cfg.Issuer == "" {
+		cfg.Issuer = i.URL()
+	}
+	verifier, err := authn.NewJWKSVerifier(cfg)
+	if err != nil {
+		t.Fatalf("NewJWKSVerifier() error = %v", err)
+	}
+	return verifier
+}
+
+// Token returns a valid session token of the Clerk user subject.
+func (i *Issuer) Token(t testing.TB, subject string) string {
+	t.Helper()
+	now := time.Now()
+	return i.Sign(t, authn.Claims{
+		Issuer:    i.URL(),
+		Subject:   subject,
+		IssuedAt:  now.Unix(),
+		NotBefore: now.Unix(),
+		ExpiresAt: now.Add(TokenLifetime).Unix(),
+		SessionID: "sess_" + subject,
+	})
+}
+
+// Sign returns a token carrying claims as they are, signed with the current key.
+func (i *Issuer) Sign(t testing.TB, claims authn.Claims) string {
+	t.Helper()
+
+	i.mu.Lock()
+	current := i.keys[len(i.keys)-1]
+	i.mu.Unlock()
+
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT", "kid": current.id})
+	if err != nil {
+		t.Fatalf("failed to encode token header: %v", err)
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatalf("failed to encode token claims: %v", err)
+	}
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signed))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, current.key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+// Rotate adds a new signing key. Tokens signed before keep verifying, because the old
+// keys stay in the key set, as they do in Clerk during a rotation.
+func (i *Issuer) Rotate(t testing.TB) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate signing key: %v", err)
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	// Key IDs are unique across issuers, so one issuer's tokens never match another's key.
+	i.keys = append(i.keys, signingKey{id: fmt.Sprintf("ins_test_%x", key.N.Bytes()[:8]), key: key})
+}
+
+// Fetches returns how many times the key set was fetched.
+func (i *Issuer) Fetches() int {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.fetches
+}
+
+// Stop stops serving the key set, as in a Clerk outage.
+func (i *Issuer) Stop() {
+	i.server.Close()
+}
+
+// serveJWKS serves the public keys of the issuer as a JSON Web Key Set.
+func (i *Issuer) serveJWKS(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/.well-known/jwks.json" {
+		http.NotFound(w, r)
+		return
+	}
+
+	i.mu.Lock()
+	i.fetches++
+	keys := make([]map[string]string, 0, len(i.keys))
+	for _, k := range i.keys {
+		keys = append(keys, map[string]string{
+			"kty": "RSA",
+			"use": "sig",
+			"alg": "RS256",
+			"kid": k.id,
+			"n":   base64.RawURLEncoding.EncodeToString(k.key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(k.key.E)).Bytes()),
+		})
+	}
+	i.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"keys": keys})
+}
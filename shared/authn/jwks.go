@@ -0,0 +1,305 @@
+/**
+ * @description
+ * This file implements `JWKSVerifier`, which verifies Clerk session tokens offline
+ * against a cached copy of the Clerk instance's JSON Web Key Set.
+ *
+ * Key features:
+ * - Only RS256 tokens are accepted, whatever their header claims, so a token cannot
+ *   choose a weaker algorithm.
+ * - The key set is fetched on first use and refetched once it is older than
+ *   `CacheTTL`. A token signed with a key that is not cached triggers a refetch, so
+ *   rotated keys are picked up without a restart; refetches are at most one per
+ *   `MinRefreshInterval`, so tokens with made-up key IDs cannot flood Clerk.
+ * - If a refetch fails, the cached keys keep being used, so a Clerk outage does not
+ *   lock out users whose tokens were signed with a known key.
+ * - `exp`, `nbf` and `iat` are checked with `Leeway` for clock skew, `iss` must equal
+ *   `Issuer`, and `aud` must contain `Audience` when one is configured.
+ *
+ * @dependencies
+ * - "context", "crypto", "crypto/rsa", "crypto/sha256", "encoding/base64",
+ *   "encoding/json", "errors", "fmt", "io", "log", "math/big", "net/http", "strings",
+ *   "sync", "time"
+ */
+package authn
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Defaults of the zero fields of Config.
+const (
+	DefaultCacheTTL           = time.Hour
+	DefaultMinRefreshInterval = time.Minute
+)
+
+// jwksPath is where a Clerk instance serves its key set, relative to its issuer URL.
+const jwksPath = "/.well-known/jwks.json"
+
+// Config configures a JWKSVerifier.
+type Config struct {
+	// Issuer is the Clerk instance's Frontend API URL, e.g.
+	// https://example.clerk.accounts.dev. Tokens must carry it as their `iss`.
+	Issuer string
+	// JWKSURL is where the key set is fetched from. It defaults to the issuer's
+	// /.well-known/jwks.json.
+	JWKSURL string
+	// Audience, if set, must be one of the token's audiences.
+	Audience string
+	// Leeway is the clock skew allowed when checking the token's times.
+	Leeway time.Duration
+	// CacheTTL is how long the key set is used before it is refetched.
+	CacheTTL time.Duration
+	// MinRefreshInterval is the least time between two fetches of the key set.
+	MinRefreshInterval time.Duration
+	// HTTPClient fetches the key set. It defaults to a client with a 10 second timeout.
+	HTTPClient *http.Client
+}
+
+// JWKSVerifier verifies RS256 session tokens against a cached JSON Web Key Set.
+type JWKSVerifier struct {
+	cfg Config
+
+	// refreshMu ensures a single fetch of the key set at a time.
+	refreshMu sync.Mutex
+
+	mu          sync.RWMutex
+	keys        map[string]*rsa.PublicKey
+	fetchedAt   time.Time
+	attemptedAt time.Time
+}
+
+var _ TokenVerifier = (*JWKSVerifier)(nil)
+
+// NewJWKSVerifier creates a verifier for the tokens of cfg.Issuer. It does not fetch the
+// key set until the first token is verified; call Refresh to fetch it up front.
+func NewJWKSVerifier(cfg Config) (*JWKSVerifier, error) {
+	if cfg.Issuer == "" {
+		return nil, errors.New("token issuer is not configured")
+	}
+	if cfg.JWKSURL == "" {
+		cfg.JWKSURL = strings.TrimSuffix(cfg.Issuer, "/") + jwksPath
+	}
+	if cfg.CacheTTL <= 0 {
+		cfg.CacheTTL = DefaultCacheTTL
+	}
+	if cfg.MinRefreshInterval <= 0 {
+		cfg.MinRefreshInterval = DefaultMinRefreshInterval
+	}
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &JWKSVerifier{cfg: cfg, keys: map[string]*rsa.PublicKey{}}, nil
+}
+
+// Verify checks the token's signature and claims and returns its claims.
+func (v *JWKSVerifier) Verify(ctx context.Context, token string) (*Claims, error) {
+	// Step 1: Split and decode the token.
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrMalformedToken
+	}
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, err
+	}
+	var claims Claims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, err
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrMalformedToken
+	}
+
+	// Step 2: Check the signature with the key the token names.
+	if header.Alg != "RS256" {
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedAlgorithm, header.Alg)
+	}
+	key, err := v.key(ctx, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature); err != nil {
+		return nil, ErrInvalidSignature
+	}
+
+	// Step 3: Check the claims.
+	if err := v.validate(&claims, time.Now()); err != nil {
+		return nil, err
+	}
+	return &claims, nil
+}
+
+// validate checks the token's times, issuer and audience.
+func (v *JWKSVerifier) validate(claims *Claims, now time.Time) error {
+	leeway := v.cfg.Leeway
+	if claims.ExpiresAt == 0 || now.Add(-leeway).After(time.Unix(claims.ExpiresAt, 0)) {
+		return ErrExpired
+	}
+	if claims.NotBefore != 0 && now.Add(leeway).Before(time.Unix(claims.NotBefore, 0)) {
+		return ErrNotYetValid
+	}
+	if claims.IssuedAt != 0 && now.Add(leeway).Before(time.Unix(claims.IssuedAt, 0)) {
+		return ErrNotYetValid
+	}
+	if claims.Issuer != v.cfg.Issuer {
+		return fmt.Errorf("%w: %q", ErrInvalidIssuer, claims.Issuer)
+	}
+	if v.cfg.Audience != "" && !claims.Audience.Contains(v.cfg.Audience) {
+		return ErrInvalidAudience
+	}
+	if claims.Subject == "" {
+		return fmt.Errorf("%w: missing subject", ErrMalformedToken)
+	}
+	return nil
+}
+
+// key returns the public key with the given ID, refetching the key set if it is stale
+// or lacks the key.
+func (v *JWKSVerifier) key(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	if key, fresh := v.cached(kid); key != nil && fresh {
+		return key, nil
+	}
+
+	v.refreshMu.Lock()
+	defer v.refreshMu.Unlock()
+
+	// Another request may have refetched the key set while this one waited.
+	key, fresh := v.cached(kid)
+	if key != nil && fresh {
+		return key, nil
+	}
+
+	v.mu.RLock()
+	attemptedAt := v.attemptedAt
+	v.mu.RUnlock()
+	if time.Since(attemptedAt) >= v.cfg.MinRefreshInterval {
+		if err := v.refresh(ctx); err != nil {
+			// Keep using the cached keys until the key set can be fetched again.
+			log.Printf("WARNING: failed to refresh JWKS from %s: %v", v.cfg.JWKSURL, err)
+		}
+		key, _ = v.cached(kid)
+	}
+
+	if key == nil {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownKey, kid)
+	}
+	return key, nil
+}
+
+// cached returns the cached key with the given ID, if any, and whether the key set is
+// younger than CacheTTL.
+func (v *JWKSVerifier) cached(kid string) (*rsa.PublicKey, bool) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return v.keys[kid], time.Since(v.fetchedAt) < v.cfg.CacheTTL
+}
+
+// Refresh fetches the key set and replaces the cached keys with it.
+func (v *JWKSVerifier) Refresh(ctx context.Context) error {
+	v.refreshMu.Lock()
+	defer v.refreshMu.Unlock()
+	return v.refresh(ctx)
+}
+
+// refresh fetches the key set. The caller must hold refreshMu.
+func (v *JWKSVerifier) refresh(ctx context.Context) error {
+	v.mu.Lock()
+	v.attemptedAt = time.Now()
+	v.mu.Unlock()
+
+	keys, err := v.fetch(ctx)
+	if err != nil {
+		return err
+	}
+
+	v.mu.Lock()
+	v.keys = keys
+	v.fetchedAt = time.Now()
+	v.mu.Unlock()
+	log.Printf("Fetched %d signing keys from %s", len(keys), v.cfg.JWKSURL)
+	return nil
+}
+
+// jsonWebKey is an entry of a JSON Web Key Set, reduced to the fields of RSA keys.
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+// fetch downloads the key set and returns its RSA signing keys by ID.
+func (v *JWKSVerifier) fetch(ctx context.Context) (map[string]*rsa.PublicKey, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, v.cfg.JWKSURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := v.cfg.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&set); err != nil {
+		return nil, fmt.Errorf("failed to decode key set: %w", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Kty != "RSA" || (jwk.Use != "" && jwk.Use != "sig") {
+			continue
+		}
+		n, errN := base64.RawURLEncoding.DecodeString(jwk.N)
+		e, errE := base64.RawURLEncoding.DecodeString(jwk.E)
+		if errN != nil || errE != nil || len(e) == 0 || len(e) > 4 {
+			log.Printf("WARNING: skipping malformed key %q in %s", jwk.Kid, v.cfg.JWKSURL)
+			continue
+		}
+		keys[jwk.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("key set holds no RSA signing keys")
+	}
+	return keys, nil
+}
+
+// decodeSegment decodes a base64url JSON segment of a token into v.
+func decodeSegment(segment string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return ErrMalformedToken
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("%w: %v", ErrMalformedToken, err)
+	}
+	return nil
+}
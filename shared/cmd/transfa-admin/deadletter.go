@@ -0,0 +1,172 @@
+/**
+ * @description
+ * This file reads dead-letter queues for the events command. RabbitMQ has no way to
+ * browse a queue, so messages are fetched with basic.get and left unacknowledged: when
+ * the channel closes, every message that was not acked is returned to the queue. A
+ * fetched message is only removed from its queue by acking it, after it was replayed
+ * or chosen for purging.
+ *
+ * @dependencies
+ * - "fmt", "time"
+ * - "github.com/rabbitmq/amqp091-go": The official Go client for RabbitMQ.
+ * - "transfa/shared/events": For decoding event envelopes.
+ * - "transfa/shared/messaging": For queue names and retry headers.
+ */
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/rabbitmq/amqp091-go"
+	"transfa/shared/events"
+	"transfa/shared/messaging"
+)
+
+// deadLetterReader fetches messages from dead-letter queues.
+type deadLetterReader struct {
+	conn *amqp091.Connection
+	ch   *amqp091.Channel
+}
+
+// newDeadLetterReader connects to RabbitMQ at url.
+func newDeadLetterReader(url string) (*deadLetterReader, error) {
+	conn, err := amqp091.Dial(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to RabbitMQ: %w", err)
+	}
+	ch, err := conn.Channel()
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to open a channel: %w", err)
+	}
+	return &deadLetterReader{conn: conn, ch: ch}, nil
+}
+
+// count returns the number of messages in the dead-letter queue of queue.
+func (r *deadLetterReader) count(queue string) (int, error) {
+	// A passive declaration of a missing queue closes the channel, so use a separate one.
+	ch, err := r.conn.Channel()
+	if err != nil {
+		return 0, fmt.Errorf("failed to open a channel: %w", err)
+	}
+	defer ch.Close()
+
+	q, err := ch.QueueDeclarePassive(messaging.DeadLetterQueueName(queue), true, false, false, false, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to inspect queue %s: %w", messaging.DeadLetterQueueName(queue), err)
+	}
+	return q.Messages, nil
+}
+
+// fetch returns up to limit messages from the dead-letter queue of queue, oldest first.
+// The messages stay in the queue unless they are acked.
+func (r *deadLetterReader) fetch(queue string, limit int) ([]deadLetter, error) {
+	var letters []deadLetter
+	for len(letters) < limit {
+		msg, ok, err := r.ch.Get(messaging.DeadLetterQueueName(queue), false)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch from queue %s: %w", messaging.DeadLetterQueueName(queue), err)
+		}
+		if !ok {
+			break
+		}
+		letters = append(letters, newDeadLetter(msg))
+	}
+	return letters, nil
+}
+
+// purge removes every message from the dead-letter queue of queue and returns how many
+// were removed.
+func (r *deadLetterReader) purge(queue string) (int, error) {
+	n, err := r.ch.QueuePurge(messaging.DeadLetterQueueName(queue), false)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge queue %s: %w", messaging.DeadLetterQueueName(queue), err)
+	}
+	return n, nil
+}
+
+// close closes the connection, returning every unacked message to its queue.
+func (r *deadLetterReader) close() {
+	r.ch.Close()
+	r.conn.Close()
+}
+
+// deadLetter is a fetched dead-lettered message.
+type deadLetter struct {
+	msg amqp091.Delivery
+	// envelope is nil if the body is not an event envelope.
+	envelope *events.Envelope
+	// exchange and routingKey are the route the message was first published with.
+	exchange   string
+	routingKey string
+	hasOrigin  bool
+	// attempts is the number of times the consumer handled the message.
+	attempts int
+	// deadLetteredAt is when the broker dead-lettered the message, if it recorded it.
+	deadLetteredAt time.Time
+}
+
+// newDeadLetter decodes what is known about a dead-lettered message.
+func newDeadLetter(msg amqp091.Delivery) deadLetter {
+	letter := deadLetter{
+		msg:      msg,
+		attempts: messaging.RetryCount(msg.Headers) + 1,
+	}
+	letter.envelope, _ = events.Parse(msg.Body)
+	letter.exchange, letter.routingKey, letter.hasOrigin = messaging.DeadLetterOrigin(msg)
+
+	deaths, _ := msg.Headers["x-death"].([]interface{})
+	for _, d := range deaths {
+		if death, ok := d.(amqp091.Table); ok && death["reason"] == "rejected" {
+			letter.deadLetteredAt, _ = death["time"].(time.Time)
+			break
+		}
+	}
+	return letter
+}
+
+// eventType returns the event type, or "-" if the message is not an envelope.
+func (l deadLetter) eventType() string {
+	if l.envelope != nil {
+		return l.envelope.Type
+	}
+	if l.msg.Type != "" {
+		return l.msg.Type
+	}
+	return "-"
+}
+
+// origin describes the route the message was first published with.
+func (l deadLetter) origin() string {
+	if !l.hasOrigin {
+		return "unknown"
+	}
+	return fmt.Sprintf("%s/%s", l.exchange, l.routingKey)
+}
+
+// replayMessage returns the message that publishes l again. It keeps the message ID, so
+// consumers that already handled the event skip it, and drops the retry and
+// dead-lettering headers, so the consumer retries it from the first attempt.
+func (l deadLetter) replayMessage() messaging.Message {
+	headers := amqp091.Table{}
+	for k, v := range l.msg.Headers {
+		switch k {
+		case messaging.RetryCountHeader, messaging.OriginalExchangeHeader, messaging.OriginalRoutingKeyHeader,
+			"x-death", "x-first-death-exchange", "x-first-death-queue", "x-first-death-reason",
+			"x-last-death-exchange", "x-last-death-queue", "x-last-death-reason":
+			continue
+		}
+		headers[k] = v
+	}
+
+	return messaging.Message{
+		ID:            l.msg.MessageId,
+		Type:          l.msg.Type,
+		CorrelationID: l.msg.CorrelationId,
+		AppID:         l.msg.AppId,
+		Timestamp:     l.msg.Timestamp,
+		Headers:       headers,
+		Body:          l.msg.Body,
+	}
+}
@@ -0,0 +1,379 @@
+/**
+ * @description
+ * This file implements `transfa-admin events`, which lets operators recover events that
+ * consumers gave up on. Each consumer queue has a dead-letter queue (`<queue>.dlq`)
+ * holding the messages its handler failed on permanently or too many times.
+ *
+ * Subcommands:
+ * - `list`: Counts the dead-lettered messages of each queue and summarises them.
+ * - `show`: Prints dead-lettered envelopes with personal data masked.
+ * - `replay`: Publishes selected messages again to the exchange and routing key they
+ *   were first published with, and removes them from the dead-letter queue.
+ * - `purge`: Deletes selected messages, or all of them, after confirmation.
+ *
+ * Messages keep their message ID when replayed, so queues whose consumer already handled
+ * them skip the copy through their inbox.
+ *
+ * @dependencies
+ * - "bufio", "bytes", "context", "encoding/json", "errors", "flag", "fmt", "os",
+ *   "strings", "text/tabwriter", "time"
+ * - "transfa/shared/events": For masking personal data.
+ * - "transfa/shared/messaging": For publishing replayed messages.
+ */
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"transfa/shared/events"
+	"transfa/shared/messaging"
+)
+
+// defaultQueues are the consumer queues of every service, under their default names.
+var defaultQueues = []string{
+	"customer_service_user_created",
+	"account_service_customer_verified",
+	"account_service_user_deleted",
+	"analytics_service_transaction_completed",
+	"transaction_service_username_changed",
+}
+
+const eventsUsage = `Usage: transfa-admin events <list|show|replay|purge> [flags]
+
+  list     Count and summarise dead-lettered messages per queue
+  show     Print dead-lettered envelopes with personal data masked
+  replay   Publish selected messages to their original exchange and routing key
+  purge    Delete selected dead-lettered messages, or all of them
+
+Queues are named by the consumer queue, e.g. customer_service_user_created; its
+dead-letter queue is customer_service_user_created.dlq.
+`
+
+// eventsFlags are the flags shared by the events subcommands.
+type eventsFlags struct {
+	url    string
+	queues string
+	ids    string
+	all    bool
+	limit  int
+	yes    bool
+}
+
+// parseEventsFlags parses the flags of subcommand name.
+func parseEventsFlags(name string, args []string) (*eventsFlags, error) {
+	f := &eventsFlags{}
+	fs := flag.NewFlagSet("events "+name, flag.ContinueOnError)
+	fs.StringVar(&f.url, "url", os.Getenv("RABBITMQ_URL"), "RabbitMQ URL (default $RABBITMQ_URL)")
+	fs.StringVar(&f.queues, "queue", "", "comma-separated consumer queues (list defaults to every service's queue)")
+	fs.IntVar(&f.limit, "limit", 100, "maximum number of messages to fetch per queue")
+	if name == "show" || name == "replay" || name == "purge" {
+		fs.StringVar(&f.ids, "id", "", "comma-separated message IDs to select")
+	}
+	if name == "replay" || name == "purge" {
+		fs.BoolVar(&f.all, "all", false, "select every fetched message (purge: the whole queue)")
+	}
+	if name == "purge" {
+		fs.BoolVar(&f.yes, "yes", false, "do not ask for confirmation")
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if f.url == "" {
+		return nil, errors.New("RabbitMQ URL is required: set -url or RABBITMQ_URL")
+	}
+	if f.limit < 1 {
+		return nil, errors.New("-limit must be at least 1")
+	}
+	if (name == "replay" || name == "purge") && f.ids == "" && !f.all {
+		return nil, errors.New("select messages with -id or -all")
+	}
+	if f.ids != "" && f.all {
+		return nil, errors.New("-id and -all cannot be used together")
+	}
+	return f, nil
+}
+
+// queueList returns the selected queues, or defaultQueues if none were given.
+func (f *eventsFlags) queueList() []string {
+	if f.queues == "" {
+		return defaultQueues
+	}
+	return splitList(f.queues)
+}
+
+// singleQueue returns the one queue a subcommand operates on.
+func (f *eventsFlags) singleQueue() (string, error) {
+	queues := splitList(f.queues)
+	if len(queues) != 1 {
+		return "", errors.New("name exactly one queue with -queue")
+	}
+	return queues[0], nil
+}
+
+// selectLetters returns the letters chosen with -id or -all, reporting IDs not found
+// among them.
+func (f *eventsFlags) selectLetters(letters []deadLetter) []deadLetter {
+	if f.ids == "" {
+		return letters
+	}
+
+	wanted := make(map[string]bool)
+	for _, id := range splitList(f.ids) {
+		wanted[id] = true
+	}
+	var selected []deadLetter
+	for _, letter := range letters {
+		if wanted[letter.msg.MessageId] {
+			selected = append(selected, letter)
+			delete(wanted, letter.msg.MessageId)
+		}
+	}
+	for id := range wanted {
+		fmt.Fprintf(os.Stderr, "Message %s was not among the fetched messages (try a larger -limit).\n", id)
+	}
+	return selected
+}
+
+// runEvents runs an events subcommand.
+func runEvents(args []string) error {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, eventsUsage)
+		return errors.New("missing events subcommand")
+	}
+
+	name := args[0]
+	switch name {
+	case "list", "show", "replay", "purge":
+	case "-h", "-help", "--help", "help":
+		fmt.Print(eventsUsage)
+		return nil
+	default:
+		fmt.Fprint(os.Stderr, eventsUsage)
+		return fmt.Errorf("unknown events subcommand %q", name)
+	}
+
+	f, err := parseEventsFlags(name, args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	reader, err := newDeadLetterReader(f.url)
+	if err != nil {
+		return err
+	}
+	defer reader.close()
+
+	switch name {
+	case "list":
+		return listEvents(reader, f)
+	case "show":
+		return showEvents(reader, f)
+	case "replay":
+		return replayEvents(reader, f)
+	default:
+		return purgeEvents(reader, f)
+	}
+}
+
+// listEvents prints the dead-lettered messages of each queue.
+func listEvents(reader *deadLetterReader, f *eventsFlags) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	defer w.Flush()
+
+	for _, queue := range f.queueList() {
+		count, err := reader.count(queue)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "%s: %d dead-lettered message(s)\n", messaging.DeadLetterQueueName(queue), count)
+		if count == 0 {
+			continue
+		}
+
+		letters, err := reader.fetch(queue, f.limit)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(w, "  MESSAGE ID\tTYPE\tPRODUCER\tATTEMPTS\tDEAD-LETTERED AT\tORIGIN")
+		for _, letter := range letters {
+			deadLetteredAt := "-"
+			if !letter.deadLetteredAt.IsZero() {
+				deadLetteredAt = letter.deadLetteredAt.UTC().Format(time.RFC3339)
+			}
+			producer := letter.msg.AppId
+			if producer == "" {
+				producer = "-"
+			}
+			fmt.Fprintf(w, "  %s\t%s\t%s\t%d\t%s\t%s\n",
+				letter.msg.MessageId, letter.eventType(), producer, letter.attempts, deadLetteredAt, letter.origin())
+		}
+		if count > len(letters) {
+			fmt.Fprintf(w, "  ... %d more not shown\n", count-len(letters))
+		}
+	}
+	return nil
+}
+
+// showEvents prints the selected dead-lettered messages of one queue with personal
+// data masked.
+func showEvents(reader *deadLetterReader, f *eventsFlags) error {
+	queue, err := f.singleQueue()
+	if err != nil {
+		return err
+	}
+	letters, err := reader.fetch(queue, f.limit)
+	if err != nil {
+		return err
+	}
+
+	for _, letter := range f.selectLetters(letters) {
+		fmt.Printf("Message %s\n", letter.msg.MessageId)
+		fmt.Printf("  Type:      %s\n", letter.eventType())
+		fmt.Printf("  Origin:    %s\n", letter.origin())
+		fmt.Printf("  Attempts:  %d\n", letter.attempts)
+		if !letter.deadLetteredAt.IsZero() {
+			fmt.Printf("  Dead-lettered at: %s\n", letter.deadLetteredAt.UTC().Format(time.RFC3339))
+		}
+
+		body, err := events.Redact(letter.msg.Body)
+		if err != nil {
+			// A body that is not JSON cannot be masked, so it is not shown.
+			fmt.Printf("  Body:      %d bytes, not JSON\n\n", len(letter.msg.Body))
+			continue
+		}
+		var indented bytes.Buffer
+		json.Indent(&indented, body, "  ", "  ")
+		fmt.Printf("  Body:\n  %s\n\n", indented.String())
+	}
+	return nil
+}
+
+// replayEvents publishes the selected dead-lettered messages of one queue to their
+// original exchange and routing key, and removes each from the dead-letter queue once
+// the broker has confirmed it.
+func replayEvents(reader *deadLetterReader, f *eventsFlags) error {
+	queue, err := f.singleQueue()
+	if err != nil {
+		return err
+	}
+	letters, err := reader.fetch(queue, f.limit)
+	if err != nil {
+		return err
+	}
+	selected := f.selectLetters(letters)
+	if len(selected) == 0 {
+		return errors.New("no messages selected")
+	}
+
+	publisher, err := messaging.NewPublisher(f.url)
+	if err != nil {
+		return err
+	}
+	defer publisher.Close()
+
+	replayed := 0
+	for _, letter := range selected {
+		if !letter.hasOrigin {
+			fmt.Fprintf(os.Stderr, "Skipping message %s: its original route is unknown.\n", letter.msg.MessageId)
+			continue
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		err := publisher.PublishMessage(ctx, letter.exchange, letter.routingKey, letter.replayMessage())
+		cancel()
+		if err != nil {
+			return fmt.Errorf("failed to replay message %s after replaying %d: %w", letter.msg.MessageId, replayed, err)
+		}
+		if err := letter.msg.Ack(false); err != nil {
+			// The message was replayed but is still in the dead-letter queue.
+			return fmt.Errorf("replayed message %s but failed to remove it from the dead-letter queue: %w", letter.msg.MessageId, err)
+		}
+		fmt.Printf("Replayed message %s to %s\n", letter.msg.MessageId, letter.origin())
+		replayed++
+	}
+
+	fmt.Printf("Replayed %d of %d selected message(s).\n", replayed, len(selected))
+	return nil
+}
+
+// purgeEvents deletes the selected dead-lettered messages of one queue, or all of them
+// with -all, after the operator confirms by typing the dead-letter queue's name.
+func purgeEvents(reader *deadLetterReader, f *eventsFlags) error {
+	queue, err := f.singleQueue()
+	if err != nil {
+		return err
+	}
+	dlq := messaging.DeadLetterQueueName(queue)
+
+	// Step 1: Purge the whole queue.
+	if f.all {
+		count, err := reader.count(queue)
+		if err != nil {
+			return err
+		}
+		if !f.yes && !confirm(fmt.Sprintf("Delete all %d message(s) from %s?", count, dlq), dlq) {
+			return errors.New("purge cancelled")
+		}
+		purged, err := reader.purge(queue)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Purged %d message(s) from %s.\n", purged, dlq)
+		return nil
+	}
+
+	// Step 2: Delete only the selected messages.
+	letters, err := reader.fetch(queue, f.limit)
+	if err != nil {
+		return err
+	}
+	selected := f.selectLetters(letters)
+	if len(selected) == 0 {
+		return errors.New("no messages selected")
+	}
+	if !f.yes && !confirm(fmt.Sprintf("Delete %d message(s) from %s?", len(selected), dlq), dlq) {
+		return errors.New("purge cancelled")
+	}
+	for _, letter := range selected {
+		if err := letter.msg.Ack(false); err != nil {
+			return fmt.Errorf("failed to delete message %s: %w", letter.msg.MessageId, err)
+		}
+	}
+	fmt.Printf("Deleted %d message(s) from %s.\n", len(selected), dlq)
+	return nil
+}
+
+// confirm asks the operator to type answer to go ahead.
+func confirm(question, answer string) bool {
+	fmt.Printf("%s Type %s to confirm: ", question, answer)
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return false
+	}
+	return strings.TrimSpace(line) == answer
+}
+
+// splitList splits a comma-separated flag value, ignoring empty items.
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,50 @@
+/**
+ * @description
+ * transfa-admin is the operations command line tool for Transfa's backend. It works
+ * directly against shared infrastructure, so it is run by an operator with access to
+ * it rather than deployed as a service.
+ *
+ * Commands:
+ * - `events`: Inspect, replay and purge dead-lettered events (see events.go).
+ *
+ * @dependencies
+ * - "fmt", "os"
+ */
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+const usage = `Usage: transfa-admin <command> [arguments]
+
+Commands:
+  events    Inspect, replay and purge dead-lettered events
+
+Run 'transfa-admin <command> -h' for details.
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "events":
+		err = runEvents(os.Args[2:])
+	case "-h", "-help", "--help", "help":
+		fmt.Print(usage)
+		return
+	default:
+		fmt.Fprintf(os.Stderr, "transfa-admin: unknown command %q\n\n%s", os.Args[1], usage)
+		os.Exit(2)
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "transfa-admin: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,217 @@
+/**
+ * @description
+ * This package defines the events exchanged between Transfa services. Every event is
+ * published as an Envelope: a fixed set of metadata wrapping the event's payload. The
+ * payload types live in this package too (see payloads.go), so a producer and its
+ * consumers always compile against the same struct.
+ *
+ * Key features:
+ * - Each envelope has a unique event ID, which is also the AMQP message ID, so the
+ *   consumers' inbox deduplicates by event.
+ * - The event type and schema version let a consumer reject payloads it does not
+ *   understand instead of misreading them.
+ * - Correlation and causation IDs link the events of one business flow, so a chain such
+ *   as user.created -> customer.verified can be followed across services.
+ * - The metadata is carried in the JSON body and mirrored in the AMQP properties and
+ *   headers, so it can be inspected without parsing the body.
+ *
+ * @dependencies
+ * - "context", "encoding/json", "errors", "fmt", "time"
+ * - "github.com/google/uuid": For event IDs.
+ * - "github.com/rabbitmq/amqp091-go": For the deliveries being decoded.
+ * - "transfa/shared/messaging": For the message published for an envelope.
+ */
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rabbitmq/amqp091-go"
+	"transfa/shared/messaging"
+)
+
+// AMQP headers that mirror envelope fields without a matching message property.
+const (
+	SchemaVersionHeader = "x-schema-version"
+	CausationIDHeader   = "x-causation-id"
+)
+
+var (
+	// ErrWrongType is returned when an envelope holds a different type of event than
+	// the payload it is decoded into.
+	ErrWrongType = errors.New("unexpected event type")
+	// ErrUnsupportedVersion is returned when an envelope's schema version is newer than
+	// the payload it is decoded into.
+	ErrUnsupportedVersion = errors.New("unsupported event schema version")
+)
+
+// Payload is the data carried by an event.
+type Payload interface {
+	// EventType is the name of the event, such as "user.created".
+	EventType() string
+	// SchemaVersion is the version of the payload's structure. It is incremented on
+	// every change that existing consumers cannot read.
+	SchemaVersion() int
+}
+
+// Envelope is the message body of every event.
+type Envelope struct {
+	EventID       uuid.UUID `json:"event_id"`
+	Type          string    `json:"type"`
+	SchemaVersion int       `json:"schema_version"`
+	OccurredAt    time.Time `json:"occurred_at"`
+	// CorrelationID is shared by every event of one business flow.
+	CorrelationID string `json:"correlation_id"`
+	// CausationID is the ID of the event whose handling produced this one, if any.
+	CausationID string `json:"causation_id,omitempty"`
+	// Producer is the name of the service that produced the event.
+	Producer string          `json:"producer"`
+	Data     json.RawMessage `json:"data"`
+}
+
+// Metadata links a new event to the flow that produced it.
+type Metadata struct {
+	// CorrelationID identifies the flow. If empty, the new event starts a flow and its
+	// own ID is used.
+	CorrelationID string
+	// CausationID is the ID of the event being handled, if any.
+	CausationID string
+}
+
+// CausedBy returns the metadata of an event produced while handling parent.
+func CausedBy(parent *Envelope) Metadata {
+	return Metadata{
+		CorrelationID: parent.CorrelationID,
+		CausationID:   parent.EventID.String(),
+	}
+}
+
+// New wraps payload in an envelope with a new event ID, produced now by producer.
+func New(producer string, payload Payload, meta Metadata) (*Envelope, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal %s payload: %w", payload.EventType(), err)
+	}
+
+	env := &Envelope{
+		EventID:       uuid.New(),
+		Type:          payload.EventType(),
+		SchemaVersion: payload.SchemaVersion(),
+		OccurredAt:    time.Now().UTC(),
+		CorrelationID: meta.CorrelationID,
+		CausationID:   meta.CausationID,
+		Producer:      producer,
+		Data:          data,
+	}
+	if env.CorrelationID == "" {
+		env.CorrelationID = env.EventID.String()
+	}
+	return env, nil
+}
+
+// Parse decodes an envelope from a message body.
+func Parse(body []byte) (*Envelope, error) {
+	var env Envelope
+	if err := json.Unmarshal(body, &env); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal event envelope: %w", err)
+	}
+	if env.EventID == uuid.Nil || env.Type == "" || env.SchemaVersion < 1 {
+		return nil, errors.New("message body is not an event envelope")
+	}
+	return &env, nil
+}
+
+// Decode unmarshals the envelope's data into payload, which must be a pointer. It fails
+// with ErrWrongType if the envelope holds another type of event, and with
+// ErrUnsupportedVersion if its schema is newer than payload's.
+func (e *Envelope) Decode(payload Payload) error {
+	if e.Type != payload.EventType() {
+		return fmt.Errorf("%w: got %s, want %s", ErrWrongType, e.Type, payload.EventType())
+	}
+	if e.SchemaVersion > payload.SchemaVersion() {
+		return fmt.Errorf("%w: %s version %d, supported up to %d", ErrUnsupportedVersion, e.Type, e.SchemaVersion, payload.SchemaVersion())
+	}
+	if err := json.Unmarshal(e.Data, payload); err != nil {
+		return fmt.Errorf("failed to unmarshal %s payload: %w", e.Type, err)
+	}
+	return nil
+}
+
+// DecodeDelivery parses the envelope in msg and decodes its data into payload. Errors
+// are marked with messaging.Permanent, because redelivering the same body cannot fix them.
+func DecodeDelivery(msg amqp091.Delivery, payload Payload) (*Envelope, error) {
+	env, err := Parse(msg.Body)
+	if err != nil {
+		return nil, messaging.Permanent(err)
+	}
+	if err := env.Decode(payload); err != nil {
+		return nil, messaging.Permanent(err)
+	}
+	return env, nil
+}
+
+// Message returns the message that publishes the envelope. The event ID is the message
+// ID, and the other metadata is copied to the message properties and headers.
+func (e *Envelope) Message() (messaging.Message, error) {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return messaging.Message{}, fmt.Errorf("failed to marshal %s envelope: %w", e.Type, err)
+	}
+
+	headers := amqp091.Table{
+		SchemaVersionHeader: int32(e.SchemaVersion),
+	}
+	if e.CausationID != "" {
+		headers[CausationIDHeader] = e.CausationID
+	}
+
+	return messaging.Message{
+		ID:            e.EventID.String(),
+		Type:          e.Type,
+		CorrelationID: e.CorrelationID,
+		AppID:         e.Producer,
+		Timestamp:     e.OccurredAt,
+		Headers:       headers,
+		Body:          body,
+	}, nil
+}
+
+// Publisher publishes messages to the broker. *messaging.Publisher implements it.
+type Publisher interface {
+	PublishMessage(ctx context.Context, exchange, routingKey string, msg messaging.Message) error
+}
+
+// Publish publishes env to exchange with routingKey and waits for the broker to confirm it.
+func Publish(ctx context.Context, publisher Publisher, exchange, routingKey string, env *Envelope) error {
+	msg, err := env.Message()
+	if err != nil {
+		return err
+	}
+	return publisher.PublishMessage(ctx, exchange, routingKey, msg)
+}
+
+// OrderingKey returns a function that keys a delivery by a field of its event data, for
+// messaging.Concurrency. Deliveries that are not envelopes, or whose data lacks the
+// field, all get the empty key.
+func OrderingKey(field string) func(msg amqp091.Delivery) string {
+	return func(msg amqp091.Delivery) string {
+		env, err := Parse(msg.Body)
+		if err != nil {
+			return ""
+		}
+		var data map[string]json.RawMessage
+		if err := json.Unmarshal(env.Data, &data); err != nil {
+			return ""
+		}
+		var key string
+		if err := json.Unmarshal(data[field], &key); err != nil {
+			return string(data[field])
+		}
+		return key
+	}
+}
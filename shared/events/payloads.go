@@ -0,0 +1,118 @@
+/**
+ * @description
+ * This file defines the payload of every event published between Transfa services.
+ * Producers and consumers use these types directly, so a change to an event is a
+ * change to one struct. A change that existing consumers cannot read, such as renaming
+ * or removing a field, must also increment the payload's schema version.
+ *
+ * @dependencies
+ * - "time"
+ * - "github.com/google/uuid": For universally unique identifiers.
+ */
+package events
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Event types. They match the default routing keys of the events.
+const (
+	TypeUserCreated                  = "user.created"
+	TypeUserDeleted                  = "user.deleted"
+	TypeUsernameChanged              = "username.changed"
+	TypeCustomerVerified             = "customer.verified"
+	TypeCustomerVerificationRejected = "customer.verification.rejected"
+	TypeTransactionCompleted         = "transaction.completed"
+)
+
+// KYCDetails holds the Know Your Customer information of a personal user.
+type KYCDetails struct {
+	FullName    string `json:"full_name"`
+	BVN         string `json:"bvn"`
+	DateOfBirth string `json:"date_of_birth"` // YYYY-MM-DD
+}
+
+// KYBDetails holds the Know Your Business information of a merchant user.
+type KYBDetails struct {
+	BusinessName string `json:"business_name"`
+	RCNumber     string `json:"rc_number"`
+}
+
+// UserCreated is published by the Auth service after a user completes onboarding.
+// It triggers customer creation in the Customer service.
+type UserCreated struct {
+	UserID      uuid.UUID   `json:"user_id"`
+	ClerkID     string      `json:"clerk_id"`
+	AccountType string      `json:"account_type"`
+	KYCDetails  *KYCDetails `json:"kyc_details,omitempty"`
+	KYBDetails  *KYBDetails `json:"kyb_details,omitempty"`
+}
+
+func (UserCreated) EventType() string  { return TypeUserCreated }
+func (UserCreated) SchemaVersion() int { return 1 }
+
+// UserDeleted is published by the Auth service when a user is deleted in Clerk. The
+// user's data is kept, but they can no longer use Transfa. It triggers the closure of the
+// user's accounts in the Account service.
+type UserDeleted struct {
+	UserID    uuid.UUID `json:"user_id"`
+	ClerkID   string    `json:"clerk_id"`
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+func (UserDeleted) EventType() string  { return TypeUserDeleted }
+func (UserDeleted) SchemaVersion() int { return 1 }
+
+// UsernameChanged is published by the Customer service when a user changes their
+// username, so that anything keyed by the old username, such as cached lookups and QR
+// payloads, can be invalidated. Until GraceUntil, payments to the old username still
+// reach the user unless the Transaction service is configured to refuse them.
+type UsernameChanged struct {
+	UserID      uuid.UUID `json:"user_id"`
+	OldUsername string    `json:"old_username"`
+	NewUsername string    `json:"new_username"`
+	ChangedAt   time.Time `json:"changed_at"`
+	GraceUntil  time.Time `json:"grace_until"`
+}
+
+func (UsernameChanged) EventType() string  { return TypeUsernameChanged }
+func (UsernameChanged) SchemaVersion() int { return 1 }
+
+// CustomerVerified is published by the Notification service when a customer's KYC or
+// KYB is approved. It triggers wallet creation in the Account service.
+type CustomerVerified struct {
+	UserID           uuid.UUID `json:"user_id"`
+	AnchorCustomerID string    `json:"anchor_customer_id"`
+}
+
+func (CustomerVerified) EventType() string  { return TypeCustomerVerified }
+func (CustomerVerified) SchemaVersion() int { return 1 }
+
+// CustomerVerificationRejected is published by the Notification service when a
+// customer's KYC or KYB is rejected.
+type CustomerVerificationRejected struct {
+	UserID           uuid.UUID `json:"user_id"`
+	AnchorCustomerID string    `json:"anchor_customer_id"`
+	Reason           string    `json:"reason"`
+}
+
+func (CustomerVerificationRejected) EventType() string  { return TypeCustomerVerificationRejected }
+func (CustomerVerificationRejected) SchemaVersion() int { return 1 }
+
+// TransactionCompleted is published by the Transaction service after a transaction is
+// recorded as completed. It is consumed by the Analytics service.
+type TransactionCompleted struct {
+	TransactionID   uuid.UUID     `json:"transaction_id"`
+	Type            string        `json:"type"`
+	Amount          int64         `json:"amount"` // In kobo
+	Fee             int64         `json:"fee"`    // In kobo
+	Category        *string       `json:"category,omitempty"`
+	SenderUserID    uuid.NullUUID `json:"sender_user_id"`
+	RecipientUserID uuid.NullUUID `json:"recipient_user_id"`
+	CompletedAt     time.Time     `json:"completed_at"`
+}
+
+func (TransactionCompleted) EventType() string  { return TypeTransactionCompleted }
+func (TransactionCompleted) SchemaVersion() int { return 1 }
@@ -0,0 +1,69 @@
+/**
+ * @description
+ * This file masks the personal data in event payloads, so that events can be shown to
+ * operators, for example when inspecting dead-lettered messages, without exposing
+ * identity documents.
+ *
+ * @dependencies
+ * - "encoding/json", "strings"
+ */
+package events
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// sensitiveFields are the JSON fields of the payloads in payloads.go that hold
+// personal data. Add a field here when adding it to a payload.
+var sensitiveFields = map[string]bool{
+	"bvn":           true,
+	"business_name": true,
+	"clerk_id":      true,
+	"date_of_birth": true,
+	"full_name":     true,
+	"rc_number":     true,
+}
+
+// Redact returns the JSON document body with the value of every sensitive field masked,
+// at any depth. It can be given a whole envelope or just its data.
+func Redact(body []byte) ([]byte, error) {
+	var doc interface{}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil, err
+	}
+	return json.Marshal(redactValue(doc))
+}
+
+// redactValue masks the sensitive fields of a decoded JSON value.
+func redactValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			if sensitiveFields[key] && field != nil {
+				v[key] = mask(field)
+			} else {
+				v[key] = redactValue(field)
+			}
+		}
+		return v
+	case []interface{}:
+		for i, item := range v {
+			v[i] = redactValue(item)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+// mask hides a sensitive value. Long strings keep their last two characters, which is
+// usually enough to tell two records apart.
+func mask(v interface{}) string {
+	s, ok := v.(string)
+	runes := []rune(s)
+	if !ok || len(runes) < 8 {
+		return "****"
+	}
+	return strings.Repeat("*", len(runes)-2) + string(runes[len(runes)-2:])
+}
@@ -0,0 +1,111 @@
+/**
+ * @description
+ * This package holds the scenarios that the services' event contract tests share. Each
+ * service can only test its own handlers, because Go does not allow one module's tests
+ * to import another service's internal packages. Instead, every service in a flow tests
+ * its leg against the same scenario: a producer's test checks that it publishes the
+ * scenario's event, and the consumer's test handles that same event. Together the legs
+ * cover the whole flow.
+ *
+ * The onboarding flow is:
+ *   auth (POST /onboarding) -> user.created -> customer (creates the Anchor customer)
+ *   -> Anchor webhook -> notification -> customer.verified -> account (creates the wallet)
+ *
+ * @dependencies
+ * - "context", "testing"
+ * - "github.com/google/uuid": For the scenario's identifiers.
+ * - "transfa/shared/events": For the scenario's events.
+ * - "transfa/shared/messaging/memory": For the in-process broker.
+ */
+package eventtest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"transfa/shared/events"
+	"transfa/shared/messaging/memory"
+)
+
+// Onboarding is one personal user's onboarding, from signup to main wallet.
+var Onboarding = struct {
+	// UserID is the user's ID. The Auth service generates it, so the Auth service's
+	// test cannot use it.
+	UserID           uuid.UUID
+	ClerkID          string
+	Username         string
+	AccountType      string
+	KYCDetails       events.KYCDetails
+	AnchorCustomerID string
+	// AnchorWebhookID is the ID of the Anchor event approving the customer.
+	AnchorWebhookID string
+	AnchorAccountID string
+}{
+	UserID:      uuid.MustParse("5b0f4c0e-1d7a-4a51-9f0e-3c1a2b4d6e80"),
+	ClerkID:     "user_2abcdefghijklmnopqrstuvwxyz",
+	Username:    "ada",
+	AccountType: "personal",
+	KYCDetails: events.KYCDetails{
+		FullName:    "Ada Lovelace",
+		BVN:         "22212345678",
+		DateOfBirth: "1990-12-10",
+	},
+	AnchorCustomerID: "17000000000000-anc_cus",
+	AnchorWebhookID:  "17000000000001-anc_evt",
+	AnchorAccountID:  "17000000000002-anc_acc",
+}
+
+// UserCreated returns the `user.created` event of the onboarding scenario.
+func UserCreated() events.UserCreated {
+	kyc := Onboarding.KYCDetails
+	return events.UserCreated{
+		UserID:      Onboarding.UserID,
+		ClerkID:     Onboarding.ClerkID,
+		AccountType: Onboarding.AccountType,
+		KYCDetails:  &kyc,
+	}
+}
+
+// CustomerVerified returns the `customer.verified` event of the onboarding scenario.
+func CustomerVerified() events.CustomerVerified {
+	return events.CustomerVerified{
+		UserID:           Onboarding.UserID,
+		AnchorCustomerID: Onboarding.AnchorCustomerID,
+	}
+}
+
+// Publish wraps payload in an envelope from producer and publishes it to broker,
+// failing the test on error. It returns the envelope.
+func Publish(t *testing.T, broker *memory.Broker, exchange, routingKey, producer string, payload events.Payload) *events.Envelope {
+	t.Helper()
+
+	env, err := events.New(producer, payload, events.Metadata{})
+	if err != nil {
+		t.Fatalf("failed to create %s event: %v", payload.EventType(), err)
+	}
+	if err := events.Publish(context.Background(), broker, exchange, routingKey, env); err != nil {
+		t.Fatalf("failed to publish %s event: %v", payload.EventType(), err)
+	}
+	return env
+}
+
+// Received decodes the single message held by queue on broker into payload, failing
+// the test if the queue does not hold exactly one message or it does not decode. It
+// returns the envelope.
+func Received(t *testing.T, broker *memory.Broker, queue string, payload events.Payload) *events.Envelope {
+	t.Helper()
+
+	msgs := broker.Messages(queue)
+	if len(msgs) != 1 {
+		t.Fatalf("queue %s holds %d messages, want 1", queue, len(msgs))
+	}
+	env, err := events.DecodeDelivery(msgs[0], payload)
+	if err != nil {
+		t.Fatalf("failed to decode message on queue %s: %v", queue, err)
+	}
+	if msgs[0].MessageId != env.EventID.String() {
+		t.Errorf("message ID = %q, want the event ID %s", msgs[0].MessageId, env.EventID)
+	}
+	return env
+}
@@ -0,0 +1,106 @@
+/**
+ * @description
+ * This package makes RabbitMQ consumers idempotent. RabbitMQ delivers messages at least
+ * once, so a handler may see the same message again after a reconnect, a retry, or an
+ * outbox relay republishing an event. An Inbox records the ID of every message a
+ * consumer has handled in public.processed_messages and skips messages already there.
+ *
+ * The check and the record are not in the same transaction as the handler's side
+ * effects, which often include calls to external APIs. Two deliveries of one message
+ * racing each other, or a crash between the handler and the record, can still run the
+ * handler twice, so handlers should also guard their side effects with natural keys.
+ *
+ * @dependencies
+ * - "context", "errors", "fmt", "log"
+ * - "github.com/jackc/pgx/v5", "github.com/jackc/pgx/v5/pgxpool": For the inbox table.
+ * - "github.com/rabbitmq/amqp091-go": For message deliveries.
+ * - "transfa/shared/messaging": For the handler type being wrapped.
+ */
+package inbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rabbitmq/amqp091-go"
+	"transfa/shared/messaging"
+)
+
+// Inbox records the messages handled by one consumer.
+type Inbox struct {
+	db       *pgxpool.Pool
+	consumer string
+}
+
+// New creates an inbox for consumer, which should be the name of the queue it reads.
+func New(db *pgxpool.Pool, consumer string) *Inbox {
+	return &Inbox{
+		db:       db,
+		consumer: consumer,
+	}
+}
+
+// Wrap returns a handler that calls next only for messages not yet processed, and
+// records each message that next handles successfully. Messages without a message ID
+// cannot be deduplicated and are always passed to next.
+func (i *Inbox) Wrap(next messaging.MessageHandler) messaging.MessageHandler {
+	return func(ctx context.Context, msg amqp091.Delivery) error {
+		if msg.MessageId == "" {
+			log.Printf("WARNING: Message on queue '%s' has no message ID; it cannot be deduplicated", i.consumer)
+			return next(ctx, msg)
+		}
+
+		// Step 1: Skip messages that were already handled.
+		processed, err := i.isProcessed(ctx, msg.MessageId)
+		if err != nil {
+			return err
+		}
+		if processed {
+			log.Printf("Message %s was already processed by '%s'; skipping", msg.MessageId, i.consumer)
+			return nil
+		}
+
+		// Step 2: Handle the message and record it.
+		if err := next(ctx, msg); err != nil {
+			return err
+		}
+		if err := i.markProcessed(ctx, msg.MessageId); err != nil {
+			// The handler succeeded, so acknowledging is still right; a redelivery would
+			// be caught by the handler's own guards.
+			log.Printf("WARNING: Failed to record message %s as processed: %v", msg.MessageId, err)
+		}
+		return nil
+	}
+}
+
+// isProcessed reports whether the message is in the inbox.
+func (i *Inbox) isProcessed(ctx context.Context, messageID string) (bool, error) {
+	query := `SELECT 1 FROM public.processed_messages WHERE consumer = $1 AND message_id = $2`
+
+	var one int
+	err := i.db.QueryRow(ctx, query, i.consumer, messageID).Scan(&one)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to check inbox for message %s: %w", messageID, err)
+	}
+	return true, nil
+}
+
+// markProcessed adds the message to the inbox.
+func (i *Inbox) markProcessed(ctx context.Context, messageID string) error {
+	query := `
+        INSERT INTO public.processed_messages (consumer, message_id)
+        VALUES ($1, $2)
+        ON CONFLICT (consumer, message_id) DO NOTHING
+    `
+	if _, err := i.db.Exec(ctx, query, i.consumer, messageID); err != nil {
+		return fmt.Errorf("failed to record message %s in inbox: %w", messageID, err)
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+/**
+ * @description
+ * This file controls how many deliveries of one queue a consumer handles at once. The
+ * broker's prefetch limit (basic.qos) bounds the deliveries sent ahead of their acks,
+ * and a fixed pool of workers handles them, so one slow handler call no longer stalls
+ * the whole queue.
+ *
+ * Key features:
+ * - The prefetch limit is set per consumer, so queues sharing a channel do not compete
+ *   for one limit.
+ * - An optional ordering key routes every delivery with the same key to the same
+ *   worker, so deliveries for one user are handled one at a time and in queue order.
+ *   It also keeps two copies of one message from being handled concurrently.
+ *
+ * @dependencies
+ * - "hash/fnv", "sync"
+ * - "github.com/rabbitmq/amqp091-go": The official Go client for RabbitMQ.
+ */
+package messaging
+
+import (
+	"hash/fnv"
+	"sync"
+
+	"github.com/rabbitmq/amqp091-go"
+)
+
+// Concurrency controls how a consumer handles the deliveries of one queue.
+type Concurrency struct {
+	// Prefetch is the number of unacknowledged deliveries the broker sends ahead.
+	// Values below Workers are raised to Workers, so no worker is left idle.
+	Prefetch int
+	// Workers is the number of deliveries handled at once. Values below 1 are treated
+	// as 1, which handles deliveries one at a time in queue order.
+	Workers int
+	// OrderingKey, if set, returns the key of a delivery. Deliveries with the same key
+	// are handled by the same worker, one at a time and in queue order.
+	OrderingKey func(msg amqp091.Delivery) string
+}
+
+// workers returns Workers, at least 1.
+func (c Concurrency) workers() int {
+	if c.Workers < 1 {
+		return 1
+	}
+	return c.Workers
+}
+
+// prefetch returns Prefetch, at least the number of workers.
+func (c Concurrency) prefetch() int {
+	if c.Prefetch < c.workers() {
+		return c.workers()
+	}
+	return c.Prefetch
+}
+
+// workerPool hands deliveries to a fixed number of worker goroutines.
+type workerPool struct {
+	// inputs holds one channel per worker when deliveries are ordered by key, or a
+	// single channel shared by every worker otherwise. The channels are unbuffered, so a
+	// delivery is only taken from the broker's stream once a worker is free for it.
+	inputs []chan amqp091.Delivery
+	key    func(msg amqp091.Delivery) string
+	wg     sync.WaitGroup
+}
+
+// newWorkerPool starts the workers, each calling handle for the deliveries it receives.
+func newWorkerPool(c Concurrency, handle func(msg amqp091.Delivery)) *workerPool {
+	p := &workerPool{key: c.OrderingKey}
+
+	if p.key == nil {
+		p.inputs = []chan amqp091.Delivery{make(chan amqp091.Delivery)}
+	} else {
+		for i := 0; i < c.workers(); i++ {
+			p.inputs = append(p.inputs, make(chan amqp091.Delivery))
+		}
+	}
+
+	for i := 0; i < c.workers(); i++ {
+		input := p.inputs[i%len(p.inputs)]
+		p.wg.Add(1)
+		go func() {
+			defer p.wg.Done()
+			for msg := range input {
+				handle(msg)
+			}
+		}()
+	}
+	return p
+}
+
+// submit blocks until a worker accepts msg. With an ordering key, that is the worker
+// for msg's key.
+func (p *workerPool) submit(msg amqp091.Delivery) {
+	input := p.inputs[0]
+	if p.key != nil {
+		h := fnv.New32a()
+		h.Write([]byte(p.key(msg)))
+		input = p.inputs[h.Sum32()%uint32(len(p.inputs))]
+	}
+	input <- msg
+}
+
+// stop waits for the workers to finish the deliveries they are handling.
+func (p *workerPool) stop() {
+	for _, input := range p.inputs {
+		close(input)
+	}
+	p.wg.Wait()
+}
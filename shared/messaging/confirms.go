@@ -0,0 +1,187 @@
+/**
+ * @description
+ * This file tracks publisher confirms and returns for a single RabbitMQ channel in
+ * confirm mode. A publish waits until the broker acks or nacks it. Because mandatory
+ * publishes are used, the broker sends a basic.return before the ack of any message it
+ * could not route to a queue, and that return marks the publish as unroutable.
+ *
+ * Key features:
+ * - Correlates confirmations by delivery tag and returns by message ID.
+ * - Reads returns and confirmations on one goroutine, so a return is always recorded
+ *   before the confirmation of the same message is processed.
+ * - Fails every outstanding publish with amqp091.ErrClosed when the channel closes, so
+ *   the publisher can retry it on the next channel.
+ *
+ * @dependencies
+ * - "context", "sync"
+ * - "github.com/rabbitmq/amqp091-go": The official Go client for RabbitMQ.
+ */
+package messaging
+
+import (
+	"context"
+	"sync"
+
+	"github.com/rabbitmq/amqp091-go"
+)
+
+// publishResult is the broker's verdict on one published message.
+type publishResult struct {
+	acked bool
+	err   error
+}
+
+// confirmPublisher is the part of *amqp091.Channel that publishes with confirms. Tests
+// substitute a fake broker for it.
+type confirmPublisher interface {
+	Confirm(noWait bool) error
+	NotifyReturn(c chan amqp091.Return) chan amqp091.Return
+	NotifyPublish(confirm chan amqp091.Confirmation) chan amqp091.Confirmation
+	PublishWithDeferredConfirmWithContext(ctx context.Context, exchange, key string, mandatory, immediate bool, msg amqp091.Publishing) (*amqp091.DeferredConfirmation, error)
+}
+
+// confirmChannel is a channel in confirm mode together with its outstanding publishes.
+type confirmChannel struct {
+	// ch is the channel itself, used by callers to declare topology; pub is the same
+	// channel, used to publish.
+	ch  *amqp091.Channel
+	pub confirmPublisher
+
+	// mu guards the maps below. It is never held while calling into the client library,
+	// which blocks its reader goroutine until track has received each notification.
+	mu sync.Mutex
+	// inflight holds the return, if any, of each message awaiting confirmation.
+	inflight map[string]*amqp091.Return
+	// waiting holds the result channel of each publish by delivery tag.
+	waiting map[uint64]chan publishResult
+	// early holds confirmations that arrived before their publish was registered.
+	early  map[uint64]publishResult
+	closed bool
+}
+
+// newConfirmChannel puts ch into confirm mode and starts tracking its confirmations.
+func newConfirmChannel(ch *amqp091.Channel) (*confirmChannel, error) {
+	c, err := trackConfirms(ch)
+	if err != nil {
+		return nil, err
+	}
+	c.ch = ch
+	return c, nil
+}
+
+// trackConfirms puts pub into confirm mode and starts tracking its confirmations.
+func trackConfirms(pub confirmPublisher) (*confirmChannel, error) {
+	if err := pub.Confirm(false); err != nil {
+		return nil, err
+	}
+
+	c := &confirmChannel{
+		pub:      pub,
+		inflight: make(map[string]*amqp091.Return),
+		waiting:  make(map[uint64]chan publishResult),
+		early:    make(map[uint64]publishResult),
+	}
+
+	returns := pub.NotifyReturn(make(chan amqp091.Return))
+	confirms := pub.NotifyPublish(make(chan amqp091.Confirmation))
+	go c.track(returns, confirms)
+
+	return c, nil
+}
+
+// track records returns and resolves confirmations until the channel closes.
+func (c *confirmChannel) track(returns <-chan amqp091.Return, confirms <-chan amqp091.Confirmation) {
+	for {
+		select {
+		case ret, ok := <-returns:
+			if !ok {
+				returns = nil
+				continue
+			}
+			c.mu.Lock()
+			if _, ok := c.inflight[ret.MessageId]; ok {
+				c.inflight[ret.MessageId] = &ret
+			}
+			c.mu.Unlock()
+		case conf, ok := <-confirms:
+			if !ok {
+				c.fail()
+				return
+			}
+			result := publishResult{acked: conf.Ack}
+			c.mu.Lock()
+			if waiter, ok := c.waiting[conf.DeliveryTag]; ok {
+				delete(c.waiting, conf.DeliveryTag)
+				waiter <- result
+			} else {
+				c.early[conf.DeliveryTag] = result
+			}
+			c.mu.Unlock()
+		}
+	}
+}
+
+// fail resolves every outstanding publish with amqp091.ErrClosed and rejects new ones.
+func (c *confirmChannel) fail() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.closed = true
+	for tag, waiter := range c.waiting {
+		delete(c.waiting, tag)
+		waiter <- publishResult{err: amqp091.ErrClosed}
+	}
+}
+
+// publish sends msg as a mandatory publish and waits for the broker's confirmation.
+// It reports whether the broker acked the message, and the return it sent if the
+// message could not be routed. msg.MessageId must be set and unique.
+func (c *confirmChannel) publish(ctx context.Context, exchange, routingKey string, msg amqp091.Publishing) (returned *amqp091.Return, acked bool, err error) {
+	// Step 1: Register the message so that a return for it is recorded.
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil, false, amqp091.ErrClosed
+	}
+	c.inflight[msg.MessageId] = nil
+	c.mu.Unlock()
+
+	defer func() {
+		c.mu.Lock()
+		delete(c.inflight, msg.MessageId)
+		c.mu.Unlock()
+	}()
+
+	// Step 2: Publish it.
+	dc, err := c.pub.PublishWithDeferredConfirmWithContext(ctx, exchange, routingKey, true, false, msg)
+	if err != nil {
+		return nil, false, err
+	}
+
+	// Step 3: Wait for the confirmation. The result channel is buffered so that track
+	// never blocks on a caller that stopped waiting.
+	result := make(chan publishResult, 1)
+	c.mu.Lock()
+	if r, ok := c.early[dc.DeliveryTag]; ok {
+		delete(c.early, dc.DeliveryTag)
+		result <- r
+	} else if c.closed {
+		result <- publishResult{err: amqp091.ErrClosed}
+	} else {
+		c.waiting[dc.DeliveryTag] = result
+	}
+	c.mu.Unlock()
+
+	select {
+	case r := <-result:
+		if r.err != nil {
+			return nil, false, r.err
+		}
+		c.mu.Lock()
+		ret := c.inflight[msg.MessageId]
+		c.mu.Unlock()
+		return ret, r.acked, nil
+	case <-ctx.Done():
+		return nil, false, ctx.Err()
+	}
+}
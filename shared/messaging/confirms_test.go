@@ -0,0 +1,179 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rabbitmq/amqp091-go"
+)
+
+// fakePublish is one message published to a fakeChannel.
+type fakePublish struct {
+	tag        uint64
+	exchange   string
+	routingKey string
+	msg        amqp091.Publishing
+}
+
+// fakeChannel is a confirmPublisher standing in for the broker. Unless respond is set,
+// publishes stay unconfirmed until the test calls confirm.
+type fakeChannel struct {
+	returns  chan amqp091.Return
+	confirms chan amqp091.Confirmation
+	// respond, if set, plays the broker's reply to each publish.
+	respond func(f *fakeChannel, p fakePublish)
+	// sent receives every publish, in order.
+	sent chan fakePublish
+
+	mu      sync.Mutex
+	nextTag uint64
+}
+
+func newFakeChannel(t *testing.T, respond func(f *fakeChannel, p fakePublish)) (*fakeChannel, *confirmChannel) {
+	t.Helper()
+	f := &fakeChannel{respond: respond, sent: make(chan fakePublish, 16)}
+	c, err := trackConfirms(f)
+	if err != nil {
+		t.Fatalf("trackConfirms() error = %v", err)
+	}
+	// Closing the confirmations stops track and fails any outstanding publish.
+	t.Cleanup(func() { close(f.confirms) })
+	return f, c
+}
+
+func (f *fakeChannel) Confirm(noWait bool) error { return nil }
+
+func (f *fakeChannel) NotifyReturn(c chan amqp091.Return) chan amqp091.Return {
+	f.returns = c
+	return c
+}
+
+func (f *fakeChannel) NotifyPublish(c chan amqp091.Confirmation) chan amqp091.Confirmation {
+	f.confirms = c
+	return c
+}
+
+func (f *fakeChannel) PublishWithDeferredConfirmWithContext(ctx context.Context, exchange, key string, mandatory, immediate bool, msg amqp091.Publishing) (*amqp091.DeferredConfirmation, error) {
+	f.mu.Lock()
+	f.nextTag++
+	p := fakePublish{tag: f.nextTag, exchange: exchange, routingKey: key, msg: msg}
+	f.mu.Unlock()
+
+	f.sent <- p
+	if f.respond != nil {
+		f.respond(f, p)
+	}
+	return &amqp091.DeferredConfirmation{DeliveryTag: p.tag}, nil
+}
+
+// confirm sends the broker's ack or nack of the publish with the given tag.
+func (f *fakeChannel) confirm(tag uint64, ack bool) {
+	f.confirms <- amqp091.Confirmation{DeliveryTag: tag, Ack: ack}
+}
+
+// giveBack sends a basic.return for p, as the broker does before acking an unroutable
+// mandatory publish.
+func (f *fakeChannel) giveBack(p fakePublish) {
+	f.returns <- amqp091.Return{ReplyCode: 312, ReplyText: "NO_ROUTE", Exchange: p.exchange, RoutingKey: p.routingKey, MessageId: p.msg.MessageId}
+}
+
+// Broker replies for fakeChannel.respond.
+var (
+	ackAll  = func(f *fakeChannel, p fakePublish) { f.confirm(p.tag, true) }
+	nackAll = func(f *fakeChannel, p fakePublish) { f.confirm(p.tag, false) }
+	dropAll = func(f *fakeChannel, p fakePublish) {}
+	// returnAll reports every publish as unroutable, then acks it.
+	returnAll = func(f *fakeChannel, p fakePublish) {
+		f.giveBack(p)
+		f.confirm(p.tag, true)
+	}
+)
+
+// publishResultOf is the outcome of one confirmChannel.publish.
+type publishResultOf struct {
+	returned *amqp091.Return
+	acked    bool
+	err      error
+}
+
+func publishAsync(c *confirmChannel, ctx context.Context, routingKey, messageID string) <-chan publishResultOf {
+	done := make(chan publishResultOf, 1)
+	go func() {
+		returned, acked, err := c.publish(ctx, "events", routingKey, amqp091.Publishing{MessageId: messageID})
+		done <- publishResultOf{returned, acked, err}
+	}()
+	return done
+}
+
+func TestPublishCorrelatesReturnsWithTheirPublish(t *testing.T) {
+	f, c := newFakeChannel(t, nil)
+	ctx := context.Background()
+
+	routed := publishAsync(c, ctx, "user.created", "m1")
+	first := <-f.sent
+	unroutable := publishAsync(c, ctx, "user.nobody", "m2")
+	second := <-f.sent
+
+	// The broker returns the second message and acks both, the second one first.
+	f.giveBack(second)
+	f.confirm(second.tag, true)
+	f.confirm(first.tag, true)
+
+	got := <-unroutable
+	if got.err != nil || !got.acked {
+		t.Fatalf("publish(m2) = acked %v, error %v; want acked", got.acked, got.err)
+	}
+	if got.returned == nil || got.returned.MessageId != "m2" {
+		t.Errorf("publish(m2) returned %+v, want the return of m2", got.returned)
+	}
+
+	got = <-routed
+	if got.err != nil || !got.acked {
+		t.Fatalf("publish(m1) = acked %v, error %v; want acked", got.acked, got.err)
+	}
+	if got.returned != nil {
+		t.Errorf("publish(m1) returned %+v, want no return", got.returned)
+	}
+}
+
+func TestPublishReportsNacks(t *testing.T) {
+	_, c := newFakeChannel(t, nackAll)
+
+	returned, acked, err := c.publish(context.Background(), "events", "user.created", amqp091.Publishing{MessageId: "m1"})
+	if err != nil || acked || returned != nil {
+		t.Errorf("publish() = returned %+v, acked %v, error %v; want a nack", returned, acked, err)
+	}
+}
+
+func TestPublishTimesOutWithoutAConfirmation(t *testing.T) {
+	_, c := newFakeChannel(t, dropAll)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, acked, err := c.publish(ctx, "events", "user.created", amqp091.Publishing{MessageId: "m1"})
+	if !errors.Is(err, context.DeadlineExceeded) || acked {
+		t.Errorf("publish() = acked %v, error %v; want context.DeadlineExceeded", acked, err)
+	}
+}
+
+func TestPublishFailsWhenTheChannelCloses(t *testing.T) {
+	f := &fakeChannel{sent: make(chan fakePublish, 16)}
+	c, err := trackConfirms(f)
+	if err != nil {
+		t.Fatalf("trackConfirms() error = %v", err)
+	}
+
+	done := publishAsync(c, context.Background(), "user.created", "m1")
+	<-f.sent
+	close(f.confirms)
+
+	if got := <-done; !errors.Is(got.err, amqp091.ErrClosed) {
+		t.Errorf("publish() error = %v, want amqp091.ErrClosed", got.err)
+	}
+	if _, _, err := c.publish(context.Background(), "events", "user.created", amqp091.Publishing{MessageId: "m2"}); !errors.Is(err, amqp091.ErrClosed) {
+		t.Errorf("publish() after close error = %v, want amqp091.ErrClosed", err)
+	}
+}
@@ -0,0 +1,293 @@
+/**
+ * @description
+ * This file provides the RabbitMQ consumer used by every Transfa service that reacts to
+ * events. It handles connecting to RabbitMQ, declaring the shared topology, and
+ * dispatching deliveries to a handler.
+ *
+ * Key features:
+ * - Declares the exchange, queue and binding through the shared topology helpers.
+ * - Starts a message consumer in a separate goroutine with manual acknowledgement, and
+ *   handles its deliveries with a bounded worker pool (see concurrency.go).
+ * - Retries failed messages with exponential backoff through delay queues, and
+ *   dead-letters them once the retry policy is exhausted (see retry.go). The channel is
+ *   in confirm mode, and a failed message is only acked once the broker has confirmed
+ *   its retry copy, so a retry is never lost between the two.
+ * - Recovers from connection loss: after reconnecting, the topology is declared again
+ *   and every registered consumer is resumed. Messages that were unacknowledged when
+ *   the connection dropped are redelivered by the broker.
+ * - Provides a clean shutdown mechanism.
+ *
+ * @dependencies
+ * - "context", "fmt", "log", "time"
+ * - "github.com/rabbitmq/amqp091-go": The official Go client for RabbitMQ.
+ */
+package messaging
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/rabbitmq/amqp091-go"
+)
+
+// MessageHandler is a function type that processes a single RabbitMQ message.
+// Returning an error schedules the message for a retry, or dead-letters it if the
+// retry policy is exhausted or the error was marked with Permanent.
+type MessageHandler func(ctx context.Context, msg amqp091.Delivery) error
+
+// subscription is a queue consumed by a Consumer, kept so it can be resumed after a reconnect.
+type subscription struct {
+	ctx         context.Context
+	exchange    string
+	queueName   string
+	routingKey  string
+	consumerTag string
+	handler     MessageHandler
+	concurrency Concurrency
+}
+
+// Consumer holds the necessary components for a RabbitMQ consumer.
+type Consumer struct {
+	session *session
+	retry   RetryPolicy
+	// subs and confirms, which wraps the session's current channel, are guarded by
+	// session.mu.
+	subs     []*subscription
+	confirms *confirmChannel
+	// confirmTimeout bounds the wait for the broker to confirm a retry copy.
+	confirmTimeout time.Duration
+}
+
+// defaultConfirmTimeout is how long a consumer waits for the broker to confirm a retry
+// copy before requeueing the original instead.
+const defaultConfirmTimeout = 10 * time.Second
+
+// NewConsumer creates and returns a new RabbitMQ consumer that retries failed messages
+// according to retry.
+func NewConsumer(amqpURL string, retry RetryPolicy) (*Consumer, error) {
+	c := &Consumer{retry: retry, confirmTimeout: defaultConfirmTimeout}
+	s, err := newSession(amqpURL, "consumer", c.setup)
+	if err != nil {
+		return nil, err
+	}
+	c.session = s
+	return c, nil
+}
+
+// StartConsumer sets up the RabbitMQ topology and begins consuming messages, handling
+// them as concurrency allows. The consumer is resumed automatically whenever the
+// connection is recovered.
+func (c *Consumer) StartConsumer(ctx context.Context, exchange, queueName, routingKey, consumerTag string, handler MessageHandler, concurrency Concurrency) error {
+	sub := &subscription{
+		ctx:         ctx,
+		exchange:    exchange,
+		queueName:   queueName,
+		routingKey:  routingKey,
+		consumerTag: consumerTag,
+		handler:     handler,
+		concurrency: concurrency,
+	}
+
+	err := c.session.withChannel(func(ch *amqp091.Channel) error {
+		// While disconnected, the subscription is started by setup on reconnection.
+		if ch != nil {
+			if err := c.subscribe(c.confirms, sub); err != nil {
+				return err
+			}
+		}
+		c.subs = append(c.subs, sub)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Consumer started. Waiting for messages on queue '%s' with routing key '%s' (%d worker(s), prefetch %d)",
+		queueName, routingKey, concurrency.workers(), concurrency.prefetch())
+	return nil
+}
+
+// setup puts each newly opened channel into confirm mode, for publishing retries, and
+// restarts every subscription on it. It is called by the session with session.mu held.
+func (c *Consumer) setup(ch *amqp091.Channel) error {
+	confirms, err := newConfirmChannel(ch)
+	if err != nil {
+		return fmt.Errorf("failed to put the channel into confirm mode: %w", err)
+	}
+	c.confirms = confirms
+
+	for _, sub := range c.subs {
+		if err := c.subscribe(confirms, sub); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// subscribe declares a subscription's topology on the channel and starts dispatching
+// its deliveries.
+func (c *Consumer) subscribe(confirms *confirmChannel, sub *subscription) error {
+	ch := confirms.ch
+	if err := DeclareQueue(ch, sub.exchange, sub.queueName, sub.routingKey, c.retry); err != nil {
+		return err
+	}
+
+	// A non-global QoS applies to the consumers started after it on the channel, so each
+	// subscription gets its own prefetch limit.
+	if err := ch.Qos(sub.concurrency.prefetch(), 0, false); err != nil {
+		return fmt.Errorf("failed to set prefetch for queue %s: %w", sub.queueName, err)
+	}
+
+	msgs, err := ch.Consume(
+		sub.queueName,   // queue
+		sub.consumerTag, // consumer
+		false,           // auto-ack is false, we will manually acknowledge
+		false,           // exclusive
+		false,           // no-local
+		false,           // no-wait
+		nil,             // args
+	)
+	if err != nil {
+		return fmt.Errorf("failed to register a consumer on queue %s: %w", sub.queueName, err)
+	}
+
+	go c.dispatch(confirms, sub, msgs)
+	return nil
+}
+
+// dispatch passes deliveries to the subscription's workers until the channel closes or
+// the subscription's context is cancelled.
+func (c *Consumer) dispatch(confirms *confirmChannel, sub *subscription, msgs <-chan amqp091.Delivery) {
+	pool := newWorkerPool(sub.concurrency, func(msg amqp091.Delivery) {
+		c.handle(confirms, sub, msg)
+	})
+
+	for {
+		select {
+		case <-sub.ctx.Done():
+			log.Println("Consumer shutting down...")
+			// Let in-flight handlers finish and ack before the connection closes.
+			pool.stop()
+			c.Close()
+			return
+		case msg, ok := <-msgs:
+			if !ok {
+				// The session reconnects and starts a new dispatcher for this queue.
+				log.Printf("Message channel for queue '%s' closed. Waiting for reconnection.", sub.queueName)
+				pool.stop()
+				return
+			}
+			pool.submit(msg)
+		}
+	}
+}
+
+// handle passes one delivery to the subscription's handler and acks it, or retries it
+// if the handler failed.
+func (c *Consumer) handle(confirms *confirmChannel, sub *subscription, msg amqp091.Delivery) {
+	// Bodies may carry personal data, so only the envelope is logged.
+	log.Printf("Received message %s with routing key %s", msg.MessageId, msg.RoutingKey)
+	if err := sub.handler(sub.ctx, msg); err != nil {
+		c.handleFailure(confirms, sub, msg, err)
+	} else if err := msg.Ack(false); err != nil {
+		// The channel closed after processing; the broker will redeliver the message.
+		log.Printf("WARNING: Failed to ack message %s: %v", msg.MessageId, err)
+	}
+}
+
+// handleFailure retries a message whose handler failed, or dead-letters it if it must
+// not be retried.
+func (c *Consumer) handleFailure(confirms *confirmChannel, sub *subscription, msg amqp091.Delivery, handlerErr error) {
+	attempt := RetryCount(msg.Headers) + 1
+
+	// Step 1: Dead-letter the message if retrying cannot help.
+	if IsPermanent(handlerErr) || attempt >= c.retry.maxAttempts() {
+		log.Printf("ERROR: Giving up on message %s from queue '%s' after %d attempt(s): %v. Dead-lettering.", msg.MessageId, sub.queueName, attempt, handlerErr)
+		if err := msg.Nack(false, false); err != nil {
+			log.Printf("WARNING: Failed to nack message %s: %v", msg.MessageId, err)
+		}
+		return
+	}
+
+	// Step 2: Republish it to the delay queue for this attempt.
+	delay := c.retry.delay(attempt)
+	log.Printf("Error processing message %s (attempt %d): %v. Retrying in %s.", msg.MessageId, attempt, handlerErr, delay)
+
+	if err := c.publishRetry(confirms, sub, msg, attempt, delay); err != nil {
+		// Without a confirmed retry, fall back to an immediate redelivery. If the retry
+		// copy did reach the delay queue, the message is handled twice, which consumers
+		// already tolerate by deduplicating on message ID.
+		log.Printf("WARNING: Failed to schedule retry of message %s: %v. Requeueing.", msg.MessageId, err)
+		if err := msg.Nack(false, true); err != nil {
+			log.Printf("WARNING: Failed to nack message %s: %v", msg.MessageId, err)
+		}
+		return
+	}
+
+	// Step 3: Ack the original only now that the broker holds the retry copy.
+	if err := msg.Ack(false); err != nil {
+		log.Printf("WARNING: Failed to ack message %s: %v", msg.MessageId, err)
+	}
+}
+
+// publishRetry publishes a copy of msg to the delay queue for attempt and waits for the
+// broker to confirm it. It fails if the broker nacks the copy or cannot route it.
+func (c *Consumer) publishRetry(confirms *confirmChannel, sub *subscription, msg amqp091.Delivery, attempt int, delay time.Duration) error {
+	headers := amqp091.Table{}
+	for k, v := range msg.Headers {
+		headers[k] = v
+	}
+	headers[RetryCountHeader] = int32(attempt)
+	if _, ok := headers[OriginalExchangeHeader]; !ok {
+		headers[OriginalExchangeHeader] = msg.Exchange
+		headers[OriginalRoutingKeyHeader] = msg.RoutingKey
+	}
+
+	// Confirms are tracked by message ID, so a message published without one is given one.
+	messageID := msg.MessageId
+	if messageID == "" {
+		generated, err := newMessageID()
+		if err != nil {
+			return fmt.Errorf("failed to generate a message ID: %w", err)
+		}
+		messageID = generated
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.confirmTimeout)
+	defer cancel()
+	// The default exchange routes by queue name.
+	queue := RetryQueueName(sub.queueName, delay)
+	returned, acked, err := confirms.publish(ctx, "", queue, amqp091.Publishing{
+		Headers:       headers,
+		ContentType:   msg.ContentType,
+		DeliveryMode:  amqp091.Persistent,
+		CorrelationId: msg.CorrelationId,
+		MessageId:     messageID,
+		Timestamp:     msg.Timestamp,
+		Type:          msg.Type,
+		AppId:         msg.AppId,
+		Body:          msg.Body,
+	})
+	if err != nil {
+		return err
+	}
+	if returned != nil {
+		return fmt.Errorf("%w: delay queue %s: %s", ErrUnroutable, queue, returned.ReplyText)
+	}
+	if !acked {
+		return fmt.Errorf("%w: delay queue %s", ErrNacked, queue)
+	}
+	return nil
+}
+
+// IsConnected reports whether the consumer currently has a connection to RabbitMQ.
+func (c *Consumer) IsConnected() bool {
+	return c.session.isConnected()
+}
+
+// Close gracefully closes the channel and connection.
+func (c *Consumer) Close() {
+	c.session.close()
+}
@@ -0,0 +1,167 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rabbitmq/amqp091-go"
+)
+
+// fakeAcknowledger records how a delivery was settled.
+type fakeAcknowledger struct {
+	mu      sync.Mutex
+	acks    int
+	nacks   int
+	requeue bool
+}
+
+func (a *fakeAcknowledger) Ack(tag uint64, multiple bool) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.acks++
+	return nil
+}
+
+func (a *fakeAcknowledger) Nack(tag uint64, multiple, requeue bool) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.nacks++
+	a.requeue = requeue
+	return nil
+}
+
+func (a *fakeAcknowledger) Reject(tag uint64, requeue bool) error {
+	return a.Nack(tag, false, requeue)
+}
+
+func TestHandleFailure(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 3, InitialDelay: time.Second}
+	failing := errors.New("anchor unavailable")
+
+	tests := []struct {
+		name       string
+		handlerErr error
+		retryCount int32
+		broker     func(f *fakeChannel, p fakePublish)
+		// wantRetry is the x-retry-count of the copy published to the delay queue, or 0
+		// if no copy must be published.
+		wantRetry int32
+		wantAck   bool
+		// wantNack and wantRequeue describe the nack of the original, if any.
+		wantNack    bool
+		wantRequeue bool
+	}{
+		{name: "handled", broker: ackAll, wantAck: true},
+		{name: "retry confirmed", handlerErr: failing, broker: ackAll, wantRetry: 1, wantAck: true},
+		{name: "later retry confirmed", handlerErr: failing, retryCount: 1, broker: ackAll, wantRetry: 2, wantAck: true},
+		{name: "retry nacked", handlerErr: failing, broker: nackAll, wantRetry: 1, wantNack: true, wantRequeue: true},
+		{name: "retry unconfirmed", handlerErr: failing, broker: dropAll, wantRetry: 1, wantNack: true, wantRequeue: true},
+		{name: "retry unroutable", handlerErr: failing, broker: returnAll, wantRetry: 1, wantNack: true, wantRequeue: true},
+		{name: "last attempt", handlerErr: failing, retryCount: 2, broker: ackAll, wantNack: true},
+		{name: "permanent", handlerErr: Permanent(failing), broker: ackAll, wantNack: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, confirms := newFakeChannel(t, tt.broker)
+			c := &Consumer{retry: policy, confirmTimeout: 50 * time.Millisecond}
+			sub := &subscription{
+				ctx:       context.Background(),
+				queueName: "analytics",
+				handler: func(ctx context.Context, msg amqp091.Delivery) error {
+					return tt.handlerErr
+				},
+			}
+
+			ack := &fakeAcknowledger{}
+			headers := amqp091.Table{"x-trace": "abc"}
+			if tt.retryCount > 0 {
+				headers[RetryCountHeader] = tt.retryCount
+			}
+			c.handle(confirms, sub, amqp091.Delivery{
+				Acknowledger: ack,
+				Headers:      headers,
+				MessageId:    "m1",
+				Exchange:     "transaction_events",
+				RoutingKey:   "transaction.completed",
+				Body:         []byte(`{}`),
+			})
+
+			select {
+			case p := <-f.sent:
+				if tt.wantRetry == 0 {
+					t.Fatalf("published %+v, want no retry", p)
+				}
+				wantQueue := RetryQueueName("analytics", policy.delay(int(tt.wantRetry)))
+				if p.exchange != "" || p.routingKey != wantQueue {
+					t.Errorf("retry published to %q/%q, want the default exchange/%q", p.exchange, p.routingKey, wantQueue)
+				}
+				if got := RetryCount(p.msg.Headers); got != int(tt.wantRetry) {
+					t.Errorf("retry count = %d, want %d", got, tt.wantRetry)
+				}
+				if exchange, routingKey, ok := DeadLetterOrigin(amqp091.Delivery{Headers: p.msg.Headers}); !ok || exchange != "transaction_events" || routingKey != "transaction.completed" {
+					t.Errorf("retry origin = %q/%q (%v), want transaction_events/transaction.completed", exchange, routingKey, ok)
+				}
+				if p.msg.Headers["x-trace"] != "abc" || p.msg.MessageId != "m1" {
+					t.Errorf("retry lost the original's headers or ID: %+v", p.msg)
+				}
+			default:
+				if tt.wantRetry != 0 {
+					t.Fatalf("nothing published, want a retry")
+				}
+			}
+
+			if got := ack.acks == 1; got != tt.wantAck {
+				t.Errorf("acked %d time(s), want acked = %v", ack.acks, tt.wantAck)
+			}
+			if got := ack.nacks == 1; got != tt.wantNack {
+				t.Errorf("nacked %d time(s), want nacked = %v", ack.nacks, tt.wantNack)
+			}
+			if tt.wantNack && ack.requeue != tt.wantRequeue {
+				t.Errorf("requeue = %v, want %v", ack.requeue, tt.wantRequeue)
+			}
+		})
+	}
+}
+
+func TestHandleFailureDeadLettersAfterMaxAttempts(t *testing.T) {
+	f, confirms := newFakeChannel(t, ackAll)
+	c := &Consumer{retry: RetryPolicy{MaxAttempts: 3, InitialDelay: time.Second}, confirmTimeout: 50 * time.Millisecond}
+	sub := &subscription{
+		ctx:       context.Background(),
+		queueName: "analytics",
+		handler: func(ctx context.Context, msg amqp091.Delivery) error {
+			return fmt.Errorf("attempt %d failed", RetryCount(msg.Headers)+1)
+		},
+	}
+
+	// Feed each retry copy back in, as the delay queue does once its TTL expires.
+	msg := amqp091.Delivery{MessageId: "m1", Exchange: "transaction_events", RoutingKey: "transaction.completed"}
+	for attempt := 1; ; attempt++ {
+		ack := &fakeAcknowledger{}
+		msg.Acknowledger = ack
+		c.handle(confirms, sub, msg)
+
+		select {
+		case p := <-f.sent:
+			if ack.acks != 1 {
+				t.Fatalf("attempt %d: original acked %d time(s) after its retry was confirmed, want 1", attempt, ack.acks)
+			}
+			msg = amqp091.Delivery{MessageId: p.msg.MessageId, Headers: p.msg.Headers, Exchange: "", RoutingKey: "analytics"}
+			continue
+		default:
+		}
+
+		if attempt != 3 {
+			t.Fatalf("dead-lettered on attempt %d, want 3", attempt)
+		}
+		if ack.nacks != 1 || ack.requeue || ack.acks != 0 {
+			t.Errorf("last attempt: acks %d, nacks %d, requeue %v; want a nack without requeue", ack.acks, ack.nacks, ack.requeue)
+		}
+		return
+	}
+}
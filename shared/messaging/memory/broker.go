@@ -0,0 +1,284 @@
+/**
+ * @description
+ * This package provides an in-process message broker for tests. It implements the
+ * publisher interfaces used by the services and the Consumer's StartConsumer method, so
+ * the same handlers wired in a service's main.go can be exercised without RabbitMQ.
+ *
+ * Key features:
+ * - Topic exchanges with RabbitMQ's routing key patterns (`*` matches one word, `#`
+ *   matches zero or more), and mandatory publishing: a message that no queue is bound
+ *   to receive fails with messaging.ErrUnroutable.
+ * - Messages are handed to handlers as amqp091.Delivery values with the same
+ *   properties and headers the real publisher sets.
+ * - Drain delivers messages one at a time in publish order, retrying failed messages
+ *   immediately according to the broker's RetryPolicy and dead-lettering them once it
+ *   is exhausted or the error is permanent, as the real consumer does.
+ * - Queues without a consumer keep their messages, so tests can inspect what was
+ *   published to them.
+ *
+ * @dependencies
+ * - "context", "fmt", "strings", "sync", "time"
+ * - "github.com/google/uuid": For message IDs.
+ * - "github.com/rabbitmq/amqp091-go": For the deliveries handed to handlers.
+ * - "transfa/shared/messaging": For the message, handler and retry types.
+ */
+package memory
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rabbitmq/amqp091-go"
+	"transfa/shared/messaging"
+)
+
+// Broker is an in-process message broker. Its zero value is not usable; use NewBroker.
+type Broker struct {
+	retry messaging.RetryPolicy
+
+	mu       sync.Mutex
+	bindings []binding
+	queues   map[string]*queue
+	// pending holds the deliveries not yet handed to a consumer, in publish order.
+	pending []pendingDelivery
+}
+
+// binding routes messages published to exchange with a matching routing key to queue.
+type binding struct {
+	exchange string
+	pattern  string
+	queue    string
+}
+
+// queue holds the messages of a queue that has no consumer, and the consumer of one
+// that has.
+type queue struct {
+	ctx         context.Context
+	handler     messaging.MessageHandler
+	messages    []amqp091.Delivery
+	deadLetters []amqp091.Delivery
+}
+
+// pendingDelivery is a delivery waiting for the consumer of its queue.
+type pendingDelivery struct {
+	queue string
+	msg   amqp091.Delivery
+}
+
+// NewBroker creates an empty broker that retries failed messages according to retry.
+func NewBroker(retry messaging.RetryPolicy) *Broker {
+	return &Broker{
+		retry:  retry,
+		queues: make(map[string]*queue),
+	}
+}
+
+// Bind declares queue and binds it to exchange with routingKey, which may be a pattern.
+// It is idempotent.
+func (b *Broker) Bind(exchange, routingKey, queueName string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.bind(exchange, routingKey, queueName)
+}
+
+// bind is Bind with b.mu held.
+func (b *Broker) bind(exchange, routingKey, queueName string) *queue {
+	q, ok := b.queues[queueName]
+	if !ok {
+		q = &queue{}
+		b.queues[queueName] = q
+	}
+	for _, existing := range b.bindings {
+		if existing == (binding{exchange, routingKey, queueName}) {
+			return q
+		}
+	}
+	b.bindings = append(b.bindings, binding{exchange, routingKey, queueName})
+	return q
+}
+
+// StartConsumer binds queueName to exchange with routingKey and registers handler as its
+// consumer. Messages already in the queue are handed to it by the next Drain. The
+// concurrency is ignored: Drain handles one message at a time, in publish order.
+func (b *Broker) StartConsumer(ctx context.Context, exchange, queueName, routingKey, consumerTag string, handler messaging.MessageHandler, concurrency messaging.Concurrency) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	q := b.bind(exchange, routingKey, queueName)
+	if q.handler != nil {
+		return fmt.Errorf("queue %s already has a consumer", queueName)
+	}
+	q.ctx = ctx
+	q.handler = handler
+	for _, msg := range q.messages {
+		b.pending = append(b.pending, pendingDelivery{queue: queueName, msg: msg})
+	}
+	q.messages = nil
+	return nil
+}
+
+// Publish publishes a JSON message with a random message ID.
+func (b *Broker) Publish(ctx context.Context, body []byte, exchange, routingKey string) error {
+	return b.PublishMessage(ctx, exchange, routingKey, messaging.Message{Body: body})
+}
+
+// PublishMessage routes msg to every queue bound to exchange with a matching pattern.
+// It returns an error wrapping messaging.ErrUnroutable if there is none.
+func (b *Broker) PublishMessage(ctx context.Context, exchange, routingKey string, msg messaging.Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if msg.ID == "" {
+		msg.ID = uuid.NewString()
+	}
+	if msg.Timestamp.IsZero() {
+		msg.Timestamp = time.Now().UTC()
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	routed := false
+	for _, bnd := range b.bindings {
+		if bnd.exchange != exchange || !matchRoutingKey(bnd.pattern, routingKey) {
+			continue
+		}
+		routed = true
+		b.enqueue(bnd.queue, delivery(exchange, routingKey, msg))
+	}
+	if !routed {
+		return fmt.Errorf("%w: exchange %s, routing key %s", messaging.ErrUnroutable, exchange, routingKey)
+	}
+	return nil
+}
+
+// enqueue adds msg to a queue, with b.mu held.
+func (b *Broker) enqueue(queueName string, msg amqp091.Delivery) {
+	q := b.queues[queueName]
+	if q.handler == nil {
+		q.messages = append(q.messages, msg)
+		return
+	}
+	b.pending = append(b.pending, pendingDelivery{queue: queueName, msg: msg})
+}
+
+// Drain hands pending messages to their consumers until none are left, including the
+// messages those consumers publish and the retries of failed ones.
+func (b *Broker) Drain() {
+	for {
+		b.mu.Lock()
+		if len(b.pending) == 0 {
+			b.mu.Unlock()
+			return
+		}
+		next := b.pending[0]
+		b.pending = b.pending[1:]
+		q := b.queues[next.queue]
+		b.mu.Unlock()
+
+		// The lock is released so that the handler can publish.
+		err := q.handler(q.ctx, next.msg)
+		if err != nil {
+			b.handleFailure(next, q, err)
+		}
+	}
+}
+
+// handleFailure retries a message whose handler failed, or dead-letters it, following
+// the rules of messaging.Consumer.
+func (b *Broker) handleFailure(failed pendingDelivery, q *queue, handlerErr error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	attempt := messaging.RetryCount(failed.msg.Headers) + 1
+	maxAttempts := b.retry.MaxAttempts
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+	if messaging.IsPermanent(handlerErr) || attempt >= maxAttempts {
+		q.deadLetters = append(q.deadLetters, failed.msg)
+		return
+	}
+
+	retry := failed.msg
+	retry.Headers = amqp091.Table{}
+	for k, v := range failed.msg.Headers {
+		retry.Headers[k] = v
+	}
+	retry.Headers[messaging.RetryCountHeader] = int32(attempt)
+	b.pending = append(b.pending, pendingDelivery{queue: failed.queue, msg: retry})
+}
+
+// Messages returns the messages held by a queue that has no consumer.
+func (b *Broker) Messages(queueName string) []amqp091.Delivery {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if q, ok := b.queues[queueName]; ok {
+		return append([]amqp091.Delivery(nil), q.messages...)
+	}
+	return nil
+}
+
+// DeadLetters returns the messages a queue's consumer gave up on.
+func (b *Broker) DeadLetters(queueName string) []amqp091.Delivery {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if q, ok := b.queues[queueName]; ok {
+		return append([]amqp091.Delivery(nil), q.deadLetters...)
+	}
+	return nil
+}
+
+// IsConnected reports true: the broker is always available.
+func (b *Broker) IsConnected() bool {
+	return true
+}
+
+// Close does nothing. It lets the broker stand in for a publisher or consumer.
+func (b *Broker) Close() {}
+
+// delivery returns the delivery of msg as RabbitMQ would hand it to a consumer.
+func delivery(exchange, routingKey string, msg messaging.Message) amqp091.Delivery {
+	return amqp091.Delivery{
+		Headers:       msg.Headers,
+		ContentType:   "application/json",
+		DeliveryMode:  amqp091.Persistent,
+		CorrelationId: msg.CorrelationID,
+		MessageId:     msg.ID,
+		Timestamp:     msg.Timestamp,
+		Type:          msg.Type,
+		AppId:         msg.AppID,
+		Exchange:      exchange,
+		RoutingKey:    routingKey,
+		Body:          msg.Body,
+	}
+}
+
+// matchRoutingKey reports whether routingKey matches a topic binding pattern.
+func matchRoutingKey(pattern, routingKey string) bool {
+	return matchWords(strings.Split(pattern, "."), strings.Split(routingKey, "."))
+}
+
+// matchWords matches the words of a pattern against the words of a routing key.
+func matchWords(pattern, key []string) bool {
+	if len(pattern) == 0 {
+		return len(key) == 0
+	}
+	switch pattern[0] {
+	case "#":
+		for i := 0; i <= len(key); i++ {
+			if matchWords(pattern[1:], key[i:]) {
+				return true
+			}
+		}
+		return false
+	case "*":
+		return len(key) > 0 && matchWords(pattern[1:], key[1:])
+	default:
+		return len(key) > 0 && pattern[0] == key[0] && matchWords(pattern[1:], key[1:])
+	}
+}
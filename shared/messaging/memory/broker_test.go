@@ -0,0 +1,126 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/rabbitmq/amqp091-go"
+	"transfa/shared/messaging"
+)
+
+func TestMatchRoutingKey(t *testing.T) {
+	tests := []struct {
+		pattern, key string
+		want         bool
+	}{
+		{"user.created", "user.created", true},
+		{"user.created", "user.deleted", false},
+		{"user.*", "user.created", true},
+		{"user.*", "user.created.v2", false},
+		{"customer.#", "customer", true},
+		{"customer.#", "customer.verification.rejected", true},
+		{"#.rejected", "customer.verification.rejected", true},
+		{"#", "anything.at.all", true},
+	}
+	for _, tt := range tests {
+		if got := matchRoutingKey(tt.pattern, tt.key); got != tt.want {
+			t.Errorf("matchRoutingKey(%q, %q) = %v, want %v", tt.pattern, tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestPublishRoutesToBoundQueues(t *testing.T) {
+	b := NewBroker(messaging.RetryPolicy{})
+	b.Bind("customer_events", "customer.verified", "account")
+	b.Bind("customer_events", "customer.#", "audit")
+
+	msg := messaging.Message{ID: "m1", Type: "customer.verified", Body: []byte(`{}`)}
+	if err := b.PublishMessage(context.Background(), "customer_events", "customer.verified", msg); err != nil {
+		t.Fatalf("PublishMessage() error = %v", err)
+	}
+
+	for _, queue := range []string{"account", "audit"} {
+		msgs := b.Messages(queue)
+		if len(msgs) != 1 {
+			t.Fatalf("queue %s holds %d messages, want 1", queue, len(msgs))
+		}
+		if msgs[0].MessageId != "m1" || msgs[0].Type != "customer.verified" || msgs[0].RoutingKey != "customer.verified" {
+			t.Errorf("queue %s got delivery %+v", queue, msgs[0])
+		}
+	}
+}
+
+func TestPublishUnroutable(t *testing.T) {
+	b := NewBroker(messaging.RetryPolicy{})
+	b.Bind("customer_events", "customer.verified", "account")
+
+	err := b.Publish(context.Background(), []byte(`{}`), "customer_events", "customer.verification.rejected")
+	if !errors.Is(err, messaging.ErrUnroutable) {
+		t.Fatalf("Publish() error = %v, want ErrUnroutable", err)
+	}
+}
+
+func TestDrainRetriesThenDeadLetters(t *testing.T) {
+	b := NewBroker(messaging.RetryPolicy{MaxAttempts: 3})
+
+	calls := 0
+	handler := func(ctx context.Context, msg amqp091.Delivery) error {
+		calls++
+		return errors.New("anchor unavailable")
+	}
+	if err := b.StartConsumer(context.Background(), "ex", "q", "rk", "tag", handler, messaging.Concurrency{}); err != nil {
+		t.Fatalf("StartConsumer() error = %v", err)
+	}
+	if err := b.Publish(context.Background(), []byte(`{}`), "ex", "rk"); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+
+	b.Drain()
+
+	if calls != 3 {
+		t.Errorf("handler called %d times, want 3", calls)
+	}
+	if got := len(b.DeadLetters("q")); got != 1 {
+		t.Errorf("%d dead letters, want 1", got)
+	}
+}
+
+func TestDrainDeadLettersPermanentErrors(t *testing.T) {
+	b := NewBroker(messaging.RetryPolicy{MaxAttempts: 5})
+
+	calls := 0
+	handler := func(ctx context.Context, msg amqp091.Delivery) error {
+		calls++
+		return messaging.Permanent(errors.New("malformed payload"))
+	}
+	b.StartConsumer(context.Background(), "ex", "q", "rk", "tag", handler, messaging.Concurrency{})
+	b.Publish(context.Background(), []byte(`{}`), "ex", "rk")
+
+	b.Drain()
+
+	if calls != 1 {
+		t.Errorf("handler called %d times, want 1", calls)
+	}
+	if got := len(b.DeadLetters("q")); got != 1 {
+		t.Errorf("%d dead letters, want 1", got)
+	}
+}
+
+func TestDrainDeliversMessagesPublishedByHandlers(t *testing.T) {
+	b := NewBroker(messaging.RetryPolicy{})
+	b.Bind("ex", "second", "out")
+
+	first := func(ctx context.Context, msg amqp091.Delivery) error {
+		return b.Publish(ctx, msg.Body, "ex", "second")
+	}
+	b.StartConsumer(context.Background(), "ex", "in", "first", "tag", first, messaging.Concurrency{})
+	b.Publish(context.Background(), []byte(`{"n":1}`), "ex", "first")
+
+	b.Drain()
+
+	msgs := b.Messages("out")
+	if len(msgs) != 1 || string(msgs[0].Body) != `{"n":1}` {
+		t.Fatalf("queue out holds %v, want the forwarded message", msgs)
+	}
+}
@@ -0,0 +1,200 @@
+/**
+ * @description
+ * This file provides the RabbitMQ publisher used by every Transfa service that emits
+ * events. It handles the connection, channel management and message publishing logic,
+ * abstracting away the complexities of the AMQP protocol from the core application.
+ *
+ * Key features:
+ * - Publishes in confirm mode and waits for the broker to ack each message, so a nil
+ *   error means the broker has taken responsibility for it.
+ * - Publishes with the mandatory flag, so a message that no queue is bound to receive
+ *   fails with ErrUnroutable instead of being dropped silently.
+ * - Marks messages persistent, so they survive a broker restart in durable queues.
+ * - Recovers the connection automatically. While the publisher is reconnecting, Publish
+ *   blocks until the channel is back or the caller's context ends, rather than failing.
+ *
+ * @dependencies
+ * - "context": For context-aware publishing.
+ * - "crypto/rand", "encoding/hex": For generating message IDs.
+ * - "errors", "fmt": For error handling.
+ * - "log": For logging recovery and shutdown.
+ * - "time": For message timestamps.
+ * - "github.com/rabbitmq/amqp091-go": The official Go client for RabbitMQ.
+ */
+package messaging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/rabbitmq/amqp091-go"
+)
+
+var (
+	// ErrUnroutable is returned when the broker returns a message because no queue is
+	// bound to receive it.
+	ErrUnroutable = errors.New("message could not be routed to any queue")
+	// ErrNacked is returned when the broker negatively acknowledges a message.
+	ErrNacked = errors.New("message was not acknowledged by the broker")
+)
+
+// Publisher publishes messages to RabbitMQ exchanges.
+type Publisher struct {
+	session *session
+	// confirms wraps the session's current channel and is guarded by session.mu.
+	confirms *confirmChannel
+}
+
+// NewPublisher creates and returns a new RabbitMQ publisher.
+// It establishes a connection and opens a channel in confirm mode.
+func NewPublisher(url string) (*Publisher, error) {
+	p := &Publisher{}
+	s, err := newSession(url, "publisher", p.setup)
+	if err != nil {
+		return nil, err
+	}
+	p.session = s
+	return p, nil
+}
+
+// setup puts each newly opened channel into confirm mode.
+// It is called by the session with session.mu held.
+func (p *Publisher) setup(ch *amqp091.Channel) error {
+	confirms, err := newConfirmChannel(ch)
+	if err != nil {
+		return fmt.Errorf("failed to put the channel into confirm mode: %w", err)
+	}
+	p.confirms = confirms
+	return nil
+}
+
+// Message is a message to publish. Only Body is required.
+type Message struct {
+	// ID is the message ID. A random one is generated if it is empty. Consumers
+	// deduplicate by message ID, so a message published again, for example by an outbox
+	// relay after a crash, must keep its original ID.
+	ID            string
+	Type          string
+	CorrelationID string
+	// AppID names the publishing service.
+	AppID     string
+	Timestamp time.Time
+	Headers   amqp091.Table
+	// Body is the JSON message body.
+	Body []byte
+}
+
+// Publish sends a persistent JSON message to an exchange with the given routing key and
+// waits for the broker to confirm it. It ensures the exchange exists before publishing.
+// If the connection is down, Publish waits for it to be recovered until ctx is done.
+// It returns an error wrapping ErrUnroutable if no queue received the message, and
+// ErrNacked if the broker rejected it. The message is given a random message ID.
+func (p *Publisher) Publish(ctx context.Context, body []byte, exchange, routingKey string) error {
+	return p.PublishMessage(ctx, exchange, routingKey, Message{Body: body})
+}
+
+// PublishMessage is like Publish, but publishes msg with its ID, properties and headers.
+func (p *Publisher) PublishMessage(ctx context.Context, exchange, routingKey string, msg Message) error {
+	if msg.ID == "" {
+		messageID, err := newMessageID()
+		if err != nil {
+			return fmt.Errorf("failed to generate a message ID: %w", err)
+		}
+		msg.ID = messageID
+	}
+	if msg.Timestamp.IsZero() {
+		msg.Timestamp = time.Now().UTC()
+	}
+
+	for {
+		confirms, err := p.currentConfirms(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to publish a message: %w", err)
+		}
+
+		err = publish(ctx, confirms, exchange, routingKey, msg)
+		if !errors.Is(err, amqp091.ErrClosed) {
+			return err
+		}
+
+		// The channel closed before the message was confirmed; wait for the session to
+		// reconnect and publish it again.
+		log.Printf("WARNING: RabbitMQ channel closed while publishing to %s; retrying after reconnect", exchange)
+		p.session.invalidate(confirms.ch)
+	}
+}
+
+// currentConfirms returns the confirm-mode wrapper of the current channel, waiting
+// while the session reconnects.
+func (p *Publisher) currentConfirms(ctx context.Context) (*confirmChannel, error) {
+	for {
+		if _, err := p.session.currentChannel(ctx); err != nil {
+			return nil, err
+		}
+
+		var confirms *confirmChannel
+		p.session.withChannel(func(ch *amqp091.Channel) error {
+			if ch != nil {
+				confirms = p.confirms
+			}
+			return nil
+		})
+		if confirms != nil {
+			return confirms, nil
+		}
+	}
+}
+
+// publish declares the exchange, publishes one message and checks the broker's verdict.
+func publish(ctx context.Context, confirms *confirmChannel, exchange, routingKey string, msg Message) error {
+	if err := DeclareExchange(confirms.ch, exchange); err != nil {
+		return err
+	}
+
+	returned, acked, err := confirms.publish(ctx, exchange, routingKey, amqp091.Publishing{
+		Headers:       msg.Headers,
+		ContentType:   "application/json",
+		DeliveryMode:  amqp091.Persistent,
+		CorrelationId: msg.CorrelationID,
+		MessageId:     msg.ID,
+		Timestamp:     msg.Timestamp,
+		Type:          msg.Type,
+		AppId:         msg.AppID,
+		Body:          msg.Body,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to publish a message: %w", err)
+	}
+	if returned != nil {
+		return fmt.Errorf("%w: exchange %s, routing key %s: %s", ErrUnroutable, exchange, routingKey, returned.ReplyText)
+	}
+	if !acked {
+		return fmt.Errorf("%w: exchange %s, routing key %s", ErrNacked, exchange, routingKey)
+	}
+	return nil
+}
+
+// newMessageID returns a random 128-bit message ID in hex.
+func newMessageID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// IsConnected reports whether the publisher currently has a connection to RabbitMQ.
+func (p *Publisher) IsConnected() bool {
+	return p.session.isConnected()
+}
+
+// Close gracefully closes the RabbitMQ channel and connection.
+func (p *Publisher) Close() {
+	p.session.close()
+	log.Println("RabbitMQ publisher connection closed.")
+}
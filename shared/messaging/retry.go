@@ -0,0 +1,166 @@
+/**
+ * @description
+ * This file defines how consumers retry messages whose handler failed. A failed message
+ * is republished to a delay queue whose TTL dead-letters it back to the consumer's
+ * queue, so retries back off exponentially without blocking the queue. Once a message
+ * has been handled MaxAttempts times, or its handler returned a permanent error, it is
+ * rejected and dead-lettered to the queue's dead-letter queue for inspection.
+ *
+ * Key features:
+ * - The number of retries so far is carried in the x-retry-count header.
+ * - The exchange and routing key a message was first published with are carried in
+ *   headers once it is retried, so a dead-lettered message can be replayed to them.
+ * - Delay queues are named after their delay, so changing the policy declares new
+ *   queues instead of conflicting with existing ones.
+ * - Permanent marks errors that no retry can fix, such as malformed payloads.
+ *
+ * @dependencies
+ * - "errors", "fmt", "time"
+ * - "github.com/rabbitmq/amqp091-go": The official Go client for RabbitMQ.
+ */
+package messaging
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rabbitmq/amqp091-go"
+)
+
+const (
+	// RetryCountHeader holds the number of times a message has been retried.
+	RetryCountHeader = "x-retry-count"
+	// OriginalExchangeHeader and OriginalRoutingKeyHeader hold the exchange and routing
+	// key a retried message was first published with.
+	OriginalExchangeHeader   = "x-original-exchange"
+	OriginalRoutingKeyHeader = "x-original-routing-key"
+	// maxRetryDelay caps the exponential backoff.
+	maxRetryDelay = time.Hour
+)
+
+// RetryPolicy controls how a consumer retries messages whose handler failed.
+type RetryPolicy struct {
+	// MaxAttempts is the number of times a message is handled before it is
+	// dead-lettered. Values below 1 are treated as 1, which disables retries.
+	MaxAttempts int
+	// InitialDelay is the delay before the first retry. It doubles for each later
+	// retry, up to one hour.
+	InitialDelay time.Duration
+}
+
+// maxAttempts returns MaxAttempts, at least 1.
+func (p RetryPolicy) maxAttempts() int {
+	if p.MaxAttempts < 1 {
+		return 1
+	}
+	return p.MaxAttempts
+}
+
+// delay returns how long to wait before retrying a message that has failed attempt
+// times, counting from 1.
+func (p RetryPolicy) delay(attempt int) time.Duration {
+	delay := p.InitialDelay
+	if delay <= 0 {
+		delay = time.Second
+	}
+	for i := 1; i < attempt && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+	return delay
+}
+
+// DeadLetterExchangeName returns the name of a queue's dead-letter exchange.
+func DeadLetterExchangeName(queueName string) string {
+	return queueName + ".dlx"
+}
+
+// DeadLetterQueueName returns the name of the queue holding a queue's dead-lettered messages.
+func DeadLetterQueueName(queueName string) string {
+	return queueName + ".dlq"
+}
+
+// RetryQueueName returns the name of the delay queue that holds a queue's messages
+// for delay before returning them to it.
+func RetryQueueName(queueName string, delay time.Duration) string {
+	return fmt.Sprintf("%s.retry.%s", queueName, delay)
+}
+
+// permanentError marks an error that retrying cannot fix.
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent wraps err so that the consumer dead-letters the message immediately
+// instead of retrying it. Use it for errors such as malformed or invalid payloads.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// IsPermanent reports whether err was marked with Permanent.
+func IsPermanent(err error) bool {
+	var perm *permanentError
+	return errors.As(err, &perm)
+}
+
+// RetryCount returns the value of the x-retry-count header in headers, or 0.
+func RetryCount(headers amqp091.Table) int {
+	switch v := headers[RetryCountHeader].(type) {
+	case int:
+		return v
+	case int8:
+		return int(v)
+	case int16:
+		return int(v)
+	case int32:
+		return int(v)
+	case int64:
+		return int(v)
+	case uint8:
+		return int(v)
+	case uint16:
+		return int(v)
+	case uint32:
+		return int(v)
+	default:
+		return 0
+	}
+}
+
+// DeadLetterOrigin returns the exchange and routing key a dead-lettered message was
+// first published with. It reports false if the message carries neither the headers
+// set when it was retried nor the broker's record of its rejection.
+func DeadLetterOrigin(msg amqp091.Delivery) (exchange, routingKey string, ok bool) {
+	// Step 1: Retried messages carry their original route.
+	if exchange, ok := msg.Headers[OriginalExchangeHeader].(string); ok {
+		routingKey, _ := msg.Headers[OriginalRoutingKeyHeader].(string)
+		return exchange, routingKey, true
+	}
+
+	// Step 2: A message rejected on its first attempt was dead-lettered from its queue
+	// with the route it was published with, which the broker records in x-death.
+	deaths, _ := msg.Headers["x-death"].([]interface{})
+	for _, d := range deaths {
+		death, ok := d.(amqp091.Table)
+		if !ok || death["reason"] != "rejected" {
+			continue
+		}
+		exchange, _ := death["exchange"].(string)
+		keys, _ := death["routing-keys"].([]interface{})
+		if len(keys) == 0 {
+			continue
+		}
+		routingKey, _ := keys[0].(string)
+		return exchange, routingKey, true
+	}
+	return "", "", false
+}
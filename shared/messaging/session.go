@@ -0,0 +1,232 @@
+/**
+ * @description
+ * This file implements the connection management shared by the RabbitMQ publisher and
+ * consumer. A session owns one connection and one channel and keeps them alive: when
+ * either is closed by the broker or the network, it reconnects with exponential backoff
+ * and runs a setup callback so that topology and consumers are restored.
+ *
+ * Key features:
+ * - Watches NotifyClose on both the connection and the channel.
+ * - Reconnects with exponential backoff, from 1s up to 30s between attempts.
+ * - Lets callers wait for a usable channel instead of failing while disconnected.
+ *
+ * @dependencies
+ * - "context", "errors", "fmt", "log", "sync", "time"
+ * - "github.com/rabbitmq/amqp091-go": The official Go client for RabbitMQ.
+ */
+package messaging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/rabbitmq/amqp091-go"
+)
+
+const (
+	initialReconnectDelay = time.Second
+	maxReconnectDelay     = 30 * time.Second
+)
+
+// ErrClosed is returned when a publisher or consumer is used after Close.
+var ErrClosed = errors.New("rabbitmq client is closed")
+
+// session maintains a RabbitMQ connection and channel, reconnecting when they are lost.
+type session struct {
+	url  string
+	name string
+	// setup is called with mu held each time a channel is opened, before the channel is
+	// handed out, to declare topology and restart consumers.
+	setup func(ch *amqp091.Channel) error
+
+	mu      sync.Mutex
+	conn    *amqp091.Connection
+	channel *amqp091.Channel
+	// ready is closed while the session is connected, and replaced when it disconnects.
+	ready chan struct{}
+
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// newSession connects to RabbitMQ and starts watching the connection. The first
+// connection attempt is not retried, so misconfiguration is reported at startup.
+func newSession(url, name string, setup func(ch *amqp091.Channel) error) (*session, error) {
+	s := &session{
+		url:   url,
+		name:  name,
+		setup: setup,
+		ready: make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+
+	conn, ch, err := s.connect()
+	if err != nil {
+		return nil, err
+	}
+	go s.watch(conn, ch)
+	return s, nil
+}
+
+// connect dials RabbitMQ, opens a channel, runs setup and marks the session connected.
+func (s *session) connect() (*amqp091.Connection, *amqp091.Channel, error) {
+	conn, err := amqp091.Dial(s.url)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to connect to RabbitMQ: %w", err)
+	}
+
+	ch, err := conn.Channel()
+	if err != nil {
+		conn.Close()
+		return nil, nil, fmt.Errorf("failed to open a channel: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	select {
+	case <-s.done:
+		conn.Close()
+		return nil, nil, ErrClosed
+	default:
+	}
+
+	if s.setup != nil {
+		if err := s.setup(ch); err != nil {
+			conn.Close()
+			return nil, nil, err
+		}
+	}
+
+	s.conn, s.channel = conn, ch
+	close(s.ready)
+	return conn, ch, nil
+}
+
+// watch waits for the connection or channel to close and reconnects, until the session
+// is closed.
+func (s *session) watch(conn *amqp091.Connection, ch *amqp091.Channel) {
+	for {
+		connClosed := conn.NotifyClose(make(chan *amqp091.Error, 1))
+		chanClosed := ch.NotifyClose(make(chan *amqp091.Error, 1))
+
+		var reason *amqp091.Error
+		select {
+		case <-s.done:
+			return
+		case reason = <-connClosed:
+		case reason = <-chanClosed:
+		}
+
+		select {
+		case <-s.done:
+			return
+		default:
+		}
+
+		log.Printf("WARNING: RabbitMQ %s lost its connection (%v); reconnecting", s.name, reason)
+		s.invalidate(ch)
+		conn.Close()
+
+		conn, ch = s.reconnect()
+		if conn == nil {
+			return
+		}
+	}
+}
+
+// reconnect retries connect with exponential backoff until it succeeds or the session
+// is closed, in which case it returns nil values.
+func (s *session) reconnect() (*amqp091.Connection, *amqp091.Channel) {
+	delay := initialReconnectDelay
+	for {
+		select {
+		case <-s.done:
+			return nil, nil
+		case <-time.After(delay):
+		}
+
+		conn, ch, err := s.connect()
+		if err == nil {
+			log.Printf("RabbitMQ %s reconnected.", s.name)
+			return conn, ch
+		}
+		if errors.Is(err, ErrClosed) {
+			return nil, nil
+		}
+
+		delay *= 2
+		if delay > maxReconnectDelay {
+			delay = maxReconnectDelay
+		}
+		log.Printf("WARNING: RabbitMQ %s failed to reconnect, retrying in %s: %v", s.name, delay, err)
+	}
+}
+
+// invalidate marks the session disconnected if ch is still its current channel, so that
+// callers wait for the next channel instead of reusing a dead one.
+func (s *session) invalidate(ch *amqp091.Channel) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.channel != ch || s.channel == nil {
+		return
+	}
+	s.conn, s.channel = nil, nil
+	s.ready = make(chan struct{})
+}
+
+// currentChannel returns the open channel, waiting while the session reconnects. It
+// fails only if ctx is done or the session is closed.
+func (s *session) currentChannel(ctx context.Context) (*amqp091.Channel, error) {
+	for {
+		s.mu.Lock()
+		ch, ready := s.channel, s.ready
+		s.mu.Unlock()
+
+		if ch != nil {
+			return ch, nil
+		}
+
+		select {
+		case <-ready:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-s.done:
+			return nil, ErrClosed
+		}
+	}
+}
+
+// withChannel calls fn with mu held and the current channel, or nil if the session is
+// disconnected. It serialises fn with the setup run on reconnection.
+func (s *session) withChannel(fn func(ch *amqp091.Channel) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return fn(s.channel)
+}
+
+// isConnected reports whether the session currently has an open channel.
+func (s *session) isConnected() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.channel != nil
+}
+
+// close stops reconnecting and closes the connection.
+func (s *session) close() {
+	s.closeOnce.Do(func() {
+		close(s.done)
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.conn != nil {
+			s.conn.Close()
+		}
+		s.conn, s.channel = nil, nil
+	})
+}
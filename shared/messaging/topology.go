@@ -0,0 +1,123 @@
+/**
+ * @description
+ * This file defines the RabbitMQ topology shared by every Transfa service. Declaring
+ * exchanges from a single place guarantees that publishers and consumers agree on
+ * their type and flags; RabbitMQ rejects a redeclaration with different arguments with
+ * a PRECONDITION_FAILED channel error.
+ *
+ * Key features:
+ * - All event exchanges are durable topic exchanges, so consumers can bind with exact
+ *   routing keys (e.g. `user.created`) or patterns (e.g. `customer.#`).
+ * - All consumer queues are durable, and each has its own dead-letter exchange and
+ *   queue, plus the delay queues used to retry failed messages.
+ *
+ * Adding dead-lettering changes a queue's arguments, so queues declared before it
+ * existed must be deleted once (after draining them) for the new declaration to succeed.
+ *
+ * @dependencies
+ * - "fmt"
+ * - "github.com/rabbitmq/amqp091-go": The official Go client for RabbitMQ.
+ */
+package messaging
+
+import (
+	"fmt"
+
+	"github.com/rabbitmq/amqp091-go"
+)
+
+// ExchangeKind is the type of every event exchange.
+const ExchangeKind = amqp091.ExchangeTopic
+
+// DeclareExchange declares a durable topic exchange. It is idempotent.
+func DeclareExchange(ch *amqp091.Channel, exchange string) error {
+	err := ch.ExchangeDeclare(
+		exchange,     // name
+		ExchangeKind, // type
+		true,         // durable
+		false,        // auto-deleted
+		false,        // internal
+		false,        // no-wait
+		nil,          // arguments
+	)
+	if err != nil {
+		return fmt.Errorf("failed to declare exchange %s: %w", exchange, err)
+	}
+	return nil
+}
+
+// DeclareQueue declares a durable queue and binds it to the exchange with the routing
+// key, declaring the exchange first. Rejected messages are dead-lettered to the queue's
+// dead-letter queue, and a delay queue is declared for each retry allowed by policy.
+// It is idempotent.
+func DeclareQueue(ch *amqp091.Channel, exchange, queueName, routingKey string, policy RetryPolicy) error {
+	if err := DeclareExchange(ch, exchange); err != nil {
+		return err
+	}
+
+	// Step 1: Declare the dead-letter exchange and queue.
+	dlx := DeadLetterExchangeName(queueName)
+	if err := DeclareExchange(ch, dlx); err != nil {
+		return err
+	}
+	if err := declareQueue(ch, DeadLetterQueueName(queueName), nil); err != nil {
+		return err
+	}
+	if err := bindQueue(ch, DeadLetterQueueName(queueName), "#", dlx); err != nil {
+		return err
+	}
+
+	// Step 2: Declare the queue itself and bind it.
+	if err := declareQueue(ch, queueName, amqp091.Table{"x-dead-letter-exchange": dlx}); err != nil {
+		return err
+	}
+	if err := bindQueue(ch, queueName, routingKey, exchange); err != nil {
+		return err
+	}
+
+	// Step 3: Declare a delay queue per retry. Expired messages are dead-lettered back to
+	// the queue through the default exchange.
+	for attempt := 1; attempt < policy.maxAttempts(); attempt++ {
+		delay := policy.delay(attempt)
+		args := amqp091.Table{
+			"x-message-ttl":             delay.Milliseconds(),
+			"x-dead-letter-exchange":    "",
+			"x-dead-letter-routing-key": queueName,
+		}
+		if err := declareQueue(ch, RetryQueueName(queueName, delay), args); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// declareQueue declares a durable queue with the given arguments.
+func declareQueue(ch *amqp091.Channel, queueName string, args amqp091.Table) error {
+	_, err := ch.QueueDeclare(
+		queueName, // name
+		true,      // durable
+		false,     // delete when unused
+		false,     // exclusive
+		false,     // no-wait
+		args,      // arguments
+	)
+	if err != nil {
+		return fmt.Errorf("failed to declare queue %s: %w", queueName, err)
+	}
+	return nil
+}
+
+// bindQueue binds a queue to an exchange with a routing key.
+func bindQueue(ch *amqp091.Channel, queueName, routingKey, exchange string) error {
+	err := ch.QueueBind(
+		queueName,  // queue name
+		routingKey, // routing key
+		exchange,   // exchange
+		false,      // no-wait
+		nil,        // arguments
+	)
+	if err != nil {
+		return fmt.Errorf("failed to bind queue %s to exchange %s: %w", queueName, exchange, err)
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+/**
+ * @description
+ * This package tracks where each user is in onboarding. Onboarding spans several
+ * services: Auth creates the user, Customer creates the Anchor customer and starts KYC,
+ * Notification receives Anchor's verdict and Account opens the wallet. Each of them
+ * records its step in public.onboarding_status, and the Auth service reports it.
+ *
+ * Key features:
+ * - A state machine: initiated → customer_created → kyc_pending → wallet_ready, with
+ *   kyc_rejected when Anchor rejects the user's KYC. Statuses only move forward, so a
+ *   redelivered or late event cannot undo a later step. A rejected user can be
+ *   verified again, and Anchor may still approve them after a rejection.
+ * - Advance is a single upsert that applies a transition only if it is allowed from the
+ *   stored status, so services racing each other never need a lock.
+ *
+ * @dependencies
+ * - "context", "errors", "fmt", "time"
+ * - "github.com/google/uuid": For user identifiers.
+ * - "github.com/jackc/pgx/v5", "github.com/jackc/pgx/v5/pgconn": For the status table.
+ */
+package onboarding
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Status is a step of onboarding.
+type Status string
+
+const (
+	// StatusInitiated: the user has been created and `user.created` queued.
+	StatusInitiated Status = "initiated"
+	// StatusCustomerCreated: the Anchor customer has been created.
+	StatusCustomerCreated Status = "customer_created"
+	// StatusKYCPending: KYC verification has been requested from Anchor.
+	StatusKYCPending Status = "kyc_pending"
+	// StatusKYCRejected: Anchor rejected the user's KYC details.
+	StatusKYCRejected Status = "kyc_rejected"
+	// StatusWalletReady: the user's main wallet is open. Onboarding is complete.
+	StatusWalletReady Status = "wallet_ready"
+)
+
+// transitions lists the statuses each status may advance to.
+var transitions = map[Status][]Status{
+	StatusInitiated:       {StatusCustomerCreated, StatusKYCPending, StatusKYCRejected, StatusWalletReady},
+	StatusCustomerCreated: {StatusKYCPending, StatusKYCRejected, StatusWalletReady},
+	StatusKYCPending:      {StatusKYCRejected, StatusWalletReady},
+	StatusKYCRejected:     {StatusKYCPending, StatusWalletReady},
+	StatusWalletReady:     {},
+}
+
+// ErrNotFound is returned when a user has no onboarding status.
+var ErrNotFound = errors.New("onboarding status not found")
+
+// CanAdvance reports whether a user in status from may move to status to.
+func CanAdvance(from, to Status) bool {
+	for _, next := range transitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// predecessors returns the statuses from which to may be reached.
+func predecessors(to Status) []string {
+	var from []string
+	for status := range transitions {
+		if CanAdvance(status, to) {
+			from = append(from, string(status))
+		}
+	}
+	return from
+}
+
+// State is a user's current onboarding status.
+type State struct {
+	UserID uuid.UUID `json:"user_id"`
+	Status Status    `json:"status"`
+	// Reason explains a kyc_rejected status.
+	Reason    string    `json:"reason,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// DB is the subset of pgxpool.Pool and pgx.Tx used by this package, so statuses can be
+// written in the caller's transaction.
+type DB interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// Start records that userID has started onboarding. It does nothing if the user
+// already has a status.
+func Start(ctx context.Context, db DB, userID uuid.UUID) error {
+	query := `
+        INSERT INTO public.onboarding_status (user_id, status)
+        VALUES ($1, $2)
+        ON CONFLICT (user_id) DO NOTHING
+    `
+	if _, err := db.Exec(ctx, query, userID, StatusInitiated); err != nil {
+		return fmt.Errorf("failed to start onboarding for user %s: %w", userID, err)
+	}
+	return nil
+}
+
+// Advance moves userID to status to, with an optional reason, if the state machine
+// allows it from the stored status. It reports whether the status changed; advancing a
+// user to a status they are already in or have moved past is not an error.
+func Advance(ctx context.Context, db DB, userID uuid.UUID, to Status, reason string) (bool, error) {
+	if _, ok := transitions[to]; !ok {
+		return false, fmt.Errorf("unknown onboarding status %q", to)
+	}
+
+	// Users onboarded before statuses were tracked may have no row yet.
+	query := `
+        INSERT INTO public.onboarding_status (user_id, status, reason)
+        VALUES ($1, $2, NULLIF($3, ''))
+        ON CONFLICT (user_id) DO UPDATE
+        SET status = EXCLUDED.status, reason = EXCLUDED.reason, updated_at = now()
+        WHERE public.onboarding_status.status = ANY($4)
+    `
+	tag, err := db.Exec(ctx, query, userID, to, reason, predecessors(to))
+	if err != nil {
+		return false, fmt.Errorf("failed to advance onboarding of user %s to %s: %w", userID, to, err)
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// Get returns the onboarding status of userID, or an error wrapping ErrNotFound.
+func Get(ctx context.Context, db DB, userID uuid.UUID) (*State, error) {
+	query := `
+        SELECT user_id, status, COALESCE(reason, ''), updated_at
+        FROM public.onboarding_status
+        WHERE user_id = $1
+    `
+	var state State
+	err := db.QueryRow(ctx, query, userID).Scan(&state.UserID, &state.Status, &state.Reason, &state.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("%w: for user %s", ErrNotFound, userID)
+		}
+		return nil, fmt.Errorf("failed to query onboarding status of user %s: %w", userID, err)
+	}
+	return &state, nil
+}
@@ -0,0 +1,45 @@
+package onboarding
+
+import (
+	"sort"
+	"testing"
+)
+
+func TestCanAdvance(t *testing.T) {
+	tests := []struct {
+		from, to Status
+		want     bool
+	}{
+		{StatusInitiated, StatusCustomerCreated, true},
+		{StatusCustomerCreated, StatusKYCPending, true},
+		{StatusKYCPending, StatusWalletReady, true},
+		{StatusKYCPending, StatusKYCRejected, true},
+		{StatusKYCRejected, StatusKYCPending, true},
+		{StatusKYCRejected, StatusWalletReady, true},
+		// Late or redelivered events must not move a user backwards.
+		{StatusKYCPending, StatusCustomerCreated, false},
+		{StatusWalletReady, StatusKYCPending, false},
+		{StatusWalletReady, StatusKYCRejected, false},
+		// Advancing to the current status is not a transition.
+		{StatusKYCPending, StatusKYCPending, false},
+	}
+	for _, tt := range tests {
+		if got := CanAdvance(tt.from, tt.to); got != tt.want {
+			t.Errorf("CanAdvance(%s, %s) = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestPredecessors(t *testing.T) {
+	got := predecessors(StatusKYCPending)
+	sort.Strings(got)
+	want := []string{"customer_created", "initiated", "kyc_rejected"}
+	if len(got) != len(want) {
+		t.Fatalf("predecessors(kyc_pending) = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("predecessors(kyc_pending) = %v, want %v", got, want)
+		}
+	}
+}
@@ -0,0 +1,313 @@
+/**
+ * @description
+ * This package implements the transactional outbox used by every Transfa service that
+ * publishes events. Events are inserted into public.event_outbox inside the caller's
+ * database transaction, and a Relay running in the same service publishes pending rows
+ * to RabbitMQ and marks them sent once the broker has confirmed them.
+ *
+ * Key features:
+ * - Insert writes events atomically with the state change that produced them.
+ * - The Relay publishes each service's events in creation order. Rows are claimed for a
+ *   lease in a short transaction and published outside it, so no row lock is held while
+ *   waiting on the broker. Only one relay at a time holds a source's oldest pending
+ *   events, so several replicas of a service can run relays without publishing the
+ *   same row concurrently or out of order.
+ * - A failed publish is retried with a doubling delay, and later events wait for it.
+ *   After maxAttempts the event is marked failed, so that one unpublishable event does
+ *   not hold back its source forever; an operator can clear `failed_at` to retry it.
+ *
+ * Delivery is at least once: if the relay stops between the broker's confirmation and
+ * the row being marked sent, the event is published again. Each row's ID is its event
+ * ID, which the relay publishes as the message ID, so consumers can recognise the copy.
+ *
+ * @dependencies
+ * - "context", "encoding/json", "fmt", "log", "time"
+ * - "github.com/google/uuid": For outbox row IDs.
+ * - "github.com/jackc/pgx/v5": For the caller's transaction and row locking.
+ * - "github.com/jackc/pgx/v5/pgxpool": For the relay's connection pool.
+ * - "transfa/shared/events": For the event envelopes stored in the outbox.
+ * - "transfa/shared/messaging": For the messages the relay publishes.
+ */
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"transfa/shared/events"
+	"transfa/shared/messaging"
+)
+
+const (
+	// batchSize is the maximum number of rows a relay claims at once.
+	batchSize = 100
+	// publishTimeout bounds each publish, which blocks while RabbitMQ is reconnecting.
+	publishTimeout = 10 * time.Second
+	// claimLease is how long claimed rows are reserved for the relay that claimed them.
+	// Rows left claimed by a relay that stopped are claimed again once it lapses.
+	claimLease = 2 * time.Minute
+	// maxAttempts is the number of publishes of an event before it is marked failed.
+	maxAttempts = 12
+	// initialRetryDelay is the delay after an event's first failed publish. It doubles
+	// with each attempt up to maxRetryDelay, so an event is given about three and a half hours.
+	initialRetryDelay = 10 * time.Second
+	maxRetryDelay     = time.Hour
+)
+
+// Event is an event to be published through the outbox.
+type Event struct {
+	Exchange   string
+	RoutingKey string
+	Envelope   *events.Envelope
+}
+
+// Publisher is the message broker publisher used by the relay.
+type Publisher interface {
+	PublishMessage(ctx context.Context, exchange, routingKey string, msg messaging.Message) error
+}
+
+// Insert writes the queued events to the outbox in tx on behalf of source, the name of
+// the service that publishes them. The events are published only if tx commits.
+func Insert(ctx context.Context, tx pgx.Tx, source string, queued ...Event) error {
+	query := `
+        INSERT INTO public.event_outbox (id, source, exchange, routing_key, payload)
+        VALUES ($1, $2, $3, $4, $5)
+    `
+	for _, event := range queued {
+		payload, err := json.Marshal(event.Envelope)
+		if err != nil {
+			return fmt.Errorf("failed to marshal %s event: %w", event.Envelope.Type, err)
+		}
+		if _, err := tx.Exec(ctx, query, event.Envelope.EventID, source, event.Exchange, event.RoutingKey, payload); err != nil {
+			return fmt.Errorf("failed to insert %s event into outbox: %w", event.RoutingKey, err)
+		}
+	}
+	return nil
+}
+
+// Relay publishes a service's pending outbox rows.
+type Relay struct {
+	db           *pgxpool.Pool
+	publisher    Publisher
+	source       string
+	pollInterval time.Duration
+}
+
+// NewRelay creates a relay that publishes the pending events of source every pollInterval.
+func NewRelay(db *pgxpool.Pool, publisher Publisher, source string, pollInterval time.Duration) *Relay {
+	return &Relay{
+		db:           db,
+		publisher:    publisher,
+		source:       source,
+		pollInterval: pollInterval,
+	}
+}
+
+// Run relays pending events until ctx is cancelled.
+func (r *Relay) Run(ctx context.Context) {
+	log.Printf("Outbox relay for %s started, polling every %s.", r.source, r.pollInterval)
+
+	ticker := time.NewTicker(r.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		// Step 1: Drain the backlog one batch at a time.
+		for {
+			sent, err := r.relayBatch(ctx)
+			if err != nil {
+				if ctx.Err() == nil {
+					log.Printf("ERROR: Outbox relay for %s failed: %v", r.source, err)
+				}
+				break
+			}
+			if sent < batchSize {
+				break
+			}
+		}
+
+		// Step 2: Wait for the next poll.
+		select {
+		case <-ctx.Done():
+			log.Printf("Outbox relay for %s stopped.", r.source)
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// pendingEvent is an outbox row claimed by the relay.
+type pendingEvent struct {
+	id         uuid.UUID
+	exchange   string
+	routingKey string
+	payload    []byte
+	attempts   int
+}
+
+// relayBatch claims up to batchSize pending events and publishes them in creation order,
+// marking each one sent. It returns the number of events sent. A failed publish ends the
+// batch, so that later events wait for the earlier one, unless it was the event's last
+// attempt and the event is marked failed instead.
+func (r *Relay) relayBatch(ctx context.Context) (int, error) {
+	// Step 1: Claim the oldest pending rows.
+	pending, err := r.claim(ctx)
+	if err != nil {
+		return 0, err
+	}
+	claimedAt := time.Now()
+
+	// Step 2: Publish them in order, recording the outcome of each. The claims of events
+	// not reached are released, so the next batch starts with them.
+	defer func() {
+		if len(pending) == 0 {
+			return
+		}
+		ids := make([]uuid.UUID, len(pending))
+		for i, event := range pending {
+			ids[i] = event.id
+		}
+		_, err := r.db.Exec(context.Background(), `UPDATE public.event_outbox SET claimed_until = NULL WHERE id = ANY($1)`, ids)
+		if err != nil {
+			log.Printf("WARNING: Failed to release %d outbox events claimed by %s: %v", len(ids), r.source, err)
+		}
+	}()
+
+	sent := 0
+	for len(pending) > 0 {
+		if time.Since(claimedAt) > claimLease-publishTimeout {
+			// The claim could lapse before the next publish ends.
+			return sent, nil
+		}
+		event := pending[0]
+
+		publishErr := r.publish(ctx, event)
+		if publishErr == nil {
+			_, err := r.db.Exec(ctx, `
+                UPDATE public.event_outbox
+                SET attempts = attempts + 1, last_error = NULL, claimed_until = NULL, sent_at = now()
+                WHERE id = $1
+            `, event.id)
+			if err != nil {
+				return sent, fmt.Errorf("failed to mark event %s as sent: %w", event.id, err)
+			}
+			pending = pending[1:]
+			sent++
+			continue
+		}
+
+		attempts := event.attempts + 1
+		failed := attempts >= maxAttempts
+		_, err := r.db.Exec(ctx, `
+            UPDATE public.event_outbox
+            SET attempts = $2, last_error = $3, claimed_until = NULL,
+                next_attempt_at = now() + $4 * interval '1 millisecond',
+                failed_at = CASE WHEN $5 THEN now() END
+            WHERE id = $1
+        `, event.id, attempts, publishErr.Error(), retryDelay(attempts).Milliseconds(), failed)
+		if err != nil {
+			return sent, fmt.Errorf("failed to record failed publish of event %s: %w", event.id, err)
+		}
+		pending = pending[1:]
+		if !failed {
+			return sent, fmt.Errorf("failed to publish event %s (attempt %d of %d): %w", event.id, attempts, maxAttempts, publishErr)
+		}
+		log.Printf("ERROR: Giving up on outbox event %s (%s) of %s after %d attempts: %v", event.id, event.routingKey, r.source, attempts, publishErr)
+	}
+	return sent, nil
+}
+
+// claim reserves the oldest pending rows of the relay's source for claimLease, in creation
+// order, and returns them. Rows are claimed from the oldest up to the first one that is
+// claimed by another relay or waiting to be retried, so that a later event is never
+// published before an earlier one. The rows are only locked while they are claimed.
+func (r *Relay) claim(ctx context.Context) ([]pendingEvent, error) {
+	dbTx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer dbTx.Rollback(ctx)
+
+	query := `
+        SELECT id, exchange, routing_key, payload, attempts,
+            COALESCE(claimed_until > now(), false) OR COALESCE(next_attempt_at > now(), false)
+        FROM public.event_outbox
+        WHERE source = $1 AND sent_at IS NULL AND failed_at IS NULL
+        ORDER BY created_at
+        LIMIT $2
+        FOR UPDATE
+    `
+	rows, err := dbTx.Query(ctx, query, r.source, batchSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query pending events: %w", err)
+	}
+	var pending []pendingEvent
+	for rows.Next() {
+		var event pendingEvent
+		var waiting bool
+		if err := rows.Scan(&event.id, &event.exchange, &event.routingKey, &event.payload, &event.attempts, &waiting); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan pending event: %w", err)
+		}
+		if waiting {
+			break
+		}
+		pending = append(pending, event)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read pending events: %w", err)
+	}
+	if len(pending) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]uuid.UUID, len(pending))
+	for i, event := range pending {
+		ids[i] = event.id
+	}
+	_, err = dbTx.Exec(ctx,
+		`UPDATE public.event_outbox SET claimed_until = now() + $2 * interval '1 millisecond' WHERE id = ANY($1)`,
+		ids, claimLease.Milliseconds(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim pending events: %w", err)
+	}
+	if err := dbTx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return pending, nil
+}
+
+// retryDelay returns how long to wait before publishing an event again after attempts
+// failed publishes.
+func retryDelay(attempts int) time.Duration {
+	delay := initialRetryDelay
+	for i := 1; i < attempts && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+	return delay
+}
+
+// publish publishes one event, giving up after publishTimeout.
+func (r *Relay) publish(ctx context.Context, event pendingEvent) error {
+	ctx, cancel := context.WithTimeout(ctx, publishTimeout)
+	defer cancel()
+
+	// Rows written before events had envelopes are published as they are.
+	msg := messaging.Message{ID: event.id.String(), Body: event.payload}
+	if env, err := events.Parse(event.payload); err == nil {
+		if msg, err = env.Message(); err != nil {
+			return err
+		}
+	}
+	return r.publisher.PublishMessage(ctx, event.exchange, event.routingKey, msg)
+}
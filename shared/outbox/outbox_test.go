@@ -0,0 +1,32 @@
+package outbox
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRetryDelay(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, 10 * time.Second},
+		{2, 20 * time.Second},
+		{4, 80 * time.Second},
+		{10, time.Hour},
+		{maxAttempts, time.Hour},
+	}
+	for _, tt := range tests {
+		if got := retryDelay(tt.attempts); got != tt.want {
+			t.Errorf("retryDelay(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+
+	var total time.Duration
+	for attempts := 1; attempts < maxAttempts; attempts++ {
+		total += retryDelay(attempts)
+	}
+	if total < 3*time.Hour {
+		t.Errorf("an event is marked failed %v after its first attempt, want at least 3h", total)
+	}
+}
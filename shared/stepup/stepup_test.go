@@ -0,0 +1,57 @@
+package stepup
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestNewToken(t *testing.T) {
+	token, hash, err := NewToken()
+	if err != nil {
+		t.Fatalf("NewToken() error = %v", err)
+	}
+	if hash != HashToken(token) {
+		t.Errorf("hash = %q, want HashToken(token)", hash)
+	}
+	if hash == token {
+		t.Error("hash is the token itself")
+	}
+
+	other, _, err := NewToken()
+	if err != nil {
+		t.Fatalf("NewToken() error = %v", err)
+	}
+	if other == token {
+		t.Error("NewToken() returned the same token twice")
+	}
+}
+
+func TestRecipients(t *testing.T) {
+	id := uuid.MustParse("7f9c2d4e-1b3a-4c5d-8e6f-0a1b2c3d4e5f")
+	tests := []struct {
+		got  string
+		want string
+	}{
+		{UserRecipient(" @Ada "), "user:ada"},
+		{UserRecipient("ada"), "user:ada"},
+		{PaymentRequestRecipient(id), "payment_request:7f9c2d4e-1b3a-4c5d-8e6f-0a1b2c3d4e5f"},
+		{BeneficiaryRecipient(id), "beneficiary:7f9c2d4e-1b3a-4c5d-8e6f-0a1b2c3d4e5f"},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("recipient = %q, want %q", tt.got, tt.want)
+		}
+	}
+}
+
+func TestValidPurpose(t *testing.T) {
+	for _, purpose := range []string{PurposeP2PTransfer, PurposeSelfTransfer, PurposeMoneyDrop} {
+		if !ValidPurpose(purpose) {
+			t.Errorf("ValidPurpose(%q) = false, want true", purpose)
+		}
+	}
+	if ValidPurpose("subscription_fee") {
+		t.Error(`ValidPurpose("subscription_fee") = true, want false`)
+	}
+}
@@ -0,0 +1,143 @@
+/**
+ * @description
+ * This package contains the rules for usernames, the @handles users pay each other by.
+ * The Auth service applies them when a user onboards and the Customer service when a
+ * user changes their username, so both accept the same usernames.
+ *
+ * Key features:
+ * - Usernames are case-insensitive: they are normalized to lowercase before they are
+ *   validated, compared or stored.
+ * - Usernames are 3-20 ASCII letters and digits.
+ * - Reserved names (Transfa's own and those of banks, regulators and payment brands)
+ *   cannot be claimed, so users cannot impersonate them. Names containing a blocked word
+ *   (offensive or fraud-related terms, or "transfa") cannot be claimed either.
+ * - `Candidates` derives alternatives to suggest for a taken username.
+ *
+ * @dependencies
+ * - "fmt", "regexp", "strings"
+ */
+package username
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Codes of the rules a username breaks, for clients to branch on.
+const (
+	CodeRequired      = "required"
+	CodeInvalidFormat = "invalid_format"
+	CodeReserved      = "reserved"
+	CodeBlocked       = "blocked"
+)
+
+// Length limits of usernames.
+const (
+	MinLength = 3
+	MaxLength = 20
+)
+
+var pattern = regexp.MustCompile(`^[a-z0-9]+$`)
+
+// reserved cannot be claimed by users, so they cannot impersonate Transfa, its partners
+// or other financial brands, or collide with routes.
+var reserved = map[string]bool{
+	"access":        true,
+	"admin":         true,
+	"administrator": true,
+	"anchor":        true,
+	"api":           true,
+	"billing":       true,
+	"cbn":           true,
+	"clerk":         true,
+	"efcc":          true,
+	"firstbank":     true,
+	"flutterwave":   true,
+	"gtbank":        true,
+	"help":          true,
+	"kuda":          true,
+	"me":            true,
+	"moneydrop":     true,
+	"moniepoint":    true,
+	"nibss":         true,
+	"null":          true,
+	"official":      true,
+	"opay":          true,
+	"palmpay":       true,
+	"paystack":      true,
+	"root":          true,
+	"security":      true,
+	"settings":      true,
+	"support":       true,
+	"system":        true,
+	"transfa":       true,
+	"undefined":     true,
+	"zenith":        true,
+}
+
+// blockedWords may not appear anywhere in a username.
+var blockedWords = []string{
+	"bitch",
+	"cunt",
+	"fraud",
+	"fuck",
+	"nazi",
+	"porn",
+	"scam",
+	"shit",
+	"transfa",
+	"whore",
+}
+
+// suggestionSuffixes are appended to a taken username to suggest alternatives.
+var suggestionSuffixes = []string{"ng", "pay", "hq", "1", "2", "3", "01", "99"}
+
+// Normalize returns the canonical form of username: trimmed and lowercase.
+func Normalize(username string) string {
+	return strings.ToLower(strings.TrimSpace(username))
+}
+
+// Check returns the code of the first rule the normalized username breaks and a message
+// explaining it, or two empty strings if the username is valid.
+func Check(username string) (code, message string) {
+	word, blocked := blockedWord(username)
+	switch {
+	case username == "":
+		return CodeRequired, "username is required"
+	case len(username) < MinLength || len(username) > MaxLength || !pattern.MatchString(username):
+		return CodeInvalidFormat, fmt.Sprintf("username must be %d-%d letters or digits", MinLength, MaxLength)
+	case reserved[username]:
+		return CodeReserved, fmt.Sprintf("username %q is reserved", username)
+	case blocked:
+		return CodeBlocked, fmt.Sprintf("username may not contain %q", word)
+	}
+	return "", ""
+}
+
+// blockedWord returns the blocked word username contains, if any.
+func blockedWord(username string) (string, bool) {
+	for _, word := range blockedWords {
+		if strings.Contains(username, word) {
+			return word, true
+		}
+	}
+	return "", false
+}
+
+// Candidates returns valid alternatives to the normalized username, made by appending
+// suffixes and shortening it where needed. Their availability is not checked.
+func Candidates(username string) []string {
+	var candidates []string
+	for _, suffix := range suggestionSuffixes {
+		base := username
+		if len(base)+len(suffix) > MaxLength {
+			base = base[:MaxLength-len(suffix)]
+		}
+		candidate := base + suffix
+		if code, _ := Check(candidate); code == "" {
+			candidates = append(candidates, candidate)
+		}
+	}
+	return candidates
+}
@@ -0,0 +1,50 @@
+package username
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCandidates(t *testing.T) {
+	tests := []struct {
+		username string
+		want     []string
+	}{
+		{"ada", []string{"adang", "adapay", "adahq", "ada1", "ada2", "ada3", "ada01", "ada99"}},
+		// Candidates are shortened to the maximum length.
+		{"abcdefghijklmnopqrst", []string{
+			"abcdefghijklmnopqrng", "abcdefghijklmnopqpay", "abcdefghijklmnopqrhq",
+			"abcdefghijklmnopqrs1", "abcdefghijklmnopqrs2", "abcdefghijklmnopqrs3",
+			"abcdefghijklmnopqr01", "abcdefghijklmnopqr99",
+		}},
+		// Candidates that would be reserved or blocked are left out.
+		{"scam", nil},
+	}
+	for _, tt := range tests {
+		if got := Candidates(tt.username); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Candidates(%q) = %v, want %v", tt.username, got, tt.want)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		username string
+		want     string
+	}{
+		{"ada", ""},
+		{"ada2024", ""},
+		{"", CodeRequired},
+		{"ad", CodeInvalidFormat},
+		{"Ada", CodeInvalidFormat}, // Not normalized.
+		{"ada_l", CodeInvalidFormat},
+		{"admin", CodeReserved},
+		{"opay", CodeReserved},
+		{"notascammer", CodeBlocked},
+	}
+	for _, tt := range tests {
+		if got, _ := Check(tt.username); got != tt.want {
+			t.Errorf("Check(%q) = %q, want %q", tt.username, got, tt.want)
+		}
+	}
+}
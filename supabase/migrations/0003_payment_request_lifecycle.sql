@@ -0,0 +1,39 @@
+/**
+ * @description
+ * Transfa App - Payment Request Lifecycle
+ *
+ * This migration extends the `payment_requests` table so that requests can be
+ * cancelled by their creator and shared through an opaque public token. The token
+ * lets a payer resolve the amount and description of a request without learning
+ * the creator's internal user ID.
+ *
+ * A request is reserved while a transfer paying it is in flight, so that it cannot be
+ * paid twice: it is `paying`, held by `paying_transaction_id`, until the transfer
+ * completes and it becomes `fulfilled`, or fails and it is `pending` again.
+ */
+
+--==============================================================
+-- `payment_requests` table changes
+--==============================================================
+
+-- Allow creators to cancel requests that have not been paid yet, and reserve requests
+-- while a transfer is paying them.
+ALTER TABLE public.payment_requests DROP CONSTRAINT IF EXISTS payment_requests_status_check;
+ALTER TABLE public.payment_requests
+    ADD CONSTRAINT payment_requests_status_check CHECK (status IN ('pending', 'paying', 'fulfilled', 'cancelled'));
+
+ALTER TABLE public.payment_requests
+    ADD COLUMN paying_transaction_id uuid REFERENCES public.transactions(id);
+ALTER TABLE public.payment_requests
+    ADD CONSTRAINT payment_requests_paying_check CHECK ((status = 'paying') = (paying_transaction_id IS NOT NULL));
+COMMENT ON COLUMN public.payment_requests.paying_transaction_id IS 'The in-flight transfer paying the request, while its status is paying.';
+
+-- Opaque token used in shareable payment request links.
+-- The default only backfills existing rows; the Transaction service generates its own tokens.
+ALTER TABLE public.payment_requests
+    ADD COLUMN public_token text NOT NULL UNIQUE DEFAULT replace(gen_random_uuid()::text, '-', '');
+COMMENT ON COLUMN public.payment_requests.public_token IS 'Opaque token used to share a payment request without exposing internal IDs.';
+
+-- Creators list their own requests, newest first.
+CREATE INDEX IF NOT EXISTS idx_payment_requests_creator_created_at
+    ON public.payment_requests (creator_user_id, created_at DESC);
@@ -0,0 +1,112 @@
+/**
+ * @description
+ * Transfa App - Scheduled Jobs
+ *
+ * This migration adds the state needed by the Scheduler service and the money
+ * movements it triggers:
+ * - `scheduler_job_runs` records every run of a scheduled job. A run is unique per
+ *   job and scheduled time, so a schedule slot is never executed twice.
+ * - `scheduler_job_run_items` records per-item progress within a run, so that a
+ *   run interrupted by a crash resumes without repeating completed items.
+ * - `transactions.idempotency_key` lets internal callers safely retry requests that
+ *   move money.
+ * - The `money_drop_refund` transaction type, for returning unclaimed Money Drop
+ *   funds to the creator when a drop expires.
+ * - `subscriptions.usage_period`, the month counted by `monthly_external_transfers_used`,
+ *   so that the monthly reset only touches counts from an earlier month and running it
+ *   twice changes nothing.
+ * - `subscriptions.payment_attempts`, the number of failed attempts to collect the fee
+ *   for the current period. Each attempt is charged with its own idempotency key, so a
+ *   debit still pending is waited on rather than charged again.
+ */
+
+--==============================================================
+-- TABLES
+--==============================================================
+
+--
+-- Table: scheduler_job_runs
+-- Description: One row per execution slot of a scheduled job.
+--
+CREATE TABLE public.scheduler_job_runs (
+    id uuid NOT NULL PRIMARY KEY DEFAULT gen_random_uuid(),
+    job_name text NOT NULL,
+    scheduled_for timestamptz NOT NULL,
+    status text NOT NULL CHECK (status IN ('running', 'succeeded', 'failed')),
+    attempts integer NOT NULL DEFAULT 1,
+    last_error text,
+    started_at timestamptz NOT NULL DEFAULT now(),
+    finished_at timestamptz,
+    created_at timestamptz NOT NULL DEFAULT now(),
+    updated_at timestamptz NOT NULL DEFAULT now(),
+    UNIQUE (job_name, scheduled_for)
+);
+COMMENT ON TABLE public.scheduler_job_runs IS 'Tracks each scheduled job run for idempotency and crash recovery.';
+
+CREATE INDEX idx_scheduler_job_runs_job_name_scheduled_for
+    ON public.scheduler_job_runs (job_name, scheduled_for DESC);
+
+-- Add trigger for scheduler_job_runs table
+CREATE TRIGGER set_timestamp
+BEFORE UPDATE ON public.scheduler_job_runs
+FOR EACH ROW
+EXECUTE PROCEDURE trigger_set_timestamp();
+
+
+--
+-- Table: scheduler_job_run_items
+-- Description: Per-item progress of a job run (e.g. one row per subscription billed).
+--
+CREATE TABLE public.scheduler_job_run_items (
+    run_id uuid NOT NULL REFERENCES public.scheduler_job_runs(id) ON DELETE CASCADE,
+    item_key text NOT NULL,
+    status text NOT NULL CHECK (status IN ('succeeded', 'failed')),
+    last_error text,
+    created_at timestamptz NOT NULL DEFAULT now(),
+    updated_at timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY (run_id, item_key)
+);
+COMMENT ON TABLE public.scheduler_job_run_items IS 'Tracks the items processed by a scheduled job run so interrupted runs can resume.';
+
+-- Add trigger for scheduler_job_run_items table
+CREATE TRIGGER set_timestamp
+BEFORE UPDATE ON public.scheduler_job_run_items
+FOR EACH ROW
+EXECUTE PROCEDURE trigger_set_timestamp();
+
+
+--==============================================================
+-- `transactions` table changes
+--==============================================================
+
+-- Optional client-supplied key used to deduplicate retried money movements.
+ALTER TABLE public.transactions ADD COLUMN idempotency_key text UNIQUE;
+
+-- Allow refunds of unclaimed Money Drop funds.
+ALTER TABLE public.transactions DROP CONSTRAINT IF EXISTS transactions_type_check;
+ALTER TABLE public.transactions
+    ADD CONSTRAINT transactions_type_check CHECK (type IN ('p2p', 'self_transfer', 'money_drop_funding', 'money_drop_claim', 'money_drop_refund', 'subscription_fee', 'wallet_funding'));
+
+
+--==============================================================
+-- `subscriptions` table changes
+--==============================================================
+
+-- The first day (UTC) of the month counted by monthly_external_transfers_used. The
+-- first transfer counted in a new month starts the count again, whether or not the
+-- reset has run yet.
+ALTER TABLE public.subscriptions
+    ADD COLUMN usage_period date NOT NULL DEFAULT date_trunc('month', now() AT TIME ZONE 'UTC')::date;
+COMMENT ON COLUMN public.subscriptions.usage_period IS 'First day (UTC) of the month counted by monthly_external_transfers_used.';
+
+ALTER TABLE public.subscriptions
+    ADD COLUMN payment_attempts integer NOT NULL DEFAULT 0;
+COMMENT ON COLUMN public.subscriptions.payment_attempts IS 'Failed attempts to collect the fee for the current billing period.';
+
+
+--==============================================================
+-- RLS
+-- Job state is only accessed by backend services using the service role.
+--==============================================================
+ALTER TABLE public.scheduler_job_runs ENABLE ROW LEVEL SECURITY;
+ALTER TABLE public.scheduler_job_run_items ENABLE ROW LEVEL SECURITY;
@@ -0,0 +1,105 @@
+/**
+ * @description
+ * Transfa App - Analytics Aggregates
+ *
+ * This migration adds the pre-aggregated tables maintained by the Analytics service
+ * from `transaction.completed` events:
+ * - `analytics_processed_transactions` records every transaction already counted, so
+ *   redelivered events are ignored instead of being counted twice.
+ * - `analytics_daily_cash_flow` and `analytics_monthly_cash_flow` hold each user's
+ *   money in and money out per UTC day and month.
+ * - `analytics_category_spending` holds each user's money out per category per month.
+ *
+ * All amounts are in kobo.
+ */
+
+--==============================================================
+-- TABLES
+--==============================================================
+
+--
+-- Table: analytics_processed_transactions
+-- Description: Transactions already applied to the aggregates.
+--
+CREATE TABLE public.analytics_processed_transactions (
+    transaction_id uuid NOT NULL PRIMARY KEY,
+    processed_at timestamptz NOT NULL DEFAULT now()
+);
+COMMENT ON TABLE public.analytics_processed_transactions IS 'Deduplicates transaction.completed events consumed by the Analytics service.';
+
+
+--
+-- Table: analytics_daily_cash_flow
+-- Description: Money in and money out per user per UTC day.
+--
+CREATE TABLE public.analytics_daily_cash_flow (
+    user_id uuid NOT NULL REFERENCES public.users(id) ON DELETE CASCADE,
+    day date NOT NULL,
+    money_in bigint NOT NULL DEFAULT 0,
+    money_out bigint NOT NULL DEFAULT 0,
+    created_at timestamptz NOT NULL DEFAULT now(),
+    updated_at timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY (user_id, day)
+);
+COMMENT ON TABLE public.analytics_daily_cash_flow IS 'Daily cash flow aggregates for the Analytics tab.';
+
+-- Add trigger for analytics_daily_cash_flow table
+CREATE TRIGGER set_timestamp
+BEFORE UPDATE ON public.analytics_daily_cash_flow
+FOR EACH ROW
+EXECUTE PROCEDURE trigger_set_timestamp();
+
+
+--
+-- Table: analytics_monthly_cash_flow
+-- Description: Money in and money out per user per UTC month, keyed by the month's first day.
+--
+CREATE TABLE public.analytics_monthly_cash_flow (
+    user_id uuid NOT NULL REFERENCES public.users(id) ON DELETE CASCADE,
+    month date NOT NULL,
+    money_in bigint NOT NULL DEFAULT 0,
+    money_out bigint NOT NULL DEFAULT 0,
+    created_at timestamptz NOT NULL DEFAULT now(),
+    updated_at timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY (user_id, month)
+);
+COMMENT ON TABLE public.analytics_monthly_cash_flow IS 'Monthly cash flow aggregates for the Analytics tab.';
+
+-- Add trigger for analytics_monthly_cash_flow table
+CREATE TRIGGER set_timestamp
+BEFORE UPDATE ON public.analytics_monthly_cash_flow
+FOR EACH ROW
+EXECUTE PROCEDURE trigger_set_timestamp();
+
+
+--
+-- Table: analytics_category_spending
+-- Description: Money out per user, category and UTC month.
+--
+CREATE TABLE public.analytics_category_spending (
+    user_id uuid NOT NULL REFERENCES public.users(id) ON DELETE CASCADE,
+    month date NOT NULL,
+    category text NOT NULL,
+    amount bigint NOT NULL DEFAULT 0,
+    transaction_count integer NOT NULL DEFAULT 0,
+    created_at timestamptz NOT NULL DEFAULT now(),
+    updated_at timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY (user_id, month, category)
+);
+COMMENT ON TABLE public.analytics_category_spending IS 'Monthly spending by category for the Analytics tab.';
+
+-- Add trigger for analytics_category_spending table
+CREATE TRIGGER set_timestamp
+BEFORE UPDATE ON public.analytics_category_spending
+FOR EACH ROW
+EXECUTE PROCEDURE trigger_set_timestamp();
+
+
+--==============================================================
+-- RLS
+-- Aggregates are served to clients through the Analytics service, which uses the service role.
+--==============================================================
+ALTER TABLE public.analytics_processed_transactions ENABLE ROW LEVEL SECURITY;
+ALTER TABLE public.analytics_daily_cash_flow ENABLE ROW LEVEL SECURITY;
+ALTER TABLE public.analytics_monthly_cash_flow ENABLE ROW LEVEL SECURITY;
+ALTER TABLE public.analytics_category_spending ENABLE ROW LEVEL SECURITY;
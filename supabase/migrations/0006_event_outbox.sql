@@ -0,0 +1,61 @@
+/**
+ * @description
+ * Transfa App - Event Outbox
+ *
+ * This migration adds the transactional outbox shared by every service that publishes
+ * events. A service writes its event to `event_outbox` in the same database transaction
+ * as the state change that caused it, and a relay worker in that service publishes
+ * pending rows to RabbitMQ and marks them sent. An event is therefore published if and
+ * only if its transaction commits, even if RabbitMQ is unavailable at the time.
+ *
+ * Each row records the `source` service that wrote it, so every relay only publishes
+ * its own service's events. A relay claims rows for a short lease before publishing
+ * them, retries a failed publish with a growing delay, and moves an event that still
+ * cannot be published after its last attempt to a failed state, where it waits for an
+ * operator instead of holding back the events after it.
+ */
+
+--==============================================================
+-- TABLES
+--==============================================================
+
+--
+-- Table: event_outbox
+-- Description: Events waiting to be published, and a record of those already published.
+--
+CREATE TABLE public.event_outbox (
+    id uuid NOT NULL PRIMARY KEY DEFAULT gen_random_uuid(),
+    source text NOT NULL,
+    exchange text NOT NULL,
+    routing_key text NOT NULL,
+    payload jsonb NOT NULL,
+    attempts integer NOT NULL DEFAULT 0,
+    last_error text,
+    next_attempt_at timestamptz,
+    claimed_until timestamptz,
+    sent_at timestamptz,
+    failed_at timestamptz,
+    created_at timestamptz NOT NULL DEFAULT now(),
+    updated_at timestamptz NOT NULL DEFAULT now()
+);
+COMMENT ON TABLE public.event_outbox IS 'Transactional outbox of events published by the services.';
+COMMENT ON COLUMN public.event_outbox.next_attempt_at IS 'When a failed publish may be retried. NULL until the first failure.';
+COMMENT ON COLUMN public.event_outbox.claimed_until IS 'When the claim of the relay publishing the event lapses. NULL while no relay holds it.';
+COMMENT ON COLUMN public.event_outbox.sent_at IS 'When the broker confirmed the event. NULL while the event is pending.';
+COMMENT ON COLUMN public.event_outbox.failed_at IS 'When the relay gave up on the event after its last attempt. Clear it, with attempts, to publish the event again.';
+
+-- Relays poll each source's pending events in creation order.
+CREATE INDEX event_outbox_pending_idx ON public.event_outbox (source, created_at) WHERE sent_at IS NULL AND failed_at IS NULL;
+
+-- Add trigger for event_outbox table
+CREATE TRIGGER set_timestamp
+BEFORE UPDATE ON public.event_outbox
+FOR EACH ROW
+EXECUTE PROCEDURE trigger_set_timestamp();
+
+
+--==============================================================
+-- RLS
+-- The outbox is only accessed by the services, which use the service role.
+--==============================================================
+ALTER TABLE public.event_outbox ENABLE ROW LEVEL SECURITY;
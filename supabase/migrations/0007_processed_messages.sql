@@ -0,0 +1,43 @@
+/**
+ * @description
+ * Transfa App - Processed Messages Inbox
+ *
+ * RabbitMQ delivers messages at least once, so consumers must tolerate redelivery. This
+ * migration adds:
+ * - `processed_messages`, the inbox recording every message each consumer has handled,
+ *   keyed by the message ID set by the publisher. Consumers skip messages already in it.
+ * - A unique index allowing a single main wallet per user, so a redelivered
+ *   `customer.verified` event can never create a second one.
+ */
+
+--==============================================================
+-- TABLES
+--==============================================================
+
+--
+-- Table: processed_messages
+-- Description: Messages already handled by each consumer.
+--
+CREATE TABLE public.processed_messages (
+    consumer text NOT NULL,
+    message_id text NOT NULL,
+    processed_at timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY (consumer, message_id)
+);
+COMMENT ON TABLE public.processed_messages IS 'Inbox of messages already handled, used to make event consumers idempotent.';
+COMMENT ON COLUMN public.processed_messages.consumer IS 'The queue the message was consumed from.';
+
+
+--==============================================================
+-- CONSTRAINTS
+--==============================================================
+
+-- Each user has exactly one main wallet.
+CREATE UNIQUE INDEX accounts_user_main_wallet_key ON public.accounts (user_id) WHERE account_purpose = 'main_wallet';
+
+
+--==============================================================
+-- RLS
+-- The inbox is only accessed by the services, which use the service role.
+--==============================================================
+ALTER TABLE public.processed_messages ENABLE ROW LEVEL SECURITY;
@@ -0,0 +1,70 @@
+/**
+ * @description
+ * Transfa App - Onboarding Status
+ *
+ * Onboarding spans the Auth, Customer, Notification and Account services, and its
+ * progress was only visible indirectly through users.anchor_customer_id,
+ * users.kyc_status and the user's accounts. This migration adds `onboarding_status`,
+ * one row per user holding their current step, which each service advances as it
+ * handles its part of the flow:
+ *
+ *   initiated → customer_created → kyc_pending → wallet_ready
+ *                                            ↘ kyc_rejected
+ *
+ * Existing users are backfilled from the data the status replaces.
+ */
+
+--==============================================================
+-- TABLES
+--==============================================================
+
+--
+-- Table: onboarding_status
+-- Description: The current onboarding step of each user.
+--
+CREATE TABLE public.onboarding_status (
+    user_id uuid NOT NULL PRIMARY KEY REFERENCES public.users(id) ON DELETE CASCADE,
+    status text NOT NULL DEFAULT 'initiated' CHECK (status IN ('initiated', 'customer_created', 'kyc_pending', 'kyc_rejected', 'wallet_ready')),
+    reason text,
+    created_at timestamptz NOT NULL DEFAULT now(),
+    updated_at timestamptz NOT NULL DEFAULT now()
+);
+COMMENT ON TABLE public.onboarding_status IS 'Current onboarding step of each user, advanced by the services that perform each step.';
+COMMENT ON COLUMN public.onboarding_status.reason IS 'Why KYC was rejected. NULL for other statuses.';
+
+-- Add trigger for onboarding_status table
+CREATE TRIGGER set_timestamp
+BEFORE UPDATE ON public.onboarding_status
+FOR EACH ROW
+EXECUTE PROCEDURE trigger_set_timestamp();
+
+
+--==============================================================
+-- BACKFILL
+--==============================================================
+
+INSERT INTO public.onboarding_status (user_id, status)
+SELECT
+    u.id,
+    CASE
+        WHEN EXISTS (
+            SELECT 1 FROM public.accounts a
+            WHERE a.user_id = u.id AND a.account_purpose = 'main_wallet'
+        ) THEN 'wallet_ready'
+        WHEN u.kyc_status = 'rejected' THEN 'kyc_rejected'
+        WHEN COALESCE(u.anchor_customer_id, '') <> '' THEN 'kyc_pending'
+        ELSE 'initiated'
+    END
+FROM public.users u;
+
+
+--==============================================================
+-- RLS
+-- Users can see their own status; only the services, which use the service role,
+-- change it.
+--==============================================================
+ALTER TABLE public.onboarding_status ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY "Users can view their own onboarding status."
+ON public.onboarding_status FOR SELECT
+USING (auth.uid() = user_id);
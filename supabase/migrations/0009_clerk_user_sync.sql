@@ -0,0 +1,29 @@
+/**
+ * @description
+ * Transfa App - Clerk User Sync
+ *
+ * The Auth service now receives Clerk's user and session webhooks, so public.users
+ * follows changes made in Clerk after onboarding. This migration adds to `users`:
+ * - `email` and `phone_number`, the user's primary contact details in Clerk.
+ * - `clerk_updated_at`, when Clerk last changed the user, so an older webhook delivered
+ *   late never overwrites newer details.
+ * - `last_sign_in_at`, when the user last started a Clerk session.
+ * - `deleted_at`, set when the user is deleted in Clerk. The row is kept, because the
+ *   user's accounts and transactions reference it.
+ */
+
+--==============================================================
+-- COLUMNS
+--==============================================================
+
+ALTER TABLE public.users
+    ADD COLUMN email text,
+    ADD COLUMN phone_number text,
+    ADD COLUMN clerk_updated_at timestamptz,
+    ADD COLUMN last_sign_in_at timestamptz,
+    ADD COLUMN deleted_at timestamptz;
+
+COMMENT ON COLUMN public.users.email IS 'Primary email address in Clerk, kept in sync by the Clerk webhook.';
+COMMENT ON COLUMN public.users.phone_number IS 'Primary phone number in Clerk, kept in sync by the Clerk webhook.';
+COMMENT ON COLUMN public.users.clerk_updated_at IS 'When Clerk last updated the user. Older webhooks are ignored.';
+COMMENT ON COLUMN public.users.deleted_at IS 'When the user was deleted in Clerk. NULL for active users.';
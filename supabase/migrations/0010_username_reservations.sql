@@ -0,0 +1,41 @@
+/**
+ * @description
+ * Transfa App - Username Availability
+ *
+ * Users can now check whether a username is available before they onboard, and an
+ * available username is reserved for them for a few minutes so that two users
+ * onboarding at once cannot race for the same @handle. This migration:
+ * - Makes usernames case-insensitive by storing them in lowercase. The existing unique
+ *   constraint then also rejects usernames that differ only in case. The UPDATE fails
+ *   if two existing usernames differ only in case; rename one of them first.
+ * - Adds `username_reservations`, the usernames held for users who are onboarding.
+ *   Expired reservations are ignored and overwritten, so they need no cleanup job.
+ */
+
+--==============================================================
+-- LOWERCASE USERNAMES
+--==============================================================
+
+UPDATE public.users SET username = lower(username) WHERE username <> lower(username);
+
+ALTER TABLE public.users
+    ADD CONSTRAINT users_username_lowercase CHECK (username = lower(username));
+
+--==============================================================
+-- USERNAME RESERVATIONS
+--==============================================================
+
+CREATE TABLE public.username_reservations (
+    username text PRIMARY KEY CHECK (username = lower(username)),
+    clerk_id text NOT NULL,
+    expires_at timestamptz NOT NULL,
+    created_at timestamptz NOT NULL DEFAULT now()
+);
+
+COMMENT ON TABLE public.username_reservations IS 'Usernames held for users who are onboarding, until expires_at.';
+COMMENT ON COLUMN public.username_reservations.clerk_id IS 'Clerk User ID of the user the username is held for. A user holds at most one username.';
+
+CREATE INDEX idx_username_reservations_clerk_id ON public.username_reservations(clerk_id);
+
+-- Only the Auth service, which bypasses RLS, reads and writes reservations.
+ALTER TABLE public.username_reservations ENABLE ROW LEVEL SECURITY;
@@ -0,0 +1,47 @@
+/**
+ * @description
+ * Transfa App - Username Changes
+ *
+ * Users can now change their username through the Customer service. Usernames are how
+ * users pay each other, so a change must not strand payments to the old handle. This
+ * migration:
+ * - Adds `users.username_changed_at`, when the user last changed their username, for
+ *   the cooldown between changes.
+ * - Adds `username_history`, one row per change. Until `grace_until`, the old username
+ *   still belongs to the user: payments to it resolve to the user (or are refused,
+ *   depending on the Transaction service's policy) and no one else can claim it.
+ */
+
+--==============================================================
+-- COLUMNS
+--==============================================================
+
+ALTER TABLE public.users ADD COLUMN username_changed_at timestamptz;
+
+COMMENT ON COLUMN public.users.username_changed_at IS 'When the user last changed their username. NULL if they never did.';
+
+--==============================================================
+-- USERNAME HISTORY
+--==============================================================
+
+CREATE TABLE public.username_history (
+    id uuid NOT NULL PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id uuid NOT NULL REFERENCES public.users(id) ON DELETE CASCADE,
+    old_username text NOT NULL CHECK (old_username = lower(old_username)),
+    new_username text NOT NULL CHECK (new_username = lower(new_username)),
+    changed_at timestamptz NOT NULL DEFAULT now(),
+    grace_until timestamptz NOT NULL
+);
+
+COMMENT ON TABLE public.username_history IS 'Username changes. The old username keeps pointing to the user until grace_until.';
+
+-- Resolves old usernames still in their grace period.
+CREATE INDEX idx_username_history_old_username ON public.username_history(old_username, grace_until DESC);
+CREATE INDEX idx_username_history_user_id ON public.username_history(user_id, changed_at DESC);
+
+-- Users can see their own username history.
+ALTER TABLE public.username_history ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY "Users can view their own username history."
+ON public.username_history FOR SELECT
+USING (auth.uid() = user_id);
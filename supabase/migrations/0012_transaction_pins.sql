@@ -0,0 +1,61 @@
+/**
+ * @description
+ * Transfa App - Transaction PINs
+ *
+ * Every outbound money movement must be approved with the user's transaction PIN. The
+ * Auth service checks the PIN and issues a step-up token for one transfer, which the
+ * Transaction service consumes before it moves any money. This migration:
+ * - Adds `transaction_pins`, each user's argon2id PIN hash with the count of failed
+ *   attempts since the last correct PIN and, once too many fail, when the lockout ends.
+ * - Adds `step_up_tokens`, the SHA-256 hashes of issued step-up tokens with the transfer
+ *   each one is bound to. A token is spent by setting `used_at`, so it approves at most
+ *   one transfer.
+ *
+ * Neither table is exposed to clients: RLS is enabled with no policies, so only the
+ * services (which connect with the service role) can read them.
+ */
+
+--==============================================================
+-- TRANSACTION PINS
+--==============================================================
+
+CREATE TABLE public.transaction_pins (
+    user_id uuid NOT NULL PRIMARY KEY REFERENCES public.users(id) ON DELETE CASCADE,
+    pin_hash text NOT NULL,
+    failed_attempts integer NOT NULL DEFAULT 0 CHECK (failed_attempts >= 0),
+    locked_until timestamptz,
+    created_at timestamptz NOT NULL DEFAULT now(),
+    updated_at timestamptz NOT NULL DEFAULT now()
+);
+
+COMMENT ON TABLE public.transaction_pins IS 'Transaction PIN hashes (argon2id) with their failed attempts and lockout.';
+
+CREATE TRIGGER set_timestamp
+BEFORE UPDATE ON public.transaction_pins
+FOR EACH ROW
+EXECUTE PROCEDURE trigger_set_timestamp();
+
+ALTER TABLE public.transaction_pins ENABLE ROW LEVEL SECURITY;
+
+--==============================================================
+-- STEP-UP TOKENS
+--==============================================================
+
+CREATE TABLE public.step_up_tokens (
+    id uuid NOT NULL PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id uuid NOT NULL REFERENCES public.users(id) ON DELETE CASCADE,
+    token_hash text NOT NULL UNIQUE,
+    purpose text NOT NULL CHECK (purpose IN ('p2p_transfer', 'self_transfer', 'money_drop')),
+    amount bigint NOT NULL CHECK (amount > 0),
+    recipient text NOT NULL,
+    expires_at timestamptz NOT NULL,
+    used_at timestamptz,
+    created_at timestamptz NOT NULL DEFAULT now()
+);
+
+COMMENT ON TABLE public.step_up_tokens IS 'Single-use approvals of one transfer, issued after a correct transaction PIN.';
+
+-- Expired and used tokens are deleted when the user is issued a new one.
+CREATE INDEX idx_step_up_tokens_user_id ON public.step_up_tokens(user_id);
+
+ALTER TABLE public.step_up_tokens ENABLE ROW LEVEL SECURITY;
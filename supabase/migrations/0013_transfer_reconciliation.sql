@@ -0,0 +1,46 @@
+/**
+ * @description
+ * Transfa App - Transfer Reconciliation
+ *
+ * The Transaction service only settles a transfer on a final Anchor status and leaves
+ * every other outcome pending, for the Scheduler service to reconcile. A pending
+ * transfer keeps hold of what it pays for until it settles, so this migration links
+ * each hold to its transfer:
+ * - Adds the `funding` and `failed` Money Drop statuses and
+ *   `money_drops.funding_transaction_id`. A drop is recorded as `funding` before the
+ *   transfer funding it, becomes `active` once the transfer completes, or `failed` if it
+ *   fails.
+ * - Adds `money_drop_claims.payout_transaction_id`, the transfer paying out a claim. A
+ *   claim whose payout fails is deleted, returning its share to the pool.
+ * - Indexes pending transactions, which the reconciliation job lists.
+ */
+
+--==============================================================
+-- `money_drops` table changes
+--==============================================================
+
+ALTER TABLE public.money_drops DROP CONSTRAINT IF EXISTS money_drops_status_check;
+ALTER TABLE public.money_drops
+    ADD CONSTRAINT money_drops_status_check CHECK (status IN ('funding', 'active', 'completed', 'expired', 'failed'));
+
+ALTER TABLE public.money_drops
+    ADD COLUMN funding_transaction_id uuid UNIQUE REFERENCES public.transactions(id);
+
+COMMENT ON COLUMN public.money_drops.funding_transaction_id IS 'The transfer funding the drop. NULL for drops created before funding was tracked.';
+
+--==============================================================
+-- `money_drop_claims` table changes
+--==============================================================
+
+ALTER TABLE public.money_drop_claims
+    ADD COLUMN payout_transaction_id uuid UNIQUE REFERENCES public.transactions(id);
+
+COMMENT ON COLUMN public.money_drop_claims.payout_transaction_id IS 'The transfer paying out the claim. NULL for claims made before payouts were tracked.';
+
+--==============================================================
+-- `transactions` table changes
+--==============================================================
+
+CREATE INDEX IF NOT EXISTS idx_transactions_pending_created_at
+    ON public.transactions (created_at)
+    WHERE status = 'pending';
@@ -0,0 +1,20 @@
+/**
+ * @description
+ * Transfa App - Subscription Billing
+ *
+ * This migration adds the state the Subscription service needs to bill subscriptions:
+ * - `subscriptions.activation_fee_key` is the idempotency key of the fee charged to
+ *   activate a subscription. It is set when a user subscribes and reused if they retry
+ *   while the charge is still pending, so the fee is debited at most once. It is
+ *   cleared when the charge completes and the subscription is activated, or when the
+ *   charge is declined, so the next attempt is a new charge.
+ */
+
+--==============================================================
+-- `subscriptions` table changes
+--==============================================================
+
+ALTER TABLE public.subscriptions
+    ADD COLUMN activation_fee_key text UNIQUE;
+
+COMMENT ON COLUMN public.subscriptions.activation_fee_key IS 'Idempotency key of the fee charged to activate the subscription, while that charge is unsettled.';